@@ -2,15 +2,21 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"observability-hub/internal/db/postgres"
 	"observability-hub/internal/env"
 	internalmcp "observability-hub/internal/mcp"
 	"observability-hub/internal/mcp/providers"
+	"observability-hub/internal/secrets"
 	"observability-hub/internal/telemetry"
 )
 
@@ -39,50 +45,350 @@ func main() {
 		Version: version,
 	}, nil)
 
+	// Tools are registered through the registry, which applies MCP_TOOLS_CONFIG (enabled flags,
+	// read-only mode, per-tool limits) and tracks what was actually registered.
+	toolsConfig, err := loadToolsConfig()
+	if err != nil {
+		telemetry.Error("mcp_tools_config_invalid", "error", err)
+		os.Exit(1)
+	}
+	registry := internalmcp.NewToolRegistry(server, toolsConfig)
+
+	// Tool calls are recorded to Postgres when MCP_TOOL_CALL_STORE=postgres; the gateway runs
+	// without the audit trail when the database is unreachable.
+	var toolCalls internalmcp.ToolCallStore
+	pgToolCalls, closeToolCalls, err := newToolCallStore(ctx)
+	if err != nil {
+		telemetry.Warn("mcp_tool_call_store_init_failed_skipping_audit_trail", "error", err)
+	} else if pgToolCalls != nil {
+		defer closeToolCalls()
+		// Records are written in the background; Close drains the buffer before the database closes.
+		async := internalmcp.NewAsyncToolCallStore(pgToolCalls, toolCallBufferSize)
+		defer async.Close()
+		toolCalls = async
+		registry.UseToolCallStore(toolCalls)
+	}
+
+	// Providers record their backend traffic to MCP_RECORD_FIXTURE, or answer from
+	// MCP_REPLAY_FIXTURE instead of the real backends.
+	fixtures, err := newFixtureHarness()
+	if err != nil {
+		telemetry.Error("mcp_fixture_init_failed", "error", err)
+		os.Exit(1)
+	}
+	defer fixtures.close()
+
 	// 3. Sequential Provider Initialization (Soft-Fail Pattern)
 
+	// Mutating tools across providers share one policy, cooldown state, hourly budget and audit trail.
+	remediation, closeAudit, err := newRemediationEngine()
+	if err != nil {
+		telemetry.Error("mcp_remediation_init_failed", "error", err)
+		os.Exit(1)
+	}
+	defer closeAudit()
+
 	// --- Hub Provider ---
-	hubProv := providers.NewHubProvider()
-	if hubProv != nil {
-		internalmcp.RegisterHubTools(server, hubProv, "mcp.hub")
-		internalmcp.RegisterNetworkTools(server, hubProv, "mcp.network")
-		telemetry.Info("registered hub and network tools")
+	var hubProv *providers.HubProvider
+	inventory, err := hostInventory()
+	if err != nil {
+		telemetry.Warn("mcp_hub_init_failed_skipping_tools", "error", err)
 	} else {
-		telemetry.Warn("mcp_hub_init_failed_skipping_tools")
+		hubProv = providers.NewHubProviderWithRunner(fixtures.runner(), inventory)
+		if relay, err := hubbleRelay(); err != nil {
+			telemetry.Warn("mcp_hubble_relay_init_failed_using_kubectl_exec", "error", err)
+		} else if relay != nil {
+			defer relay.Close()
+			hubProv.UseHubbleRelay(relay)
+		}
+		if dir := os.Getenv("MCP_POLICY_DIR"); dir != "" {
+			hubProv.UsePolicyDir(dir)
+		}
+		if root := os.Getenv("MCP_HOST_ROOT"); root != "" {
+			hubProv.UseHostRoot(root)
+		}
+		internalmcp.RegisterHubTools(registry, hubProv, remediation, "mcp.hub")
+		internalmcp.RegisterNetworkTools(registry, hubProv, "mcp.network")
+		telemetry.Info("registered hub and network tools", "node", inventory.Node, "services", len(inventory.Services))
 	}
 
 	// --- Pods Provider ---
-	if podsProv, err := providers.NewPodsProvider(); err != nil {
+	podsProv, err := fixtures.podsProvider()
+	if err != nil {
 		telemetry.Warn("mcp_pods_init_failed_skipping_tools", "error", err)
 	} else {
-		internalmcp.RegisterPodsTools(server, podsProv, "mcp.pods")
-		telemetry.Info("registered pods tools (mcp.pods)")
+		internalmcp.RegisterPodsTools(registry, podsProv, remediation, "mcp.pods")
+		internalmcp.RegisterWorkloadTools(registry, podsProv, "mcp.workloads")
+		telemetry.Info("registered pods and workload tools (mcp.pods, mcp.workloads)")
+
+		// Warning events expire from the API server after an hour, so buffer them from startup.
+		watcher, err := podsProv.WatchEvents(ctx, eventBufferSize())
+		if err != nil {
+			telemetry.Warn("mcp_event_watcher_init_failed_skipping_tools", "error", err)
+		} else {
+			internalmcp.RegisterEventTools(registry, watcher, "mcp.events")
+			telemetry.Info("registered event tools (mcp.events)")
+		}
 	}
 
 	// --- Telemetry Provider ---
-	thanosURL := os.Getenv("THANOS_URL")
-	lokiURL := os.Getenv("LOKI_URL")
-	tempoURL := os.Getenv("TEMPO_URL")
+	telemetryProv, err := newTelemetryProvider(fixtures.httpClient())
+	if err != nil {
+		telemetry.Warn("mcp_telemetry_init_failed_skipping_tools", "error", err)
+	} else {
+		defer telemetryProv.Close()
+		internalmcp.RegisterTelemetryTools(registry, telemetryProv, "mcp.telemetry")
+		telemetry.Info("registered telemetry tools (mcp.telemetry)", "targets", telemetryProv.TargetNames())
 
-	if thanosURL == "" || lokiURL == "" || tempoURL == "" {
-		telemetry.Warn("mcp_telemetry_init_failed_missing_env_skipping_tools")
+		// --- Incident Tools (telemetry required, pods and hub optional) ---
+		internalmcp.RegisterIncidentTools(registry, telemetryProv, podsProv, hubProv, "mcp.incident")
+		telemetry.Info("registered incident tools (mcp.incident)")
+	}
+
+	// --- Knowledge (skills, ADRs and RCAs as resources and prompts) ---
+	docsRoot := os.Getenv("MCP_DOCS_ROOT")
+	if docsRoot == "" {
+		docsRoot = "."
+	}
+	if kb, err := internalmcp.LoadKnowledgeBase(docsRoot); err != nil {
+		telemetry.Warn("mcp_knowledge_init_failed_skipping_resources", "error", err)
 	} else {
-		telemetryProv := providers.NewTelemetryProvider(thanosURL, lokiURL, tempoURL)
-		if telemetryProv != nil {
-			defer telemetryProv.Close()
-			internalmcp.RegisterTelemetryTools(server, telemetryProv, "mcp.telemetry")
-			telemetry.Info("registered telemetry tools (mcp.telemetry)")
-		}
+		internalmcp.RegisterKnowledge(registry, kb, "mcp.knowledge")
+	}
+
+	internalmcp.RegisterCapabilityTools(registry, "mcp.registry")
+	if toolCalls != nil {
+		internalmcp.RegisterToolCallTools(registry, toolCalls, "mcp.audit")
+	}
+	if unknown := registry.UnknownTools(); len(unknown) > 0 {
+		telemetry.Warn("mcp_tools_config_names_unregistered_tools", "tools", unknown)
 	}
 
 	// 4. Run Server (Stdio transport)
-	telemetry.Info("mcp-obs-hub ready, unified 14 tools available")
+	enabled := registry.Enabled()
+	telemetry.Info("mcp-obs-hub ready", "tools", len(enabled), "read_only", toolsConfig.ReadOnly, "names", enabled)
 
 	transport := &mcp.StdioTransport{}
 	if err := server.Run(ctx, transport); err != nil {
 		telemetry.Error("mcp-obs-hub execution failed", "error", err)
+		fixtures.close() // os.Exit skips the deferred close; keep what was recorded
 		os.Exit(1)
 	}
 
 	telemetry.Info("shutting down mcp-obs-hub")
 }
+
+// newTelemetryProvider builds the telemetry provider from the multi-target TELEMETRY_CONFIG
+// file (credentials resolved from OpenBao) or, when unset, from THANOS_URL/LOKI_URL/TEMPO_URL.
+// A non-nil client replaces the default transport of every backend.
+func newTelemetryProvider(client *http.Client) (*providers.TelemetryProvider, error) {
+	if path := os.Getenv("TELEMETRY_CONFIG"); path != "" {
+		cfg, err := providers.LoadTelemetryConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		var store secrets.SecretStore
+		bao, err := secrets.NewBaoProvider()
+		if err != nil {
+			telemetry.Warn("mcp_telemetry_secret_store_unavailable_using_inline_credentials", "error", err)
+		} else {
+			defer bao.Close()
+			store = bao
+		}
+		return providers.NewTelemetryProviderFromConfig(cfg, store, client)
+	}
+
+	thanosURL := os.Getenv("THANOS_URL")
+	lokiURL := os.Getenv("LOKI_URL")
+	tempoURL := os.Getenv("TEMPO_URL")
+	if thanosURL == "" || lokiURL == "" || tempoURL == "" {
+		return nil, fmt.Errorf("TELEMETRY_CONFIG or THANOS_URL, LOKI_URL and TEMPO_URL must be set")
+	}
+	return providers.NewTelemetryProviderWithClient(thanosURL, lokiURL, tempoURL, client), nil
+}
+
+// fixtureHarness routes provider I/O (telemetry and Kubernetes HTTP calls, host commands)
+// through a Recorder or a Replayer. The zero value uses the real backends.
+type fixtureHarness struct {
+	recorder *providers.Recorder
+	replayer *providers.Replayer
+}
+
+// newFixtureHarness records to MCP_RECORD_FIXTURE or replays MCP_REPLAY_FIXTURE (JSON files);
+// setting both is an error.
+func newFixtureHarness() (fixtureHarness, error) {
+	recordPath, replayPath := os.Getenv("MCP_RECORD_FIXTURE"), os.Getenv("MCP_REPLAY_FIXTURE")
+	switch {
+	case recordPath != "" && replayPath != "":
+		return fixtureHarness{}, fmt.Errorf("MCP_RECORD_FIXTURE and MCP_REPLAY_FIXTURE are mutually exclusive")
+	case recordPath != "":
+		telemetry.Warn("mcp_recording_provider_traffic", "path", recordPath)
+		return fixtureHarness{recorder: providers.NewRecorder(recordPath)}, nil
+	case replayPath != "":
+		fixture, err := providers.LoadFixture(replayPath)
+		if err != nil {
+			return fixtureHarness{}, err
+		}
+		telemetry.Warn("mcp_replaying_provider_traffic", "path", replayPath, "recorded_at", fixture.RecordedAt)
+		return fixtureHarness{replayer: providers.NewReplayer(fixture)}, nil
+	}
+	return fixtureHarness{}, nil
+}
+
+// close writes the interactions the recorder has not flushed yet.
+func (h fixtureHarness) close() {
+	if h.recorder == nil {
+		return
+	}
+	if err := h.recorder.Close(); err != nil {
+		telemetry.Warn("mcp_fixture_write_failed", "error", err)
+	}
+}
+
+func (h fixtureHarness) runner() providers.CommandRunner {
+	switch {
+	case h.recorder != nil:
+		return h.recorder.Runner(&providers.RealCommandRunner{})
+	case h.replayer != nil:
+		return h.replayer.Runner()
+	}
+	return &providers.RealCommandRunner{}
+}
+
+// httpClient returns the client for telemetry backends, or nil to use the default ones.
+func (h fixtureHarness) httpClient() *http.Client {
+	switch {
+	case h.recorder != nil:
+		return &http.Client{Transport: h.recorder.Transport(nil), Timeout: 30 * time.Second}
+	case h.replayer != nil:
+		return &http.Client{Transport: h.replayer.Transport(), Timeout: 30 * time.Second}
+	}
+	return nil
+}
+
+func (h fixtureHarness) podsProvider() (*providers.PodsProvider, error) {
+	if h.replayer != nil {
+		return providers.NewPodsProviderForConfig(h.replayer.KubeConfig())
+	}
+	config, err := providers.LoadKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	if h.recorder != nil {
+		config = h.recorder.KubeConfig(config)
+	}
+	return providers.NewPodsProviderForConfig(config)
+}
+
+// hostInventory loads the host service inventory from MCP_HOST_INVENTORY (YAML) or,
+// when unset, tracks the core hub units on the local host.
+func hostInventory() (providers.HostInventory, error) {
+	if path := os.Getenv("MCP_HOST_INVENTORY"); path != "" {
+		return providers.LoadHostInventory(path)
+	}
+	return providers.DefaultHostInventory(), nil
+}
+
+// hubbleRelay connects to Hubble Relay at MCP_HUBBLE_RELAY_ADDR (host:port). When unset it returns
+// nil and network tools exec the hubble CLI in a Cilium agent pod instead.
+func hubbleRelay() (*providers.HubbleRelayClient, error) {
+	addr := os.Getenv("MCP_HUBBLE_RELAY_ADDR")
+	if addr == "" {
+		return nil, nil
+	}
+	return providers.NewHubbleRelayClient(addr)
+}
+
+// loadToolsConfig reads MCP_TOOLS_CONFIG, or enables every tool with the default limits when unset.
+func loadToolsConfig() (internalmcp.ToolsConfig, error) {
+	if path := os.Getenv("MCP_TOOLS_CONFIG"); path != "" {
+		return internalmcp.LoadToolsConfig(path)
+	}
+	return internalmcp.DefaultToolsConfig(), nil
+}
+
+// defaultToolCallRetention is how long tool call records are kept when MCP_TOOL_CALL_RETENTION is unset.
+const defaultToolCallRetention = 30 * 24 * time.Hour
+
+// toolCallBufferSize is how many tool call records may wait for Postgres before new ones are dropped.
+const toolCallBufferSize = 1024
+
+// newToolCallStore connects the tool call audit trail selected by MCP_TOOL_CALL_STORE. Only
+// "postgres" is supported; it returns nil when unset. Records older than MCP_TOOL_CALL_RETENTION
+// (a Go duration, default 720h, 0 keeps them forever) are pruned hourly until ctx is done.
+func newToolCallStore(ctx context.Context) (*internalmcp.PostgresToolCallStore, func(), error) {
+	switch kind := os.Getenv("MCP_TOOL_CALL_STORE"); kind {
+	case "":
+		return nil, nil, nil
+	case "postgres":
+	default:
+		return nil, nil, fmt.Errorf("unsupported MCP_TOOL_CALL_STORE %q (supported: postgres)", kind)
+	}
+
+	retention := defaultToolCallRetention
+	if raw := os.Getenv("MCP_TOOL_CALL_RETENTION"); raw != "" {
+		var err error
+		if retention, err = time.ParseDuration(raw); err != nil || retention < 0 {
+			return nil, nil, fmt.Errorf("invalid MCP_TOOL_CALL_RETENTION %q", raw)
+		}
+	}
+
+	bao, err := secrets.NewBaoProvider()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer bao.Close()
+	wrapper, err := postgres.ConnectPostgres("postgres", bao)
+	if err != nil {
+		return nil, nil, err
+	}
+	store := internalmcp.NewPostgresToolCallStore(wrapper)
+	if err := store.EnsureSchema(ctx); err != nil {
+		wrapper.DB.Close()
+		return nil, nil, err
+	}
+	if retention > 0 {
+		go store.RunRetention(ctx, retention, time.Hour)
+	}
+	telemetry.Info("recording tool calls to postgres", "retention", retention.String())
+	return store, func() { wrapper.DB.Close() }, nil
+}
+
+// eventBufferSize reads MCP_EVENT_BUFFER_SIZE, the number of Warning events kept in memory.
+func eventBufferSize() int {
+	raw := os.Getenv("MCP_EVENT_BUFFER_SIZE")
+	if raw == "" {
+		return providers.DefaultEventBufferSize
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size <= 0 {
+		telemetry.Warn("mcp_event_buffer_size_invalid_using_default", "value", raw)
+		return providers.DefaultEventBufferSize
+	}
+	return size
+}
+
+// newRemediationEngine builds the policy engine guarding mutating tools from
+// MCP_REMEDIATION_POLICY (YAML, defaults when unset) and MCP_AUDIT_LOG (JSON lines file,
+// structured log only when unset). Misconfiguration is fatal rather than silently unguarded.
+func newRemediationEngine() (*internalmcp.RemediationEngine, func(), error) {
+	policy := internalmcp.DefaultRemediationPolicy()
+	if path := os.Getenv("MCP_REMEDIATION_POLICY"); path != "" {
+		var err error
+		if policy, err = internalmcp.LoadRemediationPolicy(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	closeAudit := func() {}
+	var audit internalmcp.AuditSink
+	if path := os.Getenv("MCP_AUDIT_LOG"); path != "" {
+		fileLog, err := internalmcp.NewFileAuditLog(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		audit = fileLog
+		closeAudit = func() { fileLog.Close() }
+	}
+	return internalmcp.NewRemediationEngine(policy, audit), closeAudit, nil
+}
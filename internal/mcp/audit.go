@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"observability-hub/internal/telemetry"
+)
+
+// Audit outcomes recorded for remediation actions.
+const (
+	AuditOutcomePreview  = "preview"
+	AuditOutcomeDenied   = "denied"
+	AuditOutcomeDeclined = "declined"
+	AuditOutcomeExecuted = "executed"
+	AuditOutcomeFailed   = "failed"
+)
+
+// AuditEntry is one record in the remediation audit trail.
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Tool      string    `json:"tool"`
+	Caller    string    `json:"caller"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason"`
+	Outcome   string    `json:"outcome"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// AuditSink persists audit entries. Implementations must be append-only.
+type AuditSink interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// FileAuditLog appends audit entries as JSON lines to a file.
+type FileAuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditLog opens (or creates) path in append-only mode.
+func NewFileAuditLog(path string) (*FileAuditLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditLog{file: f}, nil
+}
+
+// Record appends entry as a single JSON line and syncs it to disk.
+func (l *FileAuditLog) Record(_ context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// Close closes the underlying file.
+func (l *FileAuditLog) Close() error {
+	return l.file.Close()
+}
+
+// logAuditSink records entries to the structured log only. It is used when no
+// audit file is configured so remediation is never silently unaudited.
+type logAuditSink struct{}
+
+func (logAuditSink) Record(_ context.Context, e AuditEntry) error {
+	telemetry.Info("remediation_audit", "tool", e.Tool, "caller", e.Caller, "kind", e.Kind,
+		"namespace", e.Namespace, "name", e.Name, "reason", e.Reason, "outcome", e.Outcome, "detail", e.Detail)
+	return nil
+}
@@ -2,11 +2,15 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"observability-hub/internal/mcp/providers"
 	"observability-hub/internal/telemetry"
 )
 
@@ -14,6 +18,8 @@ var (
 	once             sync.Once
 	toolCallsCounter telemetry.Int64Counter
 	toolDuration     telemetry.Int64Histogram
+	toolTruncated    telemetry.Int64Counter
+	toolCallsDropped telemetry.Int64Counter
 )
 
 func initTelemetry() {
@@ -28,14 +34,63 @@ func initTelemetry() {
 		if err != nil {
 			telemetry.Error("failed to create mcp_tool_duration metric", "error", err)
 		}
+		toolTruncated, err = telemetry.NewInt64Counter(meter, "mcp_tool_output_truncated_total", "MCP tool results cut to their max output bytes")
+		if err != nil {
+			telemetry.Error("failed to create mcp_tool_output_truncated_total metric", "error", err)
+		}
+		toolCallsDropped, err = telemetry.NewInt64Counter(meter, "mcp_tool_call_records_dropped_total", "Tool call records dropped because the audit write buffer was full")
+		if err != nil {
+			telemetry.Error("failed to create mcp_tool_call_records_dropped_total metric", "error", err)
+		}
 	})
 }
 
-// InstrumentHandler wraps an MCP tool handler with tracing and metrics.
+type toolLimitsKey struct{}
+
+// withToolLimits attaches the limits InstrumentHandler enforces for one call.
+func withToolLimits(ctx context.Context, limits ToolLimits) context.Context {
+	return context.WithValue(ctx, toolLimitsKey{}, limits)
+}
+
+func toolLimitsFrom(ctx context.Context) ToolLimits {
+	limits, _ := ctx.Value(toolLimitsKey{}).(ToolLimits)
+	return limits
+}
+
+// ToolError is the content of a failed tool call.
+type ToolError struct {
+	Error string `json:"error"`
+	Class string `json:"class"` // one of the providers.ErrorClass* values
+	// Retryable is true when the same call may succeed later (timeouts, unavailable backends).
+	Retryable bool `json:"retryable"`
+}
+
+// OutputTruncated is appended as a last content block when a result exceeded its max output bytes.
+type OutputTruncated struct {
+	Truncated     bool   `json:"truncated"`
+	OriginalBytes int    `json:"original_bytes"`
+	ReturnedBytes int    `json:"returned_bytes"`
+	Hint          string `json:"hint"`
+}
+
+// InstrumentHandler wraps an MCP tool handler with tracing and metrics, and enforces the
+// ToolLimits the registry attached to the call: a deadline and a cap on the returned text.
+// When the registry has a ToolCallStore, every call is also recorded to the audit trail.
+//
+// Failures are classified (see providers.ErrorClass) and returned as a ToolError result rather
+// than a Go error, so agents receive the class; the original error stays available through
+// the result's GetError.
 func InstrumentHandler[I any, O any](name string, service string, handler mcp.ToolHandlerFor[I, O]) mcp.ToolHandlerFor[I, O] {
 	initTelemetry()
 
 	return func(ctx context.Context, req *mcp.CallToolRequest, input I) (*mcp.CallToolResult, O, error) {
+		limits := toolLimitsFrom(ctx)
+		if limits.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+			defer cancel()
+		}
+
 		tracer := telemetry.GetTracer("mcp")
 		ctx, span := tracer.Start(ctx, fmt.Sprintf("mcp.tool.%s", name))
 		defer span.End()
@@ -49,11 +104,51 @@ func InstrumentHandler[I any, O any](name string, service string, handler mcp.To
 		res, out, err := handler(ctx, req, input)
 		duration := time.Since(start)
 
-		status := "success"
+		status, class := ToolCallSuccess, ""
 		if err != nil {
-			status = "error"
+			class = providers.ErrorClass(err)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				class = providers.ErrorClassTimeout
+				if limits.Timeout > 0 {
+					err = fmt.Errorf("tool call exceeded its %s timeout: %w", limits.Timeout, err)
+				}
+			}
+			status = ToolCallError
 			span.SetStatus(telemetry.CodeError, err.Error())
 			span.RecordError(err)
+			span.SetAttributes(telemetry.StringAttribute("mcp.error_class", class))
+			res = toolErrorResult(err, class)
+			var zero O
+			out = zero
+		} else if res != nil && limits.MaxOutputBytes > 0 {
+			if marker, ok := truncateContent(res, limits.MaxOutputBytes); ok {
+				span.SetAttributes(telemetry.IntAttribute("mcp.output_bytes", marker.OriginalBytes))
+				span.SetAttributes(telemetry.BoolAttribute("mcp.output_truncated", true))
+				if toolTruncated != nil {
+					telemetry.AddInt64Counter(ctx, toolTruncated, 1,
+						telemetry.StringAttribute("tool", name),
+						telemetry.StringAttribute("service", service),
+					)
+				}
+			}
+		}
+
+		if audit, ok := toolCallAuditFrom(ctx); ok {
+			record := ToolCallRecord{
+				Time:        start.UTC(),
+				SessionID:   sessionID(req, audit.session),
+				Tool:        name,
+				Service:     service,
+				Input:       sanitizeToolInput(input),
+				OutputBytes: contentBytes(res),
+				Status:      status,
+				ErrorClass:  class,
+				DurationMs:  duration.Milliseconds(),
+			}
+			if sc := span.SpanContext(); sc.HasTraceID() {
+				record.TraceID = sc.TraceID().String()
+			}
+			recordToolCall(ctx, audit.store, record)
 		}
 
 		if toolCallsCounter != nil {
@@ -61,6 +156,7 @@ func InstrumentHandler[I any, O any](name string, service string, handler mcp.To
 				telemetry.StringAttribute("tool", name),
 				telemetry.StringAttribute("service", service),
 				telemetry.StringAttribute("status", status),
+				telemetry.StringAttribute("error_class", class),
 			)
 		}
 
@@ -71,6 +167,52 @@ func InstrumentHandler[I any, O any](name string, service string, handler mcp.To
 			)
 		}
 
-		return res, out, err
+		return res, out, nil
+	}
+}
+
+// toolErrorResult renders err as a ToolError result.
+func toolErrorResult(err error, class string) *mcp.CallToolResult {
+	res := &mcp.CallToolResult{}
+	res.SetError(err)
+	text, _ := json.Marshal(ToolError{
+		Error:     err.Error(),
+		Class:     class,
+		Retryable: class == providers.ErrorClassTimeout || class == providers.ErrorClassUpstreamUnavailable,
+	})
+	res.Content = []mcp.Content{&mcp.TextContent{Text: string(text)}}
+	return res
+}
+
+// truncateContent cuts text content beyond maxBytes in total and appends an OutputTruncated
+// block. It reports whether anything was cut.
+func truncateContent(res *mcp.CallToolResult, maxBytes int) (OutputTruncated, bool) {
+	marker := OutputTruncated{Truncated: true}
+	remaining := maxBytes
+	for _, c := range res.Content {
+		text, ok := c.(*mcp.TextContent)
+		if !ok {
+			continue
+		}
+		marker.OriginalBytes += len(text.Text)
+		if len(text.Text) <= remaining {
+			remaining -= len(text.Text)
+			marker.ReturnedBytes += len(text.Text)
+			continue
+		}
+		cut := remaining
+		for cut > 0 && !utf8.RuneStart(text.Text[cut]) {
+			cut--
+		}
+		text.Text = text.Text[:cut]
+		marker.ReturnedBytes += cut
+		remaining = 0
+	}
+	if marker.OriginalBytes == marker.ReturnedBytes {
+		return OutputTruncated{}, false
 	}
+	marker.Hint = "the result was cut to the tool's max output bytes; narrow the query (shorter window, filters, lower limit) to see the rest"
+	text, _ := json.Marshal(marker)
+	res.Content = append(res.Content, &mcp.TextContent{Text: string(text)})
+	return marker, true
 }
@@ -2,10 +2,15 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"observability-hub/internal/mcp/providers"
 	"observability-hub/internal/telemetry"
 )
 
@@ -44,10 +49,13 @@ func TestInstrumentHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			instrumented := InstrumentHandler(tt.toolName, tt.serviceName, tt.handler)
 
-			_, out, err := instrumented(context.Background(), &mcp.CallToolRequest{}, struct{}{})
+			res, out, err := instrumented(context.Background(), &mcp.CallToolRequest{}, struct{}{})
+			if err != nil {
+				t.Fatalf("InstrumentHandler() error = %v, want failures as results", err)
+			}
 
-			if (err != nil) != tt.wantErr {
-				t.Errorf("InstrumentHandler() error = %v, wantErr %v", err, tt.wantErr)
+			if res.IsError != tt.wantErr {
+				t.Errorf("InstrumentHandler() IsError = %v, wantErr %v", res.IsError, tt.wantErr)
 			}
 
 			if !tt.wantErr && out != "ok" {
@@ -56,3 +64,98 @@ func TestInstrumentHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestInstrumentHandler_LimitsAndErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    ToolLimits
+		handler   mcp.ToolHandlerFor[struct{}, any]
+		wantError *ToolError
+		wantTexts []string
+		wantTrunc *OutputTruncated
+	}{
+		{
+			name: "validation error",
+			handler: func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+				return nil, nil, providers.InvalidInputf("service is required")
+			},
+			wantError: &ToolError{Error: "service is required", Class: providers.ErrorClassValidation},
+		},
+		{
+			name: "backend unavailable is retryable",
+			handler: func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+				return nil, nil, fmt.Errorf("query execution failed: %w", &providers.StatusError{Backend: "Loki", StatusCode: 503})
+			},
+			wantError: &ToolError{Error: "query execution failed: Loki returned status 503", Class: providers.ErrorClassUpstreamUnavailable, Retryable: true},
+		},
+		{
+			name:   "deadline",
+			limits: ToolLimits{Timeout: 10 * time.Millisecond},
+			handler: func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+				<-ctx.Done()
+				return nil, nil, errors.New("signal: killed")
+			},
+			wantError: &ToolError{Error: "tool call exceeded its 10ms timeout: signal: killed", Class: providers.ErrorClassTimeout, Retryable: true},
+		},
+		{
+			name:   "output cut across content blocks",
+			limits: ToolLimits{MaxOutputBytes: 6},
+			handler: func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "abcd"}, &mcp.TextContent{Text: "é€xyz"}}}, nil, nil
+			},
+			wantTexts: []string{"abcd", "é"},
+			wantTrunc: &OutputTruncated{Truncated: true, OriginalBytes: 12, ReturnedBytes: 6},
+		},
+		{
+			name:   "output within the cap",
+			limits: ToolLimits{MaxOutputBytes: 6},
+			handler: func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "abcdef"}}}, nil, nil
+			},
+			wantTexts: []string{"abcdef"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withToolLimits(context.Background(), tt.limits)
+			res, _, err := InstrumentHandler("test_tool", "mcp.test", tt.handler)(ctx, &mcp.CallToolRequest{}, struct{}{})
+			if err != nil {
+				t.Fatalf("InstrumentHandler() error = %v, want failures as results", err)
+			}
+			texts := make([]string, len(res.Content))
+			for i, c := range res.Content {
+				texts[i] = c.(*mcp.TextContent).Text
+			}
+
+			if tt.wantError != nil {
+				var got ToolError
+				if !res.IsError || len(texts) != 1 || json.Unmarshal([]byte(texts[0]), &got) != nil {
+					t.Fatalf("result = %+v, want a ToolError", texts)
+				}
+				if got != *tt.wantError {
+					t.Errorf("ToolError = %+v, want %+v", got, *tt.wantError)
+				}
+				if res.GetError() == nil {
+					t.Error("GetError() = nil, want the original error")
+				}
+				return
+			}
+
+			if tt.wantTrunc != nil {
+				var got OutputTruncated
+				if err := json.Unmarshal([]byte(texts[len(texts)-1]), &got); err != nil {
+					t.Fatalf("last block %q is not a truncation marker", texts[len(texts)-1])
+				}
+				got.Hint = ""
+				if got != *tt.wantTrunc {
+					t.Errorf("marker = %+v, want %+v", got, *tt.wantTrunc)
+				}
+				texts = texts[:len(texts)-1]
+			}
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("texts = %q, want %q", texts, tt.wantTexts)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Knowledge document kinds.
+const (
+	KnowledgeSkill     = "skill"
+	KnowledgeReference = "skill_reference"
+	KnowledgeDecision  = "decision"
+	KnowledgeIncident  = "incident"
+)
+
+// KnowledgeURIScheme prefixes the URIs of documents served as MCP resources.
+const KnowledgeURIScheme = "obs://"
+
+const (
+	defaultKnowledgeHits = 5
+	maxKnowledgeHits     = 20
+	knowledgeSnippetLen  = 200
+)
+
+// KnowledgeDoc is a skill, skill reference, ADR or RCA served as an MCP resource.
+type KnowledgeDoc struct {
+	URI         string            `json:"uri"` // e.g. obs://skills/network, obs://decisions/017, obs://incidents/004
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Path        string            `json:"path"`               // relative to the docs root
+	Metadata    map[string]string `json:"metadata,omitempty"` // header fields such as status, date and severity
+	Content     string            `json:"-"`
+}
+
+// KnowledgeBase holds the repository's operational documents in memory, so clients can read
+// them over MCP without access to the gateway's filesystem.
+type KnowledgeBase struct {
+	docs  []KnowledgeDoc
+	byURI map[string]int
+}
+
+// numberedDoc matches ADR and RCA file names such as 017-agentic-interface-mcp.md.
+var numberedDoc = regexp.MustCompile(`^(\d+)-.+\.md$`)
+
+// metadataLine matches header bullets such as "- **Status:** Accepted".
+var metadataLine = regexp.MustCompile(`^[-*]\s+\*\*([^*:]+):?\*\*:?\s*(.+)$`)
+
+// LoadKnowledgeBase reads skills/*/SKILL.md, skills/*/references/*.md, docs/decisions and
+// docs/incidents under root (the repository checkout).
+func LoadKnowledgeBase(root string) (*KnowledgeBase, error) {
+	kb := &KnowledgeBase{byURI: make(map[string]int)}
+
+	skills, _ := filepath.Glob(filepath.Join(root, "skills", "*", "SKILL.md"))
+	for _, path := range skills {
+		skill := filepath.Base(filepath.Dir(path))
+		doc, err := readKnowledgeDoc(root, path)
+		if err != nil {
+			return nil, err
+		}
+		doc.Kind, doc.Name, doc.URI = KnowledgeSkill, skill, KnowledgeURIScheme+"skills/"+skill
+		kb.add(doc)
+
+		refs, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "references", "*.md"))
+		for _, ref := range refs {
+			doc, err := readKnowledgeDoc(root, ref)
+			if err != nil {
+				return nil, err
+			}
+			name := strings.TrimSuffix(filepath.Base(ref), ".md")
+			doc.Kind, doc.Name = KnowledgeReference, skill+"/"+name
+			doc.URI = KnowledgeURIScheme + "skills/" + skill + "/references/" + name
+			if doc.Description == "" {
+				doc.Description = fmt.Sprintf("Reference for the %s skill.", skill)
+			}
+			kb.add(doc)
+		}
+	}
+
+	for _, dir := range []struct{ path, kind, uri string }{
+		{filepath.Join("docs", "decisions"), KnowledgeDecision, "decisions/"},
+		{filepath.Join("docs", "incidents"), KnowledgeIncident, "incidents/"},
+	} {
+		entries, err := os.ReadDir(filepath.Join(root, dir.path))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			m := numberedDoc.FindStringSubmatch(e.Name())
+			if e.IsDir() || m == nil {
+				continue
+			}
+			doc, err := readKnowledgeDoc(root, filepath.Join(root, dir.path, e.Name()))
+			if err != nil {
+				return nil, err
+			}
+			doc.Kind, doc.Name, doc.URI = dir.kind, strings.TrimSuffix(e.Name(), ".md"), KnowledgeURIScheme+dir.uri+m[1]
+			kb.add(doc)
+		}
+	}
+
+	if len(kb.docs) == 0 {
+		return nil, fmt.Errorf("no skills, decisions or incidents found under %s", root)
+	}
+	return kb, nil
+}
+
+// readKnowledgeDoc reads a markdown file, taking its title from the first heading, its description
+// from YAML front matter (skills) and its metadata from the header bullets before the first section.
+func readKnowledgeDoc(root, path string) (KnowledgeDoc, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return KnowledgeDoc{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	rel, _ := filepath.Rel(root, path)
+	doc := KnowledgeDoc{Path: filepath.ToSlash(rel), Content: string(content)}
+
+	body := doc.Content
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		if front, after, ok := strings.Cut(rest, "\n---"); ok {
+			var meta struct {
+				Description string `yaml:"description"`
+			}
+			if err := yaml.Unmarshal([]byte(front), &meta); err != nil {
+				return KnowledgeDoc{}, fmt.Errorf("failed to parse front matter of %s: %w", path, err)
+			}
+			doc.Description = meta.Description
+			body = after
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "## ") {
+			break
+		}
+		if title, ok := strings.CutPrefix(line, "# "); ok && doc.Title == "" {
+			doc.Title = title
+			continue
+		}
+		if m := metadataLine.FindStringSubmatch(line); m != nil {
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]string)
+			}
+			doc.Metadata[strings.ToLower(strings.TrimSpace(m[1]))] = strings.TrimSpace(m[2])
+		}
+	}
+	if doc.Title == "" {
+		doc.Title = strings.TrimSuffix(filepath.Base(path), ".md")
+	}
+	return doc, nil
+}
+
+func (kb *KnowledgeBase) add(doc KnowledgeDoc) {
+	kb.byURI[doc.URI] = len(kb.docs)
+	kb.docs = append(kb.docs, doc)
+}
+
+// Docs lists documents in load order: skills with their references, then ADRs, then RCAs.
+func (kb *KnowledgeBase) Docs() []KnowledgeDoc {
+	return kb.docs
+}
+
+// Get returns the document with the given URI.
+func (kb *KnowledgeBase) Get(uri string) (KnowledgeDoc, bool) {
+	i, ok := kb.byURI[uri]
+	if !ok {
+		return KnowledgeDoc{}, false
+	}
+	return kb.docs[i], true
+}
+
+// KnowledgeHit is one search result.
+type KnowledgeHit struct {
+	URI     string  `json:"uri"`
+	Kind    string  `json:"kind"`
+	Title   string  `json:"title"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"` // first line mentioning a query term
+}
+
+// Search ranks documents by how often the query's terms appear, weighting titles and descriptions
+// above body text. Every term must appear somewhere in a document for it to match. kind
+// optionally restricts the search to one document kind.
+func (kb *KnowledgeBase) Search(query, kind string, limit int) []KnowledgeHit {
+	if limit <= 0 {
+		limit = defaultKnowledgeHits
+	}
+	if limit > maxKnowledgeHits {
+		limit = maxKnowledgeHits
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var hits []KnowledgeHit
+	for _, doc := range kb.docs {
+		if kind != "" && doc.Kind != kind {
+			continue
+		}
+		title, desc, body := strings.ToLower(doc.Title), strings.ToLower(doc.Description), strings.ToLower(doc.Content)
+		score := 0.0
+		matched := true
+		for _, term := range terms {
+			n := strings.Count(body, term)
+			if n == 0 {
+				matched = false
+				break
+			}
+			score += 5*float64(strings.Count(title, term)) + 3*float64(strings.Count(desc, term)) + float64(min(n, 10))
+		}
+		if !matched {
+			continue
+		}
+		hits = append(hits, KnowledgeHit{URI: doc.URI, Kind: doc.Kind, Title: doc.Title, Score: score, Snippet: snippet(doc.Content, terms)})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// snippet returns the first non-heading line containing any of terms, shortened to knowledgeSnippetLen.
+func snippet(content string, terms []string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lower := strings.ToLower(line)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				if len(line) > knowledgeSnippetLen {
+					line = line[:knowledgeSnippetLen] + "…"
+				}
+				return line
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func writeKnowledgeFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"skills/network/SKILL.md":                     "---\nname: network\ndescription: Debug Cilium drops with Hubble flows.\n---\n# Network Skill\n\nUse observe_network_flows to find drops.\n",
+		"skills/network/references/api-specs.md":      "# Network API Specs\n\n## observe_network_flows\n\nLists Hubble flows.\n",
+		"docs/decisions/README.md":                    "# Decisions\n",
+		"docs/decisions/017-agentic-interface-mcp.md": "# ADR 017: Agentic Interface via MCP\n\n- **Status:** Accepted\n- **Date:** 2026-01-20\n\n## Context\n\nAgents call tools over MCP instead of scraping dashboards.\n",
+		"docs/incidents/004-hubble-relay-outage.md":   "# RCA 004: Hubble Relay Outage\n\n- **Status:** ✅ Resolved\n- **Severity:** 🔴 High\n\n## Summary\n\nHubble relay lost its peers, so flows could not be observed.\n",
+		"docs/incidents/001-grafana-provisioning.md":  "# RCA 001: Grafana Provisioning\n\n## Summary\n\nDashboards failed to load.\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoadKnowledgeBase(t *testing.T) {
+	kb, err := LoadKnowledgeBase(writeKnowledgeFixture(t))
+	if err != nil {
+		t.Fatalf("LoadKnowledgeBase() error = %v", err)
+	}
+	if got := len(kb.Docs()); got != 5 {
+		t.Errorf("docs = %d, want 5 (README skipped)", got)
+	}
+
+	tests := []struct {
+		uri       string
+		kind      string
+		title     string
+		desc      string
+		path      string
+		metaKey   string
+		metaValue string
+	}{
+		{uri: "obs://skills/network", kind: KnowledgeSkill, title: "Network Skill", desc: "Debug Cilium drops with Hubble flows.", path: "skills/network/SKILL.md"},
+		{uri: "obs://skills/network/references/api-specs", kind: KnowledgeReference, title: "Network API Specs", desc: "Reference for the network skill.", path: "skills/network/references/api-specs.md"},
+		{uri: "obs://decisions/017", kind: KnowledgeDecision, title: "ADR 017: Agentic Interface via MCP", path: "docs/decisions/017-agentic-interface-mcp.md", metaKey: "status", metaValue: "Accepted"},
+		{uri: "obs://incidents/004", kind: KnowledgeIncident, title: "RCA 004: Hubble Relay Outage", path: "docs/incidents/004-hubble-relay-outage.md", metaKey: "severity", metaValue: "🔴 High"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			doc, ok := kb.Get(tt.uri)
+			if !ok {
+				t.Fatalf("Get(%q) not found", tt.uri)
+			}
+			if doc.Kind != tt.kind || doc.Title != tt.title || doc.Description != tt.desc || doc.Path != tt.path {
+				t.Errorf("doc = %+v", doc)
+			}
+			if tt.metaKey != "" && doc.Metadata[tt.metaKey] != tt.metaValue {
+				t.Errorf("metadata[%s] = %q, want %q", tt.metaKey, doc.Metadata[tt.metaKey], tt.metaValue)
+			}
+		})
+	}
+
+	if _, err := LoadKnowledgeBase(t.TempDir()); err == nil {
+		t.Error("LoadKnowledgeBase(empty) error = nil, want error")
+	}
+}
+
+func TestLoadKnowledgeBase_Repository(t *testing.T) {
+	kb, err := LoadKnowledgeBase(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatalf("LoadKnowledgeBase(repo) error = %v", err)
+	}
+	for _, spec := range operationalPrompts {
+		for _, skill := range spec.skills {
+			if _, ok := kb.Get(KnowledgeURIScheme + "skills/" + skill); !ok {
+				t.Errorf("prompt %s embeds missing skill %s", spec.name, skill)
+			}
+		}
+	}
+	if _, ok := kb.Get("obs://decisions/017"); !ok {
+		t.Error("ADR 017 not loaded")
+	}
+}
+
+func TestKnowledgeBase_Search(t *testing.T) {
+	kb, err := LoadKnowledgeBase(writeKnowledgeFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		query string
+		kind  string
+		want  []string
+	}{
+		{name: "title outranks body", query: "hubble", want: []string{"obs://incidents/004", "obs://skills/network", "obs://skills/network/references/api-specs"}},
+		{name: "kind filter", query: "hubble", kind: KnowledgeIncident, want: []string{"obs://incidents/004"}},
+		{name: "all terms required", query: "hubble dashboards", want: nil},
+		{name: "case insensitive", query: "MCP", want: []string{"obs://decisions/017"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, hit := range kb.Search(tt.query, tt.kind, 0) {
+				got = append(got, hit.URI)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterKnowledge(t *testing.T) {
+	kb, err := LoadKnowledgeBase(writeKnowledgeFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	server := sdkmcp.NewServer(&sdkmcp.Implementation{Name: "test", Version: "v0"}, nil)
+	RegisterKnowledge(NewToolRegistry(server, DefaultToolsConfig()), kb, "test")
+
+	serverTransport, clientTransport := sdkmcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := sdkmcp.NewClient(&sdkmcp.Implementation{Name: "client", Version: "v0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	resources, err := session.ListResources(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(resources.Resources) != 5 {
+		t.Errorf("resources = %d, want 5", len(resources.Resources))
+	}
+
+	read, err := session.ReadResource(ctx, &sdkmcp.ReadResourceParams{URI: "obs://decisions/017"})
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	if len(read.Contents) != 1 || !strings.Contains(read.Contents[0].Text, "Agents call tools over MCP") {
+		t.Errorf("ReadResource() contents = %+v", read.Contents)
+	}
+	if _, err := session.ReadResource(ctx, &sdkmcp.ReadResourceParams{URI: "obs://decisions/999"}); err == nil {
+		t.Error("ReadResource(unknown) error = nil, want not found")
+	}
+
+	res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "search_knowledge", Arguments: map[string]any{"query": "relay"}})
+	if err != nil || res.IsError {
+		t.Fatalf("search_knowledge error = %v, result = %+v", err, res)
+	}
+	var out struct {
+		Count int            `json:"count"`
+		Hits  []KnowledgeHit `json:"hits"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*sdkmcp.TextContent).Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Count != 1 || out.Hits[0].URI != "obs://incidents/004" {
+		t.Errorf("search_knowledge = %+v", out)
+	}
+
+	prompt, err := session.GetPrompt(ctx, &sdkmcp.GetPromptParams{Name: "investigate_network_drop", Arguments: map[string]string{"source": "hub/grafana", "destination": "databases/minio", "port": "9000"}})
+	if err != nil {
+		t.Fatalf("GetPrompt() error = %v", err)
+	}
+	if len(prompt.Messages) != 2 {
+		t.Fatalf("messages = %d, want instructions and the network skill", len(prompt.Messages))
+	}
+	if text := prompt.Messages[0].Content.(*sdkmcp.TextContent).Text; !strings.Contains(text, `"to_pod": "databases/minio"`) || !strings.Contains(text, `"to_port": 9000`) {
+		t.Errorf("instructions missing bound arguments:\n%s", text)
+	}
+	if res := prompt.Messages[1].Content.(*sdkmcp.EmbeddedResource).Resource; res.URI != "obs://skills/network" {
+		t.Errorf("embedded resource = %s, want obs://skills/network", res.URI)
+	}
+
+	rca, err := session.GetPrompt(ctx, &sdkmcp.GetPromptParams{Name: "write_rca", Arguments: map[string]string{"service": "proxy"}})
+	if err != nil {
+		t.Fatalf("GetPrompt(write_rca) error = %v", err)
+	}
+	if last := rca.Messages[len(rca.Messages)-1].Content.(*sdkmcp.EmbeddedResource).Resource; last.URI != "obs://incidents/004" {
+		t.Errorf("write_rca template = %s, want the latest RCA", last.URI)
+	}
+
+	if _, err := session.GetPrompt(ctx, &sdkmcp.GetPromptParams{Name: "triage_service"}); err == nil {
+		t.Error("GetPrompt(triage_service) without service error = nil, want error")
+	}
+}
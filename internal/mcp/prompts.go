@@ -0,0 +1,247 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"observability-hub/internal/mcp/providers"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+// promptSpec describes an operational prompt: the skills whose content it embeds and the tool
+// sequence it walks the agent through.
+type promptSpec struct {
+	name        string
+	title       string
+	description string
+	args        []*mcp.PromptArgument
+	skills      []string
+	// steps renders the tool sequence for the given arguments.
+	steps func(args map[string]string) []string
+	// resources lists extra documents to embed, e.g. the latest RCA as a template.
+	resources func(kb *KnowledgeBase) []string
+}
+
+var operationalPrompts = []promptSpec{
+	{
+		name:        "triage_service",
+		title:       "Triage a degraded service",
+		description: "Walk through metrics, logs, traces, dependencies, pods and network drops to find why a service is degraded.",
+		args: []*mcp.PromptArgument{
+			{Name: "service", Description: "Service name as used in telemetry, e.g. proxy", Required: true},
+			{Name: "namespace", Description: "Kubernetes namespace of the service (default: all namespaces)"},
+		},
+		skills: []string{"telemetry", "workloads", "pods", "network"},
+		steps: func(a map[string]string) []string {
+			ns := ""
+			if a["namespace"] != "" {
+				ns = fmt.Sprintf(`, "namespace": %q`, a["namespace"])
+			}
+			return []string{
+				fmt.Sprintf(`investigate_incident {"service": %q, "hours": 1} for the verdict and metric anomalies.`, a["service"]),
+				fmt.Sprintf(`build_incident_timeline {"service": %q%s} to order logs, error spans, events, journal entries and deploys.`, a["service"], ns),
+				fmt.Sprintf(`service_dependency_graph {"service": %q} to check whether a dependency is the real source of errors or latency.`, a["service"]),
+				fmt.Sprintf(`inspect_workloads and inspect_pods {%s} for the controllers and pods behind the service; describe_pod and get_pod_logs on any that are failing.`, strings.TrimPrefix(ns, ", ")),
+				`If calls to a dependency are dropped: observe_network_flows {"verdict": "DROPPED", "aggregate": true} and simulate_network_policy for the failing pair.`,
+				fmt.Sprintf(`search_knowledge {"query": %q, "kind": "incident"} for similar past incidents.`, a["service"]),
+			}
+		},
+	},
+	{
+		name:        "investigate_network_drop",
+		title:       "Explain a blocked connection",
+		description: "Find where traffic between two endpoints is dropped and which network policy rule is responsible.",
+		args: []*mcp.PromptArgument{
+			{Name: "source", Description: "Source pod as namespace/name, or an entity such as world", Required: true},
+			{Name: "destination", Description: "Destination pod as namespace/name, or an external host", Required: true},
+			{Name: "port", Description: "Destination port"},
+		},
+		skills: []string{"network"},
+		steps: func(a map[string]string) []string {
+			port := ""
+			if n, err := strconv.Atoi(a["port"]); err == nil {
+				port = fmt.Sprintf(`, "to_port": %d`, n)
+			}
+			return []string{
+				fmt.Sprintf(`observe_network_flows {"from_pod": %q, "to_pod": %q, "verdict": "DROPPED", "aggregate": true%s} to confirm the drop and its drop_reason.`, a["source"], a["destination"], port),
+				`simulate_network_policy for the same source, destination and port, first with "policies_from": "repo" and then "cluster", to find the deciding rule and any drift.`,
+				`query_metrics {"query": "sum by (reason) (rate(hubble_drop_total[5m]))"} to see whether drops are growing.`,
+				`search_knowledge {"query": "cilium", "kind": "incident"} for past network incidents.`,
+			}
+		},
+	},
+	{
+		name:        "check_host_health",
+		title:       "Check the host and its services",
+		description: "Review host resource pressure, tracked systemd units and their journal before touching anything.",
+		args: []*mcp.PromptArgument{
+			{Name: "unit", Description: "systemd unit to focus on, e.g. proxy.service"},
+		},
+		skills: []string{"platform", "host"},
+		steps: func(a map[string]string) []string {
+			steps := []string{
+				`hub_inspect_platform for the overall platform verdict.`,
+				`hub_inspect_host for CPU, memory, disk and load.`,
+				`hub_list_host_services for units that are failed, restarting or whose timers are overdue.`,
+			}
+			if a["unit"] != "" {
+				steps = append(steps, fmt.Sprintf(`hub_query_service_logs {"service": %q, "since": "1h", "priority": "warning"} for the unit's recent problems.`, a["unit"]))
+			} else {
+				steps = append(steps, `hub_query_service_logs for each unhealthy unit.`)
+			}
+			return append(steps, `Only propose hub_restart_service after the logs explain the failure; it needs a reason and a confirmed preview.`)
+		},
+	},
+	{
+		name:        "write_rca",
+		title:       "Draft a root cause analysis",
+		description: "Collect the evidence for an incident and draft an RCA in the format of the repository's existing ones.",
+		args: []*mcp.PromptArgument{
+			{Name: "service", Description: "Affected service", Required: true},
+			{Name: "since", Description: "Incident start as RFC3339 (default: the last hour)"},
+		},
+		skills: []string{"telemetry"},
+		steps: func(a map[string]string) []string {
+			window := `"hours": 1`
+			if a["since"] != "" {
+				window = fmt.Sprintf(`"since": %q`, a["since"])
+			}
+			return []string{
+				fmt.Sprintf(`build_incident_timeline {"service": %q, %s} for the timeline section.`, a["service"], window),
+				fmt.Sprintf(`investigate_incident {"service": %q} for the impact and anomalies.`, a["service"]),
+				fmt.Sprintf(`search_knowledge {"query": %q, "kind": "decision"} for ADRs that explain the affected design.`, a["service"]),
+				`Write the RCA following the structure of the embedded example: summary, timeline, root cause analysis, action items, verification and lessons learned.`,
+			}
+		},
+		resources: func(kb *KnowledgeBase) []string {
+			var latest string
+			for _, doc := range kb.Docs() {
+				if doc.Kind == KnowledgeIncident && doc.URI > latest {
+					latest = doc.URI
+				}
+			}
+			if latest == "" {
+				return nil
+			}
+			return []string{latest}
+		},
+	},
+}
+
+// SearchKnowledgeInput is the input for the search_knowledge tool.
+type SearchKnowledgeInput struct {
+	Query string `json:"query"`           // words that must all appear, e.g. "hubble relay"
+	Kind  string `json:"kind,omitempty"`  // skill, skill_reference, decision or incident
+	Limit int    `json:"limit,omitempty"` // max hits (default 5, max 20)
+}
+
+// RegisterKnowledge serves skills, ADRs and RCAs as MCP resources, adds the search_knowledge tool
+// and registers operational prompts that embed the relevant skills.
+func RegisterKnowledge(registry *ToolRegistry, kb *KnowledgeBase, serviceName string) {
+	server := registry.Server()
+	read := func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		doc, ok := kb.Get(req.Params.URI)
+		if !ok {
+			return nil, mcp.ResourceNotFoundError(req.Params.URI)
+		}
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{resourceContents(doc)}}, nil
+	}
+	for _, doc := range kb.Docs() {
+		meta := mcp.Meta{"kind": doc.Kind, "path": doc.Path}
+		for k, v := range doc.Metadata {
+			meta[k] = v
+		}
+		server.AddResource(&mcp.Resource{
+			URI:         doc.URI,
+			Name:        doc.Name,
+			Title:       doc.Title,
+			Description: doc.Description,
+			MIMEType:    "text/markdown",
+			Size:        int64(len(doc.Content)),
+			Meta:        meta,
+		}, read)
+	}
+
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "search_knowledge",
+			Description: "Search the skills, ADRs (docs/decisions) and incident RCAs served as obs:// resources; returns URIs to read with resources/read",
+		}, handleSearchKnowledge(kb, serviceName)),
+	)
+
+	for _, spec := range operationalPrompts {
+		server.AddPrompt(&mcp.Prompt{
+			Name:        spec.name,
+			Title:       spec.title,
+			Description: spec.description,
+			Arguments:   spec.args,
+		}, promptHandler(kb, spec))
+	}
+
+	libtelemetry.Info("registered knowledge resources and prompts", "resources", len(kb.Docs()), "prompts", len(operationalPrompts))
+}
+
+func handleSearchKnowledge(kb *KnowledgeBase, serviceName string) mcp.ToolHandlerFor[SearchKnowledgeInput, any] {
+	return InstrumentHandler("search_knowledge", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input SearchKnowledgeInput) (*mcp.CallToolResult, any, error) {
+		if strings.TrimSpace(input.Query) == "" {
+			return nil, nil, providers.InvalidInputf("query is required")
+		}
+		switch input.Kind {
+		case "", KnowledgeSkill, KnowledgeReference, KnowledgeDecision, KnowledgeIncident:
+		default:
+			return nil, nil, providers.InvalidInputf("kind must be skill, skill_reference, decision or incident")
+		}
+		hits := kb.Search(input.Query, input.Kind, input.Limit)
+		text, _ := json.Marshal(map[string]any{"count": len(hits), "hits": hits})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// promptHandler renders spec's instructions as the first message, followed by the embedded
+// skills and documents so clients without filesystem access get the full context.
+func promptHandler(kb *KnowledgeBase, spec promptSpec) mcp.PromptHandler {
+	return func(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := req.Params.Arguments
+		for _, a := range spec.args {
+			if a.Required && strings.TrimSpace(args[a.Name]) == "" {
+				return nil, fmt.Errorf("prompt %s requires argument %q", spec.name, a.Name)
+			}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s\n\nUse these tools in order, skipping steps that do not apply:\n", spec.description)
+		for i, step := range spec.steps(args) {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+		}
+		b.WriteString("\nThe skills below describe each tool's inputs and pitfalls. Do not run mutating tools without explicit approval. ")
+		b.WriteString("Finish with the root cause, the evidence for it and the next action.")
+		messages := []*mcp.PromptMessage{{Role: "user", Content: &mcp.TextContent{Text: b.String()}}}
+
+		uris := make([]string, 0, len(spec.skills))
+		for _, skill := range spec.skills {
+			uris = append(uris, KnowledgeURIScheme+"skills/"+skill)
+		}
+		if spec.resources != nil {
+			uris = append(uris, spec.resources(kb)...)
+		}
+		for _, uri := range uris {
+			doc, ok := kb.Get(uri)
+			if !ok {
+				continue
+			}
+			messages = append(messages, &mcp.PromptMessage{Role: "user", Content: &mcp.EmbeddedResource{Resource: resourceContents(doc)}})
+		}
+		return &mcp.GetPromptResult{Description: spec.description, Messages: messages}, nil
+	}
+}
+
+func resourceContents(doc KnowledgeDoc) *mcp.ResourceContents {
+	return &mcp.ResourceContents{URI: doc.URI, MIMEType: "text/markdown", Text: doc.Content}
+}
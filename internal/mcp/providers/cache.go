@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"observability-hub/internal/telemetry"
+)
+
+const (
+	// queryCacheTTL absorbs the repeated identical queries agents make within a few seconds
+	// without serving data noticeably older than a Prometheus scrape interval.
+	queryCacheTTL = 15 * time.Second
+	// discoveryCacheTTL keeps label/metric listings fresh enough for an agent session.
+	discoveryCacheTTL = 30 * time.Second
+	// responseCacheMaxBytes bounds the memory held by cached backend responses.
+	responseCacheMaxBytes = 32 << 20
+	// sharedFetchTimeout bounds a coalesced backend call, which outlives the caller that
+	// started it so one cancelled request does not fail every caller waiting on it.
+	sharedFetchTimeout = 60 * time.Second
+)
+
+var (
+	cacheMetricsOnce    sync.Once
+	cacheRequestCounter telemetry.Int64Counter
+)
+
+func initCacheTelemetry() {
+	cacheMetricsOnce.Do(func() {
+		meter := telemetry.GetMeter("mcp")
+		var err error
+		cacheRequestCounter, err = telemetry.NewInt64Counter(meter, "mcp_telemetry_cache_requests_total", "Telemetry backend requests by cache result (hit, miss, coalesced, bypass)")
+		if err != nil {
+			telemetry.Error("failed to create mcp_telemetry_cache_requests_total metric", "error", err)
+		}
+	})
+}
+
+type liveQueryKey struct{}
+
+// WithLiveQuery marks ctx so TelemetryProvider skips its response cache and always
+// queries the backend. The fresh response still replaces any cached entry.
+func WithLiveQuery(ctx context.Context) context.Context {
+	return context.WithValue(ctx, liveQueryKey{}, true)
+}
+
+// IsLiveQuery reports whether ctx was marked by WithLiveQuery.
+func IsLiveQuery(ctx context.Context) bool {
+	live, _ := ctx.Value(liveQueryKey{}).(bool)
+	return live
+}
+
+// responseCache is a byte-bounded LRU of JSON-encoded backend responses with a per-entry TTL.
+// Identical in-flight requests are coalesced so concurrent callers share one backend call.
+type responseCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	now      func() time.Time
+	lru      *list.List // front is most recently used
+	entries  map[string]*list.Element
+	group    singleflight.Group
+}
+
+type cacheEntry struct {
+	key     string
+	raw     []byte
+	expires time.Time
+}
+
+func newResponseCache(maxBytes int) *responseCache {
+	initCacheTelemetry()
+	return &responseCache{
+		maxBytes: maxBytes,
+		now:      time.Now,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *responseCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if c.now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e.raw, true
+}
+
+func (c *responseCache) set(key string, raw []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	// A single response larger than a quarter of the budget would flush most of the cache.
+	if len(raw) > c.maxBytes/4 {
+		return
+	}
+	for c.size+len(raw) > c.maxBytes && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, raw: raw, expires: c.now().Add(ttl)})
+	c.size += len(raw)
+}
+
+// remove must be called with mu held.
+func (c *responseCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, e.key)
+	c.size -= len(e.raw)
+}
+
+// cachedFetch returns the cached response for key or calls fetch, sharing the call with
+// concurrent identical requests. The shared call runs detached from the caller's
+// cancellation, bounded by sharedFetchTimeout, and each caller stops waiting when its own
+// ctx is done. Errors are never cached. Every caller decodes its own copy, so results can
+// be mutated freely.
+func cachedFetch[T any](ctx context.Context, c *responseCache, backend, key string, ttl time.Duration, fetch func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if IsLiveQuery(ctx) {
+		recordCacheResult(ctx, backend, "bypass")
+		v, err := fetch(ctx)
+		if err == nil {
+			if raw, err := json.Marshal(v); err == nil {
+				c.set(key, raw, ttl)
+			}
+		}
+		return v, err
+	}
+
+	if raw, ok := c.get(key); ok {
+		recordCacheResult(ctx, backend, "hit")
+		var v T
+		if err := json.Unmarshal(raw, &v); err == nil {
+			return v, nil
+		}
+	}
+
+	leader := false
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		leader = true
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedFetchTimeout)
+		defer cancel()
+		v, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, raw, ttl)
+		return raw, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if leader {
+			recordCacheResult(ctx, backend, "miss")
+		} else {
+			recordCacheResult(ctx, backend, "coalesced")
+		}
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		var v T
+		if err := json.Unmarshal(res.Val.([]byte), &v); err != nil {
+			return zero, err
+		}
+		return v, nil
+	}
+}
+
+func recordCacheResult(ctx context.Context, backend, result string) {
+	if cacheRequestCounter != nil {
+		telemetry.AddInt64Counter(ctx, cacheRequestCounter, 1,
+			telemetry.StringAttribute("backend", backend),
+			telemetry.StringAttribute("result", result),
+		)
+	}
+}
+
+// normalizeQuery collapses whitespace so trivially reformatted queries share a cache entry.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,225 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	newCache := func(maxBytes int) *responseCache {
+		c := newResponseCache(maxBytes)
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("entries expire after TTL", func(t *testing.T) {
+		c := newCache(1024)
+		c.set("a", []byte("1"), time.Minute)
+		if _, ok := c.get("a"); !ok {
+			t.Fatal("expected cached entry")
+		}
+		now = now.Add(2 * time.Minute)
+		if _, ok := c.get("a"); ok {
+			t.Error("expected entry to expire after TTL")
+		}
+		if c.size != 0 {
+			t.Errorf("got size %d after expiry, want 0", c.size)
+		}
+	})
+
+	t.Run("least recently used entries are evicted to stay within the byte bound", func(t *testing.T) {
+		c := newCache(40)
+		c.set("a", []byte("0123456789"), time.Minute)
+		c.set("b", []byte("0123456789"), time.Minute)
+		c.set("c", []byte("0123456789"), time.Minute)
+		c.get("a") // a is now more recent than b
+		c.set("d", []byte("0123456789"), time.Minute)
+		c.set("e", []byte("0123456789"), time.Minute)
+
+		if _, ok := c.get("b"); ok {
+			t.Error("expected least recently used entry b to be evicted")
+		}
+		if _, ok := c.get("a"); !ok {
+			t.Error("expected recently used entry a to survive")
+		}
+		if c.size > 40 {
+			t.Errorf("got size %d, want at most 40", c.size)
+		}
+	})
+
+	t.Run("oversized responses are not cached", func(t *testing.T) {
+		c := newCache(40)
+		c.set("big", make([]byte, 11), time.Minute)
+		if _, ok := c.get("big"); ok {
+			t.Error("expected response over a quarter of the budget to be skipped")
+		}
+	})
+}
+
+func TestCachedFetch(t *testing.T) {
+	t.Run("identical concurrent requests share one fetch", func(t *testing.T) {
+		c := newResponseCache(1024)
+		var calls int32
+		release := make(chan struct{})
+		fetch := func(context.Context) (map[string]interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return map[string]interface{}{"status": "success"}, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := cachedFetch(context.Background(), c, "thanos", "q", time.Minute, fetch)
+				if err != nil || got["status"] != "success" {
+					t.Errorf("got %v, %v, want success", got, err)
+				}
+			}()
+		}
+		// Give the goroutines a moment to join the in-flight call before it completes.
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Errorf("got %d fetches, want 1", n)
+		}
+	})
+
+	t.Run("cancelled leader does not fail waiting followers", func(t *testing.T) {
+		c := newResponseCache(1024)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		fetch := func(ctx context.Context) (map[string]interface{}, error) {
+			close(started)
+			select {
+			case <-release:
+				return map[string]interface{}{"status": "success"}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		leaderCtx, cancel := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, err := cachedFetch(leaderCtx, c, "thanos", "q", time.Minute, fetch)
+			leaderErr <- err
+		}()
+		<-started
+
+		type result struct {
+			v   map[string]interface{}
+			err error
+		}
+		follower := make(chan result, 1)
+		go func() {
+			v, err := cachedFetch(context.Background(), c, "thanos", "q", time.Minute, fetch)
+			follower <- result{v, err}
+		}()
+		// Let the follower join the in-flight call before the leader goes away.
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+			t.Errorf("leader got %v, want context.Canceled", err)
+		}
+		close(release)
+
+		got := <-follower
+		if got.err != nil || got.v["status"] != "success" {
+			t.Errorf("follower got %v, %v, want success", got.v, got.err)
+		}
+	})
+
+	t.Run("callers get independent copies", func(t *testing.T) {
+		c := newResponseCache(1024)
+		fetch := func(context.Context) (map[string]interface{}, error) { return map[string]interface{}{"v": "orig"}, nil }
+		first, _ := cachedFetch(context.Background(), c, "thanos", "q", time.Minute, fetch)
+		first["v"] = "mutated"
+		second, _ := cachedFetch(context.Background(), c, "thanos", "q", time.Minute, fetch)
+		if second["v"] != "orig" {
+			t.Errorf("got %v, want cached value unaffected by caller mutation", second["v"])
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := newResponseCache(1024)
+		var calls int32
+		fetch := func(context.Context) ([]string, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errors.New("unavailable")
+		}
+		cachedFetch(context.Background(), c, "loki", "q", time.Minute, fetch)
+		cachedFetch(context.Background(), c, "loki", "q", time.Minute, fetch)
+		if n := atomic.LoadInt32(&calls); n != 2 {
+			t.Errorf("got %d fetches, want 2", n)
+		}
+	})
+
+	t.Run("live queries bypass the cache", func(t *testing.T) {
+		c := newResponseCache(1024)
+		var calls int32
+		fetch := func(context.Context) ([]string, error) {
+			atomic.AddInt32(&calls, 1)
+			return []string{"x"}, nil
+		}
+		cachedFetch(context.Background(), c, "loki", "q", time.Minute, fetch)
+		cachedFetch(WithLiveQuery(context.Background()), c, "loki", "q", time.Minute, fetch)
+		if n := atomic.LoadInt32(&calls); n != 2 {
+			t.Errorf("got %d fetches, want 2", n)
+		}
+	})
+}
+
+func TestTelemetryProvider_QueryCaching(t *testing.T) {
+	var calls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	})
+	tp := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
+	ctx := context.Background()
+
+	if _, err := tp.QueryMetrics(ctx, "up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tp.QueryMetrics(ctx, "  up "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("got %d backend calls, want 1 (normalized query should hit the cache)", n)
+	}
+
+	if _, err := tp.QueryMetrics(WithLiveQuery(ctx), "up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tp.QueryLogs(ctx, `{service="proxy"}`, 10, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tp.QueryLogs(ctx, `{service="proxy"}`, 20, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Errorf("got %d backend calls, want 4 (live query and a different limit must miss)", n)
+	}
+
+	// Range queries a few seconds apart fall on the same step-aligned window.
+	end := time.Date(2026, 3, 11, 14, 0, 5, 0, time.UTC)
+	for _, shift := range []time.Duration{0, 20 * time.Second} {
+		if _, err := tp.QueryMetricsRange(ctx, "up", end.Add(shift-time.Hour), end.Add(shift), time.Minute); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 5 {
+		t.Errorf("got %d backend calls, want 5 (aligned range queries should share an entry)", n)
+	}
+}
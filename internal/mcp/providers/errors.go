@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Error classes reported with failed tool calls, so agents can tell a bad request from a
+// backend that is down and react accordingly.
+const (
+	ErrorClassValidation          = "validation"           // fix the input and retry
+	ErrorClassNotFound            = "not_found"            // the named object does not exist
+	ErrorClassForbidden           = "forbidden"            // denied by RBAC, backend auth or remediation policy
+	ErrorClassTimeout             = "timeout"              // the call ran out of time; narrow it or retry
+	ErrorClassUpstreamUnavailable = "upstream_unavailable" // backend unreachable or overloaded; retry later
+	ErrorClassUpstreamError       = "upstream_error"       // backend or tool failed otherwise
+)
+
+// ClassifiedError carries an explicit error class. Its message is the wrapped error's.
+type ClassifiedError struct {
+	Class string
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string { return e.Err.Error() }
+
+func (e *ClassifiedError) Unwrap() error { return e.Err }
+
+// InvalidInputf formats an error for input a tool or provider rejects.
+func InvalidInputf(format string, args ...any) error {
+	return &ClassifiedError{Class: ErrorClassValidation, Err: fmt.Errorf(format, args...)}
+}
+
+// NotFoundf formats an error for an object that does not exist.
+func NotFoundf(format string, args ...any) error {
+	return &ClassifiedError{Class: ErrorClassNotFound, Err: fmt.Errorf(format, args...)}
+}
+
+// Forbiddenf formats an error for an action that is not allowed.
+func Forbiddenf(format string, args ...any) error {
+	return &ClassifiedError{Class: ErrorClassForbidden, Err: fmt.Errorf(format, args...)}
+}
+
+// StatusError is a non-2xx HTTP response from a telemetry backend.
+type StatusError struct {
+	Backend    string // e.g. Thanos, Loki, Tempo
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.Backend, e.StatusCode)
+}
+
+// ErrorClass classifies err: explicit classes first, then context deadlines, Kubernetes API
+// errors, gRPC statuses (Hubble Relay), backend HTTP statuses and network failures. Anything
+// else is an upstream error.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+
+	var apiStatus apierrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		switch {
+		case apierrors.IsNotFound(err):
+			return ErrorClassNotFound
+		case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+			return ErrorClassForbidden
+		case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+			return ErrorClassValidation
+		case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+			return ErrorClassTimeout
+		case apierrors.IsServiceUnavailable(err), apierrors.IsTooManyRequests(err):
+			return ErrorClassUpstreamUnavailable
+		}
+		return ErrorClassUpstreamError
+	}
+
+	if st, ok := status.FromError(err); ok && st.Code() != codes.OK && st.Code() != codes.Unknown {
+		switch st.Code() {
+		case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+			return ErrorClassValidation
+		case codes.NotFound:
+			return ErrorClassNotFound
+		case codes.PermissionDenied, codes.Unauthenticated:
+			return ErrorClassForbidden
+		case codes.DeadlineExceeded:
+			return ErrorClassTimeout
+		case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+			return ErrorClassUpstreamUnavailable
+		}
+		return ErrorClassUpstreamError
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+			return ErrorClassValidation
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return ErrorClassForbidden
+		case code == http.StatusNotFound:
+			return ErrorClassNotFound
+		case code == http.StatusTooManyRequests || code == http.StatusBadGateway ||
+			code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout:
+			return ErrorClassUpstreamUnavailable
+		}
+		return ErrorClassUpstreamError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassUpstreamUnavailable
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return ErrorClassUpstreamUnavailable
+	}
+	return ErrorClassUpstreamError
+}
@@ -0,0 +1,49 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestErrorClass(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "explicit validation", err: fmt.Errorf("outer: %w", InvalidInputf("bad %s", "input")), want: ErrorClassValidation},
+		{name: "explicit not found", err: NotFoundf("unit %q is not in the host inventory", "sshd"), want: ErrorClassNotFound},
+		{name: "context deadline", err: fmt.Errorf("query failed: %w", context.DeadlineExceeded), want: ErrorClassTimeout},
+		{name: "kubernetes not found", err: fmt.Errorf("failed to get pod: %w", apierrors.NewNotFound(pods, "api-0")), want: ErrorClassNotFound},
+		{name: "kubernetes forbidden", err: apierrors.NewForbidden(pods, "api-0", errors.New("rbac")), want: ErrorClassForbidden},
+		{name: "kubernetes unavailable", err: apierrors.NewServiceUnavailable("etcd"), want: ErrorClassUpstreamUnavailable},
+		{name: "grpc unavailable", err: fmt.Errorf("hubble relay: %w", status.Error(codes.Unavailable, "no peers")), want: ErrorClassUpstreamUnavailable},
+		{name: "grpc invalid argument", err: status.Error(codes.InvalidArgument, "bad filter"), want: ErrorClassValidation},
+		{name: "backend bad request", err: &StatusError{Backend: "Thanos", StatusCode: 400}, want: ErrorClassValidation},
+		{name: "backend unauthorized", err: &StatusError{Backend: "Loki", StatusCode: 401}, want: ErrorClassForbidden},
+		{name: "backend overloaded", err: &StatusError{Backend: "Tempo", StatusCode: 429}, want: ErrorClassUpstreamUnavailable},
+		{name: "backend internal error", err: &StatusError{Backend: "Tempo", StatusCode: 500}, want: ErrorClassUpstreamError},
+		{name: "connection refused", err: fmt.Errorf("failed to query Thanos: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), want: ErrorClassUpstreamUnavailable},
+		{name: "kubectl missing", err: fmt.Errorf("hubble observe failed: %w", exec.ErrNotFound), want: ErrorClassUpstreamUnavailable},
+		{name: "anything else", err: errors.New("rust processor execution failed"), want: ErrorClassUpstreamError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorClass(tt.err); got != tt.want {
+				t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
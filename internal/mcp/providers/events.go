@@ -0,0 +1,193 @@
+package providers
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"observability-hub/internal/telemetry"
+)
+
+const (
+	// DefaultEventBufferSize bounds the Warning events kept in memory. Events are
+	// small, so a few thousand cover several hours of a noisy cluster.
+	DefaultEventBufferSize = 5000
+	// eventResyncPeriod is zero: Warning events only need add/update notifications.
+	eventResyncPeriod = 0
+)
+
+// WarningEvent is the retained part of a Kubernetes Warning event.
+type WarningEvent struct {
+	UID       types.UID `json:"-"`
+	Namespace string    `json:"namespace"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Kind      string    `json:"kind"` // involved object
+	Name      string    `json:"name"`
+	Source    string    `json:"source,omitempty"` // reporting component, e.g. kubelet or default-scheduler
+	Count     int32     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// EventSnapshot is a consistent copy of the watcher's buffer.
+type EventSnapshot struct {
+	Events    []WarningEvent // most recently seen first
+	Evicted   int            // events dropped because the buffer was full
+	Synced    bool           // the initial list of existing events has been loaded
+	StartedAt time.Time
+}
+
+// EventWatcher keeps a bounded buffer of cluster-wide Warning events fed by an informer,
+// so digests can cover events that have already expired from the API server.
+type EventWatcher struct {
+	mu        sync.Mutex
+	capacity  int
+	order     *list.List // front is most recently seen
+	entries   map[types.UID]*list.Element
+	evicted   int
+	synced    bool
+	startedAt time.Time
+}
+
+// NewEventWatcher creates an empty watcher holding at most capacity events.
+func NewEventWatcher(capacity int) *EventWatcher {
+	if capacity <= 0 {
+		capacity = DefaultEventBufferSize
+	}
+	return &EventWatcher{
+		capacity:  capacity,
+		order:     list.New(),
+		entries:   make(map[types.UID]*list.Element),
+		startedAt: time.Now(),
+	}
+}
+
+// WatchEvents starts an informer on Warning events across all namespaces and returns the
+// watcher it feeds. The informer stops when ctx is cancelled. It does not wait for the
+// initial sync, so a slow API server never delays server startup.
+func (p *PodsProvider) WatchEvents(ctx context.Context, capacity int) (*EventWatcher, error) {
+	w := NewEventWatcher(capacity)
+	if err := w.run(ctx, p.clientset); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *EventWatcher) run(ctx context.Context, clientset kubernetes.Interface) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, eventResyncPeriod,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = "type=" + corev1.EventTypeWarning
+		}))
+	informer := factory.Core().V1().Events().Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.observe,
+		UpdateFunc: func(_, obj interface{}) { w.observe(obj) },
+	}); err != nil {
+		return fmt.Errorf("failed to register event handler: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			return
+		}
+		w.mu.Lock()
+		w.synced = true
+		size := w.order.Len()
+		w.mu.Unlock()
+		telemetry.Info("event watcher synced", "buffered", size)
+	}()
+	return nil
+}
+
+// observe records an added or updated event. Deletions are ignored on purpose: the
+// buffer exists to outlive the API server's event TTL.
+func (w *EventWatcher) observe(obj interface{}) {
+	// The informer already selects Warning events server-side; this only guards
+	// against unexpected objects.
+	ev, ok := obj.(*corev1.Event)
+	if !ok || ev.Type != corev1.EventTypeWarning {
+		return
+	}
+	w.Record(warningEvent(ev))
+}
+
+// Record adds e to the buffer, replacing an earlier version of the same event and evicting
+// the least recently seen event when full.
+func (w *EventWatcher) Record(e WarningEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if el, ok := w.entries[e.UID]; ok {
+		el.Value = e
+		w.order.MoveToFront(el)
+		return
+	}
+	w.entries[e.UID] = w.order.PushFront(e)
+	for w.order.Len() > w.capacity {
+		oldest := w.order.Back()
+		delete(w.entries, oldest.Value.(WarningEvent).UID)
+		w.order.Remove(oldest)
+		w.evicted++
+	}
+}
+
+// Snapshot returns a copy of the buffered events.
+func (w *EventWatcher) Snapshot() EventSnapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	events := make([]WarningEvent, 0, w.order.Len())
+	for el := w.order.Front(); el != nil; el = el.Next() {
+		events = append(events, el.Value.(WarningEvent))
+	}
+	return EventSnapshot{Events: events, Evicted: w.evicted, Synced: w.synced, StartedAt: w.startedAt}
+}
+
+// warningEvent normalizes the legacy and series-based timestamp and count fields.
+func warningEvent(ev *corev1.Event) WarningEvent {
+	e := WarningEvent{
+		UID:       ev.UID,
+		Namespace: ev.Namespace,
+		Reason:    ev.Reason,
+		Message:   ev.Message,
+		Kind:      ev.InvolvedObject.Kind,
+		Name:      ev.InvolvedObject.Name,
+		Source:    ev.Source.Component,
+		Count:     ev.Count,
+		FirstSeen: ev.FirstTimestamp.Time,
+		LastSeen:  ev.LastTimestamp.Time,
+	}
+	if e.Source == "" {
+		e.Source = ev.ReportingController
+	}
+	if e.FirstSeen.IsZero() {
+		e.FirstSeen = ev.EventTime.Time
+	}
+	if e.FirstSeen.IsZero() {
+		e.FirstSeen = ev.CreationTimestamp.Time
+	}
+	if ev.Series != nil {
+		if e.Count == 0 {
+			e.Count = ev.Series.Count
+		}
+		if e.LastSeen.IsZero() {
+			e.LastSeen = ev.Series.LastObservedTime.Time
+		}
+	}
+	if e.LastSeen.IsZero() {
+		e.LastSeen = e.FirstSeen
+	}
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	return e
+}
@@ -0,0 +1,119 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEventWatcher_Record(t *testing.T) {
+	w := NewEventWatcher(2)
+	w.Record(WarningEvent{UID: "a", Reason: "BackOff", Count: 1})
+	w.Record(WarningEvent{UID: "b", Reason: "Unhealthy", Count: 1})
+	w.Record(WarningEvent{UID: "a", Reason: "BackOff", Count: 5})
+	w.Record(WarningEvent{UID: "c", Reason: "FailedMount", Count: 1})
+
+	snap := w.Snapshot()
+	if len(snap.Events) != 2 || snap.Evicted != 1 {
+		t.Fatalf("Snapshot() = %d events, %d evicted; want 2, 1", len(snap.Events), snap.Evicted)
+	}
+	// "b" was least recently seen once "a" was updated, so it is the one evicted.
+	if snap.Events[0].UID != "c" || snap.Events[1].UID != "a" || snap.Events[1].Count != 5 {
+		t.Errorf("Snapshot() = %+v, want c then updated a", snap.Events)
+	}
+}
+
+func TestWarningEvent(t *testing.T) {
+	first := metav1.NewTime(time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC))
+	last := metav1.NewTime(first.Add(10 * time.Minute))
+
+	tests := []struct {
+		name      string
+		event     *corev1.Event
+		wantCount int32
+		wantFirst time.Time
+		wantLast  time.Time
+		wantSrc   string
+	}{
+		{
+			name: "legacy fields",
+			event: &corev1.Event{
+				Count: 4, FirstTimestamp: first, LastTimestamp: last,
+				Source: corev1.EventSource{Component: "kubelet"},
+			},
+			wantCount: 4, wantFirst: first.Time, wantLast: last.Time, wantSrc: "kubelet",
+		},
+		{
+			name: "series fields",
+			event: &corev1.Event{
+				EventTime:           metav1.NewMicroTime(first.Time),
+				Series:              &corev1.EventSeries{Count: 7, LastObservedTime: metav1.NewMicroTime(last.Time)},
+				ReportingController: "default-scheduler",
+			},
+			wantCount: 7, wantFirst: first.Time, wantLast: last.Time, wantSrc: "default-scheduler",
+		},
+		{
+			name:      "single occurrence",
+			event:     &corev1.Event{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: first}},
+			wantCount: 1, wantFirst: first.Time, wantLast: first.Time,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := warningEvent(tt.event)
+			if got.Count != tt.wantCount || !got.FirstSeen.Equal(tt.wantFirst) || !got.LastSeen.Equal(tt.wantLast) || got.Source != tt.wantSrc {
+				t.Errorf("warningEvent() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestPodsProvider_WatchEvents(t *testing.T) {
+	event := func(uid, name, eventType string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(uid)},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-0"},
+			Type:           eventType,
+			Reason:         "BackOff",
+			Count:          1,
+		}
+	}
+	clientset := fake.NewSimpleClientset(event("1", "existing", corev1.EventTypeWarning), event("2", "normal", corev1.EventTypeNormal))
+	provider := &PodsProvider{clientset: clientset}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := provider.WatchEvents(ctx, 10)
+	if err != nil {
+		t.Fatalf("WatchEvents() unexpected error: %v", err)
+	}
+	waitFor := func(desc string, cond func(EventSnapshot) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond(w.Snapshot()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: %+v", desc, w.Snapshot())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("initial sync", func(s EventSnapshot) bool { return s.Synced && len(s.Events) == 1 })
+
+	created := event("3", "new", corev1.EventTypeWarning)
+	if _, err := clientset.CoreV1().Events("default").Create(ctx, created, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("new event", func(s EventSnapshot) bool { return len(s.Events) == 2 })
+
+	created.Count = 9
+	if _, err := clientset.CoreV1().Events("default").Update(ctx, created, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("updated count", func(s EventSnapshot) bool { return len(s.Events) == 2 && s.Events[0].Count == 9 })
+}
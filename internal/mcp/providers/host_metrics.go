@@ -0,0 +1,470 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cpuSampleInterval is the gap between the two /proc/stat reads used to compute CPU usage.
+const cpuSampleInterval = 250 * time.Millisecond
+
+// HostFS abstracts the host's procfs, sysfs and filesystem stats so host metrics can be
+// read from fixture trees in tests.
+type HostFS interface {
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Statfs(path string) (FSStats, error)
+}
+
+// FSStats is the subset of statfs(2) needed for disk usage.
+type FSStats struct {
+	TotalBytes     uint64
+	FreeBytes      uint64 // including blocks reserved for root
+	AvailableBytes uint64 // available to unprivileged users
+	Inodes         uint64
+	FreeInodes     uint64
+}
+
+// OSHostFS implements HostFS on the live host. Root prefixes every path, e.g. "/host"
+// when the host filesystem is mounted into a container.
+type OSHostFS struct {
+	Root string
+}
+
+// UseHostRoot makes host inspection read /proc, /sys and mounts under root, for a gateway
+// running in a container with the host filesystem mounted there.
+func (p *HubProvider) UseHostRoot(root string) {
+	p.fs = &OSHostFS{Root: root}
+}
+
+func (f *OSHostFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.Root, name))
+}
+
+func (f *OSHostFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(filepath.Join(f.Root, name))
+}
+
+func (f *OSHostFS) Statfs(path string) (FSStats, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(filepath.Join(f.Root, path), &st); err != nil {
+		return FSStats{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return FSStats{
+		TotalBytes:     uint64(st.Blocks) * bsize,
+		FreeBytes:      uint64(st.Bfree) * bsize,
+		AvailableBytes: uint64(st.Bavail) * bsize,
+		Inodes:         uint64(st.Files),
+		FreeInodes:     uint64(st.Ffree),
+	}, nil
+}
+
+// HostResource represents physical resource usage on the host. Sizes are in bytes,
+// shares in percent (0-100) and temperatures in degrees Celsius.
+type HostResource struct {
+	CPUUsagePercent float64                  `json:"cpu_usage_percent"` // all cores, sampled over 250ms
+	CPUCores        int                      `json:"cpu_cores"`
+	Load            LoadAverage              `json:"load_average"`
+	Memory          MemoryUsage              `json:"memory"`
+	Pressure        map[string]PressureStall `json:"pressure,omitempty"` // cpu, memory, io; absent on kernels without PSI
+	Disks           []DiskUsage              `json:"disks"`
+	Temperatures    []Temperature            `json:"temperatures,omitempty"`
+	Errors          map[string]string        `json:"errors,omitempty"` // sources that could not be read
+}
+
+// LoadAverage is /proc/loadavg.
+type LoadAverage struct {
+	Load1         float64 `json:"load1"`
+	Load5         float64 `json:"load5"`
+	Load15        float64 `json:"load15"`
+	RunnableTasks int     `json:"runnable_tasks"`
+	TotalTasks    int     `json:"total_tasks"`
+}
+
+// MemoryUsage is derived from /proc/meminfo. Used excludes reclaimable page cache.
+type MemoryUsage struct {
+	TotalBytes     uint64  `json:"total_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"`
+	UsedBytes      uint64  `json:"used_bytes"`
+	UsedPercent    float64 `json:"used_percent"`
+	SwapTotalBytes uint64  `json:"swap_total_bytes"`
+	SwapUsedBytes  uint64  `json:"swap_used_bytes"`
+}
+
+// PressureStall is one /proc/pressure file: the share of wall time in which some (or all)
+// runnable tasks were stalled on the resource, averaged over 10s, 60s and 300s.
+type PressureStall struct {
+	SomeAvg10  float64 `json:"some_avg10"`
+	SomeAvg60  float64 `json:"some_avg60"`
+	SomeAvg300 float64 `json:"some_avg300"`
+	FullAvg10  float64 `json:"full_avg10"`
+	FullAvg60  float64 `json:"full_avg60"`
+	FullAvg300 float64 `json:"full_avg300"`
+}
+
+// DiskUsage is statfs for one mounted block device.
+type DiskUsage struct {
+	Mountpoint        string  `json:"mountpoint"`
+	Device            string  `json:"device"`
+	FSType            string  `json:"fs_type"`
+	TotalBytes        uint64  `json:"total_bytes"`
+	UsedBytes         uint64  `json:"used_bytes"`
+	AvailableBytes    uint64  `json:"available_bytes"`
+	UsedPercent       float64 `json:"used_percent"` // as df reports it: used / (used + available)
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+}
+
+// Temperature is one hwmon temperature input.
+type Temperature struct {
+	Sensor          string  `json:"sensor"` // hwmon chip name, e.g. coretemp or nvme
+	Label           string  `json:"label,omitempty"`
+	Celsius         float64 `json:"celsius"`
+	CriticalCelsius float64 `json:"critical_celsius,omitempty"`
+}
+
+// InspectHost reads CPU, load, memory, pressure, disk and temperature metrics from procfs
+// and sysfs. Sources that fail are reported in Errors; it only fails when procfs itself is unreadable.
+func (p *HubProvider) InspectHost(ctx context.Context) (*HostResource, error) {
+	res := &HostResource{Errors: make(map[string]string)}
+	record := func(source string, err error) {
+		if err != nil {
+			res.Errors[source] = err.Error()
+		}
+	}
+
+	var err error
+	res.CPUUsagePercent, res.CPUCores, err = p.sampleCPU(ctx)
+	record("cpu", err)
+	res.Load, err = p.readLoadAverage()
+	record("load_average", err)
+	res.Memory, err = p.readMemory()
+	record("memory", err)
+	res.Pressure, err = p.readPressure()
+	record("pressure", err)
+	res.Disks, err = p.readDisks()
+	record("disks", err)
+	res.Temperatures, err = p.readTemperatures()
+	record("temperatures", err)
+
+	if res.Errors["cpu"] != "" && res.Errors["load_average"] != "" && res.Errors["memory"] != "" {
+		return nil, fmt.Errorf("failed to read host metrics: %s", res.Errors["memory"])
+	}
+	if len(res.Errors) == 0 {
+		res.Errors = nil
+	}
+	return res, nil
+}
+
+type cpuTimes struct {
+	idle, total uint64
+}
+
+func (p *HubProvider) sampleCPU(ctx context.Context) (float64, int, error) {
+	first, cores, err := p.readCPUTimes()
+	if err != nil {
+		return 0, 0, err
+	}
+	select {
+	case <-ctx.Done():
+		return 0, cores, ctx.Err()
+	case <-time.After(p.cpuSampleInterval):
+	}
+	second, _, err := p.readCPUTimes()
+	if err != nil {
+		return 0, cores, err
+	}
+	return cpuPercent(first, second), cores, nil
+}
+
+// readCPUTimes parses the aggregate "cpu" line of /proc/stat and counts the per-core lines.
+func (p *HubProvider) readCPUTimes() (cpuTimes, int, error) {
+	data, err := p.fs.ReadFile("/proc/stat")
+	if err != nil {
+		return cpuTimes{}, 0, err
+	}
+	var times cpuTimes
+	found, cores := false, 0
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		if fields[0] != "cpu" {
+			cores++
+			continue
+		}
+		// user nice system idle iowait irq softirq steal; guest time is already counted in user.
+		for i, f := range fields[1:] {
+			if i >= 8 {
+				break
+			}
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return cpuTimes{}, 0, fmt.Errorf("malformed /proc/stat: %w", err)
+			}
+			times.total += v
+			if i == 3 || i == 4 {
+				times.idle += v
+			}
+		}
+		found = true
+	}
+	if !found {
+		return cpuTimes{}, 0, errors.New("malformed /proc/stat: no cpu line")
+	}
+	return times, cores, nil
+}
+
+func cpuPercent(first, second cpuTimes) float64 {
+	total := float64(second.total) - float64(first.total)
+	if total <= 0 {
+		return 0
+	}
+	busy := total - (float64(second.idle) - float64(first.idle))
+	return round1(busy / total * 100)
+}
+
+func (p *HubProvider) readLoadAverage() (LoadAverage, error) {
+	data, err := p.fs.ReadFile("/proc/loadavg")
+	if err != nil {
+		return LoadAverage{}, err
+	}
+	// e.g. "0.05 0.10 0.15 2/345 6789"
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return LoadAverage{}, fmt.Errorf("malformed /proc/loadavg: %q", data)
+	}
+	var load LoadAverage
+	for i, dst := range []*float64{&load.Load1, &load.Load5, &load.Load15} {
+		if *dst, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return LoadAverage{}, fmt.Errorf("malformed /proc/loadavg: %w", err)
+		}
+	}
+	if running, total, ok := strings.Cut(fields[3], "/"); ok {
+		load.RunnableTasks, _ = strconv.Atoi(running)
+		load.TotalTasks, _ = strconv.Atoi(total)
+	}
+	return load, nil
+}
+
+func (p *HubProvider) readMemory() (MemoryUsage, error) {
+	data, err := p.fs.ReadFile("/proc/meminfo")
+	if err != nil {
+		return MemoryUsage{}, err
+	}
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// e.g. "MemAvailable:   16234567 kB"
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		values[key] = v
+	}
+	total, ok := values["MemTotal"]
+	if !ok || total == 0 {
+		return MemoryUsage{}, errors.New("malformed /proc/meminfo: no MemTotal")
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		// Kernels before 3.14 lack MemAvailable.
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	if available > total {
+		available = total
+	}
+	mem := MemoryUsage{
+		TotalBytes:     total,
+		AvailableBytes: available,
+		UsedBytes:      total - available,
+		UsedPercent:    round1(float64(total-available) / float64(total) * 100),
+		SwapTotalBytes: values["SwapTotal"],
+	}
+	if free := values["SwapFree"]; free <= mem.SwapTotalBytes {
+		mem.SwapUsedBytes = mem.SwapTotalBytes - free
+	}
+	return mem, nil
+}
+
+func (p *HubProvider) readPressure() (map[string]PressureStall, error) {
+	pressure := make(map[string]PressureStall)
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		data, err := p.fs.ReadFile("/proc/pressure/" + resource)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var stall PressureStall
+		for _, line := range strings.Split(string(data), "\n") {
+			// e.g. "some avg10=1.53 avg60=0.87 avg300=0.40 total=123456"
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+			avg := make([]float64, 3)
+			for i, f := range fields[1:4] {
+				_, v, _ := strings.Cut(f, "=")
+				avg[i], _ = strconv.ParseFloat(v, 64)
+			}
+			switch fields[0] {
+			case "some":
+				stall.SomeAvg10, stall.SomeAvg60, stall.SomeAvg300 = avg[0], avg[1], avg[2]
+			case "full":
+				stall.FullAvg10, stall.FullAvg60, stall.FullAvg300 = avg[0], avg[1], avg[2]
+			}
+		}
+		pressure[resource] = stall
+	}
+	if len(pressure) == 0 {
+		return nil, nil
+	}
+	return pressure, nil
+}
+
+// diskFSTypes are the filesystems backing real storage whose device is not under /dev.
+var diskFSTypes = map[string]bool{"zfs": true, "nfs": true, "nfs4": true, "cifs": true, "fuseblk": true}
+
+// readDisks reports every mounted block device once, at its first mountpoint, skipping
+// pseudo filesystems, loop devices (snaps) and bind mounts.
+func (p *HubProvider) readDisks() ([]DiskUsage, error) {
+	data, err := p.fs.ReadFile("/proc/mounts")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	disks := []DiskUsage{}
+	var failed []string
+	for _, line := range strings.Split(string(data), "\n") {
+		// e.g. "/dev/nvme0n1p2 / ext4 rw,relatime 0 0"
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		device, mountpoint, fsType := fields[0], unescapeMount(fields[1]), fields[2]
+		if !(strings.HasPrefix(device, "/dev/") || diskFSTypes[fsType]) || strings.HasPrefix(device, "/dev/loop") || seen[device] {
+			continue
+		}
+		seen[device] = true
+
+		st, err := p.fs.Statfs(mountpoint)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", mountpoint, err))
+			continue
+		}
+		used := st.TotalBytes - st.FreeBytes
+		disk := DiskUsage{
+			Mountpoint:     mountpoint,
+			Device:         device,
+			FSType:         fsType,
+			TotalBytes:     st.TotalBytes,
+			UsedBytes:      used,
+			AvailableBytes: st.AvailableBytes,
+		}
+		if used+st.AvailableBytes > 0 {
+			disk.UsedPercent = round1(float64(used) / float64(used+st.AvailableBytes) * 100)
+		}
+		if st.Inodes > 0 {
+			disk.InodesUsedPercent = round1(float64(st.Inodes-st.FreeInodes) / float64(st.Inodes) * 100)
+		}
+		disks = append(disks, disk)
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Mountpoint < disks[j].Mountpoint })
+	if len(failed) > 0 {
+		return disks, fmt.Errorf("statfs failed: %s", strings.Join(failed, "; "))
+	}
+	return disks, nil
+}
+
+// unescapeMount decodes the octal escapes /proc/mounts uses for spaces and tabs.
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	r := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+	return r.Replace(s)
+}
+
+// readTemperatures reads every temp*_input under /sys/class/hwmon. Hosts without
+// sensors (most VMs) return an empty list rather than an error.
+func (p *HubProvider) readTemperatures() ([]Temperature, error) {
+	chips, err := p.fs.ReadDir("/sys/class/hwmon")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var temps []Temperature
+	for _, chip := range chips {
+		dir := "/sys/class/hwmon/" + chip.Name()
+		entries, err := p.fs.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		sensor := chip.Name()
+		if name, err := p.fs.ReadFile(dir + "/name"); err == nil {
+			sensor = strings.TrimSpace(string(name))
+		}
+		for _, e := range entries {
+			prefix, ok := strings.CutSuffix(e.Name(), "_input")
+			if !ok || !strings.HasPrefix(prefix, "temp") {
+				continue
+			}
+			celsius, err := p.readMilliCelsius(dir + "/" + e.Name())
+			if err != nil {
+				continue
+			}
+			t := Temperature{Sensor: sensor, Celsius: celsius}
+			if label, err := p.fs.ReadFile(dir + "/" + prefix + "_label"); err == nil {
+				t.Label = strings.TrimSpace(string(label))
+			}
+			if crit, err := p.readMilliCelsius(dir + "/" + prefix + "_crit"); err == nil {
+				t.CriticalCelsius = crit
+			}
+			temps = append(temps, t)
+		}
+	}
+	return temps, nil
+}
+
+func (p *HubProvider) readMilliCelsius(path string) (float64, error) {
+	data, err := p.fs.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, err
+	}
+	return round1(v / 1000), nil
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
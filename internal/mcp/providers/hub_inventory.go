@@ -0,0 +1,245 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Service types describe what a healthy unit looks like.
+const (
+	ServiceLongRunning = "long-running" // must stay active (running)
+	ServiceOneshot     = "oneshot"      // runs to completion, usually from a timer
+)
+
+// HostInventory describes the host the hub provider inspects and the systemd units it tracks.
+type HostInventory struct {
+	Node     string        `yaml:"node"` // reported by hub_inspect_platform; defaults to the hostname
+	Services []HostService `yaml:"services"`
+}
+
+// HostService is one tracked systemd unit and the expectations used to judge its health.
+type HostService struct {
+	Unit        string        `yaml:"unit"`         // e.g. proxy.service; ".service" is appended when there is no suffix
+	Type        string        `yaml:"type"`         // long-running (default) or oneshot
+	Timer       string        `yaml:"timer"`        // timer unit that triggers a oneshot, e.g. backup.timer
+	MaxInterval time.Duration `yaml:"max_interval"` // oneshot with a timer: unhealthy when the last run is older than this
+	MaxRestarts int           `yaml:"max_restarts"` // long-running: unhealthy above this many automatic restarts (0 ignores restarts)
+	HealthURL   string        `yaml:"health_url"`   // optional HTTP endpoint checked before and after hub_restart_service
+}
+
+// DefaultHostInventory tracks the core hub units on the local host.
+func DefaultHostInventory() HostInventory {
+	node, _ := os.Hostname()
+	return HostInventory{
+		Node: node,
+		Services: []HostService{
+			{Unit: "proxy.service", Type: ServiceLongRunning},
+			{Unit: "openbao.service", Type: ServiceLongRunning},
+			{Unit: "tailscale-gate.service", Type: ServiceLongRunning},
+		},
+	}
+}
+
+// LoadHostInventory reads a HostInventory from a YAML file, e.g.
+//
+//	node: server2
+//	services:
+//	  - unit: proxy.service
+//	    max_restarts: 5
+//	    health_url: http://127.0.0.1:8080/healthz
+//	  - unit: pg-backup
+//	    type: oneshot
+//	    timer: pg-backup.timer
+//	    max_interval: 25h
+func LoadHostInventory(path string) (HostInventory, error) {
+	var inv HostInventory
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return inv, fmt.Errorf("failed to read host inventory: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &inv); err != nil {
+		return inv, fmt.Errorf("failed to parse host inventory: %w", err)
+	}
+	return inv, inv.normalize()
+}
+
+// normalize fills defaults and rejects entries that could never be evaluated.
+func (inv *HostInventory) normalize() error {
+	if inv.Node == "" {
+		inv.Node, _ = os.Hostname()
+	}
+	for i := range inv.Services {
+		svc := &inv.Services[i]
+		if svc.Unit == "" {
+			return fmt.Errorf("host inventory: service %d has no unit", i)
+		}
+		if !strings.Contains(svc.Unit, ".") {
+			svc.Unit += ".service"
+		}
+		if svc.Timer != "" && !strings.Contains(svc.Timer, ".") {
+			svc.Timer += ".timer"
+		}
+		switch svc.Type {
+		case "":
+			svc.Type = ServiceLongRunning
+			if svc.Timer != "" {
+				svc.Type = ServiceOneshot
+			}
+		case ServiceLongRunning, ServiceOneshot:
+		default:
+			return fmt.Errorf("host inventory: %s has unknown type %q (use %s or %s)", svc.Unit, svc.Type, ServiceLongRunning, ServiceOneshot)
+		}
+		if svc.MaxInterval > 0 && svc.Timer == "" {
+			return fmt.Errorf("host inventory: %s sets max_interval without a timer", svc.Unit)
+		}
+	}
+	return nil
+}
+
+// SystemdBackend reads unit properties. The default implementation shells out to
+// systemctl through a CommandRunner; a D-Bus client can satisfy the same interface.
+type SystemdBackend interface {
+	UnitProperties(ctx context.Context, unit string, props ...string) (map[string]string, error)
+}
+
+// SystemctlBackend implements SystemdBackend with `systemctl show`. Timestamps are requested
+// as "@<unix seconds>" (systemd 247+), since the default format names the zone by an
+// abbreviation that cannot be parsed reliably.
+type SystemctlBackend struct {
+	runner CommandRunner
+}
+
+// NewSystemctlBackend creates a SystemctlBackend running commands through runner.
+func NewSystemctlBackend(runner CommandRunner) *SystemctlBackend {
+	return &SystemctlBackend{runner: runner}
+}
+
+func (b *SystemctlBackend) UnitProperties(ctx context.Context, unit string, props ...string) (map[string]string, error) {
+	out, err := b.runner.Run(ctx, "systemctl", "show", unit, "--timestamp=unix", "--property="+strings.Join(props, ","))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[key] = strings.TrimSpace(val)
+	}
+	return values, nil
+}
+
+// TimerStatus is the state of the timer triggering a oneshot service.
+type TimerStatus struct {
+	Unit        string     `json:"unit"`
+	Active      string     `json:"active"`
+	LastTrigger *time.Time `json:"last_trigger,omitempty"`
+	NextTrigger *time.Time `json:"next_trigger,omitempty"`
+}
+
+var (
+	serviceProperties = []string{"LoadState", "ActiveState", "SubState", "ActiveEnterTimestamp", "Result", "NRestarts", "MemoryCurrent", "CPUUsageNSec"}
+	timerProperties   = []string{"ActiveState", "LastTriggerUSec", "NextElapseUSecRealtime"}
+)
+
+// serviceStatus queries one unit (and its timer) and judges it against the inventory.
+func (p *HubProvider) serviceStatus(ctx context.Context, svc HostService, now time.Time) ServiceStatus {
+	status := ServiceStatus{Name: svc.Unit, Type: svc.Type}
+	props, err := p.systemd.UnitProperties(ctx, svc.Unit, serviceProperties...)
+	if err != nil {
+		status.Problem = fmt.Sprintf("failed to query unit: %v", err)
+		return status
+	}
+	status.Load = props["LoadState"]
+	status.Active = props["ActiveState"]
+	status.Sub = props["SubState"]
+	if since := parseSystemdTime(props["ActiveEnterTimestamp"]); since != nil {
+		status.Since = since.Format(time.RFC3339)
+	}
+	status.Result = props["Result"]
+	if n, err := strconv.Atoi(props["NRestarts"]); err == nil {
+		status.Restarts = n
+	}
+	// Accounting fields read "[not set]" or the max uint64 when accounting is disabled.
+	if v, err := strconv.ParseUint(props["MemoryCurrent"], 10, 64); err == nil && v != math.MaxUint64 {
+		status.MemoryBytes = v
+	}
+	if v, err := strconv.ParseUint(props["CPUUsageNSec"], 10, 64); err == nil && v != math.MaxUint64 {
+		status.CPUSeconds = math.Round(float64(v)/1e7) / 100
+	}
+
+	if svc.Timer != "" {
+		status.Timer = &TimerStatus{Unit: svc.Timer}
+		tprops, err := p.systemd.UnitProperties(ctx, svc.Timer, timerProperties...)
+		if err != nil {
+			status.Problem = fmt.Sprintf("failed to query timer: %v", err)
+			return status
+		}
+		status.Timer.Active = tprops["ActiveState"]
+		status.Timer.LastTrigger = parseSystemdTime(tprops["LastTriggerUSec"])
+		status.Timer.NextTrigger = parseSystemdTime(tprops["NextElapseUSecRealtime"])
+	}
+
+	status.Problem = serviceProblem(svc, status, now)
+	status.Healthy = status.Problem == ""
+	return status
+}
+
+// serviceProblem explains why status does not meet svc's expectations, or returns "".
+func serviceProblem(svc HostService, status ServiceStatus, now time.Time) string {
+	if status.Active == "" || status.Load == "not-found" {
+		return "unit not found"
+	}
+	if status.Active == "failed" || (status.Result != "" && status.Result != "success") {
+		return fmt.Sprintf("unit %s (result %s)", status.Active, status.Result)
+	}
+
+	switch svc.Type {
+	case ServiceOneshot:
+		if status.Timer != nil {
+			if status.Timer.Active != "active" {
+				return fmt.Sprintf("timer %s is %s", status.Timer.Unit, status.Timer.Active)
+			}
+			if svc.MaxInterval > 0 {
+				last := status.Timer.LastTrigger
+				if last == nil {
+					return fmt.Sprintf("timer %s has never triggered (expected every %s)", status.Timer.Unit, svc.MaxInterval)
+				}
+				if age := now.Sub(*last); age > svc.MaxInterval {
+					return fmt.Sprintf("last run %s ago, expected every %s", age.Round(time.Minute), svc.MaxInterval)
+				}
+			}
+		}
+	default:
+		if status.Active != "active" || status.Sub != "running" {
+			return fmt.Sprintf("expected active (running), got %s (%s)", status.Active, status.Sub)
+		}
+		if svc.MaxRestarts > 0 && status.Restarts > svc.MaxRestarts {
+			return fmt.Sprintf("restarted %d times (max %d)", status.Restarts, svc.MaxRestarts)
+		}
+	}
+	return ""
+}
+
+// parseSystemdTime parses a "@<unix seconds>" timestamp from systemctl show --timestamp=unix.
+// "n/a", empty and unparseable values return nil.
+func parseSystemdTime(s string) *time.Time {
+	secs, ok := strings.CutPrefix(s, "@")
+	if !ok {
+		return nil
+	}
+	n, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	t := time.Unix(n, 0).UTC()
+	return &t
+}
@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalScanLimit bounds how many entries one grep page reads before giving up on more matches.
+const journalScanLimit = 5000
+
+// JournalPriorities are the syslog priority names, indexed by level.
+var JournalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// JournalQuery selects entries of one inventory unit.
+type JournalQuery struct {
+	Unit        string
+	Since       time.Time // zero means the start of the journal
+	Until       time.Time // zero means now
+	MaxPriority int       // 0 (emerg) to 7 (debug); entries less severe than this are skipped
+	Pattern     *regexp.Regexp
+	Cursor      string // continue after this entry, from a previous page's NextCursor
+	Limit       int
+}
+
+// JournalEntry is one journal record.
+type JournalEntry struct {
+	Time       time.Time `json:"time"`
+	Priority   int       `json:"priority"`
+	Level      string    `json:"level"` // priority name, e.g. err
+	PID        int       `json:"pid,omitempty"`
+	Identifier string    `json:"identifier,omitempty"` // SYSLOG_IDENTIFIER, usually the process name
+	Message    string    `json:"message"`
+
+	cursor string
+}
+
+// JournalPage is one page of a journal query, newest entry first.
+type JournalPage struct {
+	Unit       string         `json:"unit"`
+	Entries    []JournalEntry `json:"entries"`
+	Scanned    int            `json:"scanned"`               // entries read, including those the pattern rejected
+	NextCursor string         `json:"next_cursor,omitempty"` // pass as cursor to continue with older entries
+}
+
+// QueryJournal reads a unit's journal as JSON, newest first. Pattern filtering happens here rather
+// than with journalctl --grep, which depends on how journalctl was built, so a page with a pattern
+// scans up to journalScanLimit entries looking for Limit matches.
+func (p *HubProvider) QueryJournal(ctx context.Context, q JournalQuery) (*JournalPage, error) {
+	svc, ok := p.InventoryService(q.Unit)
+	if !ok {
+		return nil, NotFoundf("unit %q is not in the host inventory", q.Unit)
+	}
+	if q.Limit <= 0 {
+		return nil, InvalidInputf("limit must be positive")
+	}
+	if q.MaxPriority < 0 || q.MaxPriority >= len(JournalPriorities) {
+		return nil, InvalidInputf("priority must be between 0 and %d", len(JournalPriorities)-1)
+	}
+
+	batch := q.Limit
+	if q.Pattern != nil {
+		batch = journalScanLimit
+	}
+	args := []string{"-u", svc.Unit, "--output=json", "--no-pager", "--reverse", "-n", strconv.Itoa(batch)}
+	if !q.Since.IsZero() {
+		args = append(args, "--since", fmt.Sprintf("@%d", q.Since.Unix()))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--until", fmt.Sprintf("@%d", q.Until.Unix()))
+	}
+	if q.MaxPriority < len(JournalPriorities)-1 {
+		args = append(args, "--priority", strconv.Itoa(q.MaxPriority))
+	}
+	if q.Cursor != "" {
+		args = append(args, "--after-cursor", q.Cursor)
+	}
+
+	out, err := p.runner.Run(ctx, "journalctl", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal for %s: %w: %s", svc.Unit, err, strings.TrimSpace(string(out)))
+	}
+
+	page := &JournalPage{Unit: svc.Unit, Entries: []JournalEntry{}}
+	var last string
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		entry, err := parseJournalEntry([]byte(line))
+		if err != nil {
+			continue
+		}
+		page.Scanned++
+		last = entry.cursor
+		if q.Pattern != nil && !q.Pattern.MatchString(entry.Message) {
+			continue
+		}
+		page.Entries = append(page.Entries, entry)
+		if len(page.Entries) == q.Limit {
+			page.NextCursor = entry.cursor
+			return page, nil
+		}
+	}
+	// A full batch may have more entries behind it.
+	if page.Scanned >= batch {
+		page.NextCursor = last
+	}
+	return page, nil
+}
+
+// parseJournalEntry decodes one line of `journalctl --output=json`. Fields are strings, except
+// MESSAGE, which is an array of bytes when it is not valid UTF-8.
+func parseJournalEntry(line []byte) (JournalEntry, error) {
+	var raw struct {
+		Cursor     string          `json:"__CURSOR"`
+		Realtime   string          `json:"__REALTIME_TIMESTAMP"`
+		Priority   string          `json:"PRIORITY"`
+		PID        string          `json:"_PID"`
+		Identifier string          `json:"SYSLOG_IDENTIFIER"`
+		Message    json.RawMessage `json:"MESSAGE"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return JournalEntry{}, err
+	}
+
+	entry := JournalEntry{Identifier: raw.Identifier, cursor: raw.Cursor, Priority: 6}
+	if usec, err := strconv.ParseInt(raw.Realtime, 10, 64); err == nil {
+		entry.Time = time.UnixMicro(usec).UTC()
+	}
+	if prio, err := strconv.Atoi(raw.Priority); err == nil && prio >= 0 && prio < len(JournalPriorities) {
+		entry.Priority = prio
+	}
+	entry.Level = JournalPriorities[entry.Priority]
+	entry.PID, _ = strconv.Atoi(raw.PID)
+
+	var text string
+	var data []byte
+	switch {
+	case json.Unmarshal(raw.Message, &text) == nil:
+		entry.Message = text
+	case json.Unmarshal(raw.Message, &data) == nil:
+		entry.Message = strings.ToValidUTF8(string(data), "\uFFFD")
+	}
+	return entry, nil
+}
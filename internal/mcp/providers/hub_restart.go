@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	servicePollInterval = time.Second
+	healthProbeTimeout  = 5 * time.Second
+	// restartJournalLead is how far before the restart journal capture starts, to include what led up to it.
+	restartJournalLead = 30 * time.Second
+	// restartJournalTimeout bounds the journal read, which runs even when the call's
+	// deadline has passed: a slow restart is when the journal matters most.
+	restartJournalTimeout = 10 * time.Second
+	restartJournalLines   = 100
+)
+
+// HealthProbe is the result of a service's HTTP health check.
+type HealthProbe struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Healthy    bool   `json:"healthy"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ServiceRestart reports a systemd unit restart: its state and probe before and after, plus the journal around it.
+type ServiceRestart struct {
+	Unit        string        `json:"unit"`
+	Before      ServiceStatus `json:"before"`
+	BeforeProbe *HealthProbe  `json:"before_probe,omitempty"`
+	After       ServiceStatus `json:"after"`
+	AfterProbe  *HealthProbe  `json:"after_probe,omitempty"`
+	Healthy     bool          `json:"healthy"` // unit meets its inventory expectations and the probe (if any) passes
+	Duration    string        `json:"duration"`
+	Message     string        `json:"message"`
+	Journal     []string      `json:"journal,omitempty"`
+}
+
+// Node is the host name from the inventory.
+func (p *HubProvider) Node() string {
+	return p.inventory.Node
+}
+
+// InventoryService looks up a unit in the host inventory; ".service" is implied when there is no suffix.
+func (p *HubProvider) InventoryService(unit string) (HostService, bool) {
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	for _, svc := range p.inventory.Services {
+		if svc.Unit == unit {
+			return svc, true
+		}
+	}
+	return HostService{}, false
+}
+
+// PreflightRestartService checks that unit is in the inventory and exists, and reports its current health.
+func (p *HubProvider) PreflightRestartService(ctx context.Context, unit string) (*ServiceRestart, error) {
+	svc, ok := p.InventoryService(unit)
+	if !ok {
+		return nil, NotFoundf("unit %q is not in the host inventory; only inventory units can be restarted", unit)
+	}
+	status := p.serviceStatus(ctx, svc, p.now())
+	if status.Active == "" || status.Load == "not-found" {
+		return nil, fmt.Errorf("cannot restart %s: %s", svc.Unit, status.Problem)
+	}
+	r := &ServiceRestart{Unit: svc.Unit, Before: status}
+	if svc.HealthURL != "" {
+		r.BeforeProbe = p.probe(ctx, svc.HealthURL)
+	}
+	return r, nil
+}
+
+// RestartService restarts an inventory unit with `systemctl restart`, waits up to wait for it to
+// meet its expectations (and pass its HTTP probe), then captures the journal around the restart.
+func (p *HubProvider) RestartService(ctx context.Context, unit string, wait time.Duration) (*ServiceRestart, error) {
+	r, err := p.PreflightRestartService(ctx, unit)
+	if err != nil {
+		return nil, err
+	}
+	svc, _ := p.InventoryService(unit)
+
+	start := p.now()
+	if out, err := p.runner.Run(ctx, "systemctl", "restart", svc.Unit); err != nil {
+		r.Message = fmt.Sprintf("systemctl restart failed: %v: %s", err, strings.TrimSpace(string(out)))
+		r.After = p.serviceStatus(ctx, svc, p.now())
+		r.Journal = p.restartJournal(ctx, svc.Unit, start)
+		return r, fmt.Errorf("failed to restart %s: %s", svc.Unit, r.Message)
+	}
+
+	p.waitForService(ctx, svc, r, wait)
+	r.Duration = p.now().Sub(start).Round(time.Millisecond).String()
+	r.Journal = p.restartJournal(ctx, svc.Unit, start)
+	return r, nil
+}
+
+// waitForService polls the unit (and its probe) until it is healthy, the wait runs out or ctx ends.
+func (p *HubProvider) waitForService(ctx context.Context, svc HostService, r *ServiceRestart, wait time.Duration) {
+	wait = capWait(ctx, wait)
+	deadline := p.now().Add(wait)
+	for {
+		r.After = p.serviceStatus(ctx, svc, p.now())
+		r.AfterProbe = nil
+		if r.After.Healthy && svc.HealthURL != "" {
+			r.AfterProbe = p.probe(ctx, svc.HealthURL)
+		}
+		r.Healthy = r.After.Healthy && (r.AfterProbe == nil || r.AfterProbe.Healthy)
+		if r.Healthy {
+			r.Message = "restarted and healthy"
+			return
+		}
+		// A oneshot that finished or a unit that failed will not change by waiting.
+		if r.After.Active == "failed" || (svc.Type == ServiceOneshot && r.After.Active != "activating") {
+			r.Message = "restarted but unhealthy: " + r.problem()
+			return
+		}
+
+		remaining := deadline.Sub(p.now())
+		if remaining <= 0 {
+			r.Message = fmt.Sprintf("still unhealthy after %s: %s", wait, r.problem())
+			return
+		}
+		if remaining > servicePollInterval {
+			remaining = servicePollInterval
+		}
+		select {
+		case <-ctx.Done():
+			r.Message = fmt.Sprintf("stopped waiting (%v): %s", ctx.Err(), r.problem())
+			return
+		case <-time.After(remaining):
+		}
+	}
+}
+
+func (r *ServiceRestart) problem() string {
+	if r.After.Problem != "" {
+		return r.After.Problem
+	}
+	if r.AfterProbe != nil && !r.AfterProbe.Healthy {
+		if r.AfterProbe.Error != "" {
+			return "health probe failed: " + r.AfterProbe.Error
+		}
+		return fmt.Sprintf("health probe returned HTTP %d", r.AfterProbe.StatusCode)
+	}
+	return "unknown"
+}
+
+// probe GETs url and treats any 2xx response as healthy.
+func (p *HubProvider) probe(ctx context.Context, url string) *HealthProbe {
+	result := &HealthProbe{URL: url}
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	result.StatusCode = resp.StatusCode
+	result.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	return result
+}
+
+// restartJournal returns the unit's journal from shortly before start, newest last.
+func (p *HubProvider) restartJournal(ctx context.Context, unit string, start time.Time) []string {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), restartJournalTimeout)
+	defer cancel()
+	since := fmt.Sprintf("@%d", start.Add(-restartJournalLead).Unix())
+	out, err := p.runner.Run(ctx, "journalctl", "-u", unit, "--since", since, "--no-pager", "-o", "short-iso", "-n", fmt.Sprintf("%d", restartJournalLines))
+	if err != nil {
+		return []string{fmt.Sprintf("failed to read journal: %v", err)}
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -3,9 +3,16 @@ package providers
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 // MockCommandRunner satisfies the CommandRunner interface for testing.
@@ -21,36 +28,81 @@ func (m *MockCommandRunner) Run(ctx context.Context, name string, arg ...string)
 }
 
 func TestHubProvider_ListHostServices(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	lastBackup := now.Add(-2 * time.Hour)
+	const running = "ActiveState=active\nSubState=running\nActiveEnterTimestamp=@1773237600\nResult=success\nNRestarts=0\nMemoryCurrent=[not set]\nCPUUsageNSec=[not set]"
+
 	tests := []struct {
 		name           string
+		services       []HostService
 		mockOutput     map[string]string
 		mockErr        error
 		expectedStatus []ServiceStatus
 	}{
 		{
 			name: "All Services Active",
+			services: []HostService{
+				{Unit: "proxy.service", Type: ServiceLongRunning},
+				{Unit: "openbao.service", Type: ServiceLongRunning},
+			},
+			mockOutput: map[string]string{
+				"proxy.service":   "ActiveState=active\nSubState=running\nActiveEnterTimestamp=@1773237600\nResult=success\nNRestarts=2\nMemoryCurrent=52428800\nCPUUsageNSec=12345678901",
+				"openbao.service": running,
+			},
+			expectedStatus: []ServiceStatus{
+				{Name: "proxy.service", Type: ServiceLongRunning, Active: "active", Sub: "running", Since: "2026-03-11T14:00:00Z", Result: "success", Restarts: 2, MemoryBytes: 52428800, CPUSeconds: 12.35, Healthy: true},
+				{Name: "openbao.service", Type: ServiceLongRunning, Active: "active", Sub: "running", Since: "2026-03-11T14:00:00Z", Result: "success", Healthy: true},
+			},
+		},
+		{
+			name:     "Service Inactive",
+			services: []HostService{{Unit: "proxy.service", Type: ServiceLongRunning}},
+			mockOutput: map[string]string{
+				"proxy.service": "ActiveState=inactive\nSubState=dead\nActiveEnterTimestamp=\nResult=success",
+			},
+			expectedStatus: []ServiceStatus{
+				{Name: "proxy.service", Type: ServiceLongRunning, Active: "inactive", Sub: "dead", Result: "success", Problem: "expected active (running), got inactive (dead)"},
+			},
+		},
+		{
+			name:     "Restart Budget Exceeded",
+			services: []HostService{{Unit: "proxy.service", Type: ServiceLongRunning, MaxRestarts: 3}},
 			mockOutput: map[string]string{
-				"proxy.service":          "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Wed 2026-03-11",
-				"openbao.service":        "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Wed 2026-03-11",
-				"tailscale-gate.service": "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Wed 2026-03-11",
+				"proxy.service": strings.Replace(running, "NRestarts=0", "NRestarts=7", 1),
 			},
 			expectedStatus: []ServiceStatus{
-				{Name: "proxy.service", Active: "active", Sub: "running", Since: "Wed 2026-03-11"},
-				{Name: "openbao.service", Active: "active", Sub: "running", Since: "Wed 2026-03-11"},
-				{Name: "tailscale-gate.service", Active: "active", Sub: "running", Since: "Wed 2026-03-11"},
+				{Name: "proxy.service", Type: ServiceLongRunning, Active: "active", Sub: "running", Since: "2026-03-11T14:00:00Z", Result: "success", Restarts: 7, Problem: "restarted 7 times (max 3)"},
 			},
 		},
 		{
-			name: "Service Inactive",
+			name: "Oneshot Timers",
+			services: []HostService{
+				{Unit: "backup.service", Type: ServiceOneshot, Timer: "backup.timer", MaxInterval: 3 * time.Hour},
+				{Unit: "rotate.service", Type: ServiceOneshot, Timer: "rotate.timer", MaxInterval: time.Hour},
+				{Unit: "sync.service", Type: ServiceOneshot},
+			},
 			mockOutput: map[string]string{
-				"proxy.service":          "ActiveState=inactive\nSubState=dead\nActiveEnterTimestamp=",
-				"openbao.service":        "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Wed 2026-03-11",
-				"tailscale-gate.service": "ActiveState=active\nSubState=running\nActiveEnterTimestamp=Wed 2026-03-11",
+				"backup.service": "ActiveState=inactive\nSubState=dead\nResult=success",
+				"backup.timer":   "ActiveState=active\nLastTriggerUSec=@1773230400\nNextElapseUSecRealtime=n/a",
+				"rotate.service": "ActiveState=inactive\nSubState=dead\nResult=success",
+				"rotate.timer":   "ActiveState=active\nLastTriggerUSec=@1773230400\nNextElapseUSecRealtime=n/a",
+				"sync.service":   "ActiveState=failed\nSubState=failed\nResult=exit-code",
 			},
 			expectedStatus: []ServiceStatus{
-				{Name: "proxy.service", Active: "inactive", Sub: "dead", Since: ""},
-				{Name: "openbao.service", Active: "active", Sub: "running", Since: "Wed 2026-03-11"},
-				{Name: "tailscale-gate.service", Active: "active", Sub: "running", Since: "Wed 2026-03-11"},
+				{Name: "backup.service", Type: ServiceOneshot, Active: "inactive", Sub: "dead", Result: "success", Healthy: true,
+					Timer: &TimerStatus{Unit: "backup.timer", Active: "active", LastTrigger: &lastBackup}},
+				{Name: "rotate.service", Type: ServiceOneshot, Active: "inactive", Sub: "dead", Result: "success",
+					Timer:   &TimerStatus{Unit: "rotate.timer", Active: "active", LastTrigger: &lastBackup},
+					Problem: "last run 2h0m0s ago, expected every 1h0m0s"},
+				{Name: "sync.service", Type: ServiceOneshot, Active: "failed", Sub: "failed", Result: "exit-code", Problem: "unit failed (result exit-code)"},
+			},
+		},
+		{
+			name:     "Query Failure Still Reported",
+			services: []HostService{{Unit: "proxy.service", Type: ServiceLongRunning}},
+			mockErr:  errors.New("exec: \"systemctl\": executable file not found in $PATH"),
+			expectedStatus: []ServiceStatus{
+				{Name: "proxy.service", Type: ServiceLongRunning, Problem: "failed to query unit: exec: \"systemctl\": executable file not found in $PATH"},
 			},
 		},
 	}
@@ -59,8 +111,8 @@ func TestHubProvider_ListHostServices(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mock := &MockCommandRunner{
 				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
-					if name == "systemctl" && len(arg) > 2 {
-						// systemctl show <svc> --property=...
+					if name == "systemctl" && len(arg) > 3 && arg[2] == "--timestamp=unix" {
+						// systemctl show <svc> --timestamp=unix --property=...
 						svc := arg[1]
 						if out, ok := tt.mockOutput[svc]; ok {
 							return []byte(out), nil
@@ -69,213 +121,331 @@ func TestHubProvider_ListHostServices(t *testing.T) {
 					return nil, tt.mockErr
 				},
 			}
-			p := &HubProvider{
-				runner: mock,
-				targetServices: []string{
-					"proxy.service",
-					"openbao.service",
-					"tailscale-gate.service",
-				},
-			}
+			p := NewHubProviderWithRunner(mock, HostInventory{Services: tt.services})
+			p.now = func() time.Time { return now }
 
 			got, err := p.ListHostServices(context.Background())
 			if err != nil {
 				t.Fatalf("ListHostServices() error = %v", err)
 			}
 			if !reflect.DeepEqual(got, tt.expectedStatus) {
-				t.Errorf("got %v, want %v", got, tt.expectedStatus)
+				t.Errorf("got %+v, want %+v", got, tt.expectedStatus)
 			}
 		})
 	}
 }
 
-func TestHubProvider_QueryServiceLogs(t *testing.T) {
+func TestLoadHostInventory(t *testing.T) {
 	tests := []struct {
-		name       string
-		service    string
-		since      string
-		mockOutput string
-		mockErr    error
-		wantErr    bool
+		name    string
+		content string
+		want    HostInventory
+		wantErr string
 	}{
 		{
-			name:       "Successful Log Retrieval",
-			service:    "proxy.service",
-			since:      "5m",
-			mockOutput: "Mar 11 14:00:00 proxy logs...",
-			wantErr:    false,
-		},
-		{
-			name:    "Command Failure",
-			service: "proxy.service",
-			mockErr: errors.New("journalctl error"),
-			wantErr: true,
+			name: "defaults and suffixes",
+			content: `node: server2
+services:
+  - unit: proxy
+    max_restarts: 5
+  - unit: pg-backup.service
+    timer: pg-backup
+    max_interval: 25h
+`,
+			want: HostInventory{Node: "server2", Services: []HostService{
+				{Unit: "proxy.service", Type: ServiceLongRunning, MaxRestarts: 5},
+				{Unit: "pg-backup.service", Type: ServiceOneshot, Timer: "pg-backup.timer", MaxInterval: 25 * time.Hour},
+			}},
 		},
+		{name: "unknown type", content: "services:\n  - unit: a\n    type: daemon\n", wantErr: "unknown type"},
+		{name: "missing unit", content: "services:\n  - type: oneshot\n", wantErr: "has no unit"},
+		{name: "interval without timer", content: "services:\n  - unit: a\n    max_interval: 1h\n", wantErr: "without a timer"},
+		{name: "invalid yaml", content: "services: [", wantErr: "failed to parse"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockCommandRunner{
-				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
-					return []byte(tt.mockOutput), tt.mockErr
-				},
+			path := filepath.Join(t.TempDir(), "inventory.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
 			}
-			p := &HubProvider{runner: mock}
-
-			got, err := p.QueryServiceLogs(context.Background(), tt.service, tt.since)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("QueryServiceLogs() error = %v, wantErr %v", err, tt.wantErr)
+			got, err := LoadHostInventory(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadHostInventory() error = %v, want %q", err, tt.wantErr)
+				}
 				return
 			}
-			if !tt.wantErr && got != tt.mockOutput {
-				t.Errorf("got %q, want %q", got, tt.mockOutput)
+			if err != nil {
+				t.Fatalf("LoadHostInventory() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadHostInventory() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestHubProvider_InspectHost(t *testing.T) {
+func TestHubProvider_QueryJournal(t *testing.T) {
+	// journalctl --reverse prints newest first.
+	journal := strings.Join([]string{
+		`{"__CURSOR":"c3","__REALTIME_TIMESTAMP":"1773237600000000","PRIORITY":"3","_PID":"42","SYSLOG_IDENTIFIER":"proxy","MESSAGE":"failed to bind port"}`,
+		`{"__CURSOR":"c2","__REALTIME_TIMESTAMP":"1773237540000000","PRIORITY":"6","_PID":"42","SYSLOG_IDENTIFIER":"proxy","MESSAGE":[104,105,255]}`,
+		`{"__CURSOR":"c1","__REALTIME_TIMESTAMP":"1773237480000000","PRIORITY":"4","_PID":"41","SYSLOG_IDENTIFIER":"proxy","MESSAGE":"failed health check"}`,
+		`-- No entries --`,
+	}, "\n")
+	since := time.Date(2026, 3, 11, 13, 0, 0, 0, time.UTC)
+
 	tests := []struct {
-		name      string
-		uptimeOut string
-		freeOut   string
-		dfOut     string
-		expected  *HostResource
+		name        string
+		query       JournalQuery
+		mockErr     error
+		wantArgs    []string
+		wantCursors []string
+		wantNext    string
+		wantErr     string
 	}{
 		{
-			name:      "Successful Inspection",
-			uptimeOut: "14:30:00 up 1 day, load average: 0.05, 0.10, 0.15",
-			freeOut:   "              total        used        free\nMem:           31Gi        4.5Gi        20Gi",
-			dfOut:     "Filesystem      Size  Used Avail Use% Mounted on\n/dev/sda1       100G   40G   60G  40% /",
-			expected: &HostResource{
-				LoadAverage: "14:30:00 up 1 day, load average: 0.05, 0.10, 0.15",
-				MemoryTotal: "31Gi",
-				MemoryUsed:  "4.5Gi",
-				DiskUsage:   "40%",
-			},
+			name:        "page of entries",
+			query:       JournalQuery{Unit: "proxy", Since: since, Until: since.Add(time.Hour), MaxPriority: 4, Cursor: "c9", Limit: 10},
+			wantArgs:    []string{"-u", "proxy.service", "--output=json", "--no-pager", "--reverse", "-n", "10", "--since", "@1773234000", "--until", "@1773237600", "--priority", "4", "--after-cursor", "c9"},
+			wantCursors: []string{"c3", "c2", "c1"},
+		},
+		{
+			name:        "limit reached",
+			query:       JournalQuery{Unit: "proxy.service", MaxPriority: 7, Limit: 2},
+			wantArgs:    []string{"-u", "proxy.service", "--output=json", "--no-pager", "--reverse", "-n", "2"},
+			wantCursors: []string{"c3", "c2"},
+			wantNext:    "c2",
+		},
+		{
+			name:        "grep scans a larger batch",
+			query:       JournalQuery{Unit: "proxy", MaxPriority: 7, Pattern: regexp.MustCompile(`^failed`), Limit: 5},
+			wantArgs:    []string{"-u", "proxy.service", "--output=json", "--no-pager", "--reverse", "-n", "5000"},
+			wantCursors: []string{"c3", "c1"},
 		},
+		{name: "not in inventory", query: JournalQuery{Unit: "sshd", Limit: 10}, wantErr: "not in the host inventory"},
+		{name: "invalid priority", query: JournalQuery{Unit: "proxy", MaxPriority: 9, Limit: 10}, wantErr: "priority must be between"},
+		{name: "command failure", query: JournalQuery{Unit: "proxy", MaxPriority: 7, Limit: 10}, mockErr: errors.New("exit status 1"), wantErr: "failed to read journal"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
 			mock := &MockCommandRunner{
 				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
-					switch name {
-					case "uptime":
-						return []byte(tt.uptimeOut), nil
-					case "free":
-						return []byte(tt.freeOut), nil
-					case "df":
-						return []byte(tt.dfOut), nil
-					}
-					return nil, nil
+					gotArgs = arg
+					return []byte(journal), tt.mockErr
 				},
 			}
-			p := &HubProvider{runner: mock}
+			p := NewHubProviderWithRunner(mock, HostInventory{Services: []HostService{{Unit: "proxy.service", Type: ServiceLongRunning}}})
 
-			got, err := p.InspectHost(context.Background())
+			got, err := p.QueryJournal(context.Background(), tt.query)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("QueryJournal() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
 			if err != nil {
-				t.Fatalf("InspectHost() error = %v", err)
+				t.Fatalf("QueryJournal() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("journalctl args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			var cursors []string
+			for _, e := range got.Entries {
+				cursors = append(cursors, e.cursor)
 			}
-			if !reflect.DeepEqual(got, tt.expected) {
-				t.Errorf("got %+v, want %+v", got, tt.expected)
+			if !reflect.DeepEqual(cursors, tt.wantCursors) || got.NextCursor != tt.wantNext {
+				t.Errorf("QueryJournal() cursors = %v next %q, want %v next %q", cursors, got.NextCursor, tt.wantCursors, tt.wantNext)
 			}
 		})
 	}
 }
 
-func TestHubProvider_QueryHubbleFlows(t *testing.T) {
+func TestParseJournalEntry(t *testing.T) {
+	got, err := parseJournalEntry([]byte(`{"__CURSOR":"c2","__REALTIME_TIMESTAMP":"1773237540000000","PRIORITY":"6","_PID":"42","SYSLOG_IDENTIFIER":"proxy","MESSAGE":[104,105,255]}`))
+	if err != nil {
+		t.Fatalf("parseJournalEntry() unexpected error: %v", err)
+	}
+	want := JournalEntry{
+		Time: time.Date(2026, 3, 11, 13, 59, 0, 0, time.UTC), Priority: 6, Level: "info", PID: 42,
+		Identifier: "proxy", Message: "hi\uFFFD", cursor: "c2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJournalEntry() = %+v, want %+v", got, want)
+	}
+}
+
+// fixtureHostFS serves a procfs/sysfs fixture tree with canned statfs results.
+type fixtureHostFS struct {
+	files fstest.MapFS
+	stats map[string]FSStats
+}
+
+func (f *fixtureHostFS) ReadFile(name string) ([]byte, error) {
+	return f.files.ReadFile(strings.TrimPrefix(name, "/"))
+}
+
+func (f *fixtureHostFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return f.files.ReadDir(strings.TrimPrefix(name, "/"))
+}
+
+func (f *fixtureHostFS) Statfs(path string) (FSStats, error) {
+	st, ok := f.stats[path]
+	if !ok {
+		return FSStats{}, errors.New("permission denied")
+	}
+	return st, nil
+}
+
+func hostFixture() fstest.MapFS {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	return fstest.MapFS{
+		"proc/stat":    file("cpu  1000 0 500 8000 500 0 0 0 0 0\ncpu0 500 0 250 4000 250 0 0 0 0 0\ncpu1 500 0 250 4000 250 0 0 0 0 0\nintr 12345\n"),
+		"proc/loadavg": file("0.52 0.48 0.40 3/512 98765\n"),
+		"proc/meminfo": file("MemTotal:       16384000 kB\nMemFree:         1024000 kB\nMemAvailable:    4096000 kB\n" +
+			"SwapTotal:       2048000 kB\nSwapFree:        1536000 kB\n"),
+		"proc/pressure/cpu":    file("some avg10=1.50 avg60=0.75 avg300=0.25 total=123\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"),
+		"proc/pressure/memory": file("some avg10=12.00 avg60=8.00 avg300=2.00 total=456\nfull avg10=6.00 avg60=4.00 avg300=1.00 total=789\n"),
+		"proc/mounts": file("sysfs /sys sysfs rw 0 0\n/dev/nvme0n1p2 / ext4 rw,relatime 0 0\n" +
+			"/dev/nvme0n1p2 /var/lib/kubelet/pods/x ext4 rw 0 0\n/dev/loop3 /snap/core/1 squashfs ro 0 0\n" +
+			"/dev/sdb1 /mnt/data\\040disk xfs rw 0 0\ntank/backups /tank/backups zfs rw 0 0\ntmpfs /run tmpfs rw 0 0\n"),
+		"sys/class/hwmon/hwmon0/name":        file("coretemp\n"),
+		"sys/class/hwmon/hwmon0/temp1_input": file("54000\n"),
+		"sys/class/hwmon/hwmon0/temp1_label": file("Package id 0\n"),
+		"sys/class/hwmon/hwmon0/temp1_crit":  file("100000\n"),
+		"sys/class/hwmon/hwmon1/name":        file("nvme\n"),
+		"sys/class/hwmon/hwmon1/temp1_input": file("41850\n"),
+		"sys/class/hwmon/hwmon1/fan1_input":  file("1200\n"),
+	}
+}
+
+func TestHubProvider_InspectHost(t *testing.T) {
+	const gib = 1 << 30
+	stats := map[string]FSStats{
+		"/":              {TotalBytes: 100 * gib, FreeBytes: 40 * gib, AvailableBytes: 35 * gib, Inodes: 1000, FreeInodes: 750},
+		"/mnt/data disk": {TotalBytes: 2000 * gib, FreeBytes: 200 * gib, AvailableBytes: 200 * gib, Inodes: 100, FreeInodes: 99},
+		"/tank/backups":  {TotalBytes: 500 * gib, FreeBytes: 500 * gib, AvailableBytes: 500 * gib},
+	}
+	withoutOptional := hostFixture()
+	for name := range withoutOptional {
+		if strings.HasPrefix(name, "proc/pressure") || strings.HasPrefix(name, "sys/") {
+			delete(withoutOptional, name)
+		}
+	}
+
 	tests := []struct {
 		name       string
-		namespace  string
-		pod        string
-		fromPod    string
-		toPod      string
-		protocol   string
-		verdict    string
-		httpStatus string
-		httpMethod string
-		httpPath   string
-		reserved   string
-		port       int
-		toPort     int
-		last       int
-		mockOutput string
-		mockErr    error
+		fs         *fixtureHostFS
 		wantErr    bool
-		wantArgs   []string
+		wantErrors []string
+		check      func(t *testing.T, got *HostResource)
 	}{
 		{
-			name:       "Basic Filters",
-			namespace:  "default",
-			pod:        "proxy",
-			last:       10,
-			mockOutput: `{"flow":{}}`,
-			wantErr:    false,
-			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "10", "--output", "json", "--namespace", "default", "--pod", "proxy"},
-		},
-		{
-			name:       "Directional Pod Filters",
-			fromPod:    "default/frontend",
-			toPod:      "default/backend",
-			mockOutput: `{"flow":{}}`,
-			wantErr:    false,
-			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "20", "--output", "json", "--from-pod", "default/frontend", "--to-pod", "default/backend"},
+			name: "full fixture",
+			fs:   &fixtureHostFS{files: hostFixture(), stats: stats},
+			check: func(t *testing.T, got *HostResource) {
+				if got.CPUCores != 2 {
+					t.Errorf("cpu cores = %d, want 2", got.CPUCores)
+				}
+				wantLoad := LoadAverage{Load1: 0.52, Load5: 0.48, Load15: 0.40, RunnableTasks: 3, TotalTasks: 512}
+				if got.Load != wantLoad {
+					t.Errorf("load = %+v, want %+v", got.Load, wantLoad)
+				}
+				wantMem := MemoryUsage{TotalBytes: 16384000 * 1024, AvailableBytes: 4096000 * 1024, UsedBytes: 12288000 * 1024,
+					UsedPercent: 75, SwapTotalBytes: 2048000 * 1024, SwapUsedBytes: 512000 * 1024}
+				if got.Memory != wantMem {
+					t.Errorf("memory = %+v, want %+v", got.Memory, wantMem)
+				}
+				wantPressure := map[string]PressureStall{
+					"cpu":    {SomeAvg10: 1.5, SomeAvg60: 0.75, SomeAvg300: 0.25},
+					"memory": {SomeAvg10: 12, SomeAvg60: 8, SomeAvg300: 2, FullAvg10: 6, FullAvg60: 4, FullAvg300: 1},
+				}
+				if !reflect.DeepEqual(got.Pressure, wantPressure) {
+					t.Errorf("pressure = %+v, want %+v", got.Pressure, wantPressure)
+				}
+				wantDisks := []DiskUsage{
+					{Mountpoint: "/", Device: "/dev/nvme0n1p2", FSType: "ext4", TotalBytes: 100 * gib, UsedBytes: 60 * gib, AvailableBytes: 35 * gib, UsedPercent: 63.2, InodesUsedPercent: 25},
+					{Mountpoint: "/mnt/data disk", Device: "/dev/sdb1", FSType: "xfs", TotalBytes: 2000 * gib, UsedBytes: 1800 * gib, AvailableBytes: 200 * gib, UsedPercent: 90, InodesUsedPercent: 1},
+					{Mountpoint: "/tank/backups", Device: "tank/backups", FSType: "zfs", TotalBytes: 500 * gib, AvailableBytes: 500 * gib},
+				}
+				if !reflect.DeepEqual(got.Disks, wantDisks) {
+					t.Errorf("disks = %+v, want %+v", got.Disks, wantDisks)
+				}
+				wantTemps := []Temperature{
+					{Sensor: "coretemp", Label: "Package id 0", Celsius: 54, CriticalCelsius: 100},
+					{Sensor: "nvme", Celsius: 41.9},
+				}
+				if !reflect.DeepEqual(got.Temperatures, wantTemps) {
+					t.Errorf("temperatures = %+v, want %+v", got.Temperatures, wantTemps)
+				}
+			},
 		},
 		{
-			name:       "L4/L7 and Verdict Filters",
-			protocol:   "tcp",
-			port:       80,
-			toPort:     8080,
-			verdict:    "DROPPED",
-			mockOutput: `{"flow":{}}`,
-			wantErr:    false,
-			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "20", "--output", "json", "--protocol", "tcp", "--port", "80", "--to-port", "8080", "--verdict", "DROPPED"},
+			name: "no PSI or sensors",
+			fs:   &fixtureHostFS{files: withoutOptional, stats: stats},
+			check: func(t *testing.T, got *HostResource) {
+				if got.Pressure != nil || got.Temperatures != nil || len(got.Disks) != 3 {
+					t.Errorf("got pressure %v, temperatures %v, %d disks", got.Pressure, got.Temperatures, len(got.Disks))
+				}
+			},
 		},
 		{
-			name:       "Reserved Entity Filter",
-			reserved:   "host",
-			last:       5,
-			mockOutput: "Defaulted container\n" + `{"flow":{}}`,
-			wantErr:    false,
-			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "5", "--output", "json", "--label", "reserved:host"},
+			name:       "statfs failure keeps other disks",
+			fs:         &fixtureHostFS{files: hostFixture(), stats: map[string]FSStats{"/": stats["/"]}},
+			wantErrors: []string{"disks"},
+			check: func(t *testing.T, got *HostResource) {
+				if len(got.Disks) != 1 || !strings.Contains(got.Errors["disks"], "/mnt/data disk") {
+					t.Errorf("disks = %+v, errors = %v", got.Disks, got.Errors)
+				}
+			},
 		},
 		{
-			name:    "Command Failure",
-			mockErr: errors.New("kubectl exec error"),
+			name:    "procfs unavailable",
+			fs:      &fixtureHostFS{files: fstest.MapFS{}},
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockCommandRunner{
-				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
-					if name != "kubectl" {
-						t.Errorf("expected kubectl command, got %s", name)
-					}
-					if tt.wantArgs != nil {
-						if !reflect.DeepEqual(arg, tt.wantArgs) {
-							t.Errorf("got args %v, want %v", arg, tt.wantArgs)
-						}
-					}
-					return []byte(tt.mockOutput), tt.mockErr
-				},
-			}
-			p := &HubProvider{runner: mock}
-
-			got, err := p.QueryHubbleFlows(context.Background(), tt.namespace, tt.pod, tt.fromPod, tt.toPod, tt.protocol, tt.verdict, tt.httpStatus, tt.httpMethod, tt.httpPath, tt.reserved, tt.port, tt.toPort, tt.last)
+			p := &HubProvider{fs: tt.fs}
+			got, err := p.InspectHost(context.Background())
 			if (err != nil) != tt.wantErr {
-				t.Errorf("QueryHubbleFlows() error = %v, wantErr %v", err, tt.wantErr)
+				t.Fatalf("InspectHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
 				return
 			}
-			if !tt.wantErr {
-				if strings.Contains(got, "Defaulted container") {
-					t.Errorf("output contains noise: %q", got)
-				}
-				if got != `{"flow":{}}` {
-					t.Errorf("got %q, want %q", got, `{"flow":{}}`)
-				}
+			var gotErrors []string
+			for source := range got.Errors {
+				gotErrors = append(gotErrors, source)
+			}
+			if !reflect.DeepEqual(gotErrors, tt.wantErrors) {
+				t.Errorf("errors = %v, want sources %v", got.Errors, tt.wantErrors)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestCPUPercent(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second cpuTimes
+		want          float64
+	}{
+		{name: "quarter busy", first: cpuTimes{idle: 800, total: 1000}, second: cpuTimes{idle: 1100, total: 1400}, want: 25},
+		{name: "fully busy", first: cpuTimes{idle: 800, total: 1000}, second: cpuTimes{idle: 800, total: 1200}, want: 100},
+		{name: "no ticks elapsed", first: cpuTimes{idle: 800, total: 1000}, second: cpuTimes{idle: 800, total: 1000}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cpuPercent(tt.first, tt.second); got != tt.want {
+				t.Errorf("cpuPercent() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -301,7 +471,7 @@ func TestHubProvider_InspectPlatform(t *testing.T) {
 			kubectlErr: errors.New("connection refused"),
 			mockSvcOut: "ActiveState=inactive\nSubState=dead\nActiveEnterTimestamp=",
 			wantK3s:    "unreachable",
-			wantSvc:    "3/3",
+			wantSvc:    "1/3", // only the oneshot is expected to be inactive
 		},
 	}
 
@@ -318,10 +488,11 @@ func TestHubProvider_InspectPlatform(t *testing.T) {
 					return nil, nil
 				},
 			}
-			p := &HubProvider{
-				runner:         mock,
-				targetServices: []string{"s1", "s2", "s3"},
-			}
+			p := NewHubProviderWithRunner(mock, HostInventory{Node: "server2", Services: []HostService{
+				{Unit: "s1.service", Type: ServiceLongRunning},
+				{Unit: "s2.service", Type: ServiceLongRunning},
+				{Unit: "s3.service", Type: ServiceOneshot},
+			}})
 
 			got, err := p.InspectPlatform(context.Background())
 			if err != nil {
@@ -333,6 +504,179 @@ func TestHubProvider_InspectPlatform(t *testing.T) {
 			if got["host_services_healthy"] != tt.wantSvc {
 				t.Errorf("host_services_healthy = %v, want %v", got["host_services_healthy"], tt.wantSvc)
 			}
+			if got["node"] != "server2" {
+				t.Errorf("node = %v, want server2", got["node"])
+			}
 		})
 	}
 }
+
+func TestHubProvider_RestartService(t *testing.T) {
+	probe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unhealthy" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer probe.Close()
+
+	const (
+		failed  = "LoadState=loaded\nActiveState=failed\nSubState=failed\nResult=exit-code"
+		running = "LoadState=loaded\nActiveState=active\nSubState=running\nResult=success"
+	)
+	tests := []struct {
+		name        string
+		unit        string
+		healthURL   string
+		before      string
+		after       string
+		restartErr  error
+		wait        time.Duration
+		wantErr     string
+		wantHealthy bool
+		wantMessage string
+	}{
+		{name: "not in inventory", unit: "sshd", wantErr: "not in the host inventory"},
+		{name: "unit missing", unit: "proxy", before: "LoadState=not-found\nActiveState=inactive\nSubState=dead", wantErr: "unit not found"},
+		{
+			name: "recovers with probe", unit: "proxy", healthURL: probe.URL + "/healthz", before: failed, after: running,
+			wantHealthy: true, wantMessage: "restarted and healthy",
+		},
+		{
+			name: "probe keeps failing", unit: "proxy.service", healthURL: probe.URL + "/unhealthy", before: running, after: running,
+			wantMessage: "still unhealthy after 0s: health probe returned HTTP 503",
+		},
+		{
+			name: "fails again", unit: "proxy", before: failed, after: failed, wait: time.Minute,
+			wantMessage: "restarted but unhealthy: unit failed (result exit-code)",
+		},
+		{name: "restart command fails", unit: "proxy", before: failed, after: failed, restartErr: errors.New("exit status 1"), wantErr: "failed to restart proxy.service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			restarted := false
+			mock := &MockCommandRunner{
+				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+					calls = append(calls, name+" "+arg[0])
+					switch {
+					case name == "systemctl" && arg[0] == "restart":
+						restarted = true
+						return nil, tt.restartErr
+					case name == "systemctl" && restarted:
+						return []byte(tt.after), nil
+					case name == "systemctl":
+						return []byte(tt.before), nil
+					case name == "journalctl":
+						return []byte("2026-03-11T14:00:00+0000 server2 proxy[1]: started\n"), nil
+					}
+					return nil, errors.New("unexpected command")
+				},
+			}
+			p := NewHubProviderWithRunner(mock, HostInventory{Node: "server2", Services: []HostService{
+				{Unit: "proxy.service", Type: ServiceLongRunning, HealthURL: tt.healthURL},
+			}})
+
+			got, err := p.RestartService(context.Background(), tt.unit, tt.wait)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RestartService() error = %v, want %q", err, tt.wantErr)
+				}
+				if restarted != (tt.restartErr != nil) {
+					t.Errorf("RestartService() restarted = %v after a refused preflight", restarted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RestartService() unexpected error: %v", err)
+			}
+			if got.Healthy != tt.wantHealthy || got.Message != tt.wantMessage {
+				t.Errorf("RestartService() healthy/message = %v/%q, want %v/%q", got.Healthy, got.Message, tt.wantHealthy, tt.wantMessage)
+			}
+			if tt.healthURL != "" && (got.BeforeProbe == nil || got.AfterProbe == nil) {
+				t.Errorf("RestartService() probes = %+v/%+v, want both", got.BeforeProbe, got.AfterProbe)
+			}
+			if len(got.Journal) != 1 || calls[len(calls)-1] != "journalctl -u" {
+				t.Errorf("RestartService() journal = %v, calls = %v", got.Journal, calls)
+			}
+		})
+	}
+}
+
+func TestHubProvider_RestartServiceNearDeadline(t *testing.T) {
+	mock := &MockCommandRunner{
+		RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			switch {
+			case name == "journalctl":
+				return []byte("2026-03-11T14:00:00+0000 server2 proxy[1]: still starting\n"), nil
+			case name == "systemctl" && arg[0] == "restart":
+				return nil, nil
+			}
+			return []byte("LoadState=loaded\nActiveState=activating\nSubState=start\nResult=success"), nil
+		},
+	}
+	p := NewHubProviderWithRunner(mock, HostInventory{Services: []HostService{{Unit: "proxy.service"}}})
+
+	// The wait must end before the deadline, and the journal is read even once it has passed.
+	ctx, cancel := context.WithTimeout(context.Background(), waitMargin+100*time.Millisecond)
+	defer cancel()
+	got, err := p.RestartService(ctx, "proxy", time.Minute)
+	if err != nil {
+		t.Fatalf("RestartService() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got.Message, "still unhealthy after") {
+		t.Errorf("RestartService() message = %q, want the capped wait to run out", got.Message)
+	}
+	expired, expire := context.WithCancel(context.Background())
+	expire()
+	if journal := p.restartJournal(expired, "proxy.service", time.Now()); len(journal) != 1 || !strings.Contains(journal[0], "still starting") {
+		t.Errorf("restartJournal() after the deadline = %v", journal)
+	}
+}
+
+func TestParseSystemdTime(t *testing.T) {
+	active := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want *time.Time
+	}{
+		{in: "@1773237600", want: &active},
+		{in: "n/a"},
+		{in: ""},
+		{in: "@0"},
+		// The default format is refused rather than guessed at.
+		{in: "Wed 2026-03-11 14:00:00 CET"},
+	}
+	for _, tt := range tests {
+		got := parseSystemdTime(tt.in)
+		if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+			t.Errorf("parseSystemdTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHubProvider_UseHostRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "proc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "proc", "loadavg"), []byte("0.50 0.25 0.10 1/200 4242\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewHubProviderWithRunner(&MockCommandRunner{}, HostInventory{})
+	p.UseHostRoot(root)
+
+	load, err := p.readLoadAverage()
+	if err != nil {
+		t.Fatalf("readLoadAverage() error = %v", err)
+	}
+	if load.Load1 != 0.5 || load.Load15 != 0.1 {
+		t.Errorf("got %+v, want the load average under the host root", load)
+	}
+	if _, err := p.fs.Statfs("/"); err != nil {
+		t.Errorf("Statfs() under the host root: %v", err)
+	}
+}
@@ -0,0 +1,390 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"observability-hub/internal/telemetry"
+)
+
+// Hubble flow query bounds.
+const (
+	DefaultFlowLast = 20
+	MaxFlowLast     = 2000
+)
+
+// FlowFilter selects Hubble flows. Pod filters take "[namespace/]<pod-name>" prefixes.
+type FlowFilter struct {
+	Namespace  string
+	Pod        string
+	FromPod    string
+	ToPod      string
+	Protocol   string
+	Port       int
+	ToPort     int
+	Verdict    string
+	HTTPStatus string
+	HTTPMethod string
+	HTTPPath   string
+	Reserved   string
+	Labels     []string // either endpoint matches any of these label selectors, e.g. "k8s:app=proxy"
+	Last       int
+	Since      time.Time     // zero means no lower bound
+	Until      time.Time     // zero means now
+	Follow     time.Duration // stream new flows for this long instead of reading recent ones
+}
+
+// FlowSource reads Hubble flows. HubbleRelayClient queries Relay's gRPC API across all nodes;
+// ExecFlowSource runs the hubble CLI inside a Cilium agent pod and sees only that node.
+type FlowSource interface {
+	GetFlows(ctx context.Context, f FlowFilter) ([]Flow, error)
+}
+
+// Flow is a parsed Hubble flow.
+type Flow struct {
+	Time        time.Time    `json:"time"`
+	Node        string       `json:"node,omitempty"`
+	Verdict     string       `json:"verdict"`
+	DropReason  string       `json:"drop_reason,omitempty"`
+	Direction   string       `json:"direction,omitempty"` // INGRESS or EGRESS
+	Reply       bool         `json:"reply,omitempty"`
+	Protocol    string       `json:"protocol,omitempty"` // TCP, UDP, ICMPv4, ...
+	Source      FlowEndpoint `json:"source"`
+	Destination FlowEndpoint `json:"destination"`
+	TCPFlags    []string     `json:"tcp_flags,omitempty"`
+	L7          *FlowL7      `json:"l7,omitempty"`
+	Summary     string       `json:"summary,omitempty"`
+}
+
+// FlowEndpoint is one side of a flow.
+type FlowEndpoint struct {
+	Identity  uint32   `json:"identity,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Pod       string   `json:"pod,omitempty"`
+	Workload  string   `json:"workload,omitempty"` // owning workload name, when Cilium reports it
+	Labels    []string `json:"labels,omitempty"`
+	Names     []string `json:"names,omitempty"` // DNS names Cilium associated with the IP
+	Service   string   `json:"service,omitempty"`
+	IP        string   `json:"ip,omitempty"`
+	Port      uint32   `json:"port,omitempty"`
+}
+
+// Name identifies an endpoint for display and grouping: "namespace/workload" (or pod) for
+// Kubernetes endpoints, the reserved identity (e.g. "reserved:world") or a DNS name otherwise, then the IP.
+func (e FlowEndpoint) Name() string {
+	switch {
+	case e.Workload != "":
+		return e.Namespace + "/" + e.Workload
+	case e.Pod != "":
+		return e.Namespace + "/" + e.Pod
+	}
+	for _, l := range e.Labels {
+		if strings.HasPrefix(l, "reserved:") {
+			return l
+		}
+	}
+	if len(e.Names) > 0 {
+		return e.Names[0]
+	}
+	return e.IP
+}
+
+// FlowL7 holds the application-layer part of a flow.
+type FlowL7 struct {
+	Type      string `json:"type"`     // REQUEST or RESPONSE
+	Protocol  string `json:"protocol"` // http, dns or kafka
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Method    string `json:"method,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Status    int    `json:"status,omitempty"` // HTTP status code
+	Query     string `json:"query,omitempty"`  // DNS query
+	RCode     int    `json:"rcode,omitempty"`  // DNS response code
+}
+
+// hubbleFlow mirrors the JSON written by `hubble observe --output json`.
+type hubbleFlow struct {
+	Time           time.Time      `json:"time"`
+	Verdict        string         `json:"verdict"`
+	DropReasonDesc string         `json:"drop_reason_desc"`
+	IP             hubbleIP       `json:"IP"`
+	L4             hubbleL4       `json:"l4"`
+	Source         hubbleEndpoint `json:"source"`
+	Destination    hubbleEndpoint `json:"destination"`
+	SourceNames    []string       `json:"source_names"`
+	DestNames      []string       `json:"destination_names"`
+	SourceService  hubbleService  `json:"source_service"`
+	DestService    hubbleService  `json:"destination_service"`
+	L7             *hubbleL7      `json:"l7"`
+	NodeName       string         `json:"node_name"`
+	Direction      string         `json:"traffic_direction"`
+	IsReply        *bool          `json:"is_reply"`
+	Summary        string         `json:"Summary"`
+}
+
+type hubbleIP struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type hubbleL4 struct {
+	TCP *struct {
+		SourcePort      uint32          `json:"source_port"`
+		DestinationPort uint32          `json:"destination_port"`
+		Flags           map[string]bool `json:"flags"`
+	} `json:"TCP"`
+	UDP *struct {
+		SourcePort      uint32 `json:"source_port"`
+		DestinationPort uint32 `json:"destination_port"`
+	} `json:"UDP"`
+	ICMPv4 *json.RawMessage `json:"ICMPv4"`
+	ICMPv6 *json.RawMessage `json:"ICMPv6"`
+	SCTP   *struct {
+		SourcePort      uint32 `json:"source_port"`
+		DestinationPort uint32 `json:"destination_port"`
+	} `json:"SCTP"`
+}
+
+type hubbleEndpoint struct {
+	Identity  uint32   `json:"identity"`
+	Namespace string   `json:"namespace"`
+	Labels    []string `json:"labels"`
+	PodName   string   `json:"pod_name"`
+	Workloads []struct {
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+	} `json:"workloads"`
+}
+
+type hubbleService struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type hubbleL7 struct {
+	Type      string `json:"type"`
+	LatencyNS string `json:"latency_ns"` // uint64 fields are strings in protobuf JSON
+	HTTP      *struct {
+		Code   int    `json:"code"`
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"http"`
+	DNS *struct {
+		Query string `json:"query"`
+		RCode int    `json:"rcode"`
+	} `json:"dns"`
+	Kafka *json.RawMessage `json:"kafka"`
+}
+
+// UseHubbleRelay makes QueryHubbleFlows read from relay, falling back to the kubectl exec path
+// while relay is unreachable.
+func (p *HubProvider) UseHubbleRelay(relay FlowSource) {
+	p.relay = relay
+}
+
+// QueryHubbleFlows retrieves flows from Hubble Relay when configured, otherwise (or when Relay is
+// unavailable) from the hubble CLI in a Cilium agent pod.
+func (p *HubProvider) QueryHubbleFlows(ctx context.Context, f FlowFilter) ([]Flow, error) {
+	if p.relay != nil {
+		flows, err := p.relay.GetFlows(ctx, f)
+		if err == nil || !relayUnavailable(err) {
+			return flows, err
+		}
+		telemetry.Warn("hubble_relay_unavailable_using_kubectl_exec", "error", err)
+	}
+	return NewExecFlowSource(p.runner).GetFlows(ctx, f)
+}
+
+// ExecFlowSource reads flows by running the hubble CLI in the Cilium agent DaemonSet through kubectl.
+// It needs kubectl on the host and only sees the flows of the node whose agent pod kubectl picks.
+type ExecFlowSource struct {
+	runner CommandRunner
+}
+
+// NewExecFlowSource creates an ExecFlowSource running kubectl through runner.
+func NewExecFlowSource(runner CommandRunner) *ExecFlowSource {
+	return &ExecFlowSource{runner: runner}
+}
+
+// GetFlows runs `hubble observe` and parses its JSON output. Following is not supported, as
+// kubectl exec output is only read once the command exits.
+func (s *ExecFlowSource) GetFlows(ctx context.Context, f FlowFilter) ([]Flow, error) {
+	if f.Follow > 0 {
+		return nil, InvalidInputf("following flows requires Hubble Relay")
+	}
+	last := f.Last
+	if last <= 0 {
+		last = DefaultFlowLast
+	}
+	if last > MaxFlowLast {
+		last = MaxFlowLast
+	}
+
+	hubbleArgs := []string{"observe", "--last", fmt.Sprintf("%d", last), "--output", "json"}
+
+	// Core filters
+	if f.Namespace != "" {
+		hubbleArgs = append(hubbleArgs, "--namespace", f.Namespace)
+	}
+	if f.Pod != "" {
+		hubbleArgs = append(hubbleArgs, "--pod", f.Pod)
+	}
+	if f.Reserved != "" {
+		hubbleArgs = append(hubbleArgs, "--label", fmt.Sprintf("reserved:%s", f.Reserved))
+	}
+	for _, l := range f.Labels {
+		hubbleArgs = append(hubbleArgs, "--label", l)
+	}
+
+	// Directional pod filters
+	if f.FromPod != "" {
+		hubbleArgs = append(hubbleArgs, "--from-pod", f.FromPod)
+	}
+	if f.ToPod != "" {
+		hubbleArgs = append(hubbleArgs, "--to-pod", f.ToPod)
+	}
+
+	// Protocol and Port filters
+	if f.Protocol != "" {
+		hubbleArgs = append(hubbleArgs, "--protocol", f.Protocol)
+	}
+	if f.Port > 0 {
+		hubbleArgs = append(hubbleArgs, "--port", fmt.Sprintf("%d", f.Port))
+	}
+	if f.ToPort > 0 {
+		hubbleArgs = append(hubbleArgs, "--to-port", fmt.Sprintf("%d", f.ToPort))
+	}
+
+	// Verdict and L7 filters
+	if f.Verdict != "" {
+		hubbleArgs = append(hubbleArgs, "--verdict", f.Verdict)
+	}
+	if f.HTTPStatus != "" {
+		hubbleArgs = append(hubbleArgs, "--http-status", f.HTTPStatus)
+	}
+	if f.HTTPMethod != "" {
+		hubbleArgs = append(hubbleArgs, "--http-method", f.HTTPMethod)
+	}
+	if f.HTTPPath != "" {
+		hubbleArgs = append(hubbleArgs, "--http-path", f.HTTPPath)
+	}
+
+	// Time range
+	if !f.Since.IsZero() {
+		hubbleArgs = append(hubbleArgs, "--since", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		hubbleArgs = append(hubbleArgs, "--until", f.Until.Format(time.RFC3339))
+	}
+
+	// Build kubectl exec command: kubectl -n kube-system exec ds/cilium -- hubble <args>
+	args := []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock"}
+	args = append(args, hubbleArgs...)
+
+	out, err := s.runner.Run(ctx, "kubectl", args...)
+	if err != nil {
+		telemetry.Error("hubble observe via kubectl failed", "error", err, "output", string(out))
+		return nil, fmt.Errorf("hubble observe failed: %w", err)
+	}
+
+	// Skip kubectl stderr noise (e.g. "Defaulted container...") and non-flow events.
+	flows := []Flow{}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var event struct {
+			Flow     *hubbleFlow `json:"flow"`
+			NodeName string      `json:"node_name"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.Flow == nil {
+			continue
+		}
+		flow := parseHubbleFlow(event.Flow)
+		if flow.Node == "" {
+			flow.Node = event.NodeName
+		}
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+func parseHubbleFlow(h *hubbleFlow) Flow {
+	f := Flow{
+		Time:        h.Time,
+		Node:        h.NodeName,
+		Verdict:     h.Verdict,
+		Direction:   h.Direction,
+		Reply:       h.IsReply != nil && *h.IsReply,
+		Source:      parseHubbleEndpoint(h.Source, h.SourceService, h.SourceNames, h.IP.Source),
+		Destination: parseHubbleEndpoint(h.Destination, h.DestService, h.DestNames, h.IP.Destination),
+		Summary:     h.Summary,
+	}
+	if h.Verdict == "DROPPED" || h.Verdict == "ERROR" {
+		f.DropReason = h.DropReasonDesc
+	}
+
+	switch l4 := h.L4; {
+	case l4.TCP != nil:
+		f.Protocol = "TCP"
+		f.Source.Port, f.Destination.Port = l4.TCP.SourcePort, l4.TCP.DestinationPort
+		for flag, set := range l4.TCP.Flags {
+			if set {
+				f.TCPFlags = append(f.TCPFlags, flag)
+			}
+		}
+		sort.Strings(f.TCPFlags)
+	case l4.UDP != nil:
+		f.Protocol = "UDP"
+		f.Source.Port, f.Destination.Port = l4.UDP.SourcePort, l4.UDP.DestinationPort
+	case l4.SCTP != nil:
+		f.Protocol = "SCTP"
+		f.Source.Port, f.Destination.Port = l4.SCTP.SourcePort, l4.SCTP.DestinationPort
+	case l4.ICMPv4 != nil:
+		f.Protocol = "ICMPv4"
+	case l4.ICMPv6 != nil:
+		f.Protocol = "ICMPv6"
+	}
+
+	if h.L7 != nil {
+		l7 := &FlowL7{Type: h.L7.Type}
+		if ns, err := strconv.ParseInt(h.L7.LatencyNS, 10, 64); err == nil {
+			l7.LatencyMS = ns / int64(time.Millisecond)
+		}
+		switch {
+		case h.L7.HTTP != nil:
+			l7.Protocol = "http"
+			l7.Method, l7.URL, l7.Status = h.L7.HTTP.Method, h.L7.HTTP.URL, h.L7.HTTP.Code
+		case h.L7.DNS != nil:
+			l7.Protocol = "dns"
+			l7.Query, l7.RCode = h.L7.DNS.Query, h.L7.DNS.RCode
+		case h.L7.Kafka != nil:
+			l7.Protocol = "kafka"
+		}
+		f.L7 = l7
+	}
+	return f
+}
+
+func parseHubbleEndpoint(e hubbleEndpoint, svc hubbleService, names []string, ip string) FlowEndpoint {
+	ep := FlowEndpoint{
+		Identity:  e.Identity,
+		Namespace: e.Namespace,
+		Pod:       e.PodName,
+		Labels:    e.Labels,
+		Names:     names,
+		IP:        ip,
+	}
+	if len(e.Workloads) > 0 {
+		ep.Workload = e.Workloads[0].Name
+	}
+	if svc.Name != "" {
+		ep.Service = svc.Namespace + "/" + svc.Name
+	}
+	return ep
+}
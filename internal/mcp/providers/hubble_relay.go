@@ -0,0 +1,611 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MaxFlowFollow bounds how long a single query follows the live flow stream.
+const MaxFlowFollow = 30 * time.Second
+
+const getFlowsMethod = "/observer.Observer/GetFlows"
+
+var getFlowsStream = grpc.StreamDesc{StreamName: "GetFlows", ServerStreams: true}
+
+// HubbleRelayClient reads flows from Hubble Relay's Observer API, which aggregates every node's
+// Hubble server. The repository does not vendor Cilium's generated protobuf code, so the few
+// messages used here are encoded and decoded directly from their wire format (observer.proto and
+// flow.proto field numbers); everything else in a flow is skipped.
+type HubbleRelayClient struct {
+	conn *grpc.ClientConn
+}
+
+// NewHubbleRelayClient connects to Hubble Relay at target (e.g. hubble-relay.kube-system.svc:80)
+// without TLS, as Relay serves in-cluster by default. opts are appended to the dial options.
+func NewHubbleRelayClient(target string, opts ...grpc.DialOption) (*HubbleRelayClient, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hubble relay client: %w", err)
+	}
+	return &HubbleRelayClient{conn: conn}, nil
+}
+
+// Close releases the connection.
+func (c *HubbleRelayClient) Close() error {
+	return c.conn.Close()
+}
+
+// GetFlows runs Observer.GetFlows with f mapped to server-side filters. Without Follow it returns
+// the last f.Last flows (within Since/Until); with Follow it streams new flows until Follow
+// elapses or f.Last flows arrived.
+func (c *HubbleRelayClient) GetFlows(ctx context.Context, f FlowFilter) ([]Flow, error) {
+	req, err := encodeGetFlowsRequest(f)
+	if err != nil {
+		return nil, err
+	}
+	limit := f.Last
+	if limit <= 0 {
+		limit = DefaultFlowLast
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	if f.Follow > 0 {
+		follow := f.Follow
+		if follow > MaxFlowFollow {
+			follow = MaxFlowFollow
+		}
+		streamCtx, cancel = context.WithTimeout(ctx, follow)
+	}
+	defer cancel()
+
+	stream, err := c.conn.NewStream(streamCtx, &getFlowsStream, getFlowsMethod, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return nil, fmt.Errorf("hubble relay: %w", err)
+	}
+	if err := stream.SendMsg(&rawFrame{data: req}); err != nil {
+		return nil, fmt.Errorf("hubble relay: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, fmt.Errorf("hubble relay: %w", err)
+	}
+
+	flows := []Flow{}
+	for len(flows) < limit {
+		var frame rawFrame
+		err := stream.RecvMsg(&frame)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The follow window ending is how a followed query finishes.
+			if f.Follow > 0 && ctx.Err() == nil && status.Code(err) == codes.DeadlineExceeded {
+				break
+			}
+			return nil, fmt.Errorf("hubble relay: %w", err)
+		}
+		flow, ok, err := decodeGetFlowsResponse(frame.data)
+		if err != nil {
+			return nil, fmt.Errorf("hubble relay: invalid response: %w", err)
+		}
+		if ok {
+			flows = append(flows, flow)
+		}
+	}
+	return flows, nil
+}
+
+// relayUnavailable reports whether err means Relay could not be reached, as opposed to rejecting the query.
+func relayUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// rawFrame carries an already encoded message through gRPC.
+type rawFrame struct {
+	data []byte
+}
+
+// rawCodec passes rawFrame bytes through unchanged. It is named "proto" so the content type
+// matches what Relay expects.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "proto" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	frame, ok := v.(*rawFrame)
+	if !ok {
+		return nil, fmt.Errorf("rawCodec: cannot marshal %T", v)
+	}
+	return frame.data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	frame, ok := v.(*rawFrame)
+	if !ok {
+		return fmt.Errorf("rawCodec: cannot unmarshal into %T", v)
+	}
+	frame.data = append([]byte(nil), data...)
+	return nil
+}
+
+// Field numbers from Cilium's observer.proto and flow.proto, pinned by testdata/hubble_relay.proto.
+const (
+	getFlowsNumber    protowire.Number = 1
+	getFlowsFollow    protowire.Number = 3
+	getFlowsWhitelist protowire.Number = 4
+	getFlowsSince     protowire.Number = 7
+	getFlowsUntil     protowire.Number = 8
+
+	filterSourcePod        protowire.Number = 2
+	filterVerdict          protowire.Number = 5
+	filterDestinationPod   protowire.Number = 9
+	filterSourceLabel      protowire.Number = 10
+	filterDestinationLabel protowire.Number = 11
+	filterHTTPStatusCode   protowire.Number = 12
+	filterProtocol         protowire.Number = 13
+	filterSourcePort       protowire.Number = 14
+	filterDestinationPort  protowire.Number = 15
+	filterHTTPMethod       protowire.Number = 21
+	filterHTTPPath         protowire.Number = 22
+
+	responseFlow     protowire.Number = 1
+	responseNodeName protowire.Number = 1000
+)
+
+// flowVerdicts is flow.Verdict, indexed by value.
+var flowVerdicts = []string{"VERDICT_UNKNOWN", "FORWARDED", "DROPPED", "ERROR", "AUDIT", "REDIRECTED", "TRACED", "TRANSLATED"}
+
+// dropReasons names the flow.DropReason values seen in practice; others are reported by number.
+var dropReasons = map[uint64]string{
+	130: "INVALID_SOURCE_MAC",
+	131: "INVALID_DESTINATION_MAC",
+	132: "INVALID_SOURCE_IP",
+	133: "POLICY_DENIED",
+	134: "INVALID_PACKET_DROPPED",
+	135: "CT_TRUNCATED_OR_INVALID_HEADER",
+	136: "CT_MISSING_TCP_ACK_FLAG",
+	137: "CT_UNKNOWN_L4_PROTOCOL",
+	138: "CT_CANNOT_CREATE_ENTRY_FROM_PACKET",
+	139: "UNSUPPORTED_L3_PROTOCOL",
+	140: "MISSED_TAIL_CALL",
+	141: "ERROR_WRITING_TO_PACKET",
+	142: "UNKNOWN_L4_PROTOCOL",
+	143: "UNKNOWN_ICMPV4_CODE",
+	144: "UNKNOWN_ICMPV4_TYPE",
+	145: "UNKNOWN_ICMPV6_CODE",
+	146: "UNKNOWN_ICMPV6_TYPE",
+}
+
+// relayFlowFilter is a flow.FlowFilter: fields are ANDed, values within a field ORed.
+type relayFlowFilter struct {
+	sourcePod, destinationPod     []string
+	sourceLabel, destinationLabel []string
+	sourcePort, destinationPort   []string
+	protocol                      []string
+	verdict                       []uint64
+	httpStatus, httpMethod        []string
+	httpPath                      []string
+}
+
+// relayFilters maps f onto a whitelist the way the hubble CLI does: criteria that match either side
+// of a flow (namespace, pod, labels, reserved, port) produce a source filter and a destination filter, which
+// Relay ORs; directional and L7 criteria apply to both.
+func relayFilters(f FlowFilter) ([]relayFlowFilter, error) {
+	var common relayFlowFilter
+	if f.FromPod != "" {
+		common.sourcePod = []string{f.FromPod}
+	}
+	if f.ToPod != "" {
+		common.destinationPod = []string{f.ToPod}
+	}
+	if f.ToPort > 0 {
+		common.destinationPort = []string{fmt.Sprint(f.ToPort)}
+	}
+	if f.Protocol != "" {
+		common.protocol = []string{strings.ToLower(f.Protocol)}
+	}
+	if f.Verdict != "" {
+		v := slices.Index(flowVerdicts, strings.ToUpper(f.Verdict))
+		if v <= 0 {
+			return nil, InvalidInputf("unknown verdict %q (use one of %s)", f.Verdict, strings.Join(flowVerdicts[1:], ", "))
+		}
+		common.verdict = []uint64{uint64(v)}
+	}
+	if f.HTTPStatus != "" {
+		common.httpStatus = []string{f.HTTPStatus}
+	}
+	if f.HTTPMethod != "" {
+		common.httpMethod = []string{f.HTTPMethod}
+	}
+	if f.HTTPPath != "" {
+		common.httpPath = []string{f.HTTPPath}
+	}
+
+	var pod, port string
+	labels := slices.Clone(f.Labels)
+	switch {
+	case f.Pod != "" && f.Namespace != "" && !strings.Contains(f.Pod, "/"):
+		pod = f.Namespace + "/" + f.Pod
+	case f.Pod != "":
+		pod = f.Pod
+	case f.Namespace != "":
+		pod = f.Namespace + "/"
+	}
+	if f.Reserved != "" {
+		labels = append(labels, "reserved:"+f.Reserved)
+	}
+	if f.Port > 0 {
+		port = fmt.Sprint(f.Port)
+	}
+	if pod == "" && len(labels) == 0 && port == "" {
+		if len(common.encode()) == 0 {
+			return nil, nil
+		}
+		return []relayFlowFilter{common}, nil
+	}
+
+	// common's slices hold at most one value, so appending below never shares a backing array.
+	src, dst := common, common
+	if pod != "" {
+		src.sourcePod = append(src.sourcePod, pod)
+		dst.destinationPod = append(dst.destinationPod, pod)
+	}
+	if len(labels) > 0 {
+		src.sourceLabel = labels
+		dst.destinationLabel = labels
+	}
+	if port != "" {
+		src.sourcePort = []string{port}
+		dst.destinationPort = append(dst.destinationPort, port)
+	}
+	return []relayFlowFilter{src, dst}, nil
+}
+
+func (r relayFlowFilter) encode() []byte {
+	var b []byte
+	appendStrings := func(num protowire.Number, values []string) {
+		for _, v := range values {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, v)
+		}
+	}
+	appendStrings(filterSourcePod, r.sourcePod)
+	if len(r.verdict) > 0 {
+		var packed []byte
+		for _, v := range r.verdict {
+			packed = protowire.AppendVarint(packed, v)
+		}
+		b = protowire.AppendTag(b, filterVerdict, protowire.BytesType)
+		b = protowire.AppendBytes(b, packed)
+	}
+	appendStrings(filterDestinationPod, r.destinationPod)
+	appendStrings(filterSourceLabel, r.sourceLabel)
+	appendStrings(filterDestinationLabel, r.destinationLabel)
+	appendStrings(filterHTTPStatusCode, r.httpStatus)
+	appendStrings(filterProtocol, r.protocol)
+	appendStrings(filterSourcePort, r.sourcePort)
+	appendStrings(filterDestinationPort, r.destinationPort)
+	appendStrings(filterHTTPMethod, r.httpMethod)
+	appendStrings(filterHTTPPath, r.httpPath)
+	return b
+}
+
+// encodeGetFlowsRequest builds an observer.GetFlowsRequest.
+func encodeGetFlowsRequest(f FlowFilter) ([]byte, error) {
+	filters, err := relayFilters(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	// Following starts at the live edge (or Since) rather than replaying the last N flows.
+	if f.Follow <= 0 {
+		last := f.Last
+		if last <= 0 {
+			last = DefaultFlowLast
+		}
+		b = protowire.AppendTag(b, getFlowsNumber, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(min(last, MaxFlowLast)))
+	} else {
+		b = protowire.AppendTag(b, getFlowsFollow, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	for _, filter := range filters {
+		b = protowire.AppendTag(b, getFlowsWhitelist, protowire.BytesType)
+		b = protowire.AppendBytes(b, filter.encode())
+	}
+	if !f.Since.IsZero() {
+		b = protowire.AppendTag(b, getFlowsSince, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeTimestamp(f.Since))
+	}
+	if !f.Until.IsZero() {
+		b = protowire.AppendTag(b, getFlowsUntil, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeTimestamp(f.Until))
+	}
+	return b, nil
+}
+
+func encodeTimestamp(t time.Time) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(t.Unix()))
+	if t.Nanosecond() != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t.Nanosecond()))
+	}
+	return b
+}
+
+// wireField is one decoded field: varint-typed fields set varint, length-delimited ones set bytes.
+type wireField struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+func (w wireField) string() string { return string(w.bytes) }
+
+// decodeFields calls fn for each field of a message in wire order.
+func decodeFields(b []byte, fn func(wireField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		field := wireField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeGetFlowsResponse decodes an observer.GetFlowsResponse; ok is false for node status and
+// lost-event responses.
+func decodeGetFlowsResponse(b []byte) (flow Flow, ok bool, err error) {
+	var node string
+	err = decodeFields(b, func(w wireField) error {
+		switch w.num {
+		case responseFlow:
+			ok = true
+			flow, err = decodeFlow(w.bytes)
+			return err
+		case responseNodeName:
+			node = w.string()
+		}
+		return nil
+	})
+	if flow.Node == "" {
+		flow.Node = node
+	}
+	return flow, ok, err
+}
+
+// decodeFlow decodes the flow.Flow fields that Flow carries.
+func decodeFlow(b []byte) (Flow, error) {
+	var f Flow
+	var dropReason uint64
+	var srcPort, dstPort uint32
+	var srcService, dstService string
+	err := decodeFields(b, func(w wireField) error {
+		var err error
+		switch w.num {
+		case 1:
+			f.Time, err = decodeTimestamp(w.bytes)
+		case 2:
+			f.Verdict = enumName(flowVerdicts, w.varint)
+		case 5: // IP
+			err = decodeFields(w.bytes, func(ip wireField) error {
+				switch ip.num {
+				case 1:
+					f.Source.IP = ip.string()
+				case 2:
+					f.Destination.IP = ip.string()
+				}
+				return nil
+			})
+		case 6:
+			f.Protocol, srcPort, dstPort, f.TCPFlags, err = decodeLayer4(w.bytes)
+		case 8:
+			f.Source, err = decodeEndpoint(w.bytes, f.Source)
+		case 9:
+			f.Destination, err = decodeEndpoint(w.bytes, f.Destination)
+		case 11:
+			f.Node = w.string()
+		case 13:
+			f.Source.Names = append(f.Source.Names, w.string())
+		case 14:
+			f.Destination.Names = append(f.Destination.Names, w.string())
+		case 15:
+			f.L7, err = decodeLayer7(w.bytes)
+		case 20:
+			srcService, err = decodeService(w.bytes)
+		case 21:
+			dstService, err = decodeService(w.bytes)
+		case 22:
+			f.Direction = enumName([]string{"TRAFFIC_DIRECTION_UNKNOWN", "INGRESS", "EGRESS"}, w.varint)
+		case 25:
+			dropReason = w.varint
+		case 26: // google.protobuf.BoolValue
+			err = decodeFields(w.bytes, func(v wireField) error {
+				f.Reply = v.num == 1 && v.varint != 0
+				return nil
+			})
+		case 100000:
+			f.Summary = w.string()
+		}
+		return err
+	})
+	f.Source.Port, f.Destination.Port = srcPort, dstPort
+	f.Source.Service, f.Destination.Service = srcService, dstService
+	if dropReason != 0 && (f.Verdict == "DROPPED" || f.Verdict == "ERROR") {
+		f.DropReason = dropReasons[dropReason]
+		if f.DropReason == "" {
+			f.DropReason = fmt.Sprintf("DROP_REASON_%d", dropReason)
+		}
+	}
+	return f, err
+}
+
+func decodeTimestamp(b []byte) (time.Time, error) {
+	var sec, nsec uint64
+	err := decodeFields(b, func(w wireField) error {
+		switch w.num {
+		case 1:
+			sec = w.varint
+		case 2:
+			nsec = w.varint
+		}
+		return nil
+	})
+	return time.Unix(int64(sec), int64(nsec)).UTC(), err
+}
+
+// decodeEndpoint decodes a flow.Endpoint into ep, keeping fields that come from elsewhere in the flow.
+func decodeEndpoint(b []byte, ep FlowEndpoint) (FlowEndpoint, error) {
+	err := decodeFields(b, func(w wireField) error {
+		switch w.num {
+		case 2:
+			ep.Identity = uint32(w.varint)
+		case 3:
+			ep.Namespace = w.string()
+		case 4:
+			ep.Labels = append(ep.Labels, w.string())
+		case 5:
+			ep.Pod = w.string()
+		case 6: // Workload{name = 1, kind = 2}; the first one owns the pod
+			if ep.Workload == "" {
+				return decodeFields(w.bytes, func(wl wireField) error {
+					if wl.num == 1 {
+						ep.Workload = wl.string()
+					}
+					return nil
+				})
+			}
+		}
+		return nil
+	})
+	return ep, err
+}
+
+func decodeService(b []byte) (string, error) {
+	var name, namespace string
+	err := decodeFields(b, func(w wireField) error {
+		switch w.num {
+		case 1:
+			name = w.string()
+		case 2:
+			namespace = w.string()
+		}
+		return nil
+	})
+	if name == "" {
+		return "", err
+	}
+	return namespace + "/" + name, err
+}
+
+// tcpFlagNames is flow.TCPFlags, indexed by field number.
+var tcpFlagNames = []string{"", "FIN", "SYN", "RST", "PSH", "ACK", "URG", "ECE", "CWR", "NS"}
+
+// decodeLayer4 decodes the flow.Layer4 oneof.
+func decodeLayer4(b []byte) (protocol string, srcPort, dstPort uint32, flags []string, err error) {
+	err = decodeFields(b, func(w wireField) error {
+		protocol = enumName([]string{"", "TCP", "UDP", "ICMPv4", "ICMPv6", "SCTP"}, uint64(w.num))
+		if protocol == "ICMPv4" || protocol == "ICMPv6" {
+			return nil
+		}
+		return decodeFields(w.bytes, func(p wireField) error {
+			switch p.num {
+			case 1:
+				srcPort = uint32(p.varint)
+			case 2:
+				dstPort = uint32(p.varint)
+			case 3: // TCP flags
+				return decodeFields(p.bytes, func(flag wireField) error {
+					if flag.varint != 0 && int(flag.num) < len(tcpFlagNames) {
+						flags = append(flags, tcpFlagNames[flag.num])
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+	sort.Strings(flags)
+	return protocol, srcPort, dstPort, flags, err
+}
+
+// decodeLayer7 decodes a flow.Layer7 with its DNS, HTTP or Kafka record.
+func decodeLayer7(b []byte) (*FlowL7, error) {
+	l7 := &FlowL7{}
+	err := decodeFields(b, func(w wireField) error {
+		switch w.num {
+		case 1:
+			l7.Type = enumName([]string{"UNKNOWN_L7_TYPE", "REQUEST", "RESPONSE", "SAMPLE"}, w.varint)
+		case 2:
+			l7.LatencyMS = int64(w.varint) / int64(time.Millisecond)
+		case 100:
+			l7.Protocol = "dns"
+			return decodeFields(w.bytes, func(d wireField) error {
+				switch d.num {
+				case 1:
+					l7.Query = d.string()
+				case 6:
+					l7.RCode = int(d.varint)
+				}
+				return nil
+			})
+		case 101:
+			l7.Protocol = "http"
+			return decodeFields(w.bytes, func(h wireField) error {
+				switch h.num {
+				case 1:
+					l7.Status = int(h.varint)
+				case 2:
+					l7.Method = h.string()
+				case 3:
+					l7.URL = h.string()
+				}
+				return nil
+			})
+		case 102:
+			l7.Protocol = "kafka"
+		}
+		return nil
+	})
+	return l7, err
+}
+
+func enumName(names []string, v uint64) string {
+	if v < uint64(len(names)) && names[v] != "" {
+		return names[v]
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,467 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// startRelay serves Observer.GetFlows in-process; handle gets the encoded request and the stream.
+func startRelay(t *testing.T, handle func(req []byte, stream grpc.ServerStream) error) *HubbleRelayClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "observer.Observer",
+		HandlerType: (*any)(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "GetFlows",
+			ServerStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				var req rawFrame
+				if err := stream.RecvMsg(&req); err != nil {
+					return err
+				}
+				return handle(req.data, stream)
+			},
+		}},
+	}, struct{}{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := NewHubbleRelayClient("passthrough:///bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("NewHubbleRelayClient() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func appendField(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	return appendField(b, num, []byte(s))
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// testFlowResponse encodes a GetFlowsResponse holding a policy drop from grafana to postgres.
+func testFlowResponse(srcPort uint64) []byte {
+	var ip, tcp, flags, l4, src, dst, workload, flow []byte
+	ip = appendString(ip, 1, "10.42.0.15")
+	ip = appendString(ip, 2, "10.42.0.40")
+	flags = appendVarint(flags, 2, 1) // SYN
+	tcp = appendVarint(tcp, 1, srcPort)
+	tcp = appendVarint(tcp, 2, 5432)
+	tcp = appendField(tcp, 3, flags)
+	l4 = appendField(l4, 1, tcp)
+	workload = appendString(workload, 1, "grafana")
+	workload = appendString(workload, 2, "Deployment")
+	src = appendVarint(src, 2, 4821)
+	src = appendString(src, 3, "observability")
+	src = appendString(src, 4, "k8s:app=grafana")
+	src = appendString(src, 5, "grafana-6d9f7-abcde")
+	src = appendField(src, 6, workload)
+	dst = appendVarint(dst, 2, 5120)
+	dst = appendString(dst, 3, "observability")
+	dst = appendString(dst, 5, "postgres-0")
+
+	flow = appendField(flow, 1, encodeTimestamp(time.Date(2026, 3, 11, 14, 0, 1, 500_000_000, time.UTC)))
+	flow = appendVarint(flow, 2, 2) // DROPPED
+	flow = appendField(flow, 5, ip)
+	flow = appendField(flow, 6, l4)
+	flow = appendField(flow, 8, src)
+	flow = appendField(flow, 9, dst)
+	flow = appendVarint(flow, 22, 1)   // INGRESS
+	flow = appendVarint(flow, 25, 133) // POLICY_DENIED
+	flow = appendField(flow, 26, nil)  // is_reply = false
+	flow = appendString(flow, 100000, "TCP Flags: SYN")
+
+	var resp []byte
+	resp = appendField(resp, 1, flow)
+	return appendString(resp, 1000, "server2")
+}
+
+func TestDecodeGetFlowsResponse(t *testing.T) {
+	var l7, http, flow, resp []byte
+	http = appendVarint(http, 1, 503)
+	http = appendString(http, 2, "GET")
+	http = appendString(http, 3, "https://grafana.com/api")
+	l7 = appendVarint(l7, 1, 2) // RESPONSE
+	l7 = appendVarint(l7, 2, 12_500_000)
+	l7 = appendField(l7, 101, http)
+	flow = appendVarint(flow, 2, 1) // FORWARDED
+	flow = appendString(flow, 14, "grafana.com")
+	flow = appendField(flow, 15, l7)
+	flow = appendField(flow, 26, appendVarint(nil, 1, 1))
+	resp = appendField(resp, 1, flow)
+
+	got, ok, err := decodeGetFlowsResponse(resp)
+	if err != nil || !ok {
+		t.Fatalf("decodeGetFlowsResponse() = %v, %v", ok, err)
+	}
+	want := &FlowL7{Type: "RESPONSE", Protocol: "http", LatencyMS: 12, Method: "GET", URL: "https://grafana.com/api", Status: 503}
+	if !reflect.DeepEqual(got.L7, want) {
+		t.Errorf("l7 = %+v, want %+v", got.L7, want)
+	}
+	if !got.Reply || got.Verdict != "FORWARDED" || got.Destination.Name() != "grafana.com" {
+		t.Errorf("got %+v, want a forwarded reply to grafana.com", got)
+	}
+
+	// Node status and lost-event responses carry no flow.
+	if _, ok, err := decodeGetFlowsResponse(appendField(nil, 2, nil)); ok || err != nil {
+		t.Errorf("node status: ok = %v, err = %v; want no flow", ok, err)
+	}
+	if _, _, err := decodeGetFlowsResponse([]byte{0x0a, 0x05}); err == nil {
+		t.Error("truncated response: want error")
+	}
+}
+
+// getFlowsRequest is a decoded GetFlowsRequest; filter fields map to their values as strings.
+type getFlowsRequest struct {
+	number  uint64
+	follow  bool
+	filters []map[protowire.Number][]string
+	since   time.Time
+}
+
+func decodeTestRequest(t *testing.T, b []byte) getFlowsRequest {
+	t.Helper()
+	var req getFlowsRequest
+	err := decodeFields(b, func(w wireField) error {
+		switch w.num {
+		case getFlowsNumber:
+			req.number = w.varint
+		case getFlowsFollow:
+			req.follow = w.varint != 0
+		case getFlowsSince:
+			var err error
+			req.since, err = decodeTimestamp(w.bytes)
+			return err
+		case getFlowsWhitelist:
+			filter := make(map[protowire.Number][]string)
+			req.filters = append(req.filters, filter)
+			return decodeFields(w.bytes, func(fw wireField) error {
+				if fw.num == filterVerdict { // packed enum
+					v, _ := protowire.ConsumeVarint(fw.bytes)
+					filter[fw.num] = append(filter[fw.num], fmt.Sprint(v))
+					return nil
+				}
+				filter[fw.num] = append(filter[fw.num], fw.string())
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("invalid request: %v", err)
+	}
+	return req
+}
+
+func TestHubbleRelayClient_GetFlows(t *testing.T) {
+	since := time.Date(2026, 3, 11, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		filter    FlowFilter
+		responses int  // flow responses sent
+		hold      bool // keep the stream open after sending, like a followed stream
+		wantReq   getFlowsRequest
+		wantFlows int
+		wantErr   bool
+	}{
+		{
+			name:      "Recent Flows With Either-Side Filters",
+			filter:    FlowFilter{Namespace: "observability", Verdict: "dropped", ToPort: 5432, Last: 10, Since: since},
+			responses: 1,
+			wantReq: getFlowsRequest{
+				number: 10,
+				since:  since,
+				filters: []map[protowire.Number][]string{
+					{filterSourcePod: {"observability/"}, filterVerdict: {"2"}, filterDestinationPort: {"5432"}},
+					{filterDestinationPod: {"observability/"}, filterVerdict: {"2"}, filterDestinationPort: {"5432"}},
+				},
+			},
+			wantFlows: 1,
+		},
+		{
+			name:      "Directional Filters Only",
+			filter:    FlowFilter{FromPod: "observability/grafana", Protocol: "TCP", HTTPStatus: "5+"},
+			responses: 2,
+			wantReq: getFlowsRequest{
+				number: 20,
+				filters: []map[protowire.Number][]string{
+					{filterSourcePod: {"observability/grafana"}, filterProtocol: {"tcp"}, filterHTTPStatusCode: {"5+"}},
+				},
+			},
+			wantFlows: 2,
+		},
+		{
+			name:      "Label Filters On Either Side",
+			filter:    FlowFilter{Namespace: "apps", Labels: []string{"k8s:app=proxy"}, Reserved: "world"},
+			responses: 1,
+			wantReq: getFlowsRequest{
+				number: 20,
+				filters: []map[protowire.Number][]string{
+					{filterSourcePod: {"apps/"}, filterSourceLabel: {"k8s:app=proxy", "reserved:world"}},
+					{filterDestinationPod: {"apps/"}, filterDestinationLabel: {"k8s:app=proxy", "reserved:world"}},
+				},
+			},
+			wantFlows: 1,
+		},
+		{
+			name:      "HTTP Method And Path",
+			filter:    FlowFilter{FromPod: "observability/grafana", HTTPMethod: "GET", HTTPPath: "/api/.*"},
+			responses: 1,
+			wantReq: getFlowsRequest{
+				number: 20,
+				filters: []map[protowire.Number][]string{
+					{filterSourcePod: {"observability/grafana"}, filterHTTPMethod: {"GET"}, filterHTTPPath: {"/api/.*"}},
+				},
+			},
+			wantFlows: 1,
+		},
+		{
+			name:      "Follow Until Window Ends",
+			filter:    FlowFilter{Pod: "postgres-0", Namespace: "observability", Follow: 200 * time.Millisecond, Last: 50},
+			responses: 2,
+			hold:      true,
+			wantReq: getFlowsRequest{
+				follow: true,
+				filters: []map[protowire.Number][]string{
+					{filterSourcePod: {"observability/postgres-0"}},
+					{filterDestinationPod: {"observability/postgres-0"}},
+				},
+			},
+			wantFlows: 2,
+		},
+		{
+			name:      "Follow Until Limit",
+			filter:    FlowFilter{Follow: 10 * time.Second, Last: 3},
+			responses: 10,
+			hold:      true,
+			wantReq:   getFlowsRequest{follow: true},
+			wantFlows: 3,
+		},
+		{
+			name:    "Unknown Verdict",
+			filter:  FlowFilter{Verdict: "BLOCKED"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq getFlowsRequest
+			client := startRelay(t, func(req []byte, stream grpc.ServerStream) error {
+				gotReq = decodeTestRequest(t, req)
+				// Node status and lost events are interleaved with flows.
+				if err := stream.SendMsg(&rawFrame{data: appendField(nil, 2, nil)}); err != nil {
+					return err
+				}
+				for i := 0; i < tt.responses; i++ {
+					if err := stream.SendMsg(&rawFrame{data: testFlowResponse(uint64(40000 + i))}); err != nil {
+						return err
+					}
+				}
+				if tt.hold {
+					<-stream.Context().Done()
+				}
+				return nil
+			})
+
+			start := time.Now()
+			flows, err := client.GetFlows(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFlows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("GetFlows() took %s, want it to stop at the limit or follow window", time.Since(start))
+			}
+			if !reflect.DeepEqual(gotReq, tt.wantReq) {
+				t.Errorf("request = %+v, want %+v", gotReq, tt.wantReq)
+			}
+			if len(flows) != tt.wantFlows {
+				t.Fatalf("got %d flows, want %d", len(flows), tt.wantFlows)
+			}
+
+			want := Flow{
+				Time:       time.Date(2026, 3, 11, 14, 0, 1, 500_000_000, time.UTC),
+				Node:       "server2",
+				Verdict:    "DROPPED",
+				DropReason: "POLICY_DENIED",
+				Direction:  "INGRESS",
+				Protocol:   "TCP",
+				Source: FlowEndpoint{
+					Identity: 4821, Namespace: "observability", Pod: "grafana-6d9f7-abcde", Workload: "grafana",
+					Labels: []string{"k8s:app=grafana"}, IP: "10.42.0.15", Port: 40000,
+				},
+				Destination: FlowEndpoint{Identity: 5120, Namespace: "observability", Pod: "postgres-0", IP: "10.42.0.40", Port: 5432},
+				TCPFlags:    []string{"SYN"},
+				Summary:     "TCP Flags: SYN",
+			}
+			if !reflect.DeepEqual(flows[0], want) {
+				t.Errorf("flow =\n%+v\nwant\n%+v", flows[0], want)
+			}
+		})
+	}
+}
+
+type relayFunc func(ctx context.Context, f FlowFilter) ([]Flow, error)
+
+func (r relayFunc) GetFlows(ctx context.Context, f FlowFilter) ([]Flow, error) { return r(ctx, f) }
+
+func TestHubProvider_QueryHubbleFlows_Relay(t *testing.T) {
+	unreachable, err := NewHubbleRelayClient("passthrough:///relay", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}))
+	if err != nil {
+		t.Fatalf("NewHubbleRelayClient() error = %v", err)
+	}
+	defer unreachable.Close()
+
+	tests := []struct {
+		name         string
+		relay        FlowSource
+		filter       FlowFilter
+		wantFlows    int
+		wantFallback bool
+		wantErr      bool
+	}{
+		{
+			name:      "Relay Answers",
+			relay:     relayFunc(func(context.Context, FlowFilter) ([]Flow, error) { return []Flow{{}, {}}, nil }),
+			wantFlows: 2,
+		},
+		{
+			name:         "Relay Unreachable Falls Back To Exec",
+			relay:        unreachable,
+			wantFlows:    1,
+			wantFallback: true,
+		},
+		{
+			name: "Relay Rejects Query",
+			relay: relayFunc(func(context.Context, FlowFilter) ([]Flow, error) {
+				return nil, status.Error(codes.InvalidArgument, "bad filter")
+			}),
+			wantErr: true,
+		},
+		{
+			name:         "Follow Needs Relay",
+			relay:        unreachable,
+			filter:       FlowFilter{Follow: time.Second},
+			wantFallback: true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fellBack := false
+			p := &HubProvider{runner: &MockCommandRunner{
+				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+					fellBack = true
+					return []byte(hubbleDropLine), nil
+				},
+			}}
+			p.UseHubbleRelay(tt.relay)
+
+			flows, err := p.QueryHubbleFlows(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryHubbleFlows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(flows) != tt.wantFlows {
+				t.Errorf("got %d flows, want %d", len(flows), tt.wantFlows)
+			}
+			if tt.wantFallback && !tt.wantErr && !fellBack {
+				t.Error("expected the kubectl exec fallback")
+			}
+			if !tt.wantFallback && fellBack {
+				t.Error("unexpected kubectl exec fallback")
+			}
+		})
+	}
+}
+
+// TestRelayFieldNumbers checks the hand-encoded field numbers against the proto excerpt in testdata.
+func TestRelayFieldNumbers(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "hubble_relay.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fields maps "Message.field" to its number.
+	fields := map[string]protowire.Number{}
+	message := ""
+	messageRe := regexp.MustCompile(`^message (\w+) \{`)
+	fieldRe := regexp.MustCompile(`(\w+) = (\d+);`)
+	for _, line := range strings.Split(string(content), "\n") {
+		if m := messageRe.FindStringSubmatch(line); m != nil {
+			message = m[1]
+			continue
+		}
+		if m := fieldRe.FindStringSubmatch(line); m != nil && message != "" {
+			var n protowire.Number
+			fmt.Sscan(m[2], &n)
+			fields[message+"."+m[1]] = n
+		}
+	}
+
+	tests := []struct {
+		field string
+		got   protowire.Number
+	}{
+		{"GetFlowsRequest.number", getFlowsNumber},
+		{"GetFlowsRequest.follow", getFlowsFollow},
+		{"GetFlowsRequest.whitelist", getFlowsWhitelist},
+		{"GetFlowsRequest.since", getFlowsSince},
+		{"GetFlowsRequest.until", getFlowsUntil},
+		{"GetFlowsResponse.flow", responseFlow},
+		{"GetFlowsResponse.node_name", responseNodeName},
+		{"FlowFilter.source_pod", filterSourcePod},
+		{"FlowFilter.verdict", filterVerdict},
+		{"FlowFilter.destination_pod", filterDestinationPod},
+		{"FlowFilter.source_label", filterSourceLabel},
+		{"FlowFilter.destination_label", filterDestinationLabel},
+		{"FlowFilter.http_status_code", filterHTTPStatusCode},
+		{"FlowFilter.protocol", filterProtocol},
+		{"FlowFilter.source_port", filterSourcePort},
+		{"FlowFilter.destination_port", filterDestinationPort},
+		{"FlowFilter.http_method", filterHTTPMethod},
+		{"FlowFilter.http_path", filterHTTPPath},
+	}
+	for _, tt := range tests {
+		want, ok := fields[tt.field]
+		if !ok {
+			t.Errorf("%s is missing from testdata/hubble_relay.proto", tt.field)
+			continue
+		}
+		if tt.got != want {
+			t.Errorf("%s: got field number %d, want %d", tt.field, tt.got, want)
+		}
+	}
+}
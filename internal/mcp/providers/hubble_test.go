@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	hubbleDropLine = `{"flow":{"time":"2026-03-11T14:00:01.5Z","verdict":"DROPPED","drop_reason":133,"drop_reason_desc":"POLICY_DENIED",` +
+		`"IP":{"source":"10.42.0.15","destination":"10.42.0.40"},"l4":{"TCP":{"source_port":51234,"destination_port":5432,"flags":{"SYN":true}}},` +
+		`"source":{"identity":4821,"namespace":"observability","labels":["k8s:app=grafana"],"pod_name":"grafana-6d9f7-abcde","workloads":[{"name":"grafana","kind":"Deployment"}]},` +
+		`"destination":{"identity":5120,"namespace":"observability","labels":["k8s:app=postgres"],"pod_name":"postgres-0"},` +
+		`"traffic_direction":"INGRESS","is_reply":false,"Summary":"TCP Flags: SYN"},"node_name":"server2"}`
+	hubbleHTTPLine = `{"flow":{"time":"2026-03-11T14:00:02Z","verdict":"FORWARDED","IP":{"source":"10.42.0.15","destination":"1.1.1.1"},` +
+		`"l4":{"TCP":{"source_port":40000,"destination_port":443}},"source":{"identity":4821,"namespace":"observability","pod_name":"grafana-6d9f7-abcde"},` +
+		`"destination":{"identity":2,"labels":["reserved:world"]},"destination_names":["grafana.com"],` +
+		`"l7":{"type":"RESPONSE","latency_ns":"12500000","http":{"code":503,"method":"GET","url":"https://grafana.com/api"}},` +
+		`"node_name":"server2","traffic_direction":"EGRESS","is_reply":true}}`
+)
+
+func TestHubProvider_QueryHubbleFlows(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     FlowFilter
+		mockOutput string
+		mockErr    error
+		wantErr    bool
+		wantFlows  int
+		wantArgs   []string
+	}{
+		{
+			name:       "Basic Filters",
+			filter:     FlowFilter{Namespace: "default", Pod: "proxy", Last: 10},
+			mockOutput: hubbleDropLine,
+			wantFlows:  1,
+			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "10", "--output", "json", "--namespace", "default", "--pod", "proxy"},
+		},
+		{
+			name:       "Directional Pod Filters",
+			filter:     FlowFilter{FromPod: "default/frontend", ToPod: "default/backend"},
+			mockOutput: hubbleDropLine + "\n" + hubbleHTTPLine,
+			wantFlows:  2,
+			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "20", "--output", "json", "--from-pod", "default/frontend", "--to-pod", "default/backend"},
+		},
+		{
+			name:       "L4/L7 and Verdict Filters",
+			filter:     FlowFilter{Protocol: "tcp", Port: 80, ToPort: 8080, Verdict: "DROPPED", HTTPStatus: "5+", HTTPMethod: "GET", HTTPPath: "/api"},
+			mockOutput: hubbleDropLine,
+			wantFlows:  1,
+			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "20", "--output", "json", "--protocol", "tcp", "--port", "80", "--to-port", "8080", "--verdict", "DROPPED", "--http-status", "5+", "--http-method", "GET", "--http-path", "/api"},
+		},
+		{
+			name:       "Reserved Entity Filter Skips Noise",
+			filter:     FlowFilter{Reserved: "host", Last: 5000},
+			mockOutput: "Defaulted container \"cilium-agent\" out of: cilium-agent\n" + `{"lost_events":{"num_events_lost":3}}` + "\n" + hubbleDropLine,
+			wantFlows:  1,
+			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "2000", "--output", "json", "--label", "reserved:host"},
+		},
+		{
+			name:       "Label Filters",
+			filter:     FlowFilter{Namespace: "apps", Labels: []string{"k8s:app.kubernetes.io/name=proxy", "k8s:app=proxy"}},
+			mockOutput: hubbleDropLine,
+			wantFlows:  1,
+			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "20", "--output", "json", "--namespace", "apps", "--label", "k8s:app.kubernetes.io/name=proxy", "--label", "k8s:app=proxy"},
+		},
+		{
+			name:       "Time Range",
+			filter:     FlowFilter{Since: time.Date(2026, 3, 11, 13, 0, 0, 0, time.UTC), Until: time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)},
+			mockOutput: hubbleDropLine,
+			wantFlows:  1,
+			wantArgs:   []string{"-n", "kube-system", "exec", "ds/cilium", "--", "hubble", "--server", "unix:///var/run/cilium/hubble.sock", "observe", "--last", "20", "--output", "json", "--since", "2026-03-11T13:00:00Z", "--until", "2026-03-11T14:00:00Z"},
+		},
+		{
+			name:    "Command Failure",
+			mockErr: errors.New("kubectl exec error"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCommandRunner{
+				RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+					if name != "kubectl" {
+						t.Errorf("expected kubectl command, got %s", name)
+					}
+					if tt.wantArgs != nil && !reflect.DeepEqual(arg, tt.wantArgs) {
+						t.Errorf("got args %v, want %v", arg, tt.wantArgs)
+					}
+					return []byte(tt.mockOutput), tt.mockErr
+				},
+			}
+			p := &HubProvider{runner: mock}
+
+			got, err := p.QueryHubbleFlows(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryHubbleFlows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(got) != tt.wantFlows {
+				t.Errorf("got %d flows, want %d: %+v", len(got), tt.wantFlows, got)
+			}
+		})
+	}
+}
+
+func TestHubProvider_QueryHubbleFlows_Parse(t *testing.T) {
+	mock := &MockCommandRunner{
+		RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+			return []byte(hubbleDropLine + "\n" + hubbleHTTPLine), nil
+		},
+	}
+	p := &HubProvider{runner: mock}
+	flows, err := p.QueryHubbleFlows(context.Background(), FlowFilter{})
+	if err != nil {
+		t.Fatalf("QueryHubbleFlows() error = %v", err)
+	}
+	if len(flows) != 2 {
+		t.Fatalf("got %d flows, want 2", len(flows))
+	}
+
+	drop := flows[0]
+	want := Flow{
+		Time:       time.Date(2026, 3, 11, 14, 0, 1, 500_000_000, time.UTC),
+		Node:       "server2",
+		Verdict:    "DROPPED",
+		DropReason: "POLICY_DENIED",
+		Direction:  "INGRESS",
+		Protocol:   "TCP",
+		Source: FlowEndpoint{
+			Identity: 4821, Namespace: "observability", Pod: "grafana-6d9f7-abcde", Workload: "grafana",
+			Labels: []string{"k8s:app=grafana"}, IP: "10.42.0.15", Port: 51234,
+		},
+		Destination: FlowEndpoint{
+			Identity: 5120, Namespace: "observability", Pod: "postgres-0",
+			Labels: []string{"k8s:app=postgres"}, IP: "10.42.0.40", Port: 5432,
+		},
+		TCPFlags: []string{"SYN"},
+		Summary:  "TCP Flags: SYN",
+	}
+	if !reflect.DeepEqual(drop, want) {
+		t.Errorf("drop flow =\n%+v\nwant\n%+v", drop, want)
+	}
+	if got := drop.Source.Name(); got != "observability/grafana" {
+		t.Errorf("source name = %q, want observability/grafana", got)
+	}
+	if got := drop.Destination.Name(); got != "observability/postgres-0" {
+		t.Errorf("destination name = %q, want observability/postgres-0", got)
+	}
+
+	http := flows[1]
+	if !http.Reply || http.DropReason != "" {
+		t.Errorf("reply = %v, drop reason = %q; want reply and no drop reason", http.Reply, http.DropReason)
+	}
+	wantL7 := &FlowL7{Type: "RESPONSE", Protocol: "http", LatencyMS: 12, Method: "GET", URL: "https://grafana.com/api", Status: 503}
+	if !reflect.DeepEqual(http.L7, wantL7) {
+		t.Errorf("l7 = %+v, want %+v", http.L7, wantL7)
+	}
+	if got := http.Destination.Name(); got != "reserved:world" {
+		t.Errorf("destination name = %q, want reserved:world", got)
+	}
+}
@@ -0,0 +1,904 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPolicyDir holds the repository's Cilium policies, relative to the working directory.
+const DefaultPolicyDir = "k3s/cilium-policies"
+
+// Policy entities a non-pod endpoint can be.
+const (
+	EntityWorld         = "world"
+	EntityHost          = "host"
+	EntityRemoteNode    = "remote-node"
+	EntityKubeAPIServer = "kube-apiserver"
+)
+
+// namespaceLabel is the label Cilium gives every pod for its namespace.
+const namespaceLabel = "io.kubernetes.pod.namespace"
+
+// PolicyEndpoint is one side of a simulated connection: a pod (namespace and labels, or a Pod
+// name whose labels are read from the cluster), an entity such as world or host, an external IP,
+// or a DNS name for toFQDNs rules.
+type PolicyEndpoint struct {
+	Pod       string            // pod name; with Namespace and no Labels, labels come from the cluster
+	Namespace string            // namespace of a pod
+	Labels    map[string]string // pod labels
+	Entity    string            // world, host, remote-node or kube-apiserver; empty for pods
+	IP        string            // address matched against CIDR rules
+	FQDN      string            // DNS name matched against toFQDNs rules
+}
+
+func (e PolicyEndpoint) isPod() bool {
+	return e.Entity == ""
+}
+
+// Name describes the endpoint in results.
+func (e PolicyEndpoint) Name() string {
+	switch {
+	case e.Pod != "":
+		return e.Namespace + "/" + e.Pod
+	case e.isPod():
+		return fmt.Sprintf("%s/%s", e.Namespace, formatLabels(e.Labels))
+	case e.FQDN != "":
+		return e.FQDN
+	case e.IP != "":
+		return e.IP
+	}
+	return e.Entity
+}
+
+// entities lists the Cilium entities the endpoint belongs to.
+func (e PolicyEndpoint) entities() []string {
+	switch e.Entity {
+	case "":
+		return []string{"cluster", "all"}
+	case EntityWorld:
+		return []string{EntityWorld, "world-ipv4", "world-ipv6", "all"}
+	default:
+		return []string{e.Entity, "cluster", "all"}
+	}
+}
+
+// PolicySimulation describes a connection to evaluate against network policies.
+type PolicySimulation struct {
+	Source      PolicyEndpoint
+	Destination PolicyEndpoint
+	Port        int
+	Protocol    string // TCP, UDP, SCTP or ANY
+	FromCluster bool   // load policies from the cluster instead of the repository
+}
+
+// RuleRef identifies one rule of a policy, e.g. CiliumClusterwideNetworkPolicy/databases-core ingress[1].
+type RuleRef struct {
+	Policy string `json:"policy"`
+	Rule   string `json:"rule"`
+	L7     string `json:"l7,omitempty"` // L7 protocol (http, dns, kafka) whose rules further filter the allowed traffic
+}
+
+// DirectionVerdict is the result of one side's policies: the source's egress or the destination's ingress.
+type DirectionVerdict struct {
+	Enforced   bool      `json:"enforced"` // some policy selects the endpoint in this direction, so unmatched traffic is denied
+	Allowed    bool      `json:"allowed"`
+	SelectedBy []string  `json:"selected_by,omitempty"`
+	AllowedBy  []RuleRef `json:"allowed_by,omitempty"`
+	DeniedBy   []RuleRef `json:"denied_by,omitempty"`
+	Reason     string    `json:"reason"`
+}
+
+// PolicyVerdict is the outcome of a simulated connection.
+type PolicyVerdict struct {
+	Verdict      string           `json:"verdict"` // ALLOWED or DENIED
+	Source       string           `json:"source"`
+	Destination  string           `json:"destination"`
+	Port         int              `json:"port"`
+	Protocol     string           `json:"protocol"`
+	Egress       DirectionVerdict `json:"egress"`
+	Ingress      DirectionVerdict `json:"ingress"`
+	PolicySource string           `json:"policy_source"` // policy directory or "cluster"
+	Policies     int              `json:"policies_evaluated"`
+	Skipped      []string         `json:"skipped,omitempty"` // policies that cannot be simulated, e.g. host policies or named ports
+}
+
+// UsePolicyDir sets the directory of policy manifests simulate_network_policy reads by default.
+func (p *HubProvider) UsePolicyDir(dir string) {
+	p.policyDir = dir
+}
+
+// SimulateNetworkPolicy evaluates whether sim's connection is allowed by the repository's (or the
+// cluster's) CiliumNetworkPolicy, CiliumClusterwideNetworkPolicy and NetworkPolicy objects.
+func (p *HubProvider) SimulateNetworkPolicy(ctx context.Context, sim PolicySimulation) (*PolicyVerdict, error) {
+	var set *policySet
+	var source string
+	var err error
+	if sim.FromCluster {
+		source = "cluster"
+		set, err = p.clusterPolicies(ctx)
+	} else {
+		source = p.policyDir
+		if source == "" {
+			source = DefaultPolicyDir
+		}
+		set, err = loadPolicyDir(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ep := range []*PolicyEndpoint{&sim.Source, &sim.Destination} {
+		if ep.isPod() && ep.Pod != "" && len(ep.Labels) == 0 {
+			if ep.Labels, err = p.podLabels(ctx, ep.Namespace, ep.Pod); err != nil {
+				return nil, fmt.Errorf("failed to read labels of pod %s/%s (pass labels to simulate offline): %w", ep.Namespace, ep.Pod, err)
+			}
+		}
+	}
+
+	v := set.evaluate(sim)
+	v.PolicySource = source
+	return v, nil
+}
+
+func (p *HubProvider) clusterPolicies(ctx context.Context) (*policySet, error) {
+	out, err := p.runner.Run(ctx, "kubectl", "get", "ciliumnetworkpolicies,ciliumclusterwidenetworkpolicies,networkpolicies", "--all-namespaces", "-o", "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network policies: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	set := &policySet{}
+	if err := set.parse(out); err != nil {
+		return nil, fmt.Errorf("failed to parse network policies: %w", err)
+	}
+	return set, nil
+}
+
+func (p *HubProvider) podLabels(ctx context.Context, namespace, pod string) (map[string]string, error) {
+	out, err := p.runner.Run(ctx, "kubectl", "get", "pod", pod, "-n", namespace, "-o", "jsonpath={.metadata.labels}")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	labels := map[string]string{}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return labels, nil
+	}
+	if err := json.Unmarshal(out, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// loadPolicyDir parses every YAML file under dir; documents of other kinds are ignored.
+func loadPolicyDir(dir string) (*policySet, error) {
+	set := &policySet{}
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (path.Ext(file) != ".yaml" && path.Ext(file) != ".yml") {
+			return nil
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := set.parse(content); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies from %s: %w", dir, err)
+	}
+	return set, nil
+}
+
+// --- Manifests ---
+
+type manifestHeader struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Items []yaml.Node `yaml:"items"` // kind: List, as printed by kubectl get -o yaml
+}
+
+type ciliumManifest struct {
+	Spec  *ciliumSpec  `yaml:"spec"`
+	Specs []ciliumSpec `yaml:"specs"`
+}
+
+type ciliumSpec struct {
+	Description      string         `yaml:"description"`
+	EndpointSelector *labelSelector `yaml:"endpointSelector"`
+	NodeSelector     *labelSelector `yaml:"nodeSelector"`
+	Ingress          []ciliumRule   `yaml:"ingress"`
+	IngressDeny      []ciliumRule   `yaml:"ingressDeny"`
+	Egress           []ciliumRule   `yaml:"egress"`
+	EgressDeny       []ciliumRule   `yaml:"egressDeny"`
+}
+
+type ciliumRule struct {
+	FromEndpoints []labelSelector  `yaml:"fromEndpoints"`
+	ToEndpoints   []labelSelector  `yaml:"toEndpoints"`
+	FromEntities  []string         `yaml:"fromEntities"`
+	ToEntities    []string         `yaml:"toEntities"`
+	FromCIDR      []string         `yaml:"fromCIDR"`
+	ToCIDR        []string         `yaml:"toCIDR"`
+	FromCIDRSet   []cidrSet        `yaml:"fromCIDRSet"`
+	ToCIDRSet     []cidrSet        `yaml:"toCIDRSet"`
+	ToFQDNs       []fqdnSelector   `yaml:"toFQDNs"`
+	ToPorts       []ciliumPortRule `yaml:"toPorts"`
+}
+
+type cidrSet struct {
+	CIDR   string   `yaml:"cidr"`
+	Except []string `yaml:"except"`
+}
+
+type fqdnSelector struct {
+	MatchName    string `yaml:"matchName"`
+	MatchPattern string `yaml:"matchPattern"`
+}
+
+type ciliumPortRule struct {
+	Ports []struct {
+		Port     string `yaml:"port"`
+		EndPort  int    `yaml:"endPort"`
+		Protocol string `yaml:"protocol"`
+	} `yaml:"ports"`
+	Rules map[string]yaml.Node `yaml:"rules"` // L7 rules keyed by protocol: http, dns, kafka
+}
+
+type labelSelector struct {
+	MatchLabels      map[string]string `yaml:"matchLabels"`
+	MatchExpressions []struct {
+		Key      string   `yaml:"key"`
+		Operator string   `yaml:"operator"`
+		Values   []string `yaml:"values"`
+	} `yaml:"matchExpressions"`
+}
+
+type k8sNetworkPolicyManifest struct {
+	Spec struct {
+		PodSelector labelSelector `yaml:"podSelector"`
+		PolicyTypes []string      `yaml:"policyTypes"`
+		Ingress     []struct {
+			From  []k8sPolicyPeer `yaml:"from"`
+			Ports []k8sPolicyPort `yaml:"ports"`
+		} `yaml:"ingress"`
+		Egress []struct {
+			To    []k8sPolicyPeer `yaml:"to"`
+			Ports []k8sPolicyPort `yaml:"ports"`
+		} `yaml:"egress"`
+	} `yaml:"spec"`
+}
+
+type k8sPolicyPeer struct {
+	PodSelector       *labelSelector `yaml:"podSelector"`
+	NamespaceSelector *labelSelector `yaml:"namespaceSelector"`
+	IPBlock           *cidrSet       `yaml:"ipBlock"`
+}
+
+type k8sPolicyPort struct {
+	Protocol string `yaml:"protocol"`
+	Port     string `yaml:"port"`
+	EndPort  int    `yaml:"endPort"`
+}
+
+// --- Rule model ---
+
+// networkPolicy is a policy of any kind reduced to the rules Cilium enforces.
+type networkPolicy struct {
+	ref            string
+	subject        peerMatcher
+	ingress        []policyRule
+	ingressDeny    []policyRule
+	egress         []policyRule
+	egressDeny     []policyRule
+	enforceIngress bool
+	enforceEgress  bool
+}
+
+// policyRule allows (or denies) peers on ports; nil peers or ports match any.
+type policyRule struct {
+	name  string
+	peers []peerMatcher
+	ports []portMatch
+}
+
+type portMatch struct {
+	port, endPort int    // 0 matches any port
+	protocol      string // empty matches any protocol
+	l7            string
+}
+
+type peerMatcher interface {
+	match(ep PolicyEndpoint) bool
+}
+
+type policySet struct {
+	policies []networkPolicy
+	skipped  []string
+}
+
+// parse adds the policies in a (multi-document) YAML stream.
+func (s *policySet) parse(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := s.parseNode(&doc); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *policySet) parseNode(node *yaml.Node) error {
+	var header manifestHeader
+	if err := node.Decode(&header); err != nil {
+		return err
+	}
+	ns, name := header.Metadata.Namespace, header.Metadata.Name
+	switch header.Kind {
+	case "List":
+		for i := range header.Items {
+			if err := s.parseNode(&header.Items[i]); err != nil {
+				return err
+			}
+		}
+	case "CiliumNetworkPolicy", "CiliumClusterwideNetworkPolicy":
+		var m ciliumManifest
+		if err := node.Decode(&m); err != nil {
+			return fmt.Errorf("%s %s: %w", header.Kind, name, err)
+		}
+		ref := header.Kind + "/" + name
+		if header.Kind == "CiliumNetworkPolicy" {
+			if ns == "" {
+				ns = "default"
+			}
+			ref = header.Kind + "/" + ns + "/" + name
+		} else {
+			ns = ""
+		}
+		specs := m.Specs
+		if m.Spec != nil {
+			specs = append([]ciliumSpec{*m.Spec}, specs...)
+		}
+		for i, spec := range specs {
+			specRef := ref
+			if len(specs) > 1 {
+				specRef = fmt.Sprintf("%s specs[%d]", ref, i)
+			}
+			if spec.EndpointSelector == nil {
+				s.skipped = append(s.skipped, specRef+": host policy (nodeSelector) is not simulated")
+				continue
+			}
+			policy, err := ciliumPolicy(specRef, ns, spec)
+			if errors.Is(err, errNamedPort) {
+				s.skipped = append(s.skipped, fmt.Sprintf("%s: %v", specRef, err))
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", specRef, err)
+			}
+			s.policies = append(s.policies, policy)
+		}
+	case "NetworkPolicy":
+		var m k8sNetworkPolicyManifest
+		if err := node.Decode(&m); err != nil {
+			return fmt.Errorf("NetworkPolicy %s: %w", name, err)
+		}
+		if ns == "" {
+			ns = "default"
+		}
+		ref := "NetworkPolicy/" + ns + "/" + name
+		policy, err := k8sNetworkPolicy(ref, ns, m)
+		if errors.Is(err, errNamedPort) {
+			s.skipped = append(s.skipped, fmt.Sprintf("%s: %v", ref, err))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("NetworkPolicy %s/%s: %w", ns, name, err)
+		}
+		s.policies = append(s.policies, policy)
+	}
+	return nil
+}
+
+// ciliumPolicy converts a Cilium spec. Namespaced policies (ns != "") only select endpoints in
+// their namespace, and their endpoint peers default to it too.
+func ciliumPolicy(ref, ns string, spec ciliumSpec) (networkPolicy, error) {
+	policy := networkPolicy{
+		ref:            ref,
+		subject:        endpointPeer{sel: *spec.EndpointSelector, scope: ns, forceScope: true},
+		enforceIngress: len(spec.Ingress)+len(spec.IngressDeny) > 0,
+		enforceEgress:  len(spec.Egress)+len(spec.EgressDeny) > 0,
+	}
+	sections := []struct {
+		name    string
+		ingress bool
+		rules   []ciliumRule
+		out     *[]policyRule
+	}{
+		{"ingress", true, spec.Ingress, &policy.ingress},
+		{"ingressDeny", true, spec.IngressDeny, &policy.ingressDeny},
+		{"egress", false, spec.Egress, &policy.egress},
+		{"egressDeny", false, spec.EgressDeny, &policy.egressDeny},
+	}
+	for _, section := range sections {
+		for i, r := range section.rules {
+			rule, err := ciliumRuleFor(fmt.Sprintf("%s[%d]", section.name, i), ns, section.ingress, r)
+			if err != nil {
+				return policy, err
+			}
+			*section.out = append(*section.out, rule)
+		}
+	}
+	return policy, nil
+}
+
+func ciliumRuleFor(name, ns string, ingress bool, r ciliumRule) (policyRule, error) {
+	rule := policyRule{name: name}
+	endpoints, entities, cidrs, cidrSets := r.ToEndpoints, r.ToEntities, r.ToCIDR, r.ToCIDRSet
+	if ingress {
+		endpoints, entities, cidrs, cidrSets = r.FromEndpoints, r.FromEntities, r.FromCIDR, r.FromCIDRSet
+	}
+	for _, sel := range endpoints {
+		rule.peers = append(rule.peers, endpointPeer{sel: sel, scope: ns})
+	}
+	for _, entity := range entities {
+		rule.peers = append(rule.peers, entityPeer(entity))
+	}
+	for _, cidr := range cidrs {
+		cidrSets = append(cidrSets, cidrSet{CIDR: cidr})
+	}
+	for _, set := range cidrSets {
+		peer, err := newCIDRPeer(set)
+		if err != nil {
+			return rule, err
+		}
+		rule.peers = append(rule.peers, peer)
+	}
+	if !ingress {
+		for _, fqdn := range r.ToFQDNs {
+			rule.peers = append(rule.peers, fqdnPeer(fqdn))
+		}
+	}
+	// A rule with only toPorts applies to every peer; one with an explicitly empty peer list to none.
+	if rule.peers == nil && (endpoints != nil || entities != nil || cidrs != nil || cidrSets != nil || (!ingress && r.ToFQDNs != nil)) {
+		rule.peers = []peerMatcher{}
+	}
+
+	for _, pr := range r.ToPorts {
+		l7 := ""
+		for proto := range pr.Rules {
+			l7 = proto
+		}
+		for _, pp := range pr.Ports {
+			port, err := parsePolicyPort(pp.Port)
+			if err != nil {
+				return rule, err
+			}
+			rule.ports = append(rule.ports, portMatch{port: port, endPort: pp.EndPort, protocol: policyProtocol(pp.Protocol), l7: l7})
+		}
+	}
+	return rule, nil
+}
+
+// k8sNetworkPolicy converts a Kubernetes NetworkPolicy, which Cilium enforces alongside its own policies.
+func k8sNetworkPolicy(ref, ns string, m k8sNetworkPolicyManifest) (networkPolicy, error) {
+	spec := m.Spec
+	policy := networkPolicy{
+		ref:            ref,
+		subject:        endpointPeer{sel: spec.PodSelector, scope: ns, forceScope: true},
+		enforceIngress: true,
+		enforceEgress:  len(spec.Egress) > 0,
+	}
+	if len(spec.PolicyTypes) > 0 {
+		policy.enforceIngress, policy.enforceEgress = false, false
+		for _, t := range spec.PolicyTypes {
+			switch t {
+			case "Ingress":
+				policy.enforceIngress = true
+			case "Egress":
+				policy.enforceEgress = true
+			}
+		}
+	}
+
+	convert := func(name string, peers []k8sPolicyPeer, ports []k8sPolicyPort) (policyRule, error) {
+		rule := policyRule{name: name}
+		for _, peer := range peers {
+			if peer.IPBlock != nil {
+				cidr, err := newCIDRPeer(*peer.IPBlock)
+				if err != nil {
+					return rule, err
+				}
+				rule.peers = append(rule.peers, cidr)
+				continue
+			}
+			rule.peers = append(rule.peers, k8sPeer{pods: peer.PodSelector, namespaces: peer.NamespaceSelector, scope: ns})
+		}
+		for _, pp := range ports {
+			port, err := parsePolicyPort(pp.Port)
+			if err != nil {
+				return rule, err
+			}
+			protocol := policyProtocol(pp.Protocol)
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			rule.ports = append(rule.ports, portMatch{port: port, endPort: pp.EndPort, protocol: protocol})
+		}
+		return rule, nil
+	}
+	if policy.enforceIngress {
+		for i, r := range spec.Ingress {
+			rule, err := convert(fmt.Sprintf("ingress[%d]", i), r.From, r.Ports)
+			if err != nil {
+				return policy, err
+			}
+			policy.ingress = append(policy.ingress, rule)
+		}
+	}
+	if policy.enforceEgress {
+		for i, r := range spec.Egress {
+			rule, err := convert(fmt.Sprintf("egress[%d]", i), r.To, r.Ports)
+			if err != nil {
+				return policy, err
+			}
+			policy.egress = append(policy.egress, rule)
+		}
+	}
+	return policy, nil
+}
+
+// errNamedPort marks a policy that uses a named port. It is reported as skipped rather than
+// failing the whole simulation, since resolving the name needs the selected pods' specs.
+var errNamedPort = errors.New("named port")
+
+// parsePolicyPort parses a numeric port; empty means any. Named ports cannot be resolved offline.
+func parsePolicyPort(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q is not simulated", errNamedPort, s)
+	}
+	if port < 0 || port > 65535 {
+		return 0, InvalidInputf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+func policyProtocol(s string) string {
+	s = strings.ToUpper(s)
+	if s == "ANY" {
+		return ""
+	}
+	return s
+}
+
+// --- Matching ---
+
+// endpointPeer matches pods by label selector. Keys may carry a source prefix (k8s:, any:);
+// reserved: keys match entities instead. scope restricts matches to a namespace unless the
+// selector names one itself (forceScope applies it regardless, for a namespaced policy's subject).
+type endpointPeer struct {
+	sel        labelSelector
+	scope      string
+	forceScope bool
+}
+
+func (p endpointPeer) match(ep PolicyEndpoint) bool {
+	reserved := false
+	namesNamespace := false
+	labels := map[string]string{namespaceLabel: ep.Namespace}
+	for k, v := range ep.Labels {
+		labels[k] = v
+	}
+
+	check := func(key string, test func(value string, present bool) bool) bool {
+		source, name := labelSource(key)
+		if source == "reserved" {
+			reserved = true
+			return ep.Entity == name
+		}
+		if name == namespaceLabel {
+			namesNamespace = true
+		}
+		if !ep.isPod() {
+			return false
+		}
+		v, ok := labels[name]
+		return test(v, ok)
+	}
+
+	for k, want := range p.sel.MatchLabels {
+		if !check(k, func(v string, ok bool) bool { return ok && v == want }) {
+			return false
+		}
+	}
+	for _, expr := range p.sel.MatchExpressions {
+		values := expr.Values
+		var test func(v string, ok bool) bool
+		switch expr.Operator {
+		case "In":
+			test = func(v string, ok bool) bool { return ok && contains(values, v) }
+		case "NotIn":
+			test = func(v string, ok bool) bool { return !ok || !contains(values, v) }
+		case "Exists":
+			test = func(_ string, ok bool) bool { return ok }
+		case "DoesNotExist":
+			test = func(_ string, ok bool) bool { return !ok }
+		default:
+			return false
+		}
+		if !check(expr.Key, test) {
+			return false
+		}
+	}
+
+	if reserved {
+		return true
+	}
+	if !ep.isPod() {
+		return false
+	}
+	if p.scope != "" && (p.forceScope || !namesNamespace) && ep.Namespace != p.scope {
+		return false
+	}
+	return true
+}
+
+// labelSource splits a selector key such as k8s:app into its source and label name.
+func labelSource(key string) (source, name string) {
+	if src, rest, ok := strings.Cut(key, ":"); ok {
+		switch src {
+		case "k8s", "any", "reserved", "container", "unspec":
+			return src, rest
+		}
+	}
+	return "any", key
+}
+
+type entityPeer string
+
+func (p entityPeer) match(ep PolicyEndpoint) bool {
+	return contains(ep.entities(), string(p))
+}
+
+type cidrPeer struct {
+	prefix netip.Prefix
+	except []netip.Prefix
+}
+
+func newCIDRPeer(set cidrSet) (cidrPeer, error) {
+	prefix, err := netip.ParsePrefix(set.CIDR)
+	if err != nil {
+		return cidrPeer{}, fmt.Errorf("invalid CIDR %q: %w", set.CIDR, err)
+	}
+	peer := cidrPeer{prefix: prefix}
+	for _, e := range set.Except {
+		except, err := netip.ParsePrefix(e)
+		if err != nil {
+			return cidrPeer{}, fmt.Errorf("invalid CIDR %q: %w", e, err)
+		}
+		peer.except = append(peer.except, except)
+	}
+	return peer, nil
+}
+
+// match checks non-pod endpoints with an address; CIDR rules do not select cluster pods.
+func (p cidrPeer) match(ep PolicyEndpoint) bool {
+	if ep.isPod() || ep.IP == "" {
+		return false
+	}
+	addr, err := netip.ParseAddr(ep.IP)
+	if err != nil || !p.prefix.Contains(addr) {
+		return false
+	}
+	for _, except := range p.except {
+		if except.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+type fqdnPeer fqdnSelector
+
+// match compares DNS names; in patterns "*" matches any run of DNS characters, and "*" alone any name.
+func (p fqdnPeer) match(ep PolicyEndpoint) bool {
+	if ep.FQDN == "" {
+		return false
+	}
+	name := strings.TrimSuffix(strings.ToLower(ep.FQDN), ".")
+	if p.MatchName != "" {
+		return name == strings.TrimSuffix(strings.ToLower(p.MatchName), ".")
+	}
+	pattern := strings.TrimSuffix(strings.ToLower(p.MatchPattern), ".")
+	if pattern == "*" {
+		return true
+	}
+	ok, _ := path.Match(strings.ReplaceAll(pattern, ".", "/"), strings.ReplaceAll(name, ".", "/"))
+	return ok
+}
+
+// k8sPeer is a NetworkPolicy peer: pods in the policy's namespace, pods in namespaces matching a
+// namespace selector, or both. Only the kubernetes.io/metadata.name namespace label is known.
+type k8sPeer struct {
+	pods       *labelSelector
+	namespaces *labelSelector
+	scope      string
+}
+
+func (p k8sPeer) match(ep PolicyEndpoint) bool {
+	if !ep.isPod() {
+		return false
+	}
+	if p.namespaces == nil {
+		if ep.Namespace != p.scope {
+			return false
+		}
+	} else {
+		nsEndpoint := PolicyEndpoint{Labels: map[string]string{"kubernetes.io/metadata.name": ep.Namespace}}
+		if !(endpointPeer{sel: *p.namespaces}).match(nsEndpoint) {
+			return false
+		}
+	}
+	return p.pods == nil || (endpointPeer{sel: *p.pods}).match(PolicyEndpoint{Namespace: ep.Namespace, Labels: ep.Labels})
+}
+
+// matches reports whether the rule covers peer on port/protocol, and the L7 protocol of the matching port rule.
+func (r policyRule) matches(peer PolicyEndpoint, port int, protocol string) (bool, string) {
+	if r.peers != nil {
+		found := false
+		for _, m := range r.peers {
+			if m.match(peer) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, ""
+		}
+	}
+	if r.ports == nil {
+		return true, ""
+	}
+	for _, pm := range r.ports {
+		if pm.protocol != "" && protocol != "" && pm.protocol != protocol {
+			continue
+		}
+		end := max(pm.endPort, pm.port)
+		if pm.port == 0 || (port >= pm.port && port <= end) {
+			return true, pm.l7
+		}
+	}
+	return false, ""
+}
+
+// evaluate checks the source's egress and the destination's ingress; both must allow the connection.
+func (s *policySet) evaluate(sim PolicySimulation) *PolicyVerdict {
+	protocol := policyProtocol(sim.Protocol)
+	v := &PolicyVerdict{
+		Source:      sim.Source.Name(),
+		Destination: sim.Destination.Name(),
+		Port:        sim.Port,
+		Protocol:    sim.Protocol,
+		Policies:    len(s.policies),
+		Skipped:     s.skipped,
+		Egress:      s.evaluateDirection(sim.Source, sim.Destination, sim.Port, protocol, false),
+		Ingress:     s.evaluateDirection(sim.Destination, sim.Source, sim.Port, protocol, true),
+	}
+	v.Verdict = "DENIED"
+	if v.Egress.Allowed && v.Ingress.Allowed {
+		v.Verdict = "ALLOWED"
+	}
+	return v
+}
+
+func (s *policySet) evaluateDirection(subject, peer PolicyEndpoint, port int, protocol string, ingress bool) DirectionVerdict {
+	direction := "egress"
+	if ingress {
+		direction = "ingress"
+	}
+	if !subject.isPod() {
+		return DirectionVerdict{Allowed: true, Reason: fmt.Sprintf("%s is not a pod, so no %s policy applies", subject.Name(), direction)}
+	}
+
+	var d DirectionVerdict
+	for _, policy := range s.policies {
+		if !policy.subject.match(subject) {
+			continue
+		}
+		allows, denies, enforced := policy.egress, policy.egressDeny, policy.enforceEgress
+		if ingress {
+			allows, denies, enforced = policy.ingress, policy.ingressDeny, policy.enforceIngress
+		}
+		if !enforced {
+			continue
+		}
+		d.Enforced = true
+		d.SelectedBy = append(d.SelectedBy, policy.ref)
+		for _, rule := range denies {
+			if ok, _ := rule.matches(peer, port, protocol); ok {
+				d.DeniedBy = append(d.DeniedBy, RuleRef{Policy: policy.ref, Rule: rule.name})
+			}
+		}
+		for _, rule := range allows {
+			if ok, l7 := rule.matches(peer, port, protocol); ok {
+				d.AllowedBy = append(d.AllowedBy, RuleRef{Policy: policy.ref, Rule: rule.name, L7: l7})
+			}
+		}
+	}
+
+	switch {
+	case len(d.DeniedBy) > 0:
+		d.Reason = fmt.Sprintf("denied by %s %s", d.DeniedBy[0].Policy, d.DeniedBy[0].Rule)
+	case !d.Enforced:
+		d.Allowed = true
+		d.Reason = fmt.Sprintf("no policy selects %s for %s, so all %s traffic is allowed", subject.Name(), direction, direction)
+	case len(d.AllowedBy) > 0:
+		d.Allowed = true
+		d.Reason = fmt.Sprintf("allowed by %s %s", d.AllowedBy[0].Policy, d.AllowedBy[0].Rule)
+	default:
+		d.Reason = fmt.Sprintf("default deny: %s is selected by %s but no %s rule matches %s on %d/%s",
+			subject.Name(), strings.Join(d.SelectedBy, ", "), direction, peer.Name(), port, orAny(protocol))
+	}
+	return d
+}
+
+func orAny(protocol string) string {
+	if protocol == "" {
+		return "ANY"
+	}
+	return protocol
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "*"
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + labels[k]
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,367 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const policyFixture = `
+apiVersion: cilium.io/v2
+kind: CiliumNetworkPolicy
+metadata:
+  name: api
+  namespace: apps
+spec:
+  endpointSelector:
+    matchLabels:
+      app: api
+  ingress:
+    - fromEndpoints:
+        - matchLabels:
+            app: web
+      toPorts:
+        - ports:
+            - port: "8080"
+              protocol: TCP
+          rules:
+            http: [{}]
+    - fromEndpoints:
+        - matchLabels:
+            "k8s:io.kubernetes.pod.namespace": monitoring
+      toPorts:
+        - ports:
+            - port: "9000"
+              endPort: 9100
+  ingressDeny:
+    - fromEndpoints:
+        - matchLabels:
+            app: web
+            tier: untrusted
+  egress:
+    - toEndpoints:
+        - matchLabels:
+            app: db
+    - toFQDNs:
+        - matchPattern: "*.example.com"
+    - toCIDRSet:
+        - cidr: 192.168.0.0/16
+          except: [192.168.1.0/24]
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: networking.k8s.io/v1
+    kind: NetworkPolicy
+    metadata:
+      name: db
+      namespace: apps
+    spec:
+      podSelector:
+        matchLabels:
+          app: db
+      ingress:
+        - from:
+            - podSelector:
+                matchLabels:
+                  app: api
+            - namespaceSelector:
+                matchLabels:
+                  kubernetes.io/metadata.name: backup
+          ports:
+            - port: "5432"
+  - apiVersion: cilium.io/v2
+    kind: CiliumClusterwideNetworkPolicy
+    metadata:
+      name: nodes
+    spec:
+      nodeSelector: {}
+      ingress:
+        - fromEntities: [cluster]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+func TestPolicySet_Evaluate(t *testing.T) {
+	set := &policySet{}
+	if err := set.parse([]byte(policyFixture)); err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if len(set.policies) != 2 {
+		t.Fatalf("parsed %d policies, want 2", len(set.policies))
+	}
+	if len(set.skipped) != 1 || !strings.HasPrefix(set.skipped[0], "CiliumClusterwideNetworkPolicy/nodes") {
+		t.Errorf("skipped = %v, want the host policy", set.skipped)
+	}
+
+	pod := func(ns string, labels ...string) PolicyEndpoint {
+		ep := PolicyEndpoint{Namespace: ns, Labels: map[string]string{}}
+		for i := 0; i < len(labels); i += 2 {
+			ep.Labels[labels[i]] = labels[i+1]
+		}
+		return ep
+	}
+	web, api, db := pod("apps", "app", "web"), pod("apps", "app", "api"), pod("apps", "app", "db")
+
+	tests := []struct {
+		name        string
+		sim         PolicySimulation
+		wantVerdict string
+		wantEgress  []RuleRef
+		wantIngress []RuleRef
+		wantDenied  []RuleRef
+	}{
+		{
+			name:        "Allowed With L7 Rules",
+			sim:         PolicySimulation{Source: web, Destination: api, Port: 8080, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantIngress: []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "ingress[0]", L7: "http"}},
+		},
+		{
+			name:        "Default Deny On Unlisted Port",
+			sim:         PolicySimulation{Source: web, Destination: api, Port: 9090, Protocol: "TCP"},
+			wantVerdict: "DENIED",
+		},
+		{
+			name:        "Endpoint Peers Are Scoped To The Policy Namespace",
+			sim:         PolicySimulation{Source: pod("other", "app", "web"), Destination: api, Port: 8080, Protocol: "TCP"},
+			wantVerdict: "DENIED",
+		},
+		{
+			name:        "Namespace Label Crosses Namespaces With Port Range",
+			sim:         PolicySimulation{Source: pod("monitoring", "app", "prometheus"), Destination: api, Port: 9050, Protocol: "UDP"},
+			wantVerdict: "ALLOWED",
+			wantIngress: []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "ingress[1]"}},
+		},
+		{
+			name:        "Deny Overrides Allow",
+			sim:         PolicySimulation{Source: pod("apps", "app", "web", "tier", "untrusted"), Destination: api, Port: 8080, Protocol: "TCP"},
+			wantVerdict: "DENIED",
+			wantIngress: []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "ingress[0]", L7: "http"}},
+			wantDenied:  []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "ingressDeny[0]"}},
+		},
+		{
+			name:        "Egress And NetworkPolicy Ingress",
+			sim:         PolicySimulation{Source: api, Destination: db, Port: 5432, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantEgress:  []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "egress[0]"}},
+			wantIngress: []RuleRef{{Policy: "NetworkPolicy/apps/db", Rule: "ingress[0]"}},
+		},
+		{
+			name:        "NetworkPolicy Ports Default To TCP",
+			sim:         PolicySimulation{Source: api, Destination: db, Port: 5432, Protocol: "UDP"},
+			wantVerdict: "DENIED",
+			wantEgress:  []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "egress[0]"}},
+		},
+		{
+			name:        "NetworkPolicy Namespace Selector",
+			sim:         PolicySimulation{Source: pod("backup", "app", "pgbackrest"), Destination: db, Port: 5432, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantIngress: []RuleRef{{Policy: "NetworkPolicy/apps/db", Rule: "ingress[0]"}},
+		},
+		{
+			name:        "FQDN Egress",
+			sim:         PolicySimulation{Source: api, Destination: PolicyEndpoint{Entity: EntityWorld, FQDN: "hooks.example.com"}, Port: 443, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantEgress:  []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "egress[1]"}},
+		},
+		{
+			name:        "FQDN Wildcard Does Not Span Labels",
+			sim:         PolicySimulation{Source: api, Destination: PolicyEndpoint{Entity: EntityWorld, FQDN: "a.b.example.com"}, Port: 443, Protocol: "TCP"},
+			wantVerdict: "DENIED",
+		},
+		{
+			name:        "CIDR Except",
+			sim:         PolicySimulation{Source: api, Destination: PolicyEndpoint{Entity: EntityWorld, IP: "192.168.1.10"}, Port: 22, Protocol: "TCP"},
+			wantVerdict: "DENIED",
+		},
+		{
+			name:        "CIDR Allowed",
+			sim:         PolicySimulation{Source: api, Destination: PolicyEndpoint{Entity: EntityWorld, IP: "192.168.2.10"}, Port: 22, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantEgress:  []RuleRef{{Policy: "CiliumNetworkPolicy/apps/api", Rule: "egress[2]"}},
+		},
+		{
+			name:        "Unselected Endpoints Allow Everything",
+			sim:         PolicySimulation{Source: db, Destination: web, Port: 1234, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+		},
+		{
+			name:        "World Is Not Subject To Policies",
+			sim:         PolicySimulation{Source: PolicyEndpoint{Entity: EntityWorld}, Destination: web, Port: 80, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := set.evaluate(tt.sim)
+			if got.Verdict != tt.wantVerdict {
+				t.Errorf("verdict = %s, want %s (egress: %s; ingress: %s)", got.Verdict, tt.wantVerdict, got.Egress.Reason, got.Ingress.Reason)
+			}
+			if !reflect.DeepEqual(got.Egress.AllowedBy, tt.wantEgress) {
+				t.Errorf("egress allowed by %v, want %v", got.Egress.AllowedBy, tt.wantEgress)
+			}
+			if !reflect.DeepEqual(got.Ingress.AllowedBy, tt.wantIngress) {
+				t.Errorf("ingress allowed by %v, want %v", got.Ingress.AllowedBy, tt.wantIngress)
+			}
+			if !reflect.DeepEqual(got.Ingress.DeniedBy, tt.wantDenied) {
+				t.Errorf("ingress denied by %v, want %v", got.Ingress.DeniedBy, tt.wantDenied)
+			}
+		})
+	}
+}
+
+// TestHubProvider_SimulateNetworkPolicy_RepoPolicies runs against the policies committed in k3s/cilium-policies.
+func TestHubProvider_SimulateNetworkPolicy_RepoPolicies(t *testing.T) {
+	p := &HubProvider{}
+	p.UsePolicyDir(filepath.Join("..", "..", "..", DefaultPolicyDir))
+
+	hub := PolicyEndpoint{Namespace: "hub", Labels: map[string]string{"app.kubernetes.io/name": "grafana"}}
+	postgres := PolicyEndpoint{Namespace: "databases", Labels: map[string]string{"cnpg.io/cluster": "postgres"}}
+	redis := PolicyEndpoint{Namespace: "argocd", Labels: map[string]string{"app.kubernetes.io/name": "argocd-redis"}}
+	loki := PolicyEndpoint{Namespace: "observability", Labels: map[string]string{"app.kubernetes.io/name": "loki"}}
+
+	tests := []struct {
+		name        string
+		sim         PolicySimulation
+		wantVerdict string
+		wantRule    RuleRef // first rule allowing or denying the deciding direction
+	}{
+		{
+			name:        "Grafana To Postgres",
+			sim:         PolicySimulation{Source: hub, Destination: postgres, Port: 5432, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantRule:    RuleRef{Policy: "CiliumClusterwideNetworkPolicy/databases-core", Rule: "ingress[1]"},
+		},
+		{
+			name:        "World To ArgoCD Redis",
+			sim:         PolicySimulation{Source: PolicyEndpoint{Entity: EntityWorld, IP: "203.0.113.7"}, Destination: redis, Port: 6379, Protocol: "TCP"},
+			wantVerdict: "DENIED",
+		},
+		{
+			name:        "Loki Push Gets L7 Visibility",
+			sim:         PolicySimulation{Source: hub, Destination: loki, Port: 3100, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+			wantRule:    RuleRef{Policy: "CiliumClusterwideNetworkPolicy/observability-l7", Rule: "ingress[0]", L7: "http"},
+		},
+		{
+			name:        "Postgres Backups To Azure",
+			sim:         PolicySimulation{Source: postgres, Destination: PolicyEndpoint{Entity: EntityWorld, FQDN: "homelab.blob.core.windows.net"}, Port: 443, Protocol: "TCP"},
+			wantVerdict: "ALLOWED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.SimulateNetworkPolicy(context.Background(), tt.sim)
+			if err != nil {
+				t.Fatalf("SimulateNetworkPolicy() error = %v", err)
+			}
+			if got.Verdict != tt.wantVerdict {
+				t.Fatalf("verdict = %s, want %s (egress: %s; ingress: %s)", got.Verdict, tt.wantVerdict, got.Egress.Reason, got.Ingress.Reason)
+			}
+			if got.Policies != 5 {
+				t.Errorf("evaluated %d policies, want 5", got.Policies)
+			}
+			if tt.wantRule != (RuleRef{}) && (len(got.Ingress.AllowedBy) == 0 || got.Ingress.AllowedBy[0] != tt.wantRule) {
+				t.Errorf("ingress allowed by %v, want %v first", got.Ingress.AllowedBy, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestHubProvider_SimulateNetworkPolicy_Cluster(t *testing.T) {
+	var calls [][]string
+	mock := &MockCommandRunner{
+		RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+			calls = append(calls, arg)
+			if arg[1] == "pod" {
+				if arg[2] == "missing" {
+					return []byte(`Error from server (NotFound): pods "missing" not found`), errors.New("exit status 1")
+				}
+				return []byte(`{"app":"web"}`), nil
+			}
+			return []byte(policyFixture), nil
+		},
+	}
+	p := &HubProvider{runner: mock}
+
+	got, err := p.SimulateNetworkPolicy(context.Background(), PolicySimulation{
+		Source:      PolicyEndpoint{Namespace: "apps", Pod: "web-0"},
+		Destination: PolicyEndpoint{Namespace: "apps", Labels: map[string]string{"app": "api"}},
+		Port:        8080,
+		Protocol:    "TCP",
+		FromCluster: true,
+	})
+	if err != nil {
+		t.Fatalf("SimulateNetworkPolicy() error = %v", err)
+	}
+	if got.Verdict != "ALLOWED" || got.PolicySource != "cluster" || got.Source != "apps/web-0" {
+		t.Errorf("got %+v, want web-0 allowed by cluster policies", got)
+	}
+	wantCalls := [][]string{
+		{"get", "ciliumnetworkpolicies,ciliumclusterwidenetworkpolicies,networkpolicies", "--all-namespaces", "-o", "yaml"},
+		{"get", "pod", "web-0", "-n", "apps", "-o", "jsonpath={.metadata.labels}"},
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", calls, wantCalls)
+	}
+
+	_, err = p.SimulateNetworkPolicy(context.Background(), PolicySimulation{
+		Source:      PolicyEndpoint{Namespace: "apps", Pod: "missing"},
+		Destination: PolicyEndpoint{Entity: EntityWorld},
+		FromCluster: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "pass labels to simulate offline") {
+		t.Errorf("error = %v, want pod label lookup failure", err)
+	}
+}
+
+func TestHubProvider_SimulateNetworkPolicy_InvalidManifest(t *testing.T) {
+	dir := t.TempDir()
+	bad := "kind: CiliumNetworkPolicy\nmetadata: {name: bad}\nspec:\n  endpointSelector: {}\n  egress:\n    - toCIDR: [not-a-cidr]\n"
+	if err := os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := &HubProvider{}
+	p.UsePolicyDir(dir)
+	if _, err := p.SimulateNetworkPolicy(context.Background(), PolicySimulation{}); err == nil || !strings.Contains(err.Error(), "not-a-cidr") {
+		t.Errorf("error = %v, want invalid CIDR", err)
+	}
+}
+
+func TestHubProvider_SimulateNetworkPolicy_NamedPort(t *testing.T) {
+	dir := t.TempDir()
+	named := "kind: NetworkPolicy\nmetadata: {name: web, namespace: apps}\nspec:\n  podSelector: {}\n  ingress:\n    - ports:\n        - port: http\n"
+	for file, content := range map[string]string{"policies.yaml": policyFixture, "named.yaml": named} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &HubProvider{}
+	p.UsePolicyDir(dir)
+	got, err := p.SimulateNetworkPolicy(context.Background(), PolicySimulation{
+		Source:      PolicyEndpoint{Namespace: "apps", Labels: map[string]string{"app": "web"}},
+		Destination: PolicyEndpoint{Namespace: "apps", Labels: map[string]string{"app": "api"}},
+		Port:        8080,
+		Protocol:    "TCP",
+	})
+	if err != nil {
+		t.Fatalf("SimulateNetworkPolicy() error = %v", err)
+	}
+	if got.Verdict != "ALLOWED" || got.Policies != 2 {
+		t.Errorf("got verdict %s from %d policies, want ALLOWED from 2", got.Verdict, got.Policies)
+	}
+	if !slices.ContainsFunc(got.Skipped, func(s string) bool { return strings.HasPrefix(s, `NetworkPolicy/apps/web: named port "http"`) }) {
+		t.Errorf("skipped = %v, want the named-port policy", got.Skipped)
+	}
+}
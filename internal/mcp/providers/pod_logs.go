@@ -0,0 +1,242 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"observability-hub/internal/telemetry"
+)
+
+const (
+	// maxLogStreams bounds how many containers one search may read.
+	maxLogStreams = 50
+	// logStreamWorkers is how many container log streams are read concurrently.
+	logStreamWorkers = 4
+	// defaultLogStreamBytes caps the lines kept from a single container stream; the newest win.
+	defaultLogStreamBytes = 2 << 20
+	// defaultLogTailLines bounds what the kubelet sends per container when no tail is given.
+	defaultLogTailLines = 10000
+	// maxLogStreamReadBytes stops reading a stream whose tail is far larger than the byte cap.
+	maxLogStreamReadBytes = 32 << 20
+	// defaultLogLines caps the merged result; the most recent lines win.
+	defaultLogLines = 1000
+)
+
+// PodLogSearchOptions selects the pods and containers to read and how to filter their logs.
+// Exactly one of Name and LabelSelector selects the pods.
+type PodLogSearchOptions struct {
+	Name          string
+	LabelSelector string
+	Container     string         // empty reads every init and app container
+	Since         time.Duration  // zero reads the whole retained log
+	TailLines     int64          // per container, applied by the kubelet before filtering, default 10000
+	Previous      bool           // read the previous (crashed) container instance
+	Pattern       *regexp.Regexp // keep only matching lines; nil keeps everything
+	MaxLines      int            // cap on merged lines, default 1000
+	MaxBytes      int64          // cap on lines kept per container stream, default 2 MiB
+}
+
+// PodLogLine is one timestamped log line from a pod container.
+type PodLogLine struct {
+	Time      time.Time `json:"time"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Text      string    `json:"text"`
+}
+
+// PodLogSearch is the merged, time-ordered result of a log search.
+type PodLogSearch struct {
+	Lines     []PodLogLine      `json:"lines"`
+	Streams   int               `json:"streams"`   // containers read
+	Scanned   int               `json:"scanned"`   // lines read before filtering
+	Matched   int               `json:"matched"`   // lines that passed the filter, before the line cap
+	Truncated bool              `json:"truncated"` // a byte or line cap dropped output
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+type logStream struct {
+	pod, container string
+}
+
+// SearchPodLogs streams the logs of every selected container, filters them and
+// interleaves the result by timestamp. Streams that fail are reported in Errors
+// rather than failing the whole search.
+func (p *PodsProvider) SearchPodLogs(ctx context.Context, namespace string, opts PodLogSearchOptions) (*PodLogSearch, error) {
+	if (opts.Name == "") == (opts.LabelSelector == "") {
+		return nil, InvalidInputf("exactly one of name or label_selector is required")
+	}
+	if opts.MaxLines <= 0 {
+		opts.MaxLines = defaultLogLines
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultLogStreamBytes
+	}
+	if opts.TailLines <= 0 {
+		opts.TailLines = defaultLogTailLines
+	}
+
+	var podList []corev1.Pod
+	if opts.Name != "" {
+		pod, err := p.GetPod(ctx, namespace, opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		podList = []corev1.Pod{*pod}
+	} else {
+		list, err := p.ListPods(ctx, namespace, PodListOptions{LabelSelector: opts.LabelSelector})
+		if err != nil {
+			return nil, err
+		}
+		podList = list.Items
+	}
+
+	var streams []logStream
+	for _, pod := range podList {
+		for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			if opts.Container == "" || opts.Container == c.Name {
+				streams = append(streams, logStream{pod: pod.Name, container: c.Name})
+			}
+		}
+	}
+	if len(streams) == 0 {
+		return nil, NotFoundf("no containers matched in namespace %s", namespace)
+	}
+
+	result := &PodLogSearch{}
+	if len(streams) > maxLogStreams {
+		result.Errors = map[string]string{
+			"streams": fmt.Sprintf("read the first %d of %d containers by pod name; narrow the selector or set a container", maxLogStreams, len(streams)),
+		}
+		streams = streams[:maxLogStreams]
+		result.Truncated = true
+	}
+	result.Streams = len(streams)
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, logStreamWorkers)
+	)
+	for _, s := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			lines, scanned, matched, truncated, err := p.readLogStream(ctx, namespace, s, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Scanned += scanned
+			result.Matched += matched
+			result.Truncated = result.Truncated || truncated
+			result.Lines = append(result.Lines, lines...)
+			if err != nil {
+				telemetry.Warn("pod log stream failed", "pod", s.pod, "container", s.container, "error", err)
+				if result.Errors == nil {
+					result.Errors = make(map[string]string)
+				}
+				result.Errors[s.pod+"/"+s.container] = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(result.Lines, func(i, j int) bool {
+		a, b := result.Lines[i], result.Lines[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Container < b.Container
+	})
+	if len(result.Lines) > opts.MaxLines {
+		result.Lines = result.Lines[len(result.Lines)-opts.MaxLines:]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// readLogStream reads the last opts.TailLines lines of one container's log. Lines read
+// before an error are still returned.
+func (p *PodsProvider) readLogStream(ctx context.Context, namespace string, s logStream, opts PodLogSearchOptions) (lines []PodLogLine, scanned, matched int, truncated bool, err error) {
+	logOpts := &corev1.PodLogOptions{
+		Container:  s.container,
+		Previous:   opts.Previous,
+		Timestamps: true,
+		TailLines:  &opts.TailLines,
+	}
+	if opts.Since > 0 {
+		seconds := int64(opts.Since.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		logOpts.SinceSeconds = &seconds
+	}
+
+	body, err := p.clientset.CoreV1().Pods(namespace).GetLogs(s.pod, logOpts).Stream(ctx)
+	if err != nil {
+		return nil, 0, 0, false, fmt.Errorf("failed to stream logs: %w", err)
+	}
+	defer body.Close()
+	return readLogLines(body, s, opts)
+}
+
+// readLogLines keeps the newest matching lines of r that fit in opts.MaxBytes and
+// opts.MaxLines, since older ones could never survive the merged cap. LimitBytes is
+// not sent to the kubelet because it keeps the oldest bytes of the tail.
+func readLogLines(r io.Reader, s logStream, opts PodLogSearchOptions) (lines []PodLogLine, scanned, matched int, truncated bool, err error) {
+	limited := &io.LimitedReader{R: r, N: maxLogStreamReadBytes}
+	reader := bufio.NewReader(limited)
+	var size int64
+	for {
+		raw, readErr := reader.ReadString('\n')
+		if raw != "" {
+			scanned++
+			line := parseLogLine(strings.TrimRight(raw, "\r\n"))
+			if opts.Pattern == nil || opts.Pattern.MatchString(line.Text) {
+				matched++
+				line.Pod, line.Container = s.pod, s.container
+				lines = append(lines, line)
+				size += int64(len(line.Text))
+				for len(lines) > 0 && (len(lines) > opts.MaxLines || size > opts.MaxBytes) {
+					size -= int64(len(lines[0].Text))
+					lines = lines[1:]
+					truncated = true
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return lines, scanned, matched, truncated, fmt.Errorf("failed to read logs: %w", readErr)
+		}
+	}
+	if limited.N <= 0 {
+		truncated = true
+	}
+	return lines, scanned, matched, truncated, nil
+}
+
+// parseLogLine splits the RFC3339 timestamp the kubelet prefixes when Timestamps is set.
+// Lines without one keep a zero time and sort first.
+func parseLogLine(raw string) PodLogLine {
+	if ts, text, ok := strings.Cut(raw, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return PodLogLine{Time: t, Text: text}
+		}
+	}
+	return PodLogLine{Text: raw}
+}
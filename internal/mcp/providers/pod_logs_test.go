@@ -0,0 +1,154 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPodsProvider_SearchPodLogs(t *testing.T) {
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "api"}},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate"}},
+				Containers:     []corev1.Container{{Name: "app"}, {Name: "proxy"}},
+			},
+		}
+	}
+	// The fake clientset answers every log request with the body "fake logs".
+	provider := &PodsProvider{clientset: fake.NewSimpleClientset(pod("api-0"), pod("api-1"))}
+
+	tests := []struct {
+		name          string
+		opts          PodLogSearchOptions
+		wantStreams   int
+		wantMatched   int
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{name: "single pod, all containers", opts: PodLogSearchOptions{Name: "api-0"}, wantStreams: 3, wantMatched: 3},
+		{name: "single container", opts: PodLogSearchOptions{Name: "api-0", Container: "migrate"}, wantStreams: 1, wantMatched: 1},
+		{name: "label selector", opts: PodLogSearchOptions{LabelSelector: "app=api", Container: "app"}, wantStreams: 2, wantMatched: 2},
+		{name: "grep filters", opts: PodLogSearchOptions{Name: "api-0", Pattern: regexp.MustCompile("panic")}, wantStreams: 3, wantMatched: 0},
+		{name: "line cap", opts: PodLogSearchOptions{LabelSelector: "app=api", MaxLines: 4}, wantStreams: 6, wantMatched: 6, wantTruncated: true},
+		{name: "byte cap", opts: PodLogSearchOptions{Name: "api-0", Container: "app", MaxBytes: 4}, wantStreams: 1, wantMatched: 1, wantTruncated: true},
+		{name: "unknown container", opts: PodLogSearchOptions{Name: "api-0", Container: "sidecar"}, wantErr: true},
+		{name: "no selection", opts: PodLogSearchOptions{}, wantErr: true},
+		{name: "both selections", opts: PodLogSearchOptions{Name: "api-0", LabelSelector: "app=api"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.SearchPodLogs(context.Background(), "default", tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SearchPodLogs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Streams != tt.wantStreams || got.Matched != tt.wantMatched || got.Truncated != tt.wantTruncated {
+				t.Errorf("SearchPodLogs() streams/matched/truncated = %d/%d/%v, want %d/%d/%v",
+					got.Streams, got.Matched, got.Truncated, tt.wantStreams, tt.wantMatched, tt.wantTruncated)
+			}
+			if tt.opts.MaxLines > 0 && len(got.Lines) > tt.opts.MaxLines {
+				t.Errorf("SearchPodLogs() returned %d lines, cap %d", len(got.Lines), tt.opts.MaxLines)
+			}
+			for _, l := range got.Lines {
+				if l.Pod == "" || l.Container == "" {
+					t.Errorf("line %+v missing pod/container", l)
+				}
+			}
+		})
+	}
+}
+
+func TestPodsProvider_SearchPodLogsLimits(t *testing.T) {
+	var objects []runtime.Object
+	for i := range 20 {
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("api-%02d", i), Namespace: "default", Labels: map[string]string{"app": "api"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "proxy"}, {Name: "agent"}}},
+		})
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+	provider := &PodsProvider{clientset: clientset}
+
+	got, err := provider.SearchPodLogs(context.Background(), "default", PodLogSearchOptions{LabelSelector: "app=api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Streams != maxLogStreams || !got.Truncated || !strings.Contains(got.Errors["streams"], "of 60 containers") {
+		t.Errorf("got %d streams, truncated %v, errors %v; want the stream cap reported", got.Streams, got.Truncated, got.Errors)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetSubresource() != "log" {
+			continue
+		}
+		opts := action.(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+		if opts.TailLines == nil || *opts.TailLines != defaultLogTailLines || opts.LimitBytes != nil {
+			t.Fatalf("got log options %+v, want the default tail and no byte limit", opts)
+		}
+	}
+}
+
+func TestReadLogLines(t *testing.T) {
+	var body strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&body, "2026-03-06T17:00:%02dZ line %d\n", i, i)
+	}
+	tests := []struct {
+		name          string
+		opts          PodLogSearchOptions
+		wantFirst     string
+		wantLines     int
+		wantTruncated bool
+	}{
+		{name: "everything fits", opts: PodLogSearchOptions{MaxLines: 100, MaxBytes: 1 << 10}, wantFirst: "line 0", wantLines: 10},
+		{name: "byte cap keeps the newest", opts: PodLogSearchOptions{MaxLines: 100, MaxBytes: 18}, wantFirst: "line 7", wantLines: 3, wantTruncated: true},
+		{name: "line cap keeps the newest", opts: PodLogSearchOptions{MaxLines: 2, MaxBytes: 1 << 10}, wantFirst: "line 8", wantLines: 2, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, scanned, matched, truncated, err := readLogLines(strings.NewReader(body.String()), logStream{pod: "api-0", container: "app"}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scanned != 10 || matched != 10 || truncated != tt.wantTruncated || len(lines) != tt.wantLines || lines[0].Text != tt.wantFirst {
+				t.Errorf("got %d lines from %q, scanned/matched/truncated %d/%d/%v; want %d from %q, truncated %v",
+					len(lines), lines[0].Text, scanned, matched, truncated, tt.wantLines, tt.wantFirst, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		raw      string
+		wantTime time.Time
+		wantText string
+	}{
+		{raw: "2026-03-06T17:00:00.123456789Z GET /healthz 200", wantTime: time.Date(2026, 3, 6, 17, 0, 0, 123456789, time.UTC), wantText: "GET /healthz 200"},
+		{raw: "2026-03-06T17:00:00Z ", wantTime: time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC), wantText: ""},
+		{raw: "no timestamp here", wantText: "no timestamp here"},
+		{raw: "plain", wantText: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got := parseLogLine(tt.raw)
+			if !got.Time.Equal(tt.wantTime) || got.Text != tt.wantText {
+				t.Errorf("parseLogLine() = %v %q, want %v %q", got.Time, got.Text, tt.wantTime, tt.wantText)
+			}
+		})
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"path/filepath"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -23,28 +26,37 @@ type PodsProvider struct {
 // NewPodsProvider creates a new PodsProvider.
 // It attempts to use in-cluster config first, then falls back to local kubeconfig.
 func NewPodsProvider() (*PodsProvider, error) {
-	var config *rest.Config
-	var err error
-
-	// Try in-cluster config first
-	config, err = rest.InClusterConfig()
+	config, err := LoadKubeConfig()
 	if err != nil {
-		// Fallback to local kubeconfig
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			home, _ := os.UserHomeDir()
-			kubeconfig = filepath.Join(home, ".kube", "config")
-		}
+		return nil, err
+	}
+	return NewPodsProviderForConfig(config)
+}
 
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
-		}
-		telemetry.Info("using local kubeconfig", "path", kubeconfig)
-	} else {
+// LoadKubeConfig returns the in-cluster config, or the local kubeconfig (KUBECONFIG or
+// ~/.kube/config) outside a cluster.
+func LoadKubeConfig() (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if err == nil {
 		telemetry.Info("using in-cluster config")
+		return config, nil
 	}
 
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		home, _ := os.UserHomeDir()
+		kubeconfig = filepath.Join(home, ".kube", "config")
+	}
+	config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	telemetry.Info("using local kubeconfig", "path", kubeconfig)
+	return config, nil
+}
+
+// NewPodsProviderForConfig creates a new PodsProvider talking to the API server in config.
+func NewPodsProviderForConfig(config *rest.Config) (*PodsProvider, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
@@ -58,18 +70,89 @@ func NewPodsProviderWithClientset(clientset kubernetes.Interface) *PodsProvider
 	return &PodsProvider{clientset: clientset}
 }
 
-// ListPods returns a list of pods in the specified namespace.
-func (p *PodsProvider) ListPods(ctx context.Context, namespace string) (*corev1.PodList, error) {
+// PodListOptions narrows ListPods. Selectors use Kubernetes syntax, e.g.
+// LabelSelector "app=sensor-fleet,tier!=canary" or FieldSelector "spec.nodeName=node-a,status.phase!=Running".
+type PodListOptions struct {
+	LabelSelector string
+	FieldSelector string
+	// Limit > 0 returns a single API page of at most Limit pods, starting at the Continue
+	// token of a previous page; the next token is in the returned list's Continue.
+	Limit    int64
+	Continue string
+}
+
+const (
+	// podListChunkSize bounds each API response; ListPods follows continue tokens until done.
+	podListChunkSize = 500
+	// podListScanLimit caps a full listing. When it is reached the returned list's Continue
+	// is set, marking the result as incomplete.
+	podListScanLimit = 10000
+)
+
+// ListPods returns the pods in the specified namespace matching opts.
+// An empty namespace lists pods across the whole cluster. Without opts.Limit every
+// matching pod is listed, up to podListScanLimit.
+func (p *PodsProvider) ListPods(ctx context.Context, namespace string, opts PodListOptions) (*corev1.PodList, error) {
 	if namespace == "" {
 		namespace = metav1.NamespaceAll
 	}
-
-	pods, err := p.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	labelSelector, err := labels.Parse(opts.LabelSelector)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods: %w", err)
+		return nil, InvalidInputf("invalid label selector %q: %w", opts.LabelSelector, err)
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, InvalidInputf("invalid field selector %q: %w", opts.FieldSelector, err)
+	}
+
+	result := &corev1.PodList{Items: make([]corev1.Pod, 0)}
+	listOpts := metav1.ListOptions{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+		Limit:         podListChunkSize,
+		Continue:      opts.Continue,
+	}
+	if opts.Limit > 0 {
+		listOpts.Limit = opts.Limit
+	}
+	for {
+		pods, err := p.clientset.CoreV1().Pods(namespace).List(ctx, listOpts)
+		if apierrors.IsResourceExpired(err) {
+			return nil, InvalidInputf("continue token has expired; list again from the first page")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		// The selectors are re-applied for clients that ignore them, such as the fake clientset.
+		for _, pod := range pods.Items {
+			if labelSelector.Matches(labels.Set(pod.Labels)) && fieldSelector.Matches(podFields(&pod)) {
+				result.Items = append(result.Items, pod)
+			}
+		}
+		result.Continue = pods.Continue
+		result.RemainingItemCount = pods.RemainingItemCount
+		if pods.Continue == "" || opts.Limit > 0 || len(result.Items) >= podListScanLimit {
+			break
+		}
+		listOpts.Continue = pods.Continue
 	}
 
-	return pods, nil
+	return result, nil
+}
+
+// podFields mirrors the field selectors the API server supports for pods.
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
 }
 
 // GetPod returns the specified pod.
@@ -82,6 +165,16 @@ func (p *PodsProvider) GetPod(ctx context.Context, namespace, name string) (*cor
 	return pod, nil
 }
 
+// GetNode returns the specified node.
+func (p *PodsProvider) GetNode(ctx context.Context, name string) (*corev1.Node, error) {
+	node, err := p.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+
+	return node, nil
+}
+
 // ListEvents returns a list of events for the specified pod.
 func (p *PodsProvider) ListEvents(ctx context.Context, namespace, name string) (*corev1.EventList, error) {
 	fieldSelector := fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", name)
@@ -105,6 +198,21 @@ func (p *PodsProvider) ListEvents(ctx context.Context, namespace, name string) (
 	return filtered, nil
 }
 
+// ListNamespaceEvents returns all events in the specified namespace.
+// An empty namespace lists events across the whole cluster.
+func (p *PodsProvider) ListNamespaceEvents(ctx context.Context, namespace string) (*corev1.EventList, error) {
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+
+	events, err := p.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events in namespace %q: %w", namespace, err)
+	}
+
+	return events, nil
+}
+
 // GetPodLogs retrieves logs from the specified pod/container.
 func (p *PodsProvider) GetPodLogs(ctx context.Context, namespace, name, container string, tailLines int64, previous bool) (string, error) {
 	opts := &corev1.PodLogOptions{
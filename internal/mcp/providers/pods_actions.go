@@ -0,0 +1,472 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Bounds for workload remediation. They keep a single tool call from making
+// changes a human would not expect from a runbook step.
+const (
+	maxReplicaDelta     = 10
+	maxReplicas         = 100
+	rolloutPollInterval = 2 * time.Second
+	restartedAtKey      = "kubectl.kubernetes.io/restartedAt"
+)
+
+// RolloutStatus reports the rollout state of a Deployment, StatefulSet or DaemonSet
+// using the same rules as `kubectl rollout status`.
+type RolloutStatus struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Replicas  int32             `json:"replicas"` // desired replicas (desired scheduled pods for DaemonSets)
+	Updated   int32             `json:"updated"`
+	Ready     int32             `json:"ready"`
+	Available int32             `json:"available"`
+	Complete  bool              `json:"complete"`
+	Message   string            `json:"message"`
+	Labels    map[string]string `json:"-"`
+
+	stalled bool // progress deadline exceeded; waiting longer will not help
+}
+
+// NodeStatus reports the scheduling state of a node after a cordon change.
+type NodeStatus struct {
+	Name          string            `json:"name"`
+	Unschedulable bool              `json:"unschedulable"`
+	Ready         bool              `json:"ready"`
+	Pods          int               `json:"pods"` // non-terminated pods still bound to the node
+	Message       string            `json:"message"`
+	Labels        map[string]string `json:"-"`
+}
+
+// workload is the subset of a controller the remediation actions need.
+type workload struct {
+	status   *RolloutStatus
+	paused   bool
+	onDelete bool
+}
+
+// normalizeWorkloadKind accepts kubectl-style kind names ("deploy", "sts", "statefulset", ...).
+func normalizeWorkloadKind(kind string) (string, error) {
+	switch strings.ToLower(kind) {
+	case "deployment", "deployments", "deploy":
+		return "Deployment", nil
+	case "statefulset", "statefulsets", "sts":
+		return "StatefulSet", nil
+	case "daemonset", "daemonsets", "ds":
+		return "DaemonSet", nil
+	}
+	return "", InvalidInputf("unsupported workload kind %q (want Deployment, StatefulSet or DaemonSet)", kind)
+}
+
+func (p *PodsProvider) getWorkload(ctx context.Context, kind, namespace, name string) (*workload, error) {
+	apps := p.clientset.AppsV1()
+	switch kind {
+	case "Deployment":
+		d, err := apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %s/%s: %w", namespace, name, err)
+		}
+		return &workload{status: DeploymentRolloutStatus(d), paused: d.Spec.Paused}, nil
+	case "StatefulSet":
+		s, err := apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statefulset %s/%s: %w", namespace, name, err)
+		}
+		return &workload{status: StatefulSetRolloutStatus(s), onDelete: s.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType}, nil
+	case "DaemonSet":
+		ds, err := apps.DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daemonset %s/%s: %w", namespace, name, err)
+		}
+		return &workload{status: DaemonSetRolloutStatus(ds), onDelete: ds.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType}, nil
+	}
+	return nil, InvalidInputf("unsupported workload kind %q", kind)
+}
+
+// GetRolloutStatus returns the current rollout state of a workload.
+func (p *PodsProvider) GetRolloutStatus(ctx context.Context, kind, namespace, name string) (*RolloutStatus, error) {
+	kind, err := normalizeWorkloadKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	w, err := p.getWorkload(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return w.status, nil
+}
+
+// PreflightRolloutRestart checks that a rollout restart would take effect and returns the current status.
+func (p *PodsProvider) PreflightRolloutRestart(ctx context.Context, kind, namespace, name string) (*RolloutStatus, error) {
+	kind, err := normalizeWorkloadKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	w, err := p.getWorkload(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if w.paused {
+		return nil, InvalidInputf("deployment %s/%s is paused; resume it before restarting", namespace, name)
+	}
+	if w.onDelete {
+		return nil, InvalidInputf("%s %s/%s uses the OnDelete update strategy; a restart would not replace any pods", kind, namespace, name)
+	}
+	return w.status, nil
+}
+
+// RolloutRestart restarts all pods of a workload the way `kubectl rollout restart` does,
+// by stamping the pod template, then waits up to wait for the rollout to finish.
+func (p *PodsProvider) RolloutRestart(ctx context.Context, kind, namespace, name string, wait time.Duration) (*RolloutStatus, error) {
+	if _, err := p.PreflightRolloutRestart(ctx, kind, namespace, name); err != nil {
+		return nil, err
+	}
+	kind, _ = normalizeWorkloadKind(kind)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{restartedAtKey: time.Now().Format(time.RFC3339)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build restart patch: %w", err)
+	}
+
+	apps := p.clientset.AppsV1()
+	switch kind {
+	case "Deployment":
+		_, err = apps.Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = apps.StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	case "DaemonSet":
+		_, err = apps.DaemonSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restart %s %s/%s: %w", kind, namespace, name, err)
+	}
+
+	return p.waitForRollout(ctx, kind, namespace, name, wait)
+}
+
+// PreflightScale checks that scaling a Deployment or StatefulSet to replicas is within bounds:
+// at most maxReplicas, a change of at most maxReplicaDelta, and not managed by an HPA.
+func (p *PodsProvider) PreflightScale(ctx context.Context, kind, namespace, name string, replicas int32) (*RolloutStatus, error) {
+	kind, err := normalizeWorkloadKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "DaemonSet" {
+		return nil, InvalidInputf("daemonsets cannot be scaled; they run one pod per eligible node")
+	}
+	if replicas < 0 || replicas > maxReplicas {
+		return nil, InvalidInputf("replicas must be between 0 and %d, got %d", maxReplicas, replicas)
+	}
+	w, err := p.getWorkload(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	current := w.status.Replicas
+	if replicas == current {
+		return nil, InvalidInputf("%s %s/%s already has %d replicas", kind, namespace, name, current)
+	}
+	if delta := replicas - current; delta > maxReplicaDelta || -delta > maxReplicaDelta {
+		return nil, InvalidInputf("scaling %s %s/%s from %d to %d exceeds the maximum change of %d replicas per call", kind, namespace, name, current, replicas, maxReplicaDelta)
+	}
+
+	hpas, err := p.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list horizontal pod autoscalers in %s: %w", namespace, err)
+	}
+	for _, hpa := range hpas.Items {
+		if hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name {
+			return nil, InvalidInputf("%s %s/%s is managed by HorizontalPodAutoscaler %s, which would revert a manual scale", kind, namespace, name, hpa.Name)
+		}
+	}
+	return w.status, nil
+}
+
+// ScaleWorkload sets the replica count of a Deployment or StatefulSet, then waits up to wait
+// for the new replicas to become available (or the removed ones to terminate).
+func (p *PodsProvider) ScaleWorkload(ctx context.Context, kind, namespace, name string, replicas int32, wait time.Duration) (*RolloutStatus, error) {
+	if _, err := p.PreflightScale(ctx, kind, namespace, name, replicas); err != nil {
+		return nil, err
+	}
+	kind, _ = normalizeWorkloadKind(kind)
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	var err error
+	switch kind {
+	case "Deployment":
+		_, err = p.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = p.clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scale %s %s/%s: %w", kind, namespace, name, err)
+	}
+
+	return p.waitForRollout(ctx, kind, namespace, name, wait)
+}
+
+// waitForRollout polls the workload until its rollout completes, stalls or wait elapses.
+// Running out of time is not an error: the action already happened, so the current
+// status is returned for the caller to judge.
+func (p *PodsProvider) waitForRollout(ctx context.Context, kind, namespace, name string, wait time.Duration) (*RolloutStatus, error) {
+	wait = capWait(ctx, wait)
+	deadline := time.Now().Add(wait)
+	for {
+		w, err := p.getWorkload(ctx, kind, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		s := w.status
+		remaining := time.Until(deadline)
+		if s.Complete || s.stalled {
+			return s, nil
+		}
+		if remaining <= 0 {
+			if wait > 0 {
+				s.Message = fmt.Sprintf("still in progress after %s: %s", wait, s.Message)
+			}
+			return s, nil
+		}
+
+		if remaining > rolloutPollInterval {
+			remaining = rolloutPollInterval
+		}
+		select {
+		case <-ctx.Done():
+			s.Message = fmt.Sprintf("stopped waiting (%v): %s", ctx.Err(), s.Message)
+			return s, nil
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// waitMargin is kept between the end of a wait and the call's deadline, for the final status
+// read and the response.
+const waitMargin = 10 * time.Second
+
+// capWait shortens wait so it ends waitMargin before ctx's deadline. The status is then
+// reported as still in progress after the capped wait, instead of as an interrupted call.
+func capWait(ctx context.Context, wait time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return wait
+	}
+	if left := time.Until(deadline) - waitMargin; left < wait {
+		wait = left
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// DeploymentRolloutStatus evaluates a Deployment like `kubectl rollout status`.
+func DeploymentRolloutStatus(d *appsv1.Deployment) *RolloutStatus {
+	s := &RolloutStatus{
+		Kind:      "Deployment",
+		Namespace: d.Namespace,
+		Name:      d.Name,
+		Replicas:  replicasOrDefault(d.Spec.Replicas),
+		Updated:   d.Status.UpdatedReplicas,
+		Ready:     d.Status.ReadyReplicas,
+		Available: d.Status.AvailableReplicas,
+		Labels:    d.Labels,
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			s.stalled = true
+			s.Message = fmt.Sprintf("rollout exceeded its progress deadline: %s", c.Message)
+			return s
+		}
+	}
+	switch {
+	case d.Generation > d.Status.ObservedGeneration:
+		s.Message = "waiting for the deployment spec update to be observed"
+	case s.Updated < s.Replicas:
+		s.Message = fmt.Sprintf("%d of %d new replicas have been updated", s.Updated, s.Replicas)
+	case d.Status.Replicas > s.Updated:
+		s.Message = fmt.Sprintf("%d old replicas are pending termination", d.Status.Replicas-s.Updated)
+	case s.Available < s.Updated:
+		s.Message = fmt.Sprintf("%d of %d updated replicas are available", s.Available, s.Updated)
+	default:
+		s.Complete = true
+		s.Message = "successfully rolled out"
+	}
+	return s
+}
+
+// StatefulSetRolloutStatus evaluates a StatefulSet like `kubectl rollout status`.
+func StatefulSetRolloutStatus(sts *appsv1.StatefulSet) *RolloutStatus {
+	s := &RolloutStatus{
+		Kind:      "StatefulSet",
+		Namespace: sts.Namespace,
+		Name:      sts.Name,
+		Replicas:  replicasOrDefault(sts.Spec.Replicas),
+		Updated:   sts.Status.UpdatedReplicas,
+		Ready:     sts.Status.ReadyReplicas,
+		Available: sts.Status.AvailableReplicas,
+		Labels:    sts.Labels,
+	}
+	var partition int32
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		partition = *ru.Partition
+	}
+	switch {
+	case sts.Generation > sts.Status.ObservedGeneration:
+		s.Message = "waiting for the statefulset spec update to be observed"
+	case s.Ready < s.Replicas:
+		s.Message = fmt.Sprintf("%d of %d pods are ready", s.Ready, s.Replicas)
+	case partition > 0 && s.Updated < s.Replicas-partition:
+		s.Message = fmt.Sprintf("%d of %d pods above partition %d have been updated", s.Updated, s.Replicas-partition, partition)
+	case partition == 0 && sts.Status.UpdateRevision != sts.Status.CurrentRevision:
+		s.Message = fmt.Sprintf("%d of %d pods are at revision %s", s.Updated, s.Replicas, sts.Status.UpdateRevision)
+	default:
+		s.Complete = true
+		s.Message = "successfully rolled out"
+	}
+	return s
+}
+
+// DaemonSetRolloutStatus evaluates a DaemonSet like `kubectl rollout status`.
+func DaemonSetRolloutStatus(ds *appsv1.DaemonSet) *RolloutStatus {
+	s := &RolloutStatus{
+		Kind:      "DaemonSet",
+		Namespace: ds.Namespace,
+		Name:      ds.Name,
+		Replicas:  ds.Status.DesiredNumberScheduled,
+		Updated:   ds.Status.UpdatedNumberScheduled,
+		Ready:     ds.Status.NumberReady,
+		Available: ds.Status.NumberAvailable,
+		Labels:    ds.Labels,
+	}
+	switch {
+	case ds.Generation > ds.Status.ObservedGeneration:
+		s.Message = "waiting for the daemonset spec update to be observed"
+	case s.Updated < s.Replicas:
+		s.Message = fmt.Sprintf("%d of %d pods have been updated", s.Updated, s.Replicas)
+	case s.Available < s.Replicas:
+		s.Message = fmt.Sprintf("%d of %d updated pods are available", s.Available, s.Replicas)
+	default:
+		s.Complete = true
+		s.Message = "successfully rolled out"
+	}
+	return s
+}
+
+// replicasOrDefault mirrors the API server default of 1 replica.
+func replicasOrDefault(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+// PreflightCordon checks that changing a node's schedulability is meaningful and safe.
+// Cordoning the last schedulable Ready node is refused.
+func (p *PodsProvider) PreflightCordon(ctx context.Context, name string, cordon bool) (*NodeStatus, error) {
+	node, err := p.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	if node.Spec.Unschedulable == cordon {
+		if cordon {
+			return nil, InvalidInputf("node %s is already cordoned", name)
+		}
+		return nil, InvalidInputf("node %s is already schedulable", name)
+	}
+
+	if cordon {
+		nodes, err := p.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		schedulable := 0
+		for _, n := range nodes.Items {
+			if n.Name != name && !n.Spec.Unschedulable && nodeReady(&n) {
+				schedulable++
+			}
+		}
+		if schedulable == 0 {
+			return nil, InvalidInputf("refusing to cordon %s: it is the last schedulable ready node", name)
+		}
+	}
+	return p.nodeStatus(ctx, node)
+}
+
+// CordonNode marks a node unschedulable (cordon=true) or schedulable again (cordon=false).
+// Running pods are not evicted; the returned status reports how many remain on the node.
+func (p *PodsProvider) CordonNode(ctx context.Context, name string, cordon bool) (*NodeStatus, error) {
+	if _, err := p.PreflightCordon(ctx, name, cordon); err != nil {
+		return nil, err
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, cordon))
+	node, err := p.clientset.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update node %s: %w", name, err)
+	}
+
+	status, err := p.nodeStatus(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	if cordon {
+		status.Message = fmt.Sprintf("node cordoned; %d pods are still running on it and were not evicted", status.Pods)
+	} else {
+		status.Message = "node is schedulable again"
+	}
+	return status, nil
+}
+
+func (p *PodsProvider) nodeStatus(ctx context.Context, node *corev1.Node) (*NodeStatus, error) {
+	pods, err := p.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", node.Name, err)
+	}
+
+	// Re-check the node for clients that ignore the field selector, and skip terminated
+	// pods, which no longer occupy the node.
+	running := 0
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == node.Name && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			running++
+		}
+	}
+
+	return &NodeStatus{
+		Name:          node.Name,
+		Unschedulable: node.Spec.Unschedulable,
+		Ready:         nodeReady(node),
+		Pods:          running,
+		Labels:        node.Labels,
+	}, nil
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func rolledOutDeployment(name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": name}},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          replicas,
+			UpdatedReplicas:   replicas,
+			ReadyReplicas:     replicas,
+			AvailableReplicas: replicas,
+		},
+	}
+}
+
+func readyNode(name string, unschedulable bool) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+		}},
+	}
+}
+
+func TestPodsProvider_RolloutRestart(t *testing.T) {
+	paused := rolledOutDeployment("paused", 2)
+	paused.Spec.Paused = true
+	onDelete := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       int32Ptr(1),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+	}
+	agent := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberReady: 3, NumberAvailable: 3},
+	}
+	clientset := fake.NewSimpleClientset(rolledOutDeployment("api", 3), paused, onDelete, agent)
+	provider := &PodsProvider{clientset: clientset}
+
+	tests := []struct {
+		name    string
+		kind    string
+		target  string
+		wantErr string
+	}{
+		{name: "deployment", kind: "deploy", target: "api"},
+		{name: "daemonset", kind: "DaemonSet", target: "agent"},
+		{name: "paused deployment", kind: "Deployment", target: "paused", wantErr: "is paused"},
+		{name: "on delete statefulset", kind: "sts", target: "db", wantErr: "OnDelete"},
+		{name: "unknown kind", kind: "CronJob", target: "api", wantErr: "unsupported workload kind"},
+		{name: "missing workload", kind: "Deployment", target: "ghost", wantErr: "failed to get deployment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.RolloutRestart(context.Background(), tt.kind, "default", tt.target, time.Second)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RolloutRestart() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RolloutRestart() unexpected error: %v", err)
+			}
+			if !got.Complete {
+				t.Errorf("RolloutRestart() status = %+v, want complete", got)
+			}
+		})
+	}
+
+	d, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{})
+	if d.Spec.Template.Annotations[restartedAtKey] == "" {
+		t.Errorf("RolloutRestart() did not stamp %s on the pod template", restartedAtKey)
+	}
+}
+
+func TestPodsProvider_ScaleWorkload(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-hpa", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+		},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(3)},
+		Status:     appsv1.StatefulSetStatus{ReadyReplicas: 3},
+	}
+	clientset := fake.NewSimpleClientset(rolledOutDeployment("api", 3), rolledOutDeployment("web", 2), sts, hpa)
+	provider := &PodsProvider{clientset: clientset}
+
+	tests := []struct {
+		name     string
+		kind     string
+		target   string
+		replicas int32
+		wantErr  string
+	}{
+		{name: "scale statefulset to zero", kind: "StatefulSet", target: "db", replicas: 0},
+		{name: "scale deployment up", kind: "Deployment", target: "api", replicas: 5},
+		{name: "unchanged", kind: "Deployment", target: "api", replicas: 5, wantErr: "already has 5 replicas"},
+		{name: "delta too large", kind: "Deployment", target: "api", replicas: 50, wantErr: "maximum change"},
+		{name: "above max replicas", kind: "Deployment", target: "api", replicas: 1000, wantErr: "between 0 and"},
+		{name: "negative", kind: "Deployment", target: "api", replicas: -1, wantErr: "between 0 and"},
+		{name: "managed by hpa", kind: "Deployment", target: "web", replicas: 3, wantErr: "HorizontalPodAutoscaler web-hpa"},
+		{name: "daemonset", kind: "DaemonSet", target: "agent", replicas: 1, wantErr: "cannot be scaled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.ScaleWorkload(context.Background(), tt.kind, "default", tt.target, tt.replicas, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ScaleWorkload() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScaleWorkload() unexpected error: %v", err)
+			}
+			status, _ := provider.GetRolloutStatus(context.Background(), tt.kind, "default", tt.target)
+			if status.Replicas != tt.replicas {
+				t.Errorf("ScaleWorkload() replicas = %d, want %d", status.Replicas, tt.replicas)
+			}
+		})
+	}
+}
+
+func TestPodsProvider_WaitForRollout(t *testing.T) {
+	progressing := rolledOutDeployment("api", 3)
+	progressing.Status.UpdatedReplicas = 1
+	stalled := rolledOutDeployment("stuck", 3)
+	stalled.Status.Conditions = []appsv1.DeploymentCondition{
+		{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded", Message: "ReplicaSet has timed out progressing."},
+	}
+	provider := &PodsProvider{clientset: fake.NewSimpleClientset(progressing, stalled)}
+
+	tests := []struct {
+		name        string
+		target      string
+		wait        time.Duration
+		deadline    time.Duration // call deadline, 0 for none
+		wantMessage string
+	}{
+		{name: "no wait", target: "api", wait: 0, wantMessage: "1 of 3 new replicas have been updated"},
+		{name: "times out", target: "api", wait: 10 * time.Millisecond, wantMessage: "still in progress after 10ms"},
+		{name: "stalled returns immediately", target: "stuck", wait: time.Minute, wantMessage: "progress deadline"},
+		{name: "wait ends before the call deadline", target: "api", wait: time.Minute, deadline: waitMargin + 50*time.Millisecond, wantMessage: "still in progress after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			got, err := provider.waitForRollout(ctx, "Deployment", "default", tt.target, tt.wait)
+			if err != nil {
+				t.Fatalf("waitForRollout() unexpected error: %v", err)
+			}
+			if got.Complete || !strings.Contains(got.Message, tt.wantMessage) {
+				t.Errorf("waitForRollout() = %+v, want incomplete with %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestPodsProvider_CordonNode(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	done := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "job-0", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	clientset := fake.NewSimpleClientset(readyNode("node-a", false), readyNode("node-b", false), readyNode("node-c", true), pod, done)
+	provider := &PodsProvider{clientset: clientset}
+	ctx := context.Background()
+
+	got, err := provider.CordonNode(ctx, "node-a", true)
+	if err != nil {
+		t.Fatalf("CordonNode() unexpected error: %v", err)
+	}
+	if !got.Unschedulable || got.Pods != 1 {
+		t.Errorf("CordonNode() = %+v, want unschedulable with 1 pod", got)
+	}
+
+	tests := []struct {
+		name    string
+		node    string
+		cordon  bool
+		wantErr string
+	}{
+		{name: "already cordoned", node: "node-a", cordon: true, wantErr: "already cordoned"},
+		{name: "last schedulable node", node: "node-b", cordon: true, wantErr: "last schedulable ready node"},
+		{name: "already schedulable", node: "node-b", cordon: false, wantErr: "already schedulable"},
+		{name: "missing node", node: "ghost", cordon: true, wantErr: "failed to get node"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.CordonNode(ctx, tt.node, tt.cordon)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CordonNode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, err = provider.CordonNode(ctx, "node-c", false)
+	if err != nil || got.Unschedulable {
+		t.Errorf("CordonNode(uncordon) = %+v, %v, want schedulable", got, err)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestPodsProvider(t *testing.T) {
@@ -15,21 +18,34 @@ func TestPodsProvider(t *testing.T) {
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "test-pod",
 				Namespace: "default",
+				Labels:    map[string]string{"app": "api"},
 			},
+			Spec:   corev1.PodSpec{NodeName: "node-a"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
 		}
-		clientset := fake.NewSimpleClientset(fakePod)
+		otherPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "worker",
+				Namespace: "batch",
+				Labels:    map[string]string{"app": "worker"},
+			},
+			Spec:   corev1.PodSpec{NodeName: "node-b"},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		clientset := fake.NewSimpleClientset(fakePod, otherPod)
 		provider := &PodsProvider{clientset: clientset}
 
 		tests := []struct {
 			name      string
 			namespace string
+			opts      PodListOptions
 			wantCount int
 			wantErr   bool
 		}{
 			{
 				name:      "list all pods",
 				namespace: "",
-				wantCount: 1,
+				wantCount: 2,
 				wantErr:   false,
 			},
 			{
@@ -44,22 +60,84 @@ func TestPodsProvider(t *testing.T) {
 				wantCount: 0,
 				wantErr:   false,
 			},
+			{
+				name:      "label selector",
+				opts:      PodListOptions{LabelSelector: "app in (api, web)"},
+				wantCount: 1,
+			},
+			{
+				name:      "field selector",
+				opts:      PodListOptions{FieldSelector: "spec.nodeName=node-b,status.phase=Pending"},
+				wantCount: 1,
+			},
+			{
+				name:      "negated field selector",
+				opts:      PodListOptions{FieldSelector: "status.phase!=Running"},
+				wantCount: 1,
+			},
+			{
+				name:    "invalid label selector",
+				opts:    PodListOptions{LabelSelector: "app in ("},
+				wantErr: true,
+			},
+			{
+				name:    "invalid field selector",
+				opts:    PodListOptions{FieldSelector: "status.phase"},
+				wantErr: true,
+			},
 		}
 
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				got, err := provider.ListPods(context.Background(), tt.namespace)
+				got, err := provider.ListPods(context.Background(), tt.namespace, tt.opts)
 				if (err != nil) != tt.wantErr {
 					t.Errorf("ListPods() error = %v, wantErr %v", err, tt.wantErr)
 					return
 				}
-				if len(got.Items) != tt.wantCount {
+				if !tt.wantErr && len(got.Items) != tt.wantCount {
 					t.Errorf("ListPods() got count = %v, want %v", len(got.Items), tt.wantCount)
 				}
 			})
 		}
 	})
 
+	t.Run("ListPods paging", func(t *testing.T) {
+		// The fake clientset ignores Limit and Continue, so a reactor plays the API server.
+		clientset := fake.NewSimpleClientset()
+		var calls []metav1.ListOptions
+		clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			opts := action.(k8stesting.ListActionImpl).ListOptions
+			calls = append(calls, opts)
+			switch opts.Continue {
+			case "":
+				return true, &corev1.PodList{ListMeta: metav1.ListMeta{Continue: "page-2"}, Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}}}, nil
+			case "page-2":
+				return true, &corev1.PodList{Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "b"}}}}, nil
+			}
+			return true, nil, apierrors.NewResourceExpired("continue token too old")
+		})
+		provider := &PodsProvider{clientset: clientset}
+
+		page, err := provider.ListPods(context.Background(), "default", PodListOptions{Limit: 1})
+		if err != nil || len(page.Items) != 1 || page.Continue != "page-2" || len(calls) != 1 || calls[0].Limit != 1 {
+			t.Errorf("single page = %+v, %v after %+v, want one pod, token page-2 and one API call with limit 1", page, err, calls)
+		}
+		page, err = provider.ListPods(context.Background(), "default", PodListOptions{Limit: 1, Continue: "page-2"})
+		if err != nil || len(page.Items) != 1 || page.Continue != "" {
+			t.Errorf("next page = %+v, %v, want the last pod", page, err)
+		}
+
+		calls = nil
+		all, err := provider.ListPods(context.Background(), "default", PodListOptions{})
+		if err != nil || len(all.Items) != 2 || all.Continue != "" || len(calls) != 2 || calls[0].Limit != podListChunkSize {
+			t.Errorf("full list = %+v, %v after %+v, want both pods in chunks", all, err, calls)
+		}
+
+		if _, err := provider.ListPods(context.Background(), "default", PodListOptions{Limit: 1, Continue: "stale"}); ErrorClass(err) != ErrorClassValidation {
+			t.Errorf("expired token error = %v, want a validation error", err)
+		}
+	})
+
 	t.Run("GetPod", func(t *testing.T) {
 		fakePod := &corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
@@ -104,6 +182,17 @@ func TestPodsProvider(t *testing.T) {
 		}
 	})
 
+	t.Run("GetNode", func(t *testing.T) {
+		provider := &PodsProvider{clientset: fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}})}
+
+		if _, err := provider.GetNode(context.Background(), "node-a"); err != nil {
+			t.Errorf("GetNode() unexpected error: %v", err)
+		}
+		if _, err := provider.GetNode(context.Background(), "ghost"); err == nil {
+			t.Error("GetNode() expected error for non-existent node, got nil")
+		}
+	})
+
 	t.Run("ListEvents", func(t *testing.T) {
 		fakePod := &corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
@@ -162,6 +251,37 @@ func TestPodsProvider(t *testing.T) {
 		}
 	})
 
+	t.Run("ListNamespaceEvents", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(
+			&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "e1", Namespace: "default"}},
+			&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "e2", Namespace: "default"}},
+			&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "e3", Namespace: "kube-system"}},
+		)
+		provider := &PodsProvider{clientset: clientset}
+
+		tests := []struct {
+			name      string
+			namespace string
+			wantCount int
+		}{
+			{name: "all namespaces", namespace: "", wantCount: 3},
+			{name: "single namespace", namespace: "default", wantCount: 2},
+			{name: "namespace without events", namespace: "empty", wantCount: 0},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := provider.ListNamespaceEvents(context.Background(), tt.namespace)
+				if err != nil {
+					t.Fatalf("ListNamespaceEvents() unexpected error: %v", err)
+				}
+				if len(got.Items) != tt.wantCount {
+					t.Errorf("ListNamespaceEvents() got count = %v, want %v", len(got.Items), tt.wantCount)
+				}
+			})
+		}
+	})
+
 	t.Run("DeletePod", func(t *testing.T) {
 		fakePod := &corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
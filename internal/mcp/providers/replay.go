@@ -0,0 +1,478 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"k8s.io/client-go/rest"
+
+	"observability-hub/internal/telemetry"
+)
+
+// Fixture is a recorded set of provider interactions: HTTP calls to Thanos, Loki, Tempo and the
+// Kubernetes API, and commands run through a CommandRunner. Recorder writes fixtures and
+// Replayer serves them back, so tool handlers can run against a captured incident.
+type Fixture struct {
+	RecordedAt time.Time            `json:"recorded_at"`
+	HTTP       []HTTPInteraction    `json:"http,omitempty"`
+	Commands   []CommandInteraction `json:"commands,omitempty"`
+}
+
+// HTTPInteraction is one recorded HTTP request and its response. Request headers are not
+// recorded, so credentials never end up in fixtures.
+type HTTPInteraction struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"` // path and query; the host is not part of the match
+	Body        string `json:"body,omitempty"`
+	BodyType    string `json:"body_type,omitempty"` // request Content-Type, when there is a body
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Response    string `json:"response"`
+	Encoding    string `json:"encoding,omitempty"` // "base64" when the response is not UTF-8
+}
+
+// CommandInteraction is one recorded command and its combined output.
+type CommandInteraction struct {
+	Name   string   `json:"name"`
+	Args   []string `json:"args,omitempty"`
+	Output string   `json:"output"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// LoadFixture reads a fixture written by Recorder.
+func LoadFixture(path string) (*Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// volatileValue matches values that change between a recording and its replay: RFC 3339
+// timestamps and Unix seconds or nanoseconds, optionally prefixed with '@' as journalctl takes them.
+var volatileValue = regexp.MustCompile(`^(@?\d{9,}(\.\d+)?|\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2}))$`)
+
+func maskVolatile(v string) string {
+	if volatileValue.MatchString(v) {
+		return "*"
+	}
+	return v
+}
+
+// httpKey identifies a request for replay. Time-valued query parameters (start, end, since...)
+// are masked because tools derive them from the clock.
+func httpKey(method, rawURL, contentType, body string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL + " " + body
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(body); err == nil {
+			body = maskQuery(form)
+		}
+	}
+	return method + " " + u.Path + "?" + maskQuery(u.Query()) + " " + body
+}
+
+func maskQuery(values url.Values) string {
+	masked := make(url.Values, len(values))
+	for k, vs := range values {
+		for _, v := range vs {
+			masked.Add(k, maskVolatile(v))
+		}
+	}
+	return masked.Encode() // sorted by key
+}
+
+// commandKey identifies a command for replay, masking time-valued arguments.
+func commandKey(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, name)
+	for _, arg := range args {
+		if flag, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(flag, "-") {
+			parts = append(parts, flag+"="+maskVolatile(value))
+			continue
+		}
+		parts = append(parts, maskVolatile(arg))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// isWatch reports whether req is a Kubernetes watch, which streams until cancelled and is
+// neither recorded nor replayed.
+func isWatch(req *http.Request) bool {
+	return req.URL.Query().Get("watch") == "true" || strings.Contains(req.URL.Path, "/watch/")
+}
+
+const (
+	// recorderFlushInterval is how often new interactions are written out, so a gateway that is
+	// killed mid-incident keeps what it saw up to then.
+	recorderFlushInterval = 5 * time.Second
+	// maxRecordedBodyBytes bounds one recorded response. Larger responses are passed through
+	// but left out of the fixture, since a truncated body would not replay.
+	maxRecordedBodyBytes = 16 << 20
+)
+
+// Recorder captures provider interactions into a fixture file. Interactions are kept in
+// memory and written every recorderFlushInterval; Close writes the rest.
+type Recorder struct {
+	path    string
+	mu      sync.Mutex
+	fixture Fixture
+	dirty   bool
+
+	saveMu sync.Mutex // serializes writes of the file
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewRecorder creates a recorder writing to path. Call Close to write the last interactions.
+func NewRecorder(path string) *Recorder {
+	r := &Recorder{
+		path:    path,
+		fixture: Fixture{RecordedAt: time.Now().UTC()},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.flushLoop()
+	return r
+}
+
+// Transport wraps base (http.DefaultTransport when nil) so every response is recorded.
+func (r *Recorder) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordingTransport{base: base, recorder: r}
+}
+
+// Runner wraps base so every command is recorded.
+func (r *Recorder) Runner(base CommandRunner) CommandRunner {
+	return &recordingRunner{base: base, recorder: r}
+}
+
+// KubeConfig wraps config's transport so Kubernetes API calls are recorded.
+func (r *Recorder) KubeConfig(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper { return r.Transport(rt) })
+	return config
+}
+
+func (r *Recorder) add(fn func(f *Fixture)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.fixture)
+	r.dirty = true
+}
+
+func (r *Recorder) flushLoop() {
+	defer close(r.done)
+	ticker := time.NewTicker(recorderFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(); err != nil {
+				telemetry.Warn("failed to write fixture", "path", r.path, "error", err)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Flush writes the interactions recorded so far, if any are new.
+func (r *Recorder) Flush() error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	// Interactions are only ever appended, so the snapshot can be encoded without the lock.
+	snapshot := r.fixture
+	r.dirty = false
+	r.mu.Unlock()
+
+	if err := r.save(snapshot); err != nil {
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close stops the periodic flush and writes the remaining interactions.
+func (r *Recorder) Close() error {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+	return r.Flush()
+}
+
+// save writes the fixture through a temporary file so readers never see a partial one.
+func (r *Recorder) save(f Fixture) error {
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".fixture-*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+type recordingTransport struct {
+	base     http.RoundTripper
+	recorder *Recorder
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isWatch(req) {
+		return t.base.RoundTrip(req)
+	}
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(respBody) > maxRecordedBodyBytes {
+		telemetry.Warn("response too large to record, leaving it out of the fixture",
+			"method", req.Method, "url", req.URL.RequestURI(), "max_bytes", maxRecordedBodyBytes)
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(respBody), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := HTTPInteraction{
+		Method:      req.Method,
+		URL:         req.URL.RequestURI(),
+		Body:        string(reqBody),
+		BodyType:    req.Header.Get("Content-Type"),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Response:    string(respBody),
+	}
+	if !utf8.Valid(respBody) {
+		interaction.Response = base64.StdEncoding.EncodeToString(respBody)
+		interaction.Encoding = "base64"
+	}
+	t.recorder.add(func(f *Fixture) { f.HTTP = append(f.HTTP, interaction) })
+	return resp, nil
+}
+
+type recordingRunner struct {
+	base     CommandRunner
+	recorder *Recorder
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, arg ...string) ([]byte, error) {
+	out, err := r.base.Run(ctx, name, arg...)
+	interaction := CommandInteraction{Name: name, Args: arg, Output: string(out)}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	r.recorder.add(func(f *Fixture) { f.Commands = append(f.Commands, interaction) })
+	return out, err
+}
+
+// Replayer serves a fixture back in place of the real backends. Requests match on method,
+// path, query and body, and commands on name and arguments, with time values masked. Repeated
+// requests get the recorded responses in order, the last one once they run out.
+type Replayer struct {
+	fixture  *Fixture
+	mu       sync.Mutex
+	http     map[string][]HTTPInteraction
+	commands map[string][]CommandInteraction
+	served   map[string]int
+}
+
+// NewReplayer creates a replayer serving fixture.
+func NewReplayer(fixture *Fixture) *Replayer {
+	r := &Replayer{
+		fixture:  fixture,
+		http:     make(map[string][]HTTPInteraction),
+		commands: make(map[string][]CommandInteraction),
+		served:   make(map[string]int),
+	}
+	for _, in := range fixture.HTTP {
+		key := httpKey(in.Method, in.URL, in.BodyType, in.Body)
+		r.http[key] = append(r.http[key], in)
+	}
+	for _, in := range fixture.Commands {
+		key := commandKey(in.Name, in.Args)
+		r.commands[key] = append(r.commands[key], in)
+	}
+	return r
+}
+
+// RecordedAt is when the fixture was recorded. Tests pin their clock to it so time windows
+// line up with the recorded data.
+func (r *Replayer) RecordedAt() time.Time {
+	return r.fixture.RecordedAt
+}
+
+// Transport returns a RoundTripper answering from the fixture.
+func (r *Replayer) Transport() http.RoundTripper {
+	return replayTransport{r}
+}
+
+// Runner returns a CommandRunner answering from the fixture.
+func (r *Replayer) Runner() CommandRunner {
+	return replayRunner{r}
+}
+
+// KubeConfig returns a client config whose API calls are answered from the fixture.
+func (r *Replayer) KubeConfig() *rest.Config {
+	return &rest.Config{Host: "http://replay.invalid", Transport: r.Transport()}
+}
+
+// next returns the index of the interaction to serve for key out of n recorded ones.
+func (r *Replayer) next(key string, n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.served[key]
+	if i < n-1 {
+		r.served[key] = i + 1
+	}
+	return min(i, n-1)
+}
+
+type replayTransport struct{ r *Replayer }
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isWatch(req) {
+		return quietWatch(req), nil
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	key := httpKey(req.Method, req.URL.RequestURI(), req.Header.Get("Content-Type"), string(body))
+	recorded := t.r.http[key]
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.RequestURI())
+	}
+	in := recorded[t.r.next(key, len(recorded))]
+
+	respBody := []byte(in.Response)
+	if in.Encoding == "base64" {
+		var err error
+		if respBody, err = base64.StdEncoding.DecodeString(in.Response); err != nil {
+			return nil, fmt.Errorf("invalid recorded response for %s %s: %w", req.Method, req.URL.RequestURI(), err)
+		}
+	}
+	header := make(http.Header)
+	if in.ContentType != "" {
+		header.Set("Content-Type", in.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// quietWatch answers a watch with a stream that stays open, without events, until the
+// request is cancelled.
+func quietWatch(req *http.Request) *http.Response {
+	pr, pw := io.Pipe()
+	go func() {
+		<-req.Context().Done()
+		pw.CloseWithError(req.Context().Err())
+	}()
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       pr,
+		Request:    req,
+	}
+}
+
+type replayRunner struct{ r *Replayer }
+
+func (rr replayRunner) Run(ctx context.Context, name string, arg ...string) ([]byte, error) {
+	key := commandKey(name, arg)
+	recorded := rr.r.commands[key]
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("no recorded output for command %s %s", name, strings.Join(arg, " "))
+	}
+	in := recorded[rr.r.next(key, len(recorded))]
+	if in.Error != "" {
+		return []byte(in.Output), errors.New(in.Error)
+	}
+	return []byte(in.Output), nil
+}
@@ -0,0 +1,181 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestRecorderReplayer_RoundTrip(t *testing.T) {
+	podList, _ := json.Marshal(corev1.PodList{Items: []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "proxy-0", Namespace: "apps"}},
+	}})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/query_range":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1,"0.5"]]}]}}`))
+		case "/api/v1/namespaces/apps/pods":
+			w.Write(podList)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "incident.json")
+	recorder := NewRecorder(path)
+	runs := 0
+	runner := recorder.Runner(&MockCommandRunner{RunFn: func(ctx context.Context, name string, arg ...string) ([]byte, error) {
+		runs++
+		if runs == 1 {
+			return []byte("first"), nil
+		}
+		return []byte("unit not found"), errors.New("exit status 1")
+	}})
+
+	ctx := context.Background()
+	recordedAt := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	tp := NewTelemetryProviderWithClient(server.URL, server.URL, server.URL, &http.Client{Transport: recorder.Transport(nil)})
+	if _, err := tp.QueryMetricsRange(ctx, "up", recordedAt.Add(-time.Hour), recordedAt, time.Minute); err != nil {
+		t.Fatalf("record query: %v", err)
+	}
+	pods, err := NewPodsProviderForConfig(recorder.KubeConfig(&rest.Config{Host: server.URL}))
+	if err != nil {
+		t.Fatalf("record pods provider: %v", err)
+	}
+	if _, err := pods.ListPods(ctx, "apps", PodListOptions{}); err != nil {
+		t.Fatalf("record list pods: %v", err)
+	}
+	runner.Run(ctx, "journalctl", "-u", "proxy.service", "--since", "@1773234000")
+	runner.Run(ctx, "journalctl", "-u", "proxy.service", "--since", "@1773234000")
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	if len(fixture.HTTP) != 2 || len(fixture.Commands) != 2 {
+		t.Fatalf("recorded %d requests and %d commands, want 2 and 2", len(fixture.HTTP), len(fixture.Commands))
+	}
+	replayer := NewReplayer(fixture)
+
+	// Replay an hour later against other hosts: time values and hosts do not take part in the match.
+	later := recordedAt.Add(time.Hour)
+	tp = NewTelemetryProviderWithClient("http://thanos.invalid", "http://loki.invalid", "http://tempo.invalid", &http.Client{Transport: replayer.Transport()})
+	got, err := tp.QueryMetricsRange(ctx, "up", later.Add(-time.Hour), later, time.Minute)
+	if err != nil {
+		t.Fatalf("replay query: %v", err)
+	}
+	if b, _ := json.Marshal(got); !strings.Contains(string(b), `"0.5"`) {
+		t.Errorf("replayed query = %s, want the recorded matrix", b)
+	}
+	if _, err := tp.QueryMetricsRange(ctx, "down", later.Add(-time.Hour), later, time.Minute); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("unrecorded query error = %v, want a missing fixture error", err)
+	}
+
+	pods, err = NewPodsProviderForConfig(replayer.KubeConfig())
+	if err != nil {
+		t.Fatalf("replay pods provider: %v", err)
+	}
+	list, err := pods.ListPods(ctx, "apps", PodListOptions{})
+	if err != nil {
+		t.Fatalf("replay list pods: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "proxy-0" {
+		t.Errorf("replayed pods = %v, want proxy-0", list.Items)
+	}
+
+	// Repeated commands get the recorded results in order, then the last one again.
+	replayRunner := replayer.Runner()
+	wants := []struct {
+		out string
+		err bool
+	}{{"first", false}, {"unit not found", true}, {"unit not found", true}}
+	for i, want := range wants {
+		out, err := replayRunner.Run(ctx, "journalctl", "-u", "proxy.service", "--since", "@1773237600")
+		if string(out) != want.out || (err != nil) != want.err {
+			t.Errorf("run %d = (%q, %v), want (%q, error %v)", i, out, err, want.out, want.err)
+		}
+	}
+	if _, err := replayRunner.Run(ctx, "systemctl", "status"); err == nil {
+		t.Error("unrecorded command succeeded, want an error")
+	}
+}
+
+func TestRecorder_LargeResponse(t *testing.T) {
+	large := strings.Repeat("x", maxRecordedBodyBytes+1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			w.Write([]byte(large))
+			return
+		}
+		w.Write([]byte("small"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "incident.json")
+	recorder := NewRecorder(path)
+	client := &http.Client{Transport: recorder.Transport(nil)}
+	for _, p := range []string{"/large", "/small"} {
+		resp, err := client.Get(server.URL + p)
+		if err != nil {
+			t.Fatalf("GET %s: %v", p, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || (p == "/large" && len(body) != len(large)) {
+			t.Errorf("GET %s read %d bytes, %v; want the whole response", p, len(body), err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	if len(fixture.HTTP) != 1 || fixture.HTTP[0].URL != "/small" {
+		t.Errorf("recorded %+v, want only the small response", fixture.HTTP)
+	}
+}
+
+func TestReplayer_QuietWatch(t *testing.T) {
+	replayer := NewReplayer(&Fixture{})
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://replay.invalid/api/v1/events?watch=true", nil)
+	resp, err := replayer.Transport().RoundTrip(req)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := resp.Body.Read(make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("watch stream ended before cancellation: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watch stream still open after cancellation")
+	}
+}
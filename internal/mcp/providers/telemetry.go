@@ -9,15 +9,16 @@ import (
 	"strconv"
 	"time"
 
+	"observability-hub/internal/secrets"
 	"observability-hub/internal/telemetry"
 )
 
 // TelemetryProvider manages connections to Thanos, Loki, and Tempo and exposes telemetry tools.
+// It can serve several named targets (tenants or clusters); callers pick one with WithTelemetryTarget.
 type TelemetryProvider struct {
-	thanosURL  string
-	lokiURL    string
-	tempoURL   string
-	httpClient *http.Client
+	targets       map[string]*telemetryTarget
+	defaultTarget string
+	cache         *responseCache
 }
 
 // NewTelemetryProvider creates a new telemetry provider connected to Thanos, Loki, and Tempo.
@@ -32,14 +33,50 @@ func NewTelemetryProviderWithClient(thanosURL, lokiURL, tempoURL string, client
 	if client == nil {
 		client = &http.Client{Timeout: 30 * time.Second}
 	}
+	plain := func(u string) backend { return backend{url: u, client: client} }
 	return &TelemetryProvider{
-		thanosURL:  thanosURL,
-		lokiURL:    lokiURL,
-		tempoURL:   tempoURL,
-		httpClient: client,
+		targets: map[string]*telemetryTarget{
+			DefaultTelemetryTarget: {name: DefaultTelemetryTarget, thanos: plain(thanosURL), loki: plain(lokiURL), tempo: plain(tempoURL)},
+		},
+		defaultTarget: DefaultTelemetryTarget,
+		cache:         newResponseCache(responseCacheMaxBytes),
 	}
 }
 
+// NewTelemetryProviderFromConfig creates a telemetry provider serving every target in cfg.
+// Backend credentials are resolved once from store, which may be nil to use inline values only.
+// Passing a nil client builds one client per backend honoring its CA bundle.
+func NewTelemetryProviderFromConfig(cfg TelemetryConfig, store secrets.SecretStore, client *http.Client) (*TelemetryProvider, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("telemetry config has no targets")
+	}
+	tp := &TelemetryProvider{
+		targets:       make(map[string]*telemetryTarget, len(cfg.Targets)),
+		defaultTarget: cfg.Default,
+		cache:         newResponseCache(responseCacheMaxBytes),
+	}
+	for _, tc := range cfg.Targets {
+		if tc.Name == "" {
+			return nil, fmt.Errorf("telemetry target name cannot be empty")
+		}
+		if _, dup := tp.targets[tc.Name]; dup {
+			return nil, fmt.Errorf("duplicate telemetry target %q", tc.Name)
+		}
+		t, err := newTelemetryTarget(tc, store, client)
+		if err != nil {
+			return nil, err
+		}
+		tp.targets[tc.Name] = t
+	}
+	if tp.defaultTarget == "" {
+		tp.defaultTarget = cfg.Targets[0].Name
+	}
+	if _, ok := tp.targets[tp.defaultTarget]; !ok {
+		return nil, fmt.Errorf("default telemetry target %q is not configured", tp.defaultTarget)
+	}
+	return tp, nil
+}
+
 // QueryMetrics executes a PromQL query against Thanos.
 // Returns raw Prometheus API response (query result).
 //
@@ -48,20 +85,36 @@ func NewTelemetryProviderWithClient(thanosURL, lokiURL, tempoURL string, client
 //   - Time windows are expressed inline in PromQL (e.g. rate(...[24h])), not as a parameter.
 //   - Query length: max 5,000 chars (our safety cap, not a Thanos limit).
 //   - No result count cap — Thanos returns all matching series.
+//   - Responses are cached for 15s per normalized query; use WithLiveQuery to bypass.
 func (tp *TelemetryProvider) QueryMetrics(ctx context.Context, query string) (interface{}, error) {
 	if query == "" {
 		telemetry.Error("query metrics called with empty query")
-		return nil, fmt.Errorf("query cannot be empty")
+		return nil, InvalidInputf("query cannot be empty")
 	}
 
 	// Validate query length to prevent abuse
 	if len(query) > 5000 {
 		telemetry.Warn("query exceeds max length", "query_len", len(query))
-		return nil, fmt.Errorf("query too long (max 5000 chars)")
+		return nil, InvalidInputf("query too long (max 5000 chars)")
+	}
+
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := cachedFetch(ctx, tp.cache, "thanos", t.name+"|query|"+normalizeQuery(query), queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+		return fetchMetrics(ctx, t.thanos, query)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
+// fetchMetrics performs the uncached instant query.
+func fetchMetrics(ctx context.Context, thanos backend, query string) (map[string]interface{}, error) {
 	// Build URL with query parameters
-	endpoint := fmt.Sprintf("%s/api/v1/query", tp.thanosURL)
+	endpoint := fmt.Sprintf("%s/api/v1/query", thanos.url)
 	params := url.Values{}
 	params.Add("query", query)
 
@@ -72,7 +125,7 @@ func (tp *TelemetryProvider) QueryMetrics(ctx context.Context, query string) (in
 	}
 
 	telemetry.Info("executing PromQL query", "query", query[:min(len(query), 100)])
-	resp, err := tp.httpClient.Do(req)
+	resp, err := thanos.client.Do(req)
 	if err != nil {
 		telemetry.Error("failed to query Thanos", "error", err)
 		return nil, fmt.Errorf("failed to query Thanos: %w", err)
@@ -81,7 +134,7 @@ func (tp *TelemetryProvider) QueryMetrics(ctx context.Context, query string) (in
 
 	if resp.StatusCode != http.StatusOK {
 		telemetry.Error("Thanos returned non-OK status", "status", resp.StatusCode)
-		return nil, fmt.Errorf("Thanos returned status %d", resp.StatusCode)
+		return nil, &StatusError{Backend: "Thanos", StatusCode: resp.StatusCode}
 	}
 
 	// Return raw body for now; in production, parse JSON and structure response
@@ -95,16 +148,103 @@ func (tp *TelemetryProvider) QueryMetrics(ctx context.Context, query string) (in
 	return result, nil
 }
 
+// QueryMetricsRange executes a PromQL range query against Thanos.
+// Returns raw Prometheus API response (matrix result).
+//
+// Limits:
+//   - Uses range query endpoint (/api/v1/query_range) between start and end.
+//   - step is raised so that a single series never exceeds 11,000 points (Prometheus limit).
+//   - Query length: max 5,000 chars (our safety cap, not a Thanos limit).
+//   - start and end are aligned down to a multiple of step.
+//   - Responses are cached for 15s per query, aligned range and step; use WithLiveQuery to bypass.
+func (tp *TelemetryProvider) QueryMetricsRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (interface{}, error) {
+	if query == "" {
+		telemetry.Error("query metrics range called with empty query")
+		return nil, InvalidInputf("query cannot be empty")
+	}
+	if len(query) > 5000 {
+		telemetry.Warn("range query exceeds max length", "query_len", len(query))
+		return nil, InvalidInputf("query too long (max 5000 chars)")
+	}
+	if !end.After(start) {
+		return nil, InvalidInputf("end must be after start")
+	}
+	if minStep := end.Sub(start) / 11000; step < minStep {
+		step = minStep
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+	// Align the window to the step so calls moments apart share a cache entry and are
+	// evaluated at the same timestamps.
+	start, end = start.Truncate(step), end.Truncate(step)
+	if !end.After(start) {
+		end = start.Add(step)
+	}
+
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s|query_range|%s|%d|%d|%s", t.name, normalizeQuery(query), start.Unix(), end.Unix(), step)
+	result, err := cachedFetch(ctx, tp.cache, "thanos", key, queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+		return fetchMetricsRange(ctx, t.thanos, query, start, end, step)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fetchMetricsRange performs the uncached range query.
+func fetchMetricsRange(ctx context.Context, thanos backend, query string, start, end time.Time, step time.Duration) (map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/query_range", thanos.url)
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("start", strconv.FormatInt(start.Unix(), 10))
+	params.Add("end", strconv.FormatInt(end.Unix(), 10))
+	params.Add("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		telemetry.Error("failed to create range request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	telemetry.Info("executing PromQL range query", "query", query[:min(len(query), 100)], "step", step.String())
+	resp, err := thanos.client.Do(req)
+	if err != nil {
+		telemetry.Error("failed to query Thanos", "error", err)
+		return nil, fmt.Errorf("failed to query Thanos: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		telemetry.Error("Thanos returned non-OK status", "status", resp.StatusCode)
+		return nil, &StatusError{Backend: "Thanos", StatusCode: resp.StatusCode}
+	}
+
+	var result map[string]interface{}
+	if err := parseJSONResponse(resp, &result); err != nil {
+		telemetry.Error("failed to parse range response", "error", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	telemetry.Info("range query executed successfully")
+	return result, nil
+}
+
 // QueryLogs executes a LogQL query against Loki and returns matching log streams.
 //
 // Limits:
 //   - limit: max log lines returned (default 100, max 1000). Loki hard cap is also 5000.
 //   - hours: lookback window (default 1, max 168 = 7 days). Longer windows are slower.
 //   - Query length: max 5,000 chars (our safety cap, not a Loki limit).
+//   - Responses are cached for 15s per normalized query, limit and hours; use WithLiveQuery to bypass.
 func (tp *TelemetryProvider) QueryLogs(ctx context.Context, query string, limit int, hours int) (interface{}, error) {
 	if query == "" {
 		telemetry.Error("query logs called with empty query")
-		return nil, fmt.Errorf("query cannot be empty")
+		return nil, InvalidInputf("query cannot be empty")
 	}
 
 	if limit <= 0 {
@@ -120,14 +260,59 @@ func (tp *TelemetryProvider) QueryLogs(ctx context.Context, query string, limit
 		hours = 168
 	}
 
-	now := time.Now()
-	start := now.Add(-time.Duration(hours) * time.Hour)
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s|logs|%s|%d|%d", t.name, normalizeQuery(query), limit, hours)
+	result, err := cachedFetch(ctx, tp.cache, "loki", key, queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+		now := time.Now()
+		return fetchLogs(ctx, t.loki, query, limit, now.Add(-time.Duration(hours)*time.Hour), now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryLogsRange executes a LogQL query over the absolute window [start, end] and returns the
+// newest limit lines in it (default 100, max 1000). Use it for historical windows, where an
+// hours lookback from now would return lines after end.
+func (tp *TelemetryProvider) QueryLogsRange(ctx context.Context, query string, limit int, start, end time.Time) (interface{}, error) {
+	if query == "" {
+		return nil, InvalidInputf("query cannot be empty")
+	}
+	if !start.Before(end) {
+		return nil, InvalidInputf("start must be before end")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s|logs_range|%s|%d|%d|%d", t.name, normalizeQuery(query), limit, start.UnixNano(), end.UnixNano())
+	result, err := cachedFetch(ctx, tp.cache, "loki", key, queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+		return fetchLogs(ctx, t.loki, query, limit, start, end)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	endpoint := fmt.Sprintf("%s/loki/api/v1/query_range", tp.lokiURL)
+// fetchLogs performs the uncached log query over [start, end].
+func fetchLogs(ctx context.Context, loki backend, query string, limit int, start, end time.Time) (map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("%s/loki/api/v1/query_range", loki.url)
 	params := url.Values{}
 	params.Add("query", query)
 	params.Add("start", strconv.FormatInt(start.UnixNano(), 10))
-	params.Add("end", strconv.FormatInt(now.UnixNano(), 10))
+	params.Add("end", strconv.FormatInt(end.UnixNano(), 10))
 	params.Add("limit", strconv.Itoa(limit))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
@@ -136,8 +321,8 @@ func (tp *TelemetryProvider) QueryLogs(ctx context.Context, query string, limit
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	telemetry.Info("executing LogQL query", "query", query[:min(len(query), 100)], "limit", limit, "hours", hours)
-	resp, err := tp.httpClient.Do(req)
+	telemetry.Info("executing LogQL query", "query", query[:min(len(query), 100)], "limit", limit, "start", start, "end", end)
+	resp, err := loki.client.Do(req)
 	if err != nil {
 		telemetry.Error("failed to query Loki", "error", err)
 		return nil, fmt.Errorf("failed to query Loki: %w", err)
@@ -146,7 +331,7 @@ func (tp *TelemetryProvider) QueryLogs(ctx context.Context, query string, limit
 
 	if resp.StatusCode != http.StatusOK {
 		telemetry.Error("Loki returned non-OK status", "status", resp.StatusCode)
-		return nil, fmt.Errorf("Loki returned status %d", resp.StatusCode)
+		return nil, &StatusError{Backend: "Loki", StatusCode: resp.StatusCode}
 	}
 
 	var result map[string]interface{}
@@ -166,36 +351,20 @@ func (tp *TelemetryProvider) QueryLogs(ctx context.Context, query string, limit
 //   - If traceID is empty: searches via /api/search using TraceQL (e.g. {resource.service.name="proxy"}).
 //   - hours: lookback window for search (default 1, max 168).
 //   - limit: max traces returned in search mode (default 20, max 100).
+//   - Responses are cached for 15s; use WithLiveQuery to bypass.
 func (tp *TelemetryProvider) QueryTraces(ctx context.Context, traceID string, query string, hours int, limit int) (interface{}, error) {
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if traceID != "" {
-		endpoint := fmt.Sprintf("%s/api/traces/%s", tp.tempoURL, traceID)
-		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		result, err := cachedFetch(ctx, tp.cache, "tempo", t.name+"|trace|"+traceID, queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+			return fetchTrace(ctx, t.tempo, traceID)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-		req.Header.Set("Accept", "application/json")
-
-		telemetry.Info("retrieving trace from Tempo", "trace_id", traceID)
-		resp, err := tp.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to query Tempo: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusNotFound {
-			telemetry.Warn("trace not found in Tempo", "trace_id", traceID)
-			return nil, fmt.Errorf("trace not found: %s", traceID)
+			return nil, err
 		}
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("Tempo returned status %d", resp.StatusCode)
-		}
-
-		var raw map[string]interface{}
-		if err := parseJSONResponse(resp, &raw); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-		telemetry.Info("trace retrieved successfully", "trace_id", traceID)
-		return raw, nil
+		return result, nil
 	}
 
 	// Search mode via TraceQL
@@ -212,47 +381,118 @@ func (tp *TelemetryProvider) QueryTraces(ctx context.Context, traceID string, qu
 		limit = 100
 	}
 
-	now := time.Now()
-	start := now.Add(-time.Duration(hours) * time.Hour)
+	key := fmt.Sprintf("%s|trace_search|%s|%d|%d", t.name, normalizeQuery(query), hours, limit)
+	result, err := cachedFetch(ctx, tp.cache, "tempo", key, queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+		now := time.Now()
+		return searchTraces(ctx, t.tempo, query, now.Add(-time.Duration(hours)*time.Hour), now, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
+// SearchTracesRange searches traces with TraceQL over the absolute window [start, end]
+// (limit default 20, max 100), for historical windows like QueryLogsRange.
+func (tp *TelemetryProvider) SearchTracesRange(ctx context.Context, query string, start, end time.Time, limit int) (interface{}, error) {
+	if !start.Before(end) {
+		return nil, InvalidInputf("start must be before end")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s|trace_search_range|%s|%d|%d|%d", t.name, normalizeQuery(query), start.Unix(), end.Unix(), limit)
+	result, err := cachedFetch(ctx, tp.cache, "tempo", key, queryCacheTTL, func(ctx context.Context) (map[string]interface{}, error) {
+		return searchTraces(ctx, t.tempo, query, start, end, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fetchTrace performs the uncached lookup of a single trace by ID.
+func fetchTrace(ctx context.Context, tempo backend, traceID string) (map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("%s/api/traces/%s", tempo.url, traceID)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	telemetry.Info("retrieving trace from Tempo", "trace_id", traceID)
+	resp, err := tempo.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Tempo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		telemetry.Warn("trace not found in Tempo", "trace_id", traceID)
+		return nil, NotFoundf("trace not found: %s", traceID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Backend: "Tempo", StatusCode: resp.StatusCode}
+	}
+
+	var raw map[string]interface{}
+	if err := parseJSONResponse(resp, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	telemetry.Info("trace retrieved successfully", "trace_id", traceID)
+	return raw, nil
+}
+
+// searchTraces performs the uncached TraceQL search over [start, end].
+func searchTraces(ctx context.Context, tempo backend, query string, start, end time.Time, limit int) (map[string]interface{}, error) {
 	params := url.Values{}
 	params.Add("limit", strconv.Itoa(limit))
 	params.Add("start", strconv.FormatInt(start.Unix(), 10))
-	params.Add("end", strconv.FormatInt(now.Unix(), 10))
+	params.Add("end", strconv.FormatInt(end.Unix(), 10))
 	if query != "" {
 		params.Add("q", query)
 	}
 
-	endpoint := fmt.Sprintf("%s/api/search?%s", tp.tempoURL, params.Encode())
+	endpoint := fmt.Sprintf("%s/api/search?%s", tempo.url, params.Encode())
 	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
 
-	telemetry.Info("searching traces in Tempo", "query", query, "hours", hours, "limit", limit)
-	resp, err := tp.httpClient.Do(req)
+	telemetry.Info("searching traces in Tempo", "query", query, "start", start, "end", end, "limit", limit)
+	resp, err := tempo.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search Tempo: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Tempo returned status %d", resp.StatusCode)
+		return nil, &StatusError{Backend: "Tempo", StatusCode: resp.StatusCode}
 	}
 
 	var result map[string]interface{}
 	if err := parseJSONResponse(resp, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-	telemetry.Info("trace search completed", "query", query, "hours", hours)
+	telemetry.Info("trace search completed", "query", query)
 	return result, nil
 }
 
 // Close closes the provider's HTTP client and resources.
 func (tp *TelemetryProvider) Close() error {
 	telemetry.Info("closing telemetry provider")
-	tp.httpClient.CloseIdleConnections()
+	for _, t := range tp.targets {
+		t.closeIdle()
+	}
 	return nil
 }
 
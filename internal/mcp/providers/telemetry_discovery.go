@@ -0,0 +1,232 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"observability-hub/internal/telemetry"
+)
+
+// ListMetricNames returns all metric names known to Thanos, optionally restricted by a series selector.
+func (tp *TelemetryProvider) ListMetricNames(ctx context.Context, match string) ([]string, error) {
+	return tp.ListMetricLabelValues(ctx, "__name__", match)
+}
+
+// ListMetricLabelNames returns label names known to Thanos, optionally restricted by a series selector.
+func (tp *TelemetryProvider) ListMetricLabelNames(ctx context.Context, match string) ([]string, error) {
+	params := url.Values{}
+	if match != "" {
+		params.Add("match[]", match)
+	}
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := t.thanos.url + "/api/v1/labels"
+	return tp.fetchPromStrings(ctx, "Thanos", t.thanos, endpoint, params, t.name+"|"+endpoint+"?"+params.Encode())
+}
+
+// ListMetricLabelValues returns values of a label known to Thanos, optionally restricted by a series selector.
+func (tp *TelemetryProvider) ListMetricLabelValues(ctx context.Context, label string, match string) ([]string, error) {
+	if label == "" {
+		return nil, InvalidInputf("label cannot be empty")
+	}
+	params := url.Values{}
+	if match != "" {
+		params.Add("match[]", match)
+	}
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/label/%s/values", t.thanos.url, url.PathEscape(label))
+	return tp.fetchPromStrings(ctx, "Thanos", t.thanos, endpoint, params, t.name+"|"+endpoint+"?"+params.Encode())
+}
+
+// ListMetricSeries returns the label sets of series matching a selector via /api/v1/series.
+//
+// Limits:
+//   - match is required; listing every series in Thanos is never useful to an agent.
+//   - Only the last hour is searched to keep the lookup cheap.
+func (tp *TelemetryProvider) ListMetricSeries(ctx context.Context, match string) ([]map[string]string, error) {
+	if match == "" {
+		return nil, InvalidInputf("match selector cannot be empty")
+	}
+	now := time.Now()
+	params := url.Values{}
+	params.Add("match[]", match)
+	params.Add("start", strconv.FormatInt(now.Add(-time.Hour).Unix(), 10))
+	params.Add("end", strconv.FormatInt(now.Unix(), 10))
+
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// start/end change every call, so the cache key only uses the selector.
+	key := t.name + "|" + t.thanos.url + "/api/v1/series?match[]=" + match
+	return cachedFetch(ctx, tp.cache, "thanos", key, discoveryCacheTTL, func(ctx context.Context) ([]map[string]string, error) {
+		var body struct {
+			Status string              `json:"status"`
+			Data   []map[string]string `json:"data"`
+		}
+		if err := getJSON(ctx, "Thanos", t.thanos, t.thanos.url+"/api/v1/series", params, &body); err != nil {
+			return nil, err
+		}
+		return body.Data, nil
+	})
+}
+
+// ListLogLabels returns label names seen by Loki over the last hours.
+func (tp *TelemetryProvider) ListLogLabels(ctx context.Context, hours int) ([]string, error) {
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params, hours := lokiRangeParams(hours)
+	endpoint := t.loki.url + "/loki/api/v1/labels"
+	return tp.fetchPromStrings(ctx, "Loki", t.loki, endpoint, params, fmt.Sprintf("%s|%s?hours=%d", t.name, endpoint, hours))
+}
+
+// ListLogLabelValues returns values of a Loki label seen over the last hours.
+func (tp *TelemetryProvider) ListLogLabelValues(ctx context.Context, label string, hours int) ([]string, error) {
+	if label == "" {
+		return nil, InvalidInputf("label cannot be empty")
+	}
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params, hours := lokiRangeParams(hours)
+	endpoint := fmt.Sprintf("%s/loki/api/v1/label/%s/values", t.loki.url, url.PathEscape(label))
+	return tp.fetchPromStrings(ctx, "Loki", t.loki, endpoint, params, fmt.Sprintf("%s|%s?hours=%d", t.name, endpoint, hours))
+}
+
+// ListTraceTags returns the span and resource attribute names known to Tempo,
+// prefixed with their scope (e.g. "resource.service.name", "span.http.method").
+func (tp *TelemetryProvider) ListTraceTags(ctx context.Context) ([]string, error) {
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := t.tempo.url + "/api/v2/search/tags"
+	return cachedFetch(ctx, tp.cache, "tempo", t.name+"|"+endpoint, discoveryCacheTTL, func(ctx context.Context) ([]string, error) {
+		var body struct {
+			Scopes []struct {
+				Name string   `json:"name"`
+				Tags []string `json:"tags"`
+			} `json:"scopes"`
+		}
+		if err := getJSON(ctx, "Tempo", t.tempo, endpoint, nil, &body); err != nil {
+			return nil, err
+		}
+
+		var tags []string
+		for _, scope := range body.Scopes {
+			for _, tag := range scope.Tags {
+				if scope.Name == "" || scope.Name == "intrinsic" {
+					tags = append(tags, tag)
+					continue
+				}
+				tags = append(tags, scope.Name+"."+tag)
+			}
+		}
+		sort.Strings(tags)
+		return tags, nil
+	})
+}
+
+// ListTraceTagValues returns the values Tempo has seen for a scoped tag (e.g. "resource.service.name").
+func (tp *TelemetryProvider) ListTraceTagValues(ctx context.Context, tag string) ([]string, error) {
+	if tag == "" {
+		return nil, InvalidInputf("tag cannot be empty")
+	}
+	t, err := tp.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("%s/api/v2/search/tag/%s/values", t.tempo.url, url.PathEscape(tag))
+	return cachedFetch(ctx, tp.cache, "tempo", t.name+"|"+endpoint, discoveryCacheTTL, func(ctx context.Context) ([]string, error) {
+		var body struct {
+			TagValues []struct {
+				Value string `json:"value"`
+			} `json:"tagValues"`
+		}
+		if err := getJSON(ctx, "Tempo", t.tempo, endpoint, nil, &body); err != nil {
+			return nil, err
+		}
+
+		values := make([]string, 0, len(body.TagValues))
+		for _, v := range body.TagValues {
+			values = append(values, v.Value)
+		}
+		sort.Strings(values)
+		return values, nil
+	})
+}
+
+// fetchPromStrings fetches a Prometheus-style {"status":..., "data":[...]} string list.
+// Results are cached under key, which must name the target and not include moving time ranges.
+func (tp *TelemetryProvider) fetchPromStrings(ctx context.Context, name string, b backend, endpoint string, params url.Values, key string) ([]string, error) {
+	return cachedFetch(ctx, tp.cache, strings.ToLower(name), key, discoveryCacheTTL, func(ctx context.Context) ([]string, error) {
+		var body struct {
+			Status string   `json:"status"`
+			Data   []string `json:"data"`
+		}
+		if err := getJSON(ctx, name, b, endpoint, params, &body); err != nil {
+			return nil, err
+		}
+		sort.Strings(body.Data)
+		return body.Data, nil
+	})
+}
+
+// getJSON performs a GET against a backend and decodes the JSON body into v.
+func getJSON(ctx context.Context, name string, b backend, endpoint string, params url.Values, v interface{}) error {
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	telemetry.Info("executing discovery request", "backend", name, "endpoint", endpoint[:min(len(endpoint), 200)])
+	resp, err := b.client.Do(req)
+	if err != nil {
+		telemetry.Error("discovery request failed", "backend", name, "error", err)
+		return fmt.Errorf("failed to query %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		telemetry.Error("discovery request returned non-OK status", "backend", name, "status", resp.StatusCode)
+		return &StatusError{Backend: name, StatusCode: resp.StatusCode}
+	}
+	if err := parseJSONResponse(resp, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// lokiRangeParams builds start/end parameters for Loki metadata endpoints (default 6h, max 168h).
+// It also returns the clamped hours so callers can use them in cache keys.
+func lokiRangeParams(hours int) (url.Values, int) {
+	if hours <= 0 {
+		hours = 6
+	}
+	if hours > 168 {
+		hours = 168
+	}
+	now := time.Now()
+	params := url.Values{}
+	params.Add("start", strconv.FormatInt(now.Add(-time.Duration(hours)*time.Hour).UnixNano(), 10))
+	params.Add("end", strconv.FormatInt(now.UnixNano(), 10))
+	return params, hours
+}
@@ -0,0 +1,141 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTelemetryProvider_Discovery(t *testing.T) {
+	var calls int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/label/__name__/values":
+			w.Write([]byte(`{"status":"success","data":["up","http_requests_total"]}`))
+		case "/api/v1/labels":
+			w.Write([]byte(`{"status":"success","data":["job","__name__"]}`))
+		case "/api/v1/label/service/values":
+			if r.URL.Query().Get("match[]") != "http_requests_total" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"status":"success","data":["proxy","analytics"]}`))
+		case "/api/v1/series":
+			w.Write([]byte(`{"status":"success","data":[{"__name__":"up","job":"proxy"}]}`))
+		case "/loki/api/v1/labels":
+			w.Write([]byte(`{"status":"success","data":["service","level"]}`))
+		case "/loki/api/v1/label/service/values":
+			w.Write([]byte(`{"status":"success","data":["proxy"]}`))
+		case "/api/v2/search/tags":
+			w.Write([]byte(`{"scopes":[{"name":"resource","tags":["service.name"]},{"name":"span","tags":["http.method"]},{"name":"intrinsic","tags":["duration"]}]}`))
+		case "/api/v2/search/tag/resource.service.name/values":
+			w.Write([]byte(`{"tagValues":[{"type":"string","value":"proxy"},{"type":"string","value":"analytics"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	tests := []struct {
+		name string
+		call func(tp *TelemetryProvider) (interface{}, error)
+		want interface{}
+	}{
+		{
+			name: "metric names are sorted",
+			call: func(tp *TelemetryProvider) (interface{}, error) { return tp.ListMetricNames(context.Background(), "") },
+			want: []string{"http_requests_total", "up"},
+		},
+		{
+			name: "metric label names",
+			call: func(tp *TelemetryProvider) (interface{}, error) {
+				return tp.ListMetricLabelNames(context.Background(), "")
+			},
+			want: []string{"__name__", "job"},
+		},
+		{
+			name: "metric label values scoped by match",
+			call: func(tp *TelemetryProvider) (interface{}, error) {
+				return tp.ListMetricLabelValues(context.Background(), "service", "http_requests_total")
+			},
+			want: []string{"analytics", "proxy"},
+		},
+		{
+			name: "metric series",
+			call: func(tp *TelemetryProvider) (interface{}, error) {
+				return tp.ListMetricSeries(context.Background(), "up")
+			},
+			want: []map[string]string{{"__name__": "up", "job": "proxy"}},
+		},
+		{
+			name: "log labels",
+			call: func(tp *TelemetryProvider) (interface{}, error) { return tp.ListLogLabels(context.Background(), 0) },
+			want: []string{"level", "service"},
+		},
+		{
+			name: "log label values",
+			call: func(tp *TelemetryProvider) (interface{}, error) {
+				return tp.ListLogLabelValues(context.Background(), "service", 24)
+			},
+			want: []string{"proxy"},
+		},
+		{
+			name: "trace tags are scoped",
+			call: func(tp *TelemetryProvider) (interface{}, error) { return tp.ListTraceTags(context.Background()) },
+			want: []string{"duration", "resource.service.name", "span.http.method"},
+		},
+		{
+			name: "trace tag values",
+			call: func(tp *TelemetryProvider) (interface{}, error) {
+				return tp.ListTraceTagValues(context.Background(), "resource.service.name")
+			},
+			want: []string{"analytics", "proxy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
+			atomic.StoreInt32(&calls, 0)
+
+			got, err := tt.call(tp)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			// A second identical call must be served from the cache.
+			if _, err := tt.call(tp); err != nil {
+				t.Fatalf("unexpected error on cached call: %v", err)
+			}
+			if n := atomic.LoadInt32(&calls); n != 1 {
+				t.Errorf("got %d backend calls, want 1 (second call should hit the cache)", n)
+			}
+		})
+	}
+}
+
+func TestTelemetryProvider_DiscoveryErrors(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	tp := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
+
+	if _, err := tp.ListMetricNames(context.Background(), ""); err == nil {
+		t.Error("expected error for non-OK status")
+	}
+	if _, err := tp.ListMetricSeries(context.Background(), ""); err == nil {
+		t.Error("expected error for empty series selector")
+	}
+	if _, err := tp.ListLogLabelValues(context.Background(), "", 1); err == nil {
+		t.Error("expected error for empty label")
+	}
+	if _, err := tp.ListTraceTagValues(context.Background(), ""); err == nil {
+		t.Error("expected error for empty tag")
+	}
+}
@@ -0,0 +1,273 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"observability-hub/internal/secrets"
+	"observability-hub/internal/telemetry"
+)
+
+// DefaultTelemetryTarget is the target name used when a tool does not choose one
+// and for providers built from plain URLs.
+const DefaultTelemetryTarget = "default"
+
+// BackendConfig configures how TelemetryProvider reaches one of Thanos, Loki or Tempo.
+//
+// Credentials are read from the secret store at SecretPath: "token" selects bearer auth,
+// otherwise "username"/"password" select basic auth. The inline fields are fallbacks used
+// when the store is unavailable, matching SecretStore.GetSecret semantics.
+type BackendConfig struct {
+	URL         string            `yaml:"url"`
+	OrgID       string            `yaml:"org_id"`      // sent as X-Scope-OrgID for multi-tenant backends
+	SecretPath  string            `yaml:"secret_path"` // e.g. "mcp/telemetry/loki"
+	BearerToken string            `yaml:"bearer_token"`
+	Username    string            `yaml:"username"`
+	Password    string            `yaml:"password"`
+	CAFile      string            `yaml:"ca_file"` // PEM bundle added to the system roots
+	Headers     map[string]string `yaml:"headers"`
+}
+
+// DefaultDeployLogQuery selects GitOps sync events in Loki: the webhook served by proxy logs
+// one line per triggered, successful or failed sync.
+const DefaultDeployLogQuery = `{service="proxy"} |~ "webhook_sync_(triggered|success|failed)"`
+
+// TelemetryTarget is a named tenant or cluster with its own set of backends.
+type TelemetryTarget struct {
+	Name   string        `yaml:"name"`
+	Thanos BackendConfig `yaml:"thanos"`
+	Loki   BackendConfig `yaml:"loki"`
+	Tempo  BackendConfig `yaml:"tempo"`
+	// DeployLogQuery is the LogQL selecting deploy events in this target's Loki
+	// (default DefaultDeployLogQuery).
+	DeployLogQuery string `yaml:"deploy_log_query"`
+}
+
+// TelemetryConfig lists the targets a TelemetryProvider can query.
+// Default names the target used when a tool input leaves it empty (default: the first target).
+type TelemetryConfig struct {
+	Default string            `yaml:"default"`
+	Targets []TelemetryTarget `yaml:"targets"`
+}
+
+// LoadTelemetryConfig reads a TelemetryConfig from a YAML file.
+func LoadTelemetryConfig(path string) (TelemetryConfig, error) {
+	var cfg TelemetryConfig
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read telemetry config: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse telemetry config: %w", err)
+	}
+	return cfg, nil
+}
+
+// backend is a resolved BackendConfig: a base URL and a client that adds auth and headers.
+type backend struct {
+	url    string
+	client *http.Client
+}
+
+type telemetryTarget struct {
+	name        string
+	thanos      backend
+	loki        backend
+	tempo       backend
+	deployQuery string
+}
+
+type targetKey struct{}
+
+// WithTelemetryTarget selects the named target for TelemetryProvider calls made with ctx.
+// An empty name keeps the provider's default target.
+func WithTelemetryTarget(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, targetKey{}, name)
+}
+
+// target resolves the target selected in ctx.
+func (tp *TelemetryProvider) target(ctx context.Context) (*telemetryTarget, error) {
+	name, _ := ctx.Value(targetKey{}).(string)
+	if name == "" {
+		name = tp.defaultTarget
+	}
+	t, ok := tp.targets[name]
+	if !ok {
+		return nil, InvalidInputf("unknown telemetry target %q (available: %s)", name, strings.Join(tp.TargetNames(), ", "))
+	}
+	return t, nil
+}
+
+// DeployLogQuery returns the LogQL selecting deploy events for the target selected in ctx.
+// An unknown target falls back to DefaultDeployLogQuery; the query itself then reports the error.
+func (tp *TelemetryProvider) DeployLogQuery(ctx context.Context) string {
+	if t, err := tp.target(ctx); err == nil && t.deployQuery != "" {
+		return t.deployQuery
+	}
+	return DefaultDeployLogQuery
+}
+
+// TargetNames returns the configured target names in sorted order.
+func (tp *TelemetryProvider) TargetNames() []string {
+	names := make([]string, 0, len(tp.targets))
+	for name := range tp.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newBackend resolves credentials and TLS settings for one backend.
+// When client is non-nil its transport is reused so tests and fixture recording can inject
+// one; CAFile is then applied to it (see withCAFile).
+func newBackend(cfg BackendConfig, store secrets.SecretStore, client *http.Client) (backend, error) {
+	headers := make(http.Header)
+	for k, v := range cfg.Headers {
+		headers.Set(k, v)
+	}
+	if cfg.OrgID != "" {
+		headers.Set("X-Scope-OrgID", cfg.OrgID)
+	}
+
+	token, username, password := cfg.BearerToken, cfg.Username, cfg.Password
+	if store != nil && cfg.SecretPath != "" {
+		token = store.GetSecret(cfg.SecretPath, "token", token)
+		username = store.GetSecret(cfg.SecretPath, "username", username)
+		password = store.GetSecret(cfg.SecretPath, "password", password)
+	}
+	switch {
+	case token != "":
+		headers.Set("Authorization", "Bearer "+token)
+	case username != "":
+		headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	}
+
+	var base http.RoundTripper = http.DefaultTransport.(*http.Transport).Clone()
+	timeout := 30 * time.Second
+	if client != nil {
+		base = client.Transport
+		timeout = client.Timeout
+	}
+	if cfg.CAFile != "" {
+		var err error
+		if base, err = withCAFile(base, cfg.CAFile); err != nil {
+			return backend{}, err
+		}
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(headers) > 0 {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			return backend{}, fmt.Errorf("invalid url %q: %w", cfg.URL, err)
+		}
+		base = &headerTransport{base: base, host: u.Host, headers: headers}
+	}
+	return backend{url: strings.TrimRight(cfg.URL, "/"), client: &http.Client{Transport: base, Timeout: timeout}}, nil
+}
+
+// withCAFile returns rt trusting the CA bundle at path. Plain transports are cloned and a
+// recording transport has the CA applied to the transport it wraps. A replaying transport
+// never dials, so it is returned as-is. Any other injected transport is an error rather
+// than silently skipping certificate verification setup.
+func withCAFile(rt http.RoundTripper, path string) (http.RoundTripper, error) {
+	switch t := rt.(type) {
+	case replayTransport:
+		return t, nil
+	case *recordingTransport:
+		base, err := withCAFile(t.base, path)
+		if err != nil {
+			return nil, err
+		}
+		return &recordingTransport{base: base, recorder: t.recorder}, nil
+	case nil:
+		return withCAFile(http.DefaultTransport, path)
+	case *http.Transport:
+		pool, err := loadCAPool(path)
+		if err != nil {
+			return nil, err
+		}
+		transport := t.Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+		return transport, nil
+	}
+	return nil, fmt.Errorf("ca_file %s cannot be applied to injected transport %T", path, rt)
+}
+
+func loadCAPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// headerTransport sets fixed headers (tenant, auth, extras) on requests to the backend's host.
+// Requests to any other host, e.g. after a redirect, go out without them so credentials do
+// not leak.
+type headerTransport struct {
+	base    http.RoundTripper
+	host    string
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.EqualFold(req.URL.Host, t.host) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+	return t.base.RoundTrip(req)
+}
+
+// closeIdle releases idle connections of every backend client.
+func (t *telemetryTarget) closeIdle() {
+	for _, b := range []backend{t.thanos, t.loki, t.tempo} {
+		if b.client != nil {
+			b.client.CloseIdleConnections()
+		}
+	}
+}
+
+func newTelemetryTarget(cfg TelemetryTarget, store secrets.SecretStore, client *http.Client) (*telemetryTarget, error) {
+	t := &telemetryTarget{name: cfg.Name, deployQuery: cfg.DeployLogQuery}
+	var err error
+	if t.thanos, err = newBackend(cfg.Thanos, store, client); err != nil {
+		return nil, fmt.Errorf("target %s thanos: %w", cfg.Name, err)
+	}
+	if t.loki, err = newBackend(cfg.Loki, store, client); err != nil {
+		return nil, fmt.Errorf("target %s loki: %w", cfg.Name, err)
+	}
+	if t.tempo, err = newBackend(cfg.Tempo, store, client); err != nil {
+		return nil, fmt.Errorf("target %s tempo: %w", cfg.Name, err)
+	}
+	telemetry.Info("configured telemetry target", "target", cfg.Name,
+		"thanos_url", cfg.Thanos.URL, "loki_url", cfg.Loki.URL, "tempo_url", cfg.Tempo.URL)
+	return t, nil
+}
@@ -0,0 +1,279 @@
+package providers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockSecretStore struct {
+	data map[string]string // "path/key" -> value
+}
+
+func (m *mockSecretStore) GetSecret(path, key, fallback string) string {
+	if v, ok := m.data[path+"/"+key]; ok {
+		return v
+	}
+	return fallback
+}
+
+func (m *mockSecretStore) Close() error { return nil }
+
+func TestNewTelemetryProviderFromConfig_Headers(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = map[string]http.Header{}
+	)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.Host] = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"result":[]}}`))
+	})
+
+	cfg := TelemetryConfig{
+		Targets: []TelemetryTarget{
+			{
+				Name:   "prod",
+				Thanos: BackendConfig{URL: "http://thanos-prod", SecretPath: "mcp/thanos", Username: "fallback-user"},
+				Loki:   BackendConfig{URL: "http://loki-prod/", OrgID: "team-a", SecretPath: "mcp/loki", Headers: map[string]string{"X-Extra": "1"}},
+				Tempo:  BackendConfig{URL: "http://tempo-prod", OrgID: "team-a"},
+			},
+			{
+				Name:   "staging",
+				Thanos: BackendConfig{URL: "http://thanos-staging"},
+				Loki:   BackendConfig{URL: "http://loki-staging", OrgID: "team-b"},
+				Tempo:  BackendConfig{URL: "http://tempo-staging"},
+			},
+		},
+	}
+	store := &mockSecretStore{data: map[string]string{
+		"mcp/thanos/password": "s3cret",
+		"mcp/loki/token":      "loki-token",
+	}}
+
+	tp, err := NewTelemetryProviderFromConfig(cfg, store, newInMemoryHTTPClient(h))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := tp.QueryMetrics(ctx, "up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tp.QueryLogs(ctx, `{service="proxy"}`, 10, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tp.QueryLogs(WithTelemetryTarget(ctx, "staging"), `{service="proxy"}`, 10, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		host   string
+		header string
+		want   string
+	}{
+		{host: "thanos-prod", header: "Authorization", want: "Basic ZmFsbGJhY2stdXNlcjpzM2NyZXQ="},
+		{host: "loki-prod", header: "Authorization", want: "Bearer loki-token"},
+		{host: "loki-prod", header: "X-Scope-OrgID", want: "team-a"},
+		{host: "loki-prod", header: "X-Extra", want: "1"},
+		{host: "loki-staging", header: "X-Scope-OrgID", want: "team-b"},
+		{host: "loki-staging", header: "Authorization", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host+" "+tt.header, func(t *testing.T) {
+			hdr, ok := seen[tt.host]
+			if !ok {
+				t.Fatalf("no request reached %s", tt.host)
+			}
+			if got := hdr.Get(tt.header); got != tt.want {
+				t.Errorf("got %s=%q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBackend_RedirectDropsCredentials(t *testing.T) {
+	var elsewhere http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		elsewhere = r.Header.Clone()
+	}))
+	defer other.Close()
+	var direct http.Header
+	loki := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		direct = r.Header.Clone()
+		http.Redirect(w, r, other.URL+"/moved", http.StatusFound)
+	}))
+	defer loki.Close()
+
+	b, err := newBackend(BackendConfig{URL: loki.URL, BearerToken: "secret", OrgID: "tenant"}, nil, nil)
+	if err != nil {
+		t.Fatalf("newBackend: %v", err)
+	}
+	resp, err := b.client.Get(b.url + "/loki/api/v1/labels")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+
+	if direct.Get("Authorization") != "Bearer secret" || direct.Get("X-Scope-OrgID") != "tenant" {
+		t.Errorf("backend headers = %v, want auth and tenant", direct)
+	}
+	if elsewhere == nil {
+		t.Fatal("redirect was not followed")
+	}
+	if elsewhere.Get("Authorization") != "" || elsewhere.Get("X-Scope-OrgID") != "" {
+		t.Errorf("redirect target headers = %v, want no credentials", elsewhere)
+	}
+}
+
+func TestNewTelemetryProviderFromConfig_Validation(t *testing.T) {
+	target := func(name string) TelemetryTarget {
+		return TelemetryTarget{Name: name, Thanos: BackendConfig{URL: "http://t"}, Loki: BackendConfig{URL: "http://l"}, Tempo: BackendConfig{URL: "http://te"}}
+	}
+	tests := []struct {
+		name    string
+		cfg     TelemetryConfig
+		wantErr string
+	}{
+		{name: "no targets", cfg: TelemetryConfig{}, wantErr: "no targets"},
+		{name: "empty name", cfg: TelemetryConfig{Targets: []TelemetryTarget{target("")}}, wantErr: "name cannot be empty"},
+		{name: "duplicate", cfg: TelemetryConfig{Targets: []TelemetryTarget{target("a"), target("a")}}, wantErr: "duplicate"},
+		{name: "unknown default", cfg: TelemetryConfig{Default: "b", Targets: []TelemetryTarget{target("a")}}, wantErr: "not configured"},
+		{
+			name:    "missing CA bundle",
+			cfg:     TelemetryConfig{Targets: []TelemetryTarget{{Name: "a", Loki: BackendConfig{URL: "https://l", CAFile: "/nonexistent/ca.pem"}}}},
+			wantErr: "CA bundle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTelemetryProviderFromConfig(tt.cfg, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// writeTestCA writes a self-signed CA certificate in PEM form and returns its path.
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWithCAFile(t *testing.T) {
+	caFile := writeTestCA(t)
+	hasCA := func(rt http.RoundTripper) bool {
+		transport, ok := rt.(*http.Transport)
+		return ok && transport.TLSClientConfig != nil && transport.TLSClientConfig.RootCAs != nil
+	}
+
+	t.Run("recording transport gets the CA on the transport it wraps", func(t *testing.T) {
+		recorder := NewRecorder(filepath.Join(t.TempDir(), "fixture.json"))
+		got, err := withCAFile(recorder.Transport(nil), caFile)
+		if err != nil {
+			t.Fatalf("withCAFile: %v", err)
+		}
+		rec, ok := got.(*recordingTransport)
+		if !ok || rec.recorder != recorder || !hasCA(rec.base) {
+			t.Errorf("got %#v, want a recording transport wrapping a transport with the CA pool", got)
+		}
+		if hasCA(http.DefaultTransport) {
+			t.Error("the shared default transport must not be modified")
+		}
+	})
+
+	t.Run("replay transport is kept without reading the bundle", func(t *testing.T) {
+		rt := NewReplayer(&Fixture{}).Transport()
+		if got, err := withCAFile(rt, "/nonexistent/ca.pem"); err != nil || got != rt {
+			t.Errorf("got %v, %v, want the replay transport unchanged", got, err)
+		}
+	})
+
+	t.Run("unknown injected transport is rejected", func(t *testing.T) {
+		rt := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+		if _, err := withCAFile(rt, caFile); err == nil || !strings.Contains(err.Error(), "cannot be applied") {
+			t.Errorf("got error %v, want the CA bundle to be rejected", err)
+		}
+	})
+}
+
+func TestTelemetryProvider_UnknownTarget(t *testing.T) {
+	tp := NewTelemetryProvider("http://thanos", "http://loki", "http://tempo")
+	_, err := tp.QueryMetrics(WithTelemetryTarget(context.Background(), "nope"), "up")
+	if err == nil || !strings.Contains(err.Error(), "available: default") {
+		t.Errorf("got error %v, want unknown target listing available targets", err)
+	}
+}
+
+func TestLoadTelemetryConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.yaml")
+	content := `
+default: prod
+targets:
+  - name: prod
+    thanos:
+      url: http://thanos:9090
+      secret_path: mcp/thanos
+    loki:
+      url: http://loki:3100
+      org_id: team-a
+      headers:
+        X-Extra: "1"
+    tempo:
+      url: http://tempo:3200
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadTelemetryConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Default != "prod" || len(cfg.Targets) != 1 {
+		t.Fatalf("got %+v, want one prod target", cfg)
+	}
+	loki := cfg.Targets[0].Loki
+	if loki.OrgID != "team-a" || loki.Headers["X-Extra"] != "1" || cfg.Targets[0].Thanos.SecretPath != "mcp/thanos" {
+		t.Errorf("got %+v, want org_id, headers and secret_path parsed", cfg.Targets[0])
+	}
+
+	if _, err := LoadTelemetryConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -62,16 +63,20 @@ func TestTelemetryProvider_NewAndInitialization(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			provider := NewTelemetryProvider(tt.thanosURL, tt.lokiURL, tt.tempoURL)
-			if provider.thanosURL != tt.thanosURL {
-				t.Errorf("expected thanosURL %q, got %q", tt.thanosURL, provider.thanosURL)
+			target, ok := provider.targets[DefaultTelemetryTarget]
+			if !ok {
+				t.Fatalf("expected %q target to be configured", DefaultTelemetryTarget)
 			}
-			if provider.lokiURL != tt.lokiURL {
-				t.Errorf("expected lokiURL %q, got %q", tt.lokiURL, provider.lokiURL)
+			if target.thanos.url != tt.thanosURL {
+				t.Errorf("expected thanosURL %q, got %q", tt.thanosURL, target.thanos.url)
 			}
-			if provider.tempoURL != tt.tempoURL {
-				t.Errorf("expected tempoURL %q, got %q", tt.tempoURL, provider.tempoURL)
+			if target.loki.url != tt.lokiURL {
+				t.Errorf("expected lokiURL %q, got %q", tt.lokiURL, target.loki.url)
 			}
-			if provider.httpClient == nil {
+			if target.tempo.url != tt.tempoURL {
+				t.Errorf("expected tempoURL %q, got %q", tt.tempoURL, target.tempo.url)
+			}
+			if target.thanos.client == nil {
 				t.Error("expected httpClient to be initialized")
 			}
 		})
@@ -134,8 +139,7 @@ func TestTelemetryProvider_QueryMetrics(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			h := http.HandlerFunc(tt.setupServer)
 
-			provider := NewTelemetryProvider("http://thanos", "http://loki", "http://tempo")
-			provider.httpClient = newInMemoryHTTPClient(h)
+			provider := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
@@ -151,6 +155,97 @@ func TestTelemetryProvider_QueryMetrics(t *testing.T) {
 	}
 }
 
+func TestTelemetryProvider_QueryMetricsRange(t *testing.T) {
+	end := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		query       string
+		start       time.Time
+		step        time.Duration
+		setupServer func(w http.ResponseWriter, r *http.Request)
+		wantErr     bool
+		errMsg      string
+	}{
+		{
+			name:  "successful range query",
+			query: "up",
+			start: end.Add(-time.Hour),
+			step:  time.Minute,
+			setupServer: func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if r.URL.Path != "/api/v1/query_range" || q.Get("step") != "60" || q.Get("end") != "1773237600" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+			},
+		},
+		{
+			name:  "step raised to stay under point limit",
+			query: "up",
+			start: end.Add(-168 * time.Hour),
+			step:  time.Second,
+			setupServer: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("step") == "1" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+			},
+		},
+		{
+			name:    "empty query",
+			query:   "",
+			start:   end.Add(-time.Hour),
+			wantErr: true,
+			errMsg:  "query cannot be empty",
+		},
+		{
+			name:    "end before start",
+			query:   "up",
+			start:   end.Add(time.Hour),
+			wantErr: true,
+			errMsg:  "end must be after start",
+		},
+		{
+			name:  "server returns 500",
+			query: "up",
+			start: end.Add(-time.Hour),
+			step:  time.Minute,
+			setupServer: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+			errMsg:  "status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var client *http.Client
+			if tt.setupServer != nil {
+				client = newInMemoryHTTPClient(http.HandlerFunc(tt.setupServer))
+			}
+			provider := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", client)
+
+			result, err := provider.QueryMetricsRange(context.Background(), tt.query, tt.start, end, tt.step)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("got error %q, want error containing %q", err.Error(), tt.errMsg)
+			}
+			if !tt.wantErr && result == nil {
+				t.Error("expected non-nil result for successful query")
+			}
+		})
+	}
+}
+
 func TestTelemetryProvider_RequestTimeout(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -177,9 +272,9 @@ func TestTelemetryProvider_RequestTimeout(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 			})
 
-			provider := NewTelemetryProvider("http://thanos", "http://loki", "http://tempo")
-			provider.httpClient = newInMemoryHTTPClient(h)
-			provider.httpClient.Timeout = tt.timeout
+			client := newInMemoryHTTPClient(h)
+			client.Timeout = tt.timeout
+			provider := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", client)
 			ctx := context.Background()
 
 			_, err := provider.QueryMetrics(ctx, "up")
@@ -294,8 +389,7 @@ func TestTelemetryProvider_QueryLogs(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			h := http.HandlerFunc(tt.setupServer)
 
-			provider := NewTelemetryProvider("http://thanos", "http://loki", "http://tempo")
-			provider.httpClient = newInMemoryHTTPClient(h)
+			provider := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
@@ -311,6 +405,34 @@ func TestTelemetryProvider_QueryLogs(t *testing.T) {
 	}
 }
 
+func TestTelemetryProvider_RangeQueries(t *testing.T) {
+	start := time.Date(2026, 3, 11, 11, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	var got []string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		got = append(got, r.URL.Path+" "+q.Get("start")+" "+q.Get("end"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]},"traces":[]}`))
+	})
+	provider := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
+	ctx := context.Background()
+
+	if _, err := provider.QueryLogsRange(ctx, `{service="proxy"}`, 50, start, end); err != nil {
+		t.Fatalf("QueryLogsRange: %v", err)
+	}
+	if _, err := provider.SearchTracesRange(ctx, `{resource.service.name="proxy"}`, start, end, 10); err != nil {
+		t.Fatalf("SearchTracesRange: %v", err)
+	}
+	want := []string{"/loki/api/v1/query_range 1773226800000000000 1773230400000000000", "/api/search 1773226800 1773230400"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+	if _, err := provider.QueryLogsRange(ctx, `{service="proxy"}`, 50, end, start); err == nil {
+		t.Error("QueryLogsRange with end before start succeeded")
+	}
+}
+
 func TestTelemetryProvider_QueryTraces(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -383,8 +505,7 @@ func TestTelemetryProvider_QueryTraces(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			h := http.HandlerFunc(tt.setupServer)
 
-			provider := NewTelemetryProvider("http://thanos", "http://loki", "http://tempo")
-			provider.httpClient = newInMemoryHTTPClient(h)
+			provider := NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", newInMemoryHTTPClient(h))
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
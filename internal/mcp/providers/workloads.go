@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadSet is a snapshot of the objects in a namespace that the workload tools
+// summarize. Each List* method fills only the fields its tool needs, always including
+// Pods so objects can be linked to the pods backing them.
+type WorkloadSet struct {
+	Deployments    []appsv1.Deployment            `json:"deployments,omitempty"`
+	StatefulSets   []appsv1.StatefulSet           `json:"statefulsets,omitempty"`
+	DaemonSets     []appsv1.DaemonSet             `json:"daemonsets,omitempty"`
+	Jobs           []batchv1.Job                  `json:"jobs,omitempty"`
+	CronJobs       []batchv1.CronJob              `json:"cronjobs,omitempty"`
+	Services       []corev1.Service               `json:"services,omitempty"`
+	EndpointSlices []discoveryv1.EndpointSlice    `json:"endpointslices,omitempty"`
+	PVCs           []corev1.PersistentVolumeClaim `json:"pvcs,omitempty"`
+	Pods           []corev1.Pod                   `json:"pods,omitempty"`
+}
+
+// ListWorkloads returns the Deployments, StatefulSets, DaemonSets, Jobs and CronJobs in
+// namespace along with its pods. An empty namespace covers the whole cluster.
+func (p *PodsProvider) ListWorkloads(ctx context.Context, namespace string) (*WorkloadSet, error) {
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+	apps, batch := p.clientset.AppsV1(), p.clientset.BatchV1()
+	set := &WorkloadSet{}
+
+	deployments, err := apps.Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	set.Deployments = deployments.Items
+
+	statefulSets, err := apps.StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	set.StatefulSets = statefulSets.Items
+
+	daemonSets, err := apps.DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	set.DaemonSets = daemonSets.Items
+
+	jobs, err := batch.Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	set.Jobs = jobs.Items
+
+	cronJobs, err := batch.CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	set.CronJobs = cronJobs.Items
+
+	if set.Pods, err = p.listPodItems(ctx, namespace); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// ListServices returns the Services in namespace with their EndpointSlices and pods.
+func (p *PodsProvider) ListServices(ctx context.Context, namespace string) (*WorkloadSet, error) {
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+	set := &WorkloadSet{}
+
+	services, err := p.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	set.Services = services.Items
+
+	slices, err := p.clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpointslices: %w", err)
+	}
+	set.EndpointSlices = slices.Items
+
+	if set.Pods, err = p.listPodItems(ctx, namespace); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// ListVolumeClaims returns the PersistentVolumeClaims in namespace with the pods that may mount them.
+func (p *PodsProvider) ListVolumeClaims(ctx context.Context, namespace string) (*WorkloadSet, error) {
+	if namespace == "" {
+		namespace = metav1.NamespaceAll
+	}
+	set := &WorkloadSet{}
+
+	pvcs, err := p.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistentvolumeclaims: %w", err)
+	}
+	set.PVCs = pvcs.Items
+
+	if set.Pods, err = p.listPodItems(ctx, namespace); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func (p *PodsProvider) listPodItems(ctx context.Context, namespace string) ([]corev1.Pod, error) {
+	pods, err := p.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods.Items, nil
+}
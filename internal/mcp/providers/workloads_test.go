@@ -0,0 +1,63 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodsProvider_WorkloadLists(t *testing.T) {
+	meta := func(name, namespace string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace}
+	}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: meta("api", "default")},
+		&appsv1.Deployment{ObjectMeta: meta("coredns", "kube-system")},
+		&appsv1.StatefulSet{ObjectMeta: meta("db", "default")},
+		&appsv1.DaemonSet{ObjectMeta: meta("agent", "default")},
+		&batchv1.Job{ObjectMeta: meta("migrate", "default")},
+		&batchv1.CronJob{ObjectMeta: meta("backup", "default")},
+		&corev1.Service{ObjectMeta: meta("api", "default")},
+		&discoveryv1.EndpointSlice{ObjectMeta: meta("api-abc", "default")},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("data-db-0", "default")},
+		&corev1.Pod{ObjectMeta: meta("api-0", "default")},
+	)
+	provider := &PodsProvider{clientset: clientset}
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		list  func(ctx context.Context, namespace string) (*WorkloadSet, error)
+		ns    string
+		count func(s *WorkloadSet) int
+		want  int
+	}{
+		{name: "deployments in namespace", list: provider.ListWorkloads, ns: "default", count: func(s *WorkloadSet) int { return len(s.Deployments) }, want: 1},
+		{name: "deployments cluster wide", list: provider.ListWorkloads, ns: "", count: func(s *WorkloadSet) int { return len(s.Deployments) }, want: 2},
+		{name: "controllers", list: provider.ListWorkloads, ns: "default", count: func(s *WorkloadSet) int {
+			return len(s.StatefulSets) + len(s.DaemonSets) + len(s.Jobs) + len(s.CronJobs)
+		}, want: 4},
+		{name: "workload pods", list: provider.ListWorkloads, ns: "default", count: func(s *WorkloadSet) int { return len(s.Pods) }, want: 1},
+		{name: "services", list: provider.ListServices, ns: "default", count: func(s *WorkloadSet) int { return len(s.Services) + len(s.EndpointSlices) }, want: 2},
+		{name: "volume claims", list: provider.ListVolumeClaims, ns: "default", count: func(s *WorkloadSet) int { return len(s.PVCs) }, want: 1},
+		{name: "empty namespace", list: provider.ListVolumeClaims, ns: "other", count: func(s *WorkloadSet) int { return len(s.PVCs) + len(s.Pods) }, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.list(ctx, tt.ns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := tt.count(got); n != tt.want {
+				t.Errorf("got count = %d, want %d", n, tt.want)
+			}
+		})
+	}
+}
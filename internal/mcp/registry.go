@@ -3,46 +3,67 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"observability-hub/internal/mcp/providers"
+	"observability-hub/internal/mcp/tools/events"
 	"observability-hub/internal/mcp/tools/hub"
 	"observability-hub/internal/mcp/tools/pods"
 	"observability-hub/internal/mcp/tools/telemetry"
-	libtelemetry "observability-hub/internal/telemetry"
+	"observability-hub/internal/mcp/tools/workloads"
 )
 
 // --- Telemetry Tools ---
 
 // RegisterTelemetryTools registers all telemetry-related tools (Thanos, Loki, Tempo) to the MCP server.
-func RegisterTelemetryTools(server *mcp.Server, provider *providers.TelemetryProvider, serviceName string) {
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "query_metrics",
-		Description: "Execute PromQL queries against Thanos/Prometheus for metrics analysis (See skills/telemetry/SKILL.md for guidance)",
-	}, handleQueryMetrics(provider, serviceName))
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "query_logs",
-		Description: "Execute LogQL queries against Loki for log analysis (See skills/telemetry/SKILL.md for guidance)",
-	}, handleQueryLogs(provider, serviceName))
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "query_traces",
-		Description: "Retrieve distributed traces from Tempo by trace ID (See skills/telemetry/SKILL.md for guidance)",
-	}, handleQueryTraces(provider, serviceName))
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "investigate_incident",
-		Description: "Correlate metrics, logs, and traces to produce a structured incident report for a service (See skills/telemetry/SKILL.md for guidance)",
-	}, handleInvestigateIncident(provider, serviceName))
-
-	libtelemetry.Info("registered telemetry tools", "count", 4)
+func RegisterTelemetryTools(registry *ToolRegistry, provider *providers.TelemetryProvider, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "query_metrics",
+			Description: "Execute PromQL queries against Thanos/Prometheus for metrics analysis (See skills/telemetry/SKILL.md for guidance)",
+		}, handleQueryMetrics(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "query_logs",
+			Description: "Execute LogQL queries against Loki for log analysis (See skills/telemetry/SKILL.md for guidance)",
+		}, handleQueryLogs(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "query_traces",
+			Description: "Retrieve distributed traces from Tempo by trace ID (See skills/telemetry/SKILL.md for guidance)",
+		}, handleQueryTraces(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "investigate_incident",
+			Description: "Correlate metrics, logs, and traces to produce a structured incident report for a service (See skills/telemetry/SKILL.md for guidance)",
+		}, handleInvestigateIncident(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_metrics",
+			Description: "List metric names known to Thanos, filtered by prefix or series selector (See skills/telemetry/SKILL.md for guidance)",
+		}, handleListMetrics(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_metric_labels",
+			Description: "List label names, cardinality and values for Thanos metrics (See skills/telemetry/SKILL.md for guidance)",
+		}, handleListMetricLabels(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_log_labels",
+			Description: "List Loki label names or the values of a label (See skills/telemetry/SKILL.md for guidance)",
+		}, handleListLogLabels(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_trace_tags",
+			Description: "List Tempo span/resource tags or the values of a tag (See skills/telemetry/SKILL.md for guidance)",
+		}, handleListTraceTags(provider, serviceName)),
+	)
 }
 
 func handleQueryMetrics(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.QueryMetricsInput, any] {
 	handler := telemetry.NewQueryMetricsHandler(provider.QueryMetrics)
 	return InstrumentHandler("query_metrics", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.QueryMetricsInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		if input.Live {
+			ctx = providers.WithLiveQuery(ctx)
+		}
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
@@ -57,6 +78,10 @@ func handleQueryMetrics(provider *providers.TelemetryProvider, serviceName strin
 func handleQueryLogs(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.QueryLogsInput, any] {
 	handler := telemetry.NewQueryLogsHandler(provider.QueryLogs)
 	return InstrumentHandler("query_logs", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.QueryLogsInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		if input.Live {
+			ctx = providers.WithLiveQuery(ctx)
+		}
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
@@ -69,8 +94,9 @@ func handleQueryLogs(provider *providers.TelemetryProvider, serviceName string)
 }
 
 func handleInvestigateIncident(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.InvestigateIncidentInput, any] {
-	handler := telemetry.NewInvestigateIncidentHandler(provider.QueryMetrics, provider.QueryLogs, provider.QueryTraces)
+	handler := telemetry.NewInvestigateIncidentHandler(provider.QueryMetricsRange, provider.QueryLogs, provider.QueryTraces)
 	return InstrumentHandler("investigate_incident", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.InvestigateIncidentInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
@@ -85,6 +111,10 @@ func handleInvestigateIncident(provider *providers.TelemetryProvider, serviceNam
 func handleQueryTraces(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.QueryTracesInput, any] {
 	handler := telemetry.NewQueryTracesHandler(provider.QueryTraces)
 	return InstrumentHandler("query_traces", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.QueryTracesInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		if input.Live {
+			ctx = providers.WithLiveQuery(ctx)
+		}
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
@@ -96,41 +126,183 @@ func handleQueryTraces(provider *providers.TelemetryProvider, serviceName string
 	})
 }
 
-// --- Pods Tools ---
+func handleListMetrics(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.ListMetricsInput, any] {
+	handler := telemetry.NewListMetricsHandler(provider.ListMetricNames)
+	return InstrumentHandler("list_metrics", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.ListMetricsInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-// RegisterPodsTools registers all Kubernetes-related tools (Pods, Events) to the MCP server.
-func RegisterPodsTools(server *mcp.Server, provider *providers.PodsProvider, serviceName string) {
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "inspect_pods",
-		Description: "List all pods in a namespace with status summary (See skills/pods/SKILL.md for guidance)",
-	}, handleInspectPods(provider, serviceName))
+func handleListMetricLabels(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.ListMetricLabelsInput, any] {
+	handler := telemetry.NewListMetricLabelsHandler(provider.ListMetricLabelNames, provider.ListMetricLabelValues, provider.ListMetricSeries)
+	return InstrumentHandler("list_metric_labels", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.ListMetricLabelsInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "describe_pod",
-		Description: "Get detailed status and configuration for a specific pod (See skills/pods/SKILL.md for guidance)",
-	}, handleDescribePod(provider, serviceName))
+func handleListLogLabels(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.ListLogLabelsInput, any] {
+	handler := telemetry.NewListLogLabelsHandler(provider.ListLogLabels, provider.ListLogLabelValues)
+	return InstrumentHandler("list_log_labels", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.ListLogLabelsInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "list_pod_events",
-		Description: "List all lifecycle events associated with a specific pod (See skills/pods/SKILL.md for guidance)",
-	}, handleListPodEvents(provider, serviceName))
+func handleListTraceTags(provider *providers.TelemetryProvider, serviceName string) mcp.ToolHandlerFor[telemetry.ListTraceTagsInput, any] {
+	handler := telemetry.NewListTraceTagsHandler(provider.ListTraceTags, provider.ListTraceTagValues)
+	return InstrumentHandler("list_trace_tags", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.ListTraceTagsInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "get_pod_logs",
-		Description: "Retrieve logs from a specific pod/container (See skills/pods/SKILL.md for guidance)",
-	}, handleGetPodLogs(provider, serviceName))
+// --- Incident Tools ---
+
+// RegisterIncidentTools registers cross-domain incident tools that combine telemetry, pods and hub data.
+// The pods and hub providers are optional; their sources are skipped when nil.
+func RegisterIncidentTools(registry *ToolRegistry, telemetryProv *providers.TelemetryProvider, podsProv *providers.PodsProvider, hubProv *providers.HubProvider, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "build_incident_timeline",
+			Description: "Merge logs, error spans, Kubernetes events, journal entries and GitOps syncs into one chronological timeline for an incident window (See skills/telemetry/SKILL.md for guidance)",
+		}, handleBuildIncidentTimeline(telemetryProv, podsProv, hubProv, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "service_dependency_graph",
+			Description: "Map what a service depends on and what depends on it from Tempo spans and Hubble flows, with call counts, error rates, p95 latency and a Mermaid or DOT diagram (See skills/telemetry/SKILL.md for guidance)",
+		}, handleServiceDependencyGraph(telemetryProv, hubProv, serviceName)),
+	)
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "delete_pod",
-		Description: "Delete a specific pod (useful for restarting stuck pods) (See skills/pods/SKILL.md for guidance)",
-	}, handleDeletePod(provider, serviceName))
+func handleBuildIncidentTimeline(telemetryProv *providers.TelemetryProvider, podsProv *providers.PodsProvider, hubProv *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[telemetry.BuildIncidentTimelineInput, any] {
+	sources := telemetry.TimelineSources{
+		QueryLogs:      telemetryProv.QueryLogsRange,
+		SearchTraces:   telemetryProv.SearchTracesRange,
+		DeployLogQuery: telemetryProv.DeployLogQuery,
+	}
+	if podsProv != nil {
+		sources.ListEvents = podsProv.ListNamespaceEvents
+	}
+	if hubProv != nil {
+		sources.QueryJournal = hubProv.QueryJournal
+	}
+	handler := telemetry.NewBuildIncidentTimelineHandler(sources)
+	return InstrumentHandler("build_incident_timeline", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.BuildIncidentTimelineInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	libtelemetry.Info("registered pods tools", "count", 5)
+func handleServiceDependencyGraph(telemetryProv *providers.TelemetryProvider, hubProv *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[telemetry.ServiceDependencyGraphInput, any] {
+	sources := telemetry.DependencySources{QueryTraces: telemetryProv.QueryTraces}
+	if hubProv != nil {
+		sources.QueryFlows = hubProv.QueryHubbleFlows
+	}
+	handler := telemetry.NewServiceDependencyGraphHandler(sources)
+	return InstrumentHandler("service_dependency_graph", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input telemetry.ServiceDependencyGraphInput) (*mcp.CallToolResult, any, error) {
+		ctx = providers.WithTelemetryTarget(ctx, input.Target)
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
 }
 
-func handleInspectPods(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[pods.PodsInput, any] {
+// --- Pods Tools ---
+
+// rolloutToolTimeout lets rollout_restart and scale_workload wait their full wait_seconds
+// (max 300) for the rollout and still answer.
+const rolloutToolTimeout = 6 * time.Minute
+
+// RegisterPodsTools registers all Kubernetes-related tools (Pods, Events) to the MCP server.
+// Mutating tools run through remediation; nil uses DefaultRemediationPolicy with log-only auditing.
+func RegisterPodsTools(registry *ToolRegistry, provider *providers.PodsProvider, remediation *RemediationEngine, serviceName string) {
+	if remediation == nil {
+		remediation = NewRemediationEngine(DefaultRemediationPolicy(), nil)
+	}
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "inspect_pods",
+			Description: "List pods with a health summary, filtered by label/field selector or status, sorted and paginated (See skills/pods/SKILL.md for guidance)",
+		}, handleInspectPods(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "describe_pod",
+			Description: "Get detailed status and configuration for a specific pod (See skills/pods/SKILL.md for guidance)",
+		}, handleDescribePod(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_pod_events",
+			Description: "List all lifecycle events associated with a specific pod (See skills/pods/SKILL.md for guidance)",
+		}, handleListPodEvents(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "get_pod_logs",
+			Description: "Retrieve logs from a specific pod/container (See skills/pods/SKILL.md for guidance)",
+		}, handleGetPodLogs(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "search_pod_logs",
+			Description: "Search logs across all containers (including init) of a pod or every pod matching a label selector, with a since window and regex filter. Lines are interleaved by timestamp and prefixed with pod/container; large results include a digest (See skills/pods/SKILL.md for guidance)",
+		}, handleSearchPodLogs(provider, serviceName)),
+		mutatingTool(&mcp.Tool{
+			Name:        "delete_pod",
+			Description: "Delete a specific pod (useful for restarting stuck pods). Guarded: first call returns a dry-run preview with a confirm_token (See skills/pods/SKILL.md for guidance)",
+		}, handleDeletePod(provider, remediation, serviceName)),
+		mutatingTool(&mcp.Tool{
+			Name:        "rollout_restart",
+			Description: "Restart all pods of a Deployment, StatefulSet or DaemonSet and report rollout status. Guarded: first call returns a dry-run preview with a confirm_token (See skills/pods/SKILL.md for guidance)",
+		}, handleRolloutRestart(provider, remediation, serviceName)).withTimeout(rolloutToolTimeout),
+		mutatingTool(&mcp.Tool{
+			Name:        "scale_workload",
+			Description: "Scale a Deployment or StatefulSet by a bounded number of replicas and report rollout status. Guarded: first call returns a dry-run preview with a confirm_token (See skills/pods/SKILL.md for guidance)",
+		}, handleScaleWorkload(provider, remediation, serviceName)).withTimeout(rolloutToolTimeout),
+		mutatingTool(&mcp.Tool{
+			Name:        "cordon_node",
+			Description: "Cordon or uncordon a node without evicting its pods. Guarded: first call returns a dry-run preview with a confirm_token (See skills/pods/SKILL.md for guidance)",
+		}, handleCordonNode(provider, remediation, serviceName)),
+	)
+}
+
+func handleInspectPods(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[pods.InspectPodsInput, any] {
 	handler := pods.NewInspectPodsHandler(provider.ListPods)
-	return InstrumentHandler("inspect_pods", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input pods.PodsInput) (*mcp.CallToolResult, any, error) {
+	return InstrumentHandler("inspect_pods", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input pods.InspectPodsInput) (*mcp.CallToolResult, any, error) {
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
@@ -143,7 +315,7 @@ func handleInspectPods(provider *providers.PodsProvider, serviceName string) mcp
 }
 
 func handleDescribePod(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[pods.PodsInput, any] {
-	handler := pods.NewDescribePodHandler(provider.GetPod)
+	handler := pods.NewDescribePodHandler(provider.GetPod, provider.GetNode)
 	return InstrumentHandler("describe_pod", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input pods.PodsInput) (*mcp.CallToolResult, any, error) {
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
@@ -183,9 +355,157 @@ func handleGetPodLogs(provider *providers.PodsProvider, serviceName string) mcp.
 	})
 }
 
-func handleDeletePod(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[pods.DeletePodInput, any] {
+func handleSearchPodLogs(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[pods.SearchPodLogsInput, any] {
+	handler := pods.NewSearchPodLogsHandler(provider.SearchPodLogs)
+	return InstrumentHandler("search_pod_logs", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input pods.SearchPodLogsInput) (*mcp.CallToolResult, any, error) {
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+func handleDeletePod(provider *providers.PodsProvider, remediation *RemediationEngine, serviceName string) mcp.ToolHandlerFor[pods.DeletePodInput, any] {
 	handler := pods.NewDeletePodHandler(provider.DeletePod)
-	return InstrumentHandler("delete_pod", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input pods.DeletePodInput) (*mcp.CallToolResult, any, error) {
+	return InstrumentHandler("delete_pod", serviceName, func(ctx context.Context, req *mcp.CallToolRequest, input pods.DeletePodInput) (*mcp.CallToolResult, any, error) {
+		pod, err := provider.GetPod(ctx, input.Namespace, input.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := fmt.Sprintf("delete pod %s/%s", input.Namespace, input.Name)
+		if owner := metav1.GetControllerOf(pod); owner != nil {
+			summary += fmt.Sprintf(" (controlled by %s %s, which will recreate it)", owner.Kind, owner.Name)
+		} else {
+			summary += " (no controller; it will NOT be recreated)"
+		}
+
+		return runRemediation(ctx, req, remediation, RemediationRequest{
+			Tool:         "delete_pod",
+			Target:       RemediationTarget{Kind: "Pod", Namespace: input.Namespace, Name: input.Name, Labels: pod.Labels},
+			Params:       map[string]any{"grace_seconds": input.GraceSeconds},
+			Summary:      summary,
+			Reason:       input.Reason,
+			DryRun:       input.DryRun,
+			ConfirmToken: input.ConfirmToken,
+		}, func(ctx context.Context) (interface{}, error) {
+			return handler.Execute(ctx, input)
+		})
+	})
+}
+
+func handleRolloutRestart(provider *providers.PodsProvider, remediation *RemediationEngine, serviceName string) mcp.ToolHandlerFor[pods.RolloutRestartInput, any] {
+	handler := pods.NewRolloutRestartHandler(provider.RolloutRestart)
+	return InstrumentHandler("rollout_restart", serviceName, func(ctx context.Context, req *mcp.CallToolRequest, input pods.RolloutRestartInput) (*mcp.CallToolResult, any, error) {
+		status, err := provider.PreflightRolloutRestart(ctx, input.Kind, input.Namespace, input.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := fmt.Sprintf("rollout restart %s %s/%s (replaces all %d pods; currently %s)",
+			status.Kind, input.Namespace, input.Name, status.Replicas, status.Message)
+
+		return runRemediation(ctx, req, remediation, RemediationRequest{
+			Tool:         "rollout_restart",
+			Target:       RemediationTarget{Kind: status.Kind, Namespace: input.Namespace, Name: input.Name, Labels: status.Labels},
+			Summary:      summary,
+			Reason:       input.Reason,
+			DryRun:       input.DryRun,
+			ConfirmToken: input.ConfirmToken,
+		}, func(ctx context.Context) (interface{}, error) {
+			return handler.Execute(ctx, input)
+		})
+	})
+}
+
+func handleScaleWorkload(provider *providers.PodsProvider, remediation *RemediationEngine, serviceName string) mcp.ToolHandlerFor[pods.ScaleWorkloadInput, any] {
+	handler := pods.NewScaleWorkloadHandler(provider.ScaleWorkload)
+	return InstrumentHandler("scale_workload", serviceName, func(ctx context.Context, req *mcp.CallToolRequest, input pods.ScaleWorkloadInput) (*mcp.CallToolResult, any, error) {
+		status, err := provider.PreflightScale(ctx, input.Kind, input.Namespace, input.Name, input.Replicas)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := fmt.Sprintf("scale %s %s/%s from %d to %d replicas", status.Kind, input.Namespace, input.Name, status.Replicas, input.Replicas)
+		if input.Replicas == 0 {
+			summary += " (takes the workload fully offline)"
+		}
+		direction := "up"
+		if input.Replicas < status.Replicas {
+			direction = "down"
+		}
+
+		return runRemediation(ctx, req, remediation, RemediationRequest{
+			Tool:         "scale_workload",
+			Target:       RemediationTarget{Kind: status.Kind, Namespace: input.Namespace, Name: input.Name, Labels: status.Labels},
+			Params:       map[string]any{"replicas": input.Replicas},
+			Direction:    direction,
+			Summary:      summary,
+			Reason:       input.Reason,
+			DryRun:       input.DryRun,
+			ConfirmToken: input.ConfirmToken,
+		}, func(ctx context.Context) (interface{}, error) {
+			return handler.Execute(ctx, input)
+		})
+	})
+}
+
+func handleCordonNode(provider *providers.PodsProvider, remediation *RemediationEngine, serviceName string) mcp.ToolHandlerFor[pods.CordonNodeInput, any] {
+	handler := pods.NewCordonNodeHandler(provider.CordonNode)
+	return InstrumentHandler("cordon_node", serviceName, func(ctx context.Context, req *mcp.CallToolRequest, input pods.CordonNodeInput) (*mcp.CallToolResult, any, error) {
+		status, err := provider.PreflightCordon(ctx, input.Name, !input.Uncordon)
+		if err != nil {
+			return nil, nil, err
+		}
+		direction := "cordon"
+		summary := fmt.Sprintf("cordon node %s (%d running pods stay in place; new pods will not be scheduled there)", input.Name, status.Pods)
+		if input.Uncordon {
+			direction = "uncordon"
+			summary = fmt.Sprintf("uncordon node %s (new pods may be scheduled there again)", input.Name)
+		}
+
+		return runRemediation(ctx, req, remediation, RemediationRequest{
+			Tool:         "cordon_node",
+			Target:       RemediationTarget{Kind: "Node", Name: input.Name, Labels: status.Labels},
+			Direction:    direction,
+			Summary:      summary,
+			Reason:       input.Reason,
+			DryRun:       input.DryRun,
+			ConfirmToken: input.ConfirmToken,
+		}, func(ctx context.Context) (interface{}, error) {
+			return handler.Execute(ctx, input)
+		})
+	})
+}
+
+// runRemediation runs a mutating tool through the remediation engine and renders its result.
+func runRemediation(ctx context.Context, req *mcp.CallToolRequest, remediation *RemediationEngine, r RemediationRequest, execute func(ctx context.Context) (interface{}, error)) (*mcp.CallToolResult, any, error) {
+	result, err := remediation.Run(ctx, req, r, execute)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, _ := json.Marshal(result)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+	}, nil, nil
+}
+
+// --- Event Tools ---
+
+// RegisterEventTools registers tools reading the Warning events buffered by watcher.
+func RegisterEventTools(registry *ToolRegistry, watcher *providers.EventWatcher, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "cluster_event_digest",
+			Description: "Group cluster-wide Kubernetes Warning events over a time window by reason and involved object, including events already expired from the API server (See skills/pods/SKILL.md for guidance)",
+		}, handleClusterEventDigest(watcher, serviceName)),
+	)
+}
+
+func handleClusterEventDigest(watcher *providers.EventWatcher, serviceName string) mcp.ToolHandlerFor[events.DigestInput, any] {
+	handler := events.NewClusterEventDigestHandler(watcher.Snapshot)
+	return InstrumentHandler("cluster_event_digest", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input events.DigestInput) (*mcp.CallToolResult, any, error) {
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
@@ -197,33 +517,105 @@ func handleDeletePod(provider *providers.PodsProvider, serviceName string) mcp.T
 	})
 }
 
-// --- Hub Tools ---
+// --- Workload Tools ---
+
+// RegisterWorkloadTools registers controller, service and volume inspection tools to the MCP server.
+// They share the pods provider's Kubernetes client.
+func RegisterWorkloadTools(registry *ToolRegistry, provider *providers.PodsProvider, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "inspect_workloads",
+			Description: "Summarize Deployments, StatefulSets, DaemonSets, Jobs and CronJobs: desired vs ready replicas, rollout conditions, recent job runs and their pods (See skills/workloads/SKILL.md for guidance)",
+		}, handleInspectWorkloads(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "inspect_services",
+			Description: "Summarize Services with endpoint readiness and the pods behind them (See skills/workloads/SKILL.md for guidance)",
+		}, handleInspectServices(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "inspect_volumes",
+			Description: "Summarize PersistentVolumeClaims with binding state, capacity and the pods mounting them (See skills/workloads/SKILL.md for guidance)",
+		}, handleInspectVolumes(provider, serviceName)),
+	)
+}
 
-// RegisterHubTools registers all host-level and platform status tools to the MCP server.
-func RegisterHubTools(server *mcp.Server, provider *providers.HubProvider, serviceName string) {
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "hub_inspect_platform",
-		Description: "Get an executive summary of the entire platform health (See skills/platform/SKILL.md for guidance)",
-	}, handleInspectPlatform(provider, serviceName))
+func handleInspectWorkloads(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[workloads.WorkloadsInput, any] {
+	handler := workloads.NewInspectWorkloadsHandler(provider.ListWorkloads)
+	return InstrumentHandler("inspect_workloads", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input workloads.WorkloadsInput) (*mcp.CallToolResult, any, error) {
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "hub_inspect_host",
-		Description: "Inspect physical resource pressure (Load, Memory, Disk) on the main server (See skills/host/SKILL.md for guidance)",
-	}, handleInspectHost(provider, serviceName))
+func handleInspectServices(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[workloads.ResourceInput, any] {
+	handler := workloads.NewInspectServicesHandler(provider.ListServices)
+	return InstrumentHandler("inspect_services", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input workloads.ResourceInput) (*mcp.CallToolResult, any, error) {
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "hub_list_host_services",
-		Description: "List and check status of core systemd units (ingestion, proxy, openbao) (See skills/host/SKILL.md for guidance)",
-	}, handleListHostServices(provider, serviceName))
+func handleInspectVolumes(provider *providers.PodsProvider, serviceName string) mcp.ToolHandlerFor[workloads.ResourceInput, any] {
+	handler := workloads.NewInspectVolumesHandler(provider.ListVolumeClaims)
+	return InstrumentHandler("inspect_volumes", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input workloads.ResourceInput) (*mcp.CallToolResult, any, error) {
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "hub_query_service_logs",
-		Description: "Query systemd journal logs for a specific service since a relative time (e.g., past 5m, 1h) (See skills/host/SKILL.md for guidance)",
-	}, handleQueryServiceLogs(provider, serviceName))
+// --- Hub Tools ---
 
-	libtelemetry.Info("registered hub tools", "count", 4)
+// RegisterHubTools registers all host-level and platform status tools to the MCP server.
+// Mutating tools run through remediation; nil uses DefaultRemediationPolicy with log-only auditing.
+func RegisterHubTools(registry *ToolRegistry, provider *providers.HubProvider, remediation *RemediationEngine, serviceName string) {
+	if remediation == nil {
+		remediation = NewRemediationEngine(DefaultRemediationPolicy(), nil)
+	}
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "hub_inspect_platform",
+			Description: "Get an executive summary of the entire platform health (See skills/platform/SKILL.md for guidance)",
+		}, handleInspectPlatform(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "hub_inspect_host",
+			Description: "Inspect physical resources on the main server: CPU %, load, memory, pressure stall (PSI), per-mount disk usage and temperatures, as numbers with units (See skills/host/SKILL.md for guidance)",
+		}, handleInspectHost(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "hub_list_host_services",
+			Description: "Check the host inventory systemd units against their expectations: state, restart count, resource accounting and timer cadence (See skills/host/SKILL.md for guidance)",
+		}, handleListHostServices(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "hub_query_service_logs",
+			Description: "Query the systemd journal of an inventory unit as typed entries (time, priority, PID, message), newest first, filtered by time range, priority and regex, with cursor paging; large pages include a digest (See skills/host/SKILL.md for guidance)",
+		}, handleQueryServiceLogs(provider, serviceName)),
+		mutatingTool(&mcp.Tool{
+			Name:        "hub_restart_service",
+			Description: "Restart a systemd unit from the host inventory, wait for it to become healthy (unit state plus optional HTTP probe) and return the journal around the restart. Guarded: first call returns a dry-run preview with a confirm_token (See skills/host/SKILL.md for guidance)",
+		}, handleRestartService(provider, remediation, serviceName)).withTimeout(restartToolTimeout),
+	)
 }
 
+// restartToolTimeout lets hub_restart_service wait its full wait_seconds (max 120) and still
+// read the journal.
+const restartToolTimeout = 3 * time.Minute
+
 func handleInspectPlatform(provider *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[hub.HubInput, any] {
 	handler := hub.NewInspectPlatformHandler(provider.InspectPlatform)
 	return InstrumentHandler("hub_inspect_platform", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input hub.HubInput) (*mcp.CallToolResult, any, error) {
@@ -266,29 +658,77 @@ func handleListHostServices(provider *providers.HubProvider, serviceName string)
 	})
 }
 
-func handleQueryServiceLogs(provider *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[hub.HubInput, any] {
-	handler := hub.NewQueryServiceLogsHandler(provider.QueryServiceLogs)
-	return InstrumentHandler("hub_query_service_logs", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input hub.HubInput) (*mcp.CallToolResult, any, error) {
+func handleQueryServiceLogs(provider *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[hub.QueryServiceLogsInput, any] {
+	handler := hub.NewQueryServiceLogsHandler(provider.QueryJournal)
+	return InstrumentHandler("hub_query_service_logs", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input hub.QueryServiceLogsInput) (*mcp.CallToolResult, any, error) {
 		result, err := handler.Execute(ctx, input)
 		if err != nil {
 			return nil, nil, err
 		}
+		text, _ := json.Marshal(result)
 		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: result.(string)}},
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
 		}, nil, nil
 	})
 }
 
+func handleRestartService(provider *providers.HubProvider, remediation *RemediationEngine, serviceName string) mcp.ToolHandlerFor[hub.RestartServiceInput, any] {
+	handler := hub.NewRestartServiceHandler(provider.RestartService)
+	return InstrumentHandler("hub_restart_service", serviceName, func(ctx context.Context, req *mcp.CallToolRequest, input hub.RestartServiceInput) (*mcp.CallToolResult, any, error) {
+		before, err := provider.PreflightRestartService(ctx, input.Service)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary := fmt.Sprintf("restart systemd unit %s on %s (currently %s (%s)", before.Unit, provider.Node(), before.Before.Active, before.Before.Sub)
+		if before.Before.Problem != "" {
+			summary += ", " + before.Before.Problem
+		}
+		if before.BeforeProbe != nil {
+			summary += fmt.Sprintf(", health probe healthy=%t", before.BeforeProbe.Healthy)
+		}
+		summary += ")"
+
+		return runRemediation(ctx, req, remediation, RemediationRequest{
+			Tool:         "hub_restart_service",
+			Target:       RemediationTarget{Kind: "SystemdUnit", Name: before.Unit},
+			Summary:      summary,
+			Reason:       input.Reason,
+			DryRun:       input.DryRun,
+			ConfirmToken: input.ConfirmToken,
+		}, func(ctx context.Context) (interface{}, error) {
+			return handler.Execute(ctx, input)
+		})
+	})
+}
+
 // --- Network Tools ---
 
 // RegisterNetworkTools registers all networking-related tools (Hubble) to the MCP server.
-func RegisterNetworkTools(server *mcp.Server, provider *providers.HubProvider, serviceName string) {
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "observe_network_flows",
-		Description: "Query real-time network flows from Hubble Relay (See skills/network/SKILL.md for guidance)",
-	}, handleObserveNetworkFlows(provider, serviceName))
+func RegisterNetworkTools(registry *ToolRegistry, provider *providers.HubProvider, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "observe_network_flows",
+			Description: "Query Hubble network flows as typed records, or aggregate them by source, destination, port and verdict (See skills/network/SKILL.md for guidance)",
+		}, handleObserveNetworkFlows(provider, serviceName)),
+		readOnlyTool(&mcp.Tool{
+			Name:        "simulate_network_policy",
+			Description: "Check whether CiliumNetworkPolicy/NetworkPolicy rules allow a connection between pods, labels, entities, IPs or FQDNs, with the matching rule names; works offline against the repo's policies (See skills/network/SKILL.md for guidance)",
+		}, handleSimulateNetworkPolicy(provider, serviceName)),
+	)
+}
 
-	libtelemetry.Info("registered network tools", "count", 1)
+func handleSimulateNetworkPolicy(provider *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[hub.SimulateNetworkPolicyInput, any] {
+	handler := hub.NewSimulateNetworkPolicyHandler(provider.SimulateNetworkPolicy)
+	return InstrumentHandler("simulate_network_policy", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input hub.SimulateNetworkPolicyInput) (*mcp.CallToolResult, any, error) {
+		result, err := handler.Execute(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(result)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
 }
 
 func handleObserveNetworkFlows(provider *providers.HubProvider, serviceName string) mcp.ToolHandlerFor[hub.ObserveNetworkFlowsInput, any] {
@@ -298,8 +738,9 @@ func handleObserveNetworkFlows(provider *providers.HubProvider, serviceName stri
 		if err != nil {
 			return nil, nil, err
 		}
+		text, _ := json.Marshal(result)
 		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: result.(string)}},
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
 		}, nil, nil
 	})
 }
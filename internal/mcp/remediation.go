@@ -0,0 +1,394 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gopkg.in/yaml.v3"
+
+	"observability-hub/internal/mcp/providers"
+	"observability-hub/internal/telemetry"
+)
+
+// RemediationPolicy limits what mutating tools may do.
+//
+// Label selectors are "key=value" or "key" (presence). Deny rules always win over allow rules;
+// empty allow lists allow everything not denied.
+//
+// Namespace lists only apply to namespaced targets; nodes are limited by AllowNodes instead.
+// Label lists only apply to targets that carry labels, so they do not block systemd units.
+type RemediationPolicy struct {
+	AllowNamespaces []string `yaml:"allow_namespaces"`
+	DenyNamespaces  []string `yaml:"deny_namespaces"`
+	AllowLabels     []string `yaml:"allow_labels"`
+	DenyLabels      []string `yaml:"deny_labels"`
+	// AllowNodes limits node actions (cordon_node) to these node names.
+	AllowNodes []string `yaml:"allow_nodes"`
+	// Cooldown is the minimum time between two actions by the same tool, in the same
+	// direction, on the same target. Reverting an action (scaling back up, uncordoning)
+	// is a different direction and is not blocked.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// MaxActionsPerHour caps executed actions across all targets (sliding window).
+	MaxActionsPerHour int `yaml:"max_actions_per_hour"`
+	// PreviewTTL is how long a dry-run confirm token stays valid.
+	PreviewTTL time.Duration `yaml:"preview_ttl"`
+	// RequireHumanConfirmation refuses to execute when the client cannot elicit a confirmation.
+	RequireHumanConfirmation bool `yaml:"require_human_confirmation"`
+}
+
+// DefaultRemediationPolicy protects system namespaces and keeps the blast radius small.
+func DefaultRemediationPolicy() RemediationPolicy {
+	return RemediationPolicy{
+		DenyNamespaces:    []string{"kube-system", "kube-public", "kube-node-lease"},
+		DenyLabels:        []string{"mcp.remediation/protected=true"},
+		Cooldown:          10 * time.Minute,
+		MaxActionsPerHour: 10,
+		PreviewTTL:        10 * time.Minute,
+	}
+}
+
+// LoadRemediationPolicy reads a policy from a YAML file. Unset fields keep their defaults.
+func LoadRemediationPolicy(path string) (RemediationPolicy, error) {
+	policy := DefaultRemediationPolicy()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read remediation policy: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse remediation policy: %w", err)
+	}
+	return policy, nil
+}
+
+// RemediationTarget identifies the object a mutating tool acts on.
+type RemediationTarget struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"-"`
+}
+
+func (t RemediationTarget) key() string {
+	return t.Kind + "/" + t.Namespace + "/" + t.Name
+}
+
+// RemediationRequest describes one invocation of a mutating tool.
+type RemediationRequest struct {
+	Tool   string
+	Target RemediationTarget
+	// Params holds the parameters that change what the action does (e.g. a grace period).
+	// A confirm token is only accepted for the same tool, target and Params it previewed.
+	Params map[string]any
+	// Direction distinguishes opposite actions of one tool, e.g. "down"/"up" for scaling or
+	// "cordon"/"uncordon". Each direction has its own cooldown.
+	Direction    string
+	Summary      string // human-readable description, e.g. "delete pod default/api-0"
+	Reason       string // the caller's justification, required
+	DryRun       bool
+	ConfirmToken string
+}
+
+// RemediationResult is returned by mutating tools for both previews and executions.
+type RemediationResult struct {
+	Status       string            `json:"status"` // "preview" or "executed"
+	Tool         string            `json:"tool"`
+	Target       RemediationTarget `json:"target"`
+	Summary      string            `json:"summary"`
+	ConfirmToken string            `json:"confirm_token,omitempty"`
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`
+	Result       interface{}       `json:"result,omitempty"`
+	Note         string            `json:"note,omitempty"`
+}
+
+type pendingPreview struct {
+	fingerprint string
+	expires     time.Time
+}
+
+// cooldownKey identifies the action for cooldown purposes.
+func (r RemediationRequest) cooldownKey() string {
+	return r.Tool + "/" + r.Direction + "/" + r.Target.key()
+}
+
+// fingerprint identifies exactly what a request would do: the tool, its direction and
+// target, and its normalised parameters (JSON sorts map keys).
+func (r RemediationRequest) fingerprint() string {
+	params, _ := json.Marshal(r.Params)
+	sum := sha256.Sum256([]byte(r.cooldownKey() + "\n" + string(params)))
+	return hex.EncodeToString(sum[:])
+}
+
+// confirmFunc asks a human to approve an action. supported is false when the client
+// cannot be asked, in which case accepted is meaningless.
+type confirmFunc func(ctx context.Context, req *mcp.CallToolRequest, prompt string) (accepted bool, supported bool, err error)
+
+// RemediationEngine enforces a RemediationPolicy around every mutating tool:
+// allow/deny lists, a mandatory dry-run preview, per-target cooldowns, an hourly
+// action budget, human confirmation via elicitation and an append-only audit trail.
+type RemediationEngine struct {
+	policy  RemediationPolicy
+	audit   AuditSink
+	confirm confirmFunc
+	now     func() time.Time
+
+	mu         sync.Mutex
+	lastAction map[string]time.Time
+	executed   []time.Time
+	previews   map[string]pendingPreview
+}
+
+// NewRemediationEngine creates an engine. A nil audit sink records to the structured log.
+func NewRemediationEngine(policy RemediationPolicy, audit AuditSink) *RemediationEngine {
+	if audit == nil {
+		audit = logAuditSink{}
+	}
+	return &RemediationEngine{
+		policy:     policy,
+		audit:      audit,
+		confirm:    elicitConfirmation,
+		now:        time.Now,
+		lastAction: make(map[string]time.Time),
+		previews:   make(map[string]pendingPreview),
+	}
+}
+
+// Run guards execute with the policy. Without a valid confirm token (or with DryRun set)
+// it only returns a preview carrying a single-use token for the real call.
+func (e *RemediationEngine) Run(ctx context.Context, req *mcp.CallToolRequest, r RemediationRequest, execute func(ctx context.Context) (interface{}, error)) (*RemediationResult, error) {
+	entry := AuditEntry{
+		Tool:      r.Tool,
+		Caller:    callerName(req),
+		Kind:      r.Target.Kind,
+		Namespace: r.Target.Namespace,
+		Name:      r.Target.Name,
+		Reason:    r.Reason,
+	}
+
+	if strings.TrimSpace(r.Reason) == "" {
+		return nil, e.deny(ctx, entry, "reason is required for mutating tools")
+	}
+	if reason := e.policyViolation(r); reason != "" {
+		return nil, e.deny(ctx, entry, reason)
+	}
+
+	result := &RemediationResult{Tool: r.Tool, Target: r.Target, Summary: r.Summary}
+	if r.DryRun || r.ConfirmToken == "" {
+		token, expires, err := e.issuePreview(r.fingerprint())
+		if err != nil {
+			return nil, err
+		}
+		result.Status = AuditOutcomePreview
+		result.ConfirmToken = token
+		result.ExpiresAt = &expires
+		result.Note = "No changes made. Call again with dry_run=false and this confirm_token to execute."
+		e.record(ctx, entry, AuditOutcomePreview, r.Summary)
+		return result, nil
+	}
+
+	if !e.consumePreview(r.ConfirmToken, r.fingerprint()) {
+		return nil, e.deny(ctx, entry, "invalid or expired confirm_token; run a dry-run preview first")
+	}
+
+	prompt := fmt.Sprintf("Approve %s?\nReason: %s", r.Summary, r.Reason)
+	accepted, supported, err := e.confirm(ctx, req, prompt)
+	switch {
+	case err != nil:
+		return nil, e.deny(ctx, entry, fmt.Sprintf("confirmation failed: %v", err))
+	case !supported && e.policy.RequireHumanConfirmation:
+		return nil, e.deny(ctx, entry, "policy requires human confirmation but the client does not support elicitation")
+	case supported && !accepted:
+		e.record(ctx, entry, AuditOutcomeDeclined, r.Summary)
+		return nil, providers.Forbiddenf("action declined by user")
+	}
+
+	if reason := e.reserve(r.cooldownKey()); reason != "" {
+		return nil, e.deny(ctx, entry, reason)
+	}
+
+	out, err := execute(ctx)
+	if err != nil {
+		e.record(ctx, entry, AuditOutcomeFailed, err.Error())
+		return nil, err
+	}
+	e.record(ctx, entry, AuditOutcomeExecuted, r.Summary)
+	result.Status = AuditOutcomeExecuted
+	result.Result = out
+	return result, nil
+}
+
+// policyViolation checks the static allow/deny lists and the current cooldown and budget.
+// unlabeledKinds are target kinds that never carry labels; label rules do not apply to them.
+var unlabeledKinds = map[string]bool{"SystemdUnit": true}
+
+func (e *RemediationEngine) policyViolation(r RemediationRequest) string {
+	p, t := e.policy, r.Target
+	if t.Namespace != "" {
+		if slices.Contains(p.DenyNamespaces, t.Namespace) {
+			return fmt.Sprintf("namespace %q is denied by policy", t.Namespace)
+		}
+		if len(p.AllowNamespaces) > 0 && !slices.Contains(p.AllowNamespaces, t.Namespace) {
+			return fmt.Sprintf("namespace %q is not in the allow list", t.Namespace)
+		}
+	}
+	if t.Kind == "Node" && len(p.AllowNodes) > 0 && !slices.Contains(p.AllowNodes, t.Name) {
+		return fmt.Sprintf("node %q is not in the allow list", t.Name)
+	}
+	if !unlabeledKinds[t.Kind] {
+		for _, sel := range p.DenyLabels {
+			if matchesLabel(t.Labels, sel) {
+				return fmt.Sprintf("target carries denied label %q", sel)
+			}
+		}
+		if len(p.AllowLabels) > 0 && !slices.ContainsFunc(p.AllowLabels, func(sel string) bool { return matchesLabel(t.Labels, sel) }) {
+			return "target matches no allowed label"
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.budgetViolationLocked(r.cooldownKey())
+}
+
+// reserve re-checks cooldown and budget and records the action atomically.
+func (e *RemediationEngine) reserve(key string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if reason := e.budgetViolationLocked(key); reason != "" {
+		return reason
+	}
+	now := e.now()
+	e.lastAction[key] = now
+	e.executed = append(e.executed, now)
+	return ""
+}
+
+func (e *RemediationEngine) budgetViolationLocked(key string) string {
+	now := e.now()
+	if last, ok := e.lastAction[key]; ok && e.policy.Cooldown > 0 {
+		if wait := last.Add(e.policy.Cooldown).Sub(now); wait > 0 {
+			return fmt.Sprintf("target is in cooldown for this action for another %s", wait.Round(time.Second))
+		}
+	}
+
+	cutoff := now.Add(-time.Hour)
+	recent := e.executed[:0]
+	for _, t := range e.executed {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	e.executed = recent
+	if e.policy.MaxActionsPerHour > 0 && len(e.executed) >= e.policy.MaxActionsPerHour {
+		return fmt.Sprintf("hourly action budget of %d exhausted", e.policy.MaxActionsPerHour)
+	}
+	return ""
+}
+
+func (e *RemediationEngine) issuePreview(fingerprint string) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate confirm token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	for t, p := range e.previews {
+		if now.After(p.expires) {
+			delete(e.previews, t)
+		}
+	}
+	expires := now.Add(e.policy.PreviewTTL)
+	e.previews[token] = pendingPreview{fingerprint: fingerprint, expires: expires}
+	return token, expires, nil
+}
+
+// consumePreview validates a token for the request fingerprint it was issued for.
+// Tokens are single-use.
+func (e *RemediationEngine) consumePreview(token, fingerprint string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p, ok := e.previews[token]
+	if !ok {
+		return false
+	}
+	delete(e.previews, token)
+	return p.fingerprint == fingerprint && !e.now().After(p.expires)
+}
+
+func (e *RemediationEngine) deny(ctx context.Context, entry AuditEntry, reason string) error {
+	e.record(ctx, entry, AuditOutcomeDenied, reason)
+	return providers.Forbiddenf("remediation denied: %s", reason)
+}
+
+func (e *RemediationEngine) record(ctx context.Context, entry AuditEntry, outcome, detail string) {
+	entry.Time = e.now().UTC()
+	entry.Outcome = outcome
+	entry.Detail = detail
+	if err := e.audit.Record(ctx, entry); err != nil {
+		telemetry.Error("failed to record remediation audit entry", "tool", entry.Tool, "outcome", outcome, "error", err)
+	}
+}
+
+// matchesLabel reports whether labels satisfy a "key=value" or "key" selector.
+func matchesLabel(labels map[string]string, selector string) bool {
+	key, value, hasValue := strings.Cut(selector, "=")
+	got, ok := labels[key]
+	if !ok {
+		return false
+	}
+	return !hasValue || got == value
+}
+
+// callerName identifies the MCP client from its initialize handshake.
+func callerName(req *mcp.CallToolRequest) string {
+	if req == nil || req.Session == nil {
+		return "unknown"
+	}
+	params := req.Session.InitializeParams()
+	if params == nil || params.ClientInfo == nil {
+		return "unknown"
+	}
+	if params.ClientInfo.Version != "" {
+		return params.ClientInfo.Name + "/" + params.ClientInfo.Version
+	}
+	return params.ClientInfo.Name
+}
+
+// elicitConfirmation asks the user through MCP elicitation when the client supports it.
+func elicitConfirmation(ctx context.Context, req *mcp.CallToolRequest, prompt string) (bool, bool, error) {
+	if req == nil || req.Session == nil {
+		return false, false, nil
+	}
+	params := req.Session.InitializeParams()
+	if params == nil || params.Capabilities == nil || params.Capabilities.Elicitation == nil {
+		return false, false, nil
+	}
+
+	res, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+		Message: prompt,
+		RequestedSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"confirm": map[string]any{"type": "boolean", "description": "Execute this action"},
+			},
+			"required": []string{"confirm"},
+		},
+	})
+	if err != nil {
+		return false, true, err
+	}
+	confirmed, _ := res.Content["confirm"].(bool)
+	return res.Action == "accept" && confirmed, true, nil
+}
@@ -0,0 +1,372 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (m *memoryAuditSink) Record(_ context.Context, e AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memoryAuditSink) outcomes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]string, 0, len(m.entries))
+	for _, e := range m.entries {
+		out = append(out, e.Outcome)
+	}
+	return out
+}
+
+func newTestEngine(policy RemediationPolicy) (*RemediationEngine, *memoryAuditSink, *time.Time) {
+	audit := &memoryAuditSink{}
+	e := NewRemediationEngine(policy, audit)
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	e.confirm = func(context.Context, *sdkmcp.CallToolRequest, string) (bool, bool, error) { return false, false, nil }
+	return e, audit, &now
+}
+
+func podRequest(ns, name string) RemediationRequest {
+	return RemediationRequest{
+		Tool:    "delete_pod",
+		Target:  RemediationTarget{Kind: "Pod", Namespace: ns, Name: name, Labels: map[string]string{"app": name}},
+		Summary: "delete pod " + ns + "/" + name,
+		Reason:  "stuck in CrashLoopBackOff",
+	}
+}
+
+// previewAndRun performs the mandatory preview and then executes with its token.
+func previewAndRun(t *testing.T, e *RemediationEngine, r RemediationRequest, execute func(context.Context) (interface{}, error)) (*RemediationResult, error) {
+	t.Helper()
+	preview, err := e.Run(context.Background(), nil, r, execute)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Status != AuditOutcomePreview || preview.ConfirmToken == "" {
+		t.Fatalf("got %+v, want preview with confirm token", preview)
+	}
+	r.ConfirmToken = preview.ConfirmToken
+	return e.Run(context.Background(), nil, r, execute)
+}
+
+func TestRemediationEngine_Policy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  func(p *RemediationPolicy)
+		req     RemediationRequest
+		wantErr string
+	}{
+		{name: "allowed by default", req: podRequest("default", "api")},
+		{name: "system namespace denied", req: podRequest("kube-system", "coredns"), wantErr: `namespace "kube-system" is denied`},
+		{
+			name:    "namespace outside allow list",
+			policy:  func(p *RemediationPolicy) { p.AllowNamespaces = []string{"apps"} },
+			req:     podRequest("default", "api"),
+			wantErr: "not in the allow list",
+		},
+		{
+			name:    "denied label",
+			policy:  func(p *RemediationPolicy) { p.DenyLabels = []string{"app=api"} },
+			req:     podRequest("default", "api"),
+			wantErr: `denied label "app=api"`,
+		},
+		{
+			name:   "allow label presence",
+			policy: func(p *RemediationPolicy) { p.AllowLabels = []string{"app"} },
+			req:    podRequest("default", "api"),
+		},
+		{
+			name:    "no allowed label",
+			policy:  func(p *RemediationPolicy) { p.AllowLabels = []string{"tier=web"} },
+			req:     podRequest("default", "api"),
+			wantErr: "matches no allowed label",
+		},
+		{
+			name:   "label allow list does not block systemd units",
+			policy: func(p *RemediationPolicy) { p.AllowLabels = []string{"tier=web"} },
+			req: RemediationRequest{
+				Tool:   "hub_restart_service",
+				Target: RemediationTarget{Kind: "SystemdUnit", Name: "grafana.service"},
+				Reason: "unresponsive",
+			},
+		},
+		{
+			name:    "node outside allow list",
+			policy:  func(p *RemediationPolicy) { p.AllowNodes = []string{"worker-1"} },
+			req:     RemediationRequest{Tool: "cordon_node", Target: RemediationTarget{Kind: "Node", Name: "worker-2"}, Direction: "cordon", Reason: "disk pressure"},
+			wantErr: `node "worker-2" is not in the allow list`,
+		},
+		{
+			name:   "namespace allow list does not apply to nodes",
+			policy: func(p *RemediationPolicy) { p.AllowNamespaces = []string{"apps"}; p.AllowNodes = []string{"worker-1"} },
+			req:    RemediationRequest{Tool: "cordon_node", Target: RemediationTarget{Kind: "Node", Name: "worker-1"}, Direction: "cordon", Reason: "disk pressure"},
+		},
+		{
+			name:    "reason is required",
+			req:     RemediationRequest{Tool: "delete_pod", Target: RemediationTarget{Kind: "Pod", Namespace: "default", Name: "api"}},
+			wantErr: "reason is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultRemediationPolicy()
+			if tt.policy != nil {
+				tt.policy(&policy)
+			}
+			e, audit, _ := newTestEngine(policy)
+			executed := false
+			res, err := e.Run(context.Background(), nil, tt.req, func(context.Context) (interface{}, error) {
+				executed = true
+				return nil, nil
+			})
+			if executed {
+				t.Fatal("a first call must never execute")
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				if got := audit.outcomes(); len(got) != 1 || got[0] != AuditOutcomeDenied {
+					t.Errorf("got audit outcomes %v, want [denied]", got)
+				}
+				return
+			}
+			if err != nil || res.Status != AuditOutcomePreview {
+				t.Fatalf("got %+v, %v, want preview", res, err)
+			}
+		})
+	}
+}
+
+func TestRemediationEngine_Execution(t *testing.T) {
+	ok := func(context.Context) (interface{}, error) { return map[string]string{"status": "deleted"}, nil }
+
+	t.Run("preview then execute with single-use token", func(t *testing.T) {
+		policy := DefaultRemediationPolicy()
+		policy.Cooldown = 0
+		e, audit, _ := newTestEngine(policy)
+		r := podRequest("default", "api")
+		preview, err := e.Run(context.Background(), nil, r, ok)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		r.ConfirmToken = preview.ConfirmToken
+		res, err := e.Run(context.Background(), nil, r, ok)
+		if err != nil || res.Status != AuditOutcomeExecuted {
+			t.Fatalf("got %+v, %v, want executed", res, err)
+		}
+
+		if _, err := e.Run(context.Background(), nil, r, ok); err == nil || !strings.Contains(err.Error(), "confirm_token") {
+			t.Errorf("got error %v, want invalid token", err)
+		}
+		want := []string{AuditOutcomePreview, AuditOutcomeExecuted, AuditOutcomeDenied}
+		if got := audit.outcomes(); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("got audit outcomes %v, want %v", got, want)
+		}
+	})
+
+	t.Run("token is bound to its target", func(t *testing.T) {
+		e, _, _ := newTestEngine(DefaultRemediationPolicy())
+		preview, _ := e.Run(context.Background(), nil, podRequest("default", "api"), ok)
+		other := podRequest("default", "worker")
+		other.ConfirmToken = preview.ConfirmToken
+		if _, err := e.Run(context.Background(), nil, other, ok); err == nil {
+			t.Error("expected token for a different target to be rejected")
+		}
+	})
+
+	t.Run("token is bound to its tool and parameters", func(t *testing.T) {
+		policy := DefaultRemediationPolicy()
+		policy.Cooldown = 0
+		e, _, _ := newTestEngine(policy)
+		graceful := podRequest("default", "api")
+		graceful.Params = map[string]any{"grace_seconds": 30}
+		preview, _ := e.Run(context.Background(), nil, graceful, ok)
+
+		for name, mutate := range map[string]func(r *RemediationRequest){
+			"other parameters": func(r *RemediationRequest) { r.Params = map[string]any{"grace_seconds": 0} },
+			"other tool":       func(r *RemediationRequest) { r.Tool = "rollout_restart" },
+		} {
+			r := graceful
+			mutate(&r)
+			r.ConfirmToken = preview.ConfirmToken
+			if _, err := e.Run(context.Background(), nil, r, ok); err == nil || !strings.Contains(err.Error(), "confirm_token") {
+				t.Errorf("%s: got error %v, want the token to be rejected", name, err)
+			}
+			// A rejected token is spent; preview again for the next case.
+			preview, _ = e.Run(context.Background(), nil, graceful, ok)
+		}
+
+		graceful.ConfirmToken = preview.ConfirmToken
+		if res, err := e.Run(context.Background(), nil, graceful, ok); err != nil || res.Status != AuditOutcomeExecuted {
+			t.Errorf("got %+v, %v, want the previewed parameters to execute", res, err)
+		}
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		e, _, now := newTestEngine(DefaultRemediationPolicy())
+		r := podRequest("default", "api")
+		preview, _ := e.Run(context.Background(), nil, r, ok)
+		*now = now.Add(11 * time.Minute)
+		r.ConfirmToken = preview.ConfirmToken
+		if _, err := e.Run(context.Background(), nil, r, ok); err == nil {
+			t.Error("expected expired token to be rejected")
+		}
+	})
+
+	t.Run("cooldown per target", func(t *testing.T) {
+		e, _, now := newTestEngine(DefaultRemediationPolicy())
+		if _, err := previewAndRun(t, e, podRequest("default", "api"), ok); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := e.Run(context.Background(), nil, podRequest("default", "api"), ok); err == nil || !strings.Contains(err.Error(), "cooldown") {
+			t.Errorf("got error %v, want cooldown", err)
+		}
+		if _, err := previewAndRun(t, e, podRequest("default", "worker"), ok); err != nil {
+			t.Errorf("other targets must not be in cooldown: %v", err)
+		}
+		*now = now.Add(11 * time.Minute)
+		if _, err := previewAndRun(t, e, podRequest("default", "api"), ok); err != nil {
+			t.Errorf("expected cooldown to elapse: %v", err)
+		}
+	})
+
+	t.Run("hourly budget", func(t *testing.T) {
+		policy := DefaultRemediationPolicy()
+		policy.MaxActionsPerHour = 2
+		e, _, now := newTestEngine(policy)
+		for _, name := range []string{"a", "b"} {
+			if _, err := previewAndRun(t, e, podRequest("default", name), ok); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if _, err := e.Run(context.Background(), nil, podRequest("default", "c"), ok); err == nil || !strings.Contains(err.Error(), "budget") {
+			t.Errorf("got error %v, want budget exhausted", err)
+		}
+		*now = now.Add(61 * time.Minute)
+		if _, err := previewAndRun(t, e, podRequest("default", "c"), ok); err != nil {
+			t.Errorf("expected budget to recover after an hour: %v", err)
+		}
+	})
+
+	t.Run("failed execution is audited", func(t *testing.T) {
+		e, audit, _ := newTestEngine(DefaultRemediationPolicy())
+		_, err := previewAndRun(t, e, podRequest("default", "api"), func(context.Context) (interface{}, error) {
+			return nil, errors.New("forbidden")
+		})
+		if err == nil {
+			t.Fatal("expected execution error")
+		}
+		if got := audit.outcomes(); got[len(got)-1] != AuditOutcomeFailed {
+			t.Errorf("got audit outcomes %v, want last to be failed", got)
+		}
+	})
+}
+
+func TestRemediationEngine_Confirmation(t *testing.T) {
+	ok := func(context.Context) (interface{}, error) { return "done", nil }
+	tests := []struct {
+		name      string
+		requireHI bool
+		accepted  bool
+		supported bool
+		wantErr   string
+		wantAudit string
+	}{
+		{name: "accepted by user", accepted: true, supported: true, wantAudit: AuditOutcomeExecuted},
+		{name: "declined by user", supported: true, wantErr: "declined", wantAudit: AuditOutcomeDeclined},
+		{name: "unsupported client proceeds", wantAudit: AuditOutcomeExecuted},
+		{name: "unsupported client with required confirmation", requireHI: true, wantErr: "requires human confirmation", wantAudit: AuditOutcomeDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultRemediationPolicy()
+			policy.RequireHumanConfirmation = tt.requireHI
+			e, audit, _ := newTestEngine(policy)
+			e.confirm = func(context.Context, *sdkmcp.CallToolRequest, string) (bool, bool, error) {
+				return tt.accepted, tt.supported, nil
+			}
+			_, err := previewAndRun(t, e, podRequest("default", "api"), ok)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if got := audit.outcomes(); got[len(got)-1] != tt.wantAudit {
+				t.Errorf("got audit outcomes %v, want last to be %s", got, tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestFileAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		l, err := NewFileAuditLog(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := l.Record(context.Background(), AuditEntry{Tool: "delete_pod", Name: "api", Outcome: AuditOutcomeExecuted}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		l.Close()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Tool != "delete_pod" {
+			t.Errorf("got line %q, want delete_pod audit entry", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("got %d lines, want 2 (reopening must append)", lines)
+	}
+}
+
+func TestLoadRemediationPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	content := "allow_namespaces: [apps]\ncooldown: 5m\nmax_actions_per_hour: 3\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	p, err := LoadRemediationPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Cooldown != 5*time.Minute || p.MaxActionsPerHour != 3 || len(p.AllowNamespaces) != 1 {
+		t.Errorf("got %+v, want parsed overrides", p)
+	}
+	if len(p.DenyNamespaces) == 0 || p.PreviewTTL != 10*time.Minute {
+		t.Errorf("got %+v, want unset fields to keep defaults", p)
+	}
+}
@@ -0,0 +1,451 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"observability-hub/internal/db/postgres"
+	"observability-hub/internal/mcp/providers"
+	"observability-hub/internal/telemetry"
+)
+
+// TableToolCalls holds the agent session audit trail.
+const TableToolCalls = "mcp_tool_calls"
+
+// Tool call statuses, as reported in mcp_tool_calls_total.
+const (
+	ToolCallSuccess = "success"
+	ToolCallError   = "error"
+)
+
+// ToolCallRecord is one tool call in the agent session audit trail.
+type ToolCallRecord struct {
+	Time        time.Time       `json:"time"`
+	SessionID   string          `json:"session_id"`
+	Tool        string          `json:"tool"`
+	Service     string          `json:"service"`
+	Input       json.RawMessage `json:"input"` // sanitized, see sanitizeToolInput
+	OutputBytes int             `json:"output_bytes"`
+	Status      string          `json:"status"`
+	ErrorClass  string          `json:"error_class,omitempty"`
+	DurationMs  int64           `json:"duration_ms"`
+	TraceID     string          `json:"trace_id,omitempty"`
+}
+
+// ToolCallFilter narrows RecentToolCalls. Empty fields match everything.
+type ToolCallFilter struct {
+	SessionID string
+	Tool      string
+	Status    string
+	Since     time.Time
+	Limit     int
+}
+
+// ToolCallStore persists tool call records and serves them back, newest first.
+type ToolCallStore interface {
+	RecordToolCall(ctx context.Context, record ToolCallRecord) error
+	RecentToolCalls(ctx context.Context, filter ToolCallFilter) ([]ToolCallRecord, error)
+}
+
+// toolCallAudit is what InstrumentHandler needs to record a call: where, and the session to
+// attribute it to when the transport has no session ID of its own (stdio).
+type toolCallAudit struct {
+	store   ToolCallStore
+	session string
+}
+
+type toolCallAuditKey struct{}
+
+func withToolCallAudit(ctx context.Context, audit toolCallAudit) context.Context {
+	if audit.store == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, toolCallAuditKey{}, audit)
+}
+
+func toolCallAuditFrom(ctx context.Context) (toolCallAudit, bool) {
+	audit, ok := ctx.Value(toolCallAuditKey{}).(toolCallAudit)
+	return audit, ok
+}
+
+// newProcessSessionID identifies this gateway process. A stdio gateway serves a single agent
+// session, so the process ID stands in for the session ID the transport does not have.
+func newProcessSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("pid-%d", time.Now().UnixNano())
+	}
+	return "stdio-" + hex.EncodeToString(b)
+}
+
+// sessionID returns the transport's session ID, or fallback when it has none.
+func sessionID(req *mcp.CallToolRequest, fallback string) string {
+	if req != nil && req.Session != nil {
+		if id := req.Session.ID(); id != "" {
+			return id
+		}
+	}
+	return fallback
+}
+
+// maxRecordedStringBytes caps each string in a recorded input, so a pasted manifest or log
+// excerpt does not bloat the audit trail.
+const maxRecordedStringBytes = 512
+
+// sanitizeToolInput renders input as JSON with values of sensitive keys (telemetry.SensitiveKeys,
+// matched as substrings, e.g. "bearer_token") redacted and long strings cut.
+func sanitizeToolInput(input any) json.RawMessage {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return json.RawMessage(`{}`)
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return json.RawMessage(`{}`)
+	}
+	sanitized, err := json.Marshal(sanitizeValue(value))
+	if err != nil || string(sanitized) == "null" {
+		return json.RawMessage(`{}`)
+	}
+	return sanitized
+}
+
+func sanitizeValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitiveKey(key) {
+				v[key] = "[REDACTED]"
+				continue
+			}
+			v[key] = sanitizeValue(field)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = sanitizeValue(item)
+		}
+		return v
+	case string:
+		if len(v) <= maxRecordedStringBytes {
+			return v
+		}
+		return fmt.Sprintf("%s… (%d bytes)", strings.ToValidUTF8(v[:maxRecordedStringBytes], ""), len(v))
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range telemetry.SensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentBytes is the size of the text returned to the agent.
+func contentBytes(res *mcp.CallToolResult) int {
+	if res == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range res.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			n += len(text.Text)
+		}
+	}
+	return n
+}
+
+// recordToolCall stores record without failing the call: the agent already has its answer,
+// and a database hiccup must not turn it into an error.
+func recordToolCall(ctx context.Context, store ToolCallStore, record ToolCallRecord) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := store.RecordToolCall(ctx, record); err != nil {
+		telemetry.Warn("failed to record tool call", "tool", record.Tool, "error", err)
+	}
+}
+
+// toolCallWriteTimeout bounds one write of the background writer.
+const toolCallWriteTimeout = 5 * time.Second
+
+// ErrToolCallBufferFull is returned by AsyncToolCallStore when a record is dropped.
+var ErrToolCallBufferFull = errors.New("tool call buffer full, record dropped")
+
+// AsyncToolCallStore queues records in a bounded buffer drained by a background writer, so a
+// slow database never delays a tool call. When the buffer is full the record is dropped and
+// counted in mcp_tool_call_records_dropped_total. Reads go straight to the wrapped store.
+type AsyncToolCallStore struct {
+	store   ToolCallStore
+	records chan ToolCallRecord
+	done    chan struct{}
+
+	mu     sync.RWMutex // guards closed against sends on a closed channel
+	closed bool
+}
+
+// NewAsyncToolCallStore starts a writer for store buffering up to size records.
+// Call Close to write what is still buffered.
+func NewAsyncToolCallStore(store ToolCallStore, size int) *AsyncToolCallStore {
+	initTelemetry()
+	s := &AsyncToolCallStore{store: store, records: make(chan ToolCallRecord, size), done: make(chan struct{})}
+	go s.run()
+	return s
+}
+
+func (s *AsyncToolCallStore) run() {
+	defer close(s.done)
+	for record := range s.records {
+		ctx, cancel := context.WithTimeout(context.Background(), toolCallWriteTimeout)
+		if err := s.store.RecordToolCall(ctx, record); err != nil {
+			telemetry.Warn("failed to record tool call", "tool", record.Tool, "error", err)
+		}
+		cancel()
+	}
+}
+
+// RecordToolCall queues record without waiting for it to be written.
+func (s *AsyncToolCallStore) RecordToolCall(ctx context.Context, record ToolCallRecord) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.closed {
+		select {
+		case s.records <- record:
+			return nil
+		default:
+		}
+	}
+	telemetry.AddInt64Counter(ctx, toolCallsDropped, 1, telemetry.StringAttribute("tool", record.Tool))
+	return ErrToolCallBufferFull
+}
+
+// RecentToolCalls reads from the wrapped store. Records still buffered are not included.
+func (s *AsyncToolCallStore) RecentToolCalls(ctx context.Context, filter ToolCallFilter) ([]ToolCallRecord, error) {
+	return s.store.RecentToolCalls(ctx, filter)
+}
+
+// Close stops accepting records and waits for the buffered ones to be written.
+func (s *AsyncToolCallStore) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.records)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+// PostgresToolCallStore keeps the agent session audit trail in Postgres.
+type PostgresToolCallStore struct {
+	wrapper *postgres.PostgresWrapper
+}
+
+// NewPostgresToolCallStore returns a store writing through w. Call EnsureSchema before use.
+func NewPostgresToolCallStore(w *postgres.PostgresWrapper) *PostgresToolCallStore {
+	return &PostgresToolCallStore{wrapper: w}
+}
+
+// EnsureSchema creates the tool call table and its indexes.
+func (s *PostgresToolCallStore) EnsureSchema(ctx context.Context) error {
+	q := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGSERIAL PRIMARY KEY,
+		time TIMESTAMPTZ NOT NULL,
+		session_id TEXT NOT NULL,
+		tool TEXT NOT NULL,
+		service TEXT NOT NULL,
+		input JSONB NOT NULL DEFAULT '{}',
+		output_bytes INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		error_class TEXT NOT NULL DEFAULT '',
+		duration_ms BIGINT NOT NULL,
+		trace_id TEXT NOT NULL DEFAULT ''
+	);`, TableToolCalls)
+	if _, err := s.wrapper.Exec(ctx, "db.ensure_mcp_tool_calls", q); err != nil {
+		return fmt.Errorf("failed to create %s: %w", TableToolCalls, err)
+	}
+
+	qTime := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_time ON %s (time DESC);", TableToolCalls)
+	if _, err := s.wrapper.Exec(ctx, "db.ensure_mcp_tool_calls_time_idx", qTime); err != nil {
+		return fmt.Errorf("failed to index %s: %w", TableToolCalls, err)
+	}
+	qSession := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_session ON %s (session_id, time DESC);", TableToolCalls)
+	if _, err := s.wrapper.Exec(ctx, "db.ensure_mcp_tool_calls_session_idx", qSession); err != nil {
+		return fmt.Errorf("failed to index %s: %w", TableToolCalls, err)
+	}
+	return nil
+}
+
+// RecordToolCall inserts one record.
+func (s *PostgresToolCallStore) RecordToolCall(ctx context.Context, r ToolCallRecord) error {
+	q := fmt.Sprintf(`INSERT INTO %s (time, session_id, tool, service, input, output_bytes, status, error_class, duration_ms, trace_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, TableToolCalls)
+	input := string(r.Input)
+	if input == "" {
+		input = "{}"
+	}
+	_, err := s.wrapper.Exec(ctx, "db.insert_mcp_tool_call", q,
+		r.Time, r.SessionID, r.Tool, r.Service, input, r.OutputBytes, r.Status, r.ErrorClass, r.DurationMs, r.TraceID)
+	if err != nil {
+		return fmt.Errorf("failed to insert tool call: %w", err)
+	}
+	return nil
+}
+
+// RecentToolCalls returns the records matching filter, newest first.
+func (s *PostgresToolCallStore) RecentToolCalls(ctx context.Context, f ToolCallFilter) ([]ToolCallRecord, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.SessionID != "" {
+		add("session_id = $%d", f.SessionID)
+	}
+	if f.Tool != "" {
+		add("tool = $%d", f.Tool)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if !f.Since.IsZero() {
+		add("time >= $%d", f.Since)
+	}
+	q := fmt.Sprintf(`SELECT time, session_id, tool, service, input, output_bytes, status, error_class, duration_ms, trace_id FROM %s`, TableToolCalls)
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit)
+	q += fmt.Sprintf(" ORDER BY time DESC LIMIT $%d", len(args))
+
+	rows, err := s.wrapper.Query(ctx, "db.list_mcp_tool_calls", q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tool calls: %w", err)
+	}
+	defer rows.Close()
+
+	records := []ToolCallRecord{}
+	for rows.Next() {
+		var r ToolCallRecord
+		var input []byte
+		if err := rows.Scan(&r.Time, &r.SessionID, &r.Tool, &r.Service, &input, &r.OutputBytes,
+			&r.Status, &r.ErrorClass, &r.DurationMs, &r.TraceID); err != nil {
+			return nil, fmt.Errorf("failed to scan tool call: %w", err)
+		}
+		r.Input = json.RawMessage(input)
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tool calls: %w", err)
+	}
+	return records, nil
+}
+
+// Prune deletes records older than before and returns how many were removed.
+func (s *PostgresToolCallStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	q := fmt.Sprintf("DELETE FROM %s WHERE time < $1", TableToolCalls)
+	res, err := s.wrapper.Exec(ctx, "db.prune_mcp_tool_calls", q, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune tool calls: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// RunRetention prunes records older than retention now and then every interval, until ctx
+// is cancelled.
+func (s *PostgresToolCallStore) RunRetention(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.Prune(ctx, time.Now().Add(-retention)); err != nil {
+			telemetry.Warn("tool call retention failed", "error", err)
+		} else if n > 0 {
+			telemetry.Info("pruned tool call records", "deleted", n, "retention", retention.String())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Limits of list_recent_tool_calls.
+const (
+	defaultToolCallHours = 24
+	maxToolCallHours     = 24 * 90
+	defaultToolCallLimit = 50
+	maxToolCallLimit     = 500
+)
+
+// ListRecentToolCallsInput is the input for list_recent_tool_calls.
+type ListRecentToolCallsInput struct {
+	SessionID string `json:"session_id,omitempty"` // only calls of this agent session
+	Tool      string `json:"tool,omitempty"`       // only calls of this tool, e.g. delete_pod
+	Status    string `json:"status,omitempty"`     // success or error
+	Hours     int    `json:"hours,omitempty"`      // lookback window in hours (default 24, max 2160)
+	Limit     int    `json:"limit,omitempty"`      // max records, newest first (default 50, max 500)
+}
+
+// ToolCallsReport is the output of list_recent_tool_calls.
+type ToolCallsReport struct {
+	Count int              `json:"count"`
+	Calls []ToolCallRecord `json:"calls"`
+}
+
+// RegisterToolCallTools registers list_recent_tool_calls, which reads the audit trail in store.
+func RegisterToolCallTools(registry *ToolRegistry, store ToolCallStore, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_recent_tool_calls",
+			Description: "List recent MCP tool calls from the audit trail (session, tool, sanitized input, output size, status, duration, trace ID), newest first, to review what an agent did during an incident",
+		}, handleListRecentToolCalls(store, serviceName, time.Now)),
+	)
+}
+
+func handleListRecentToolCalls(store ToolCallStore, serviceName string, now func() time.Time) mcp.ToolHandlerFor[ListRecentToolCallsInput, any] {
+	return InstrumentHandler("list_recent_tool_calls", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input ListRecentToolCallsInput) (*mcp.CallToolResult, any, error) {
+		if input.Status != "" && input.Status != ToolCallSuccess && input.Status != ToolCallError {
+			return nil, nil, providers.InvalidInputf("status must be %q or %q", ToolCallSuccess, ToolCallError)
+		}
+		if input.Hours <= 0 {
+			input.Hours = defaultToolCallHours
+		}
+		if input.Hours > maxToolCallHours {
+			input.Hours = maxToolCallHours
+		}
+		if input.Limit <= 0 {
+			input.Limit = defaultToolCallLimit
+		}
+		if input.Limit > maxToolCallLimit {
+			input.Limit = maxToolCallLimit
+		}
+
+		calls, err := store.RecentToolCalls(ctx, ToolCallFilter{
+			SessionID: input.SessionID,
+			Tool:      input.Tool,
+			Status:    input.Status,
+			Since:     now().Add(-time.Duration(input.Hours) * time.Hour),
+			Limit:     input.Limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		text, _ := json.Marshal(ToolCallsReport{Count: len(calls), Calls: calls})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,302 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"observability-hub/internal/db/postgres"
+	"observability-hub/internal/mcp/providers"
+)
+
+// memoryToolCallStore keeps records in memory and remembers the last filter it was asked for.
+type memoryToolCallStore struct {
+	mu      sync.Mutex
+	records []ToolCallRecord
+	filter  ToolCallFilter
+}
+
+func (s *memoryToolCallStore) RecordToolCall(_ context.Context, r ToolCallRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *memoryToolCallStore) RecentToolCalls(_ context.Context, f ToolCallFilter) ([]ToolCallRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = f
+	return append([]ToolCallRecord{}, s.records...), nil
+}
+
+func TestSanitizeToolInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+		want  string
+	}{
+		{
+			name: "plain fields kept",
+			input: struct {
+				Service string `json:"service"`
+				Hours   int    `json:"hours"`
+			}{"proxy", 2},
+			want: `{"hours":2,"service":"proxy"}`,
+		},
+		{
+			name:  "sensitive keys redacted, nested too",
+			input: map[string]any{"bearer_token": "abc", "headers": map[string]any{"Authorization": "Basic x"}, "query": "up"},
+			want:  `{"bearer_token":"[REDACTED]","headers":{"Authorization":"[REDACTED]"},"query":"up"}`,
+		},
+		{
+			name:  "long strings cut",
+			input: map[string]string{"query": strings.Repeat("a", maxRecordedStringBytes+10)},
+			want:  `{"query":"` + strings.Repeat("a", maxRecordedStringBytes) + `… (522 bytes)"}`,
+		},
+		{
+			name:  "empty input",
+			input: struct{}{},
+			want:  `{}`,
+		},
+		{
+			name:  "nil input",
+			input: nil,
+			want:  `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(sanitizeToolInput(tt.input)); got != tt.want {
+				t.Errorf("sanitizeToolInput() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstrumentHandler_RecordsToolCalls(t *testing.T) {
+	type input struct {
+		Service  string `json:"service"`
+		Password string `json:"password"`
+	}
+	store := &memoryToolCallStore{}
+	ctx := withToolCallAudit(context.Background(), toolCallAudit{store: store, session: "stdio-test"})
+
+	ok := InstrumentHandler("get_thing", "mcp.test", func(ctx context.Context, _ *mcp.CallToolRequest, _ input) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "12345"}}}, nil, nil
+	})
+	failing := InstrumentHandler("get_thing", "mcp.test", func(ctx context.Context, _ *mcp.CallToolRequest, _ input) (*mcp.CallToolResult, any, error) {
+		return nil, nil, providers.NotFoundf("no such thing")
+	})
+	ok(ctx, &mcp.CallToolRequest{}, input{Service: "proxy", Password: "hunter2"})
+	failing(ctx, nil, input{Service: "ghost"})
+
+	if len(store.records) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(store.records))
+	}
+	first, second := store.records[0], store.records[1]
+	if first.SessionID != "stdio-test" || first.Tool != "get_thing" || first.Service != "mcp.test" ||
+		first.Status != ToolCallSuccess || first.OutputBytes != 5 || first.Time.IsZero() {
+		t.Errorf("success record = %+v", first)
+	}
+	if string(first.Input) != `{"password":"[REDACTED]","service":"proxy"}` {
+		t.Errorf("success record input = %s, want the password redacted", first.Input)
+	}
+	if second.Status != ToolCallError || second.ErrorClass != providers.ErrorClassNotFound || second.OutputBytes == 0 {
+		t.Errorf("error record = %+v, want a not_found error with the ToolError size", second)
+	}
+
+	// Without a store in the context nothing is recorded.
+	ok(context.Background(), nil, input{})
+	if len(store.records) != 2 {
+		t.Errorf("recorded %d calls without a store, want 2", len(store.records))
+	}
+}
+
+// gatedToolCallStore blocks every write until release is closed, reporting each write on started.
+type gatedToolCallStore struct {
+	memoryToolCallStore
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *gatedToolCallStore) RecordToolCall(ctx context.Context, r ToolCallRecord) error {
+	s.started <- struct{}{}
+	<-s.release
+	return s.memoryToolCallStore.RecordToolCall(ctx, r)
+}
+
+func TestAsyncToolCallStore(t *testing.T) {
+	const size = 3
+	store := &gatedToolCallStore{started: make(chan struct{}, 10), release: make(chan struct{})}
+	async := NewAsyncToolCallStore(store, size)
+	ctx := context.Background()
+
+	// The writer holds the first record while the next ones fill the buffer.
+	if err := async.RecordToolCall(ctx, ToolCallRecord{Tool: "t0"}); err != nil {
+		t.Fatalf("RecordToolCall: %v", err)
+	}
+	<-store.started
+	start := time.Now()
+	for i := 1; i <= size; i++ {
+		if err := async.RecordToolCall(ctx, ToolCallRecord{Tool: "t"}); err != nil {
+			t.Fatalf("RecordToolCall %d: %v", i, err)
+		}
+	}
+	if err := async.RecordToolCall(ctx, ToolCallRecord{Tool: "dropped"}); err != ErrToolCallBufferFull {
+		t.Errorf("RecordToolCall on a full buffer = %v, want ErrToolCallBufferFull", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("queueing took %s while the store was blocked", elapsed)
+	}
+
+	close(store.release)
+	async.Close()
+	if len(store.records) != size+1 {
+		t.Errorf("wrote %d records, want %d", len(store.records), size+1)
+	}
+	if err := async.RecordToolCall(ctx, ToolCallRecord{Tool: "late"}); err != ErrToolCallBufferFull {
+		t.Errorf("RecordToolCall after Close = %v, want ErrToolCallBufferFull", err)
+	}
+}
+
+func TestPostgresToolCallStore(t *testing.T) {
+	mock, cleanup := postgres.NewMockDB(t)
+	defer cleanup()
+	store := NewPostgresToolCallStore(mock.Wrapper())
+	ctx := context.Background()
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+
+	mock.ExpectTableCreation(TableToolCalls)
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_time").WillReturnResult(mock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_session").WillReturnResult(mock.NewResult(0, 0))
+	if err := store.EnsureSchema(ctx); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+
+	record := ToolCallRecord{Time: now, SessionID: "s1", Tool: "delete_pod", Service: "mcp.pods",
+		Input: json.RawMessage(`{"name":"proxy-0"}`), OutputBytes: 42, Status: ToolCallSuccess, DurationMs: 12, TraceID: "abc"}
+	mock.ExpectExec("INSERT INTO "+TableToolCalls).
+		WithArgs(now, "s1", "delete_pod", "mcp.pods", `{"name":"proxy-0"}`, 42, ToolCallSuccess, "", int64(12), "abc").
+		WillReturnResult(mock.NewResult(1, 1))
+	if err := store.RecordToolCall(ctx, record); err != nil {
+		t.Fatalf("RecordToolCall: %v", err)
+	}
+
+	since := now.Add(-time.Hour)
+	columns := []string{"time", "session_id", "tool", "service", "input", "output_bytes", "status", "error_class", "duration_ms", "trace_id"}
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE session_id = $1 AND status = $2 AND time >= $3 ORDER BY time DESC LIMIT $4")).
+		WithArgs("s1", ToolCallSuccess, since, 10).
+		WillReturnRows(mock.NewRows(columns).AddRow(now, "s1", "delete_pod", "mcp.pods", []byte(`{"name":"proxy-0"}`), 42, ToolCallSuccess, "", 12, "abc"))
+	got, err := store.RecentToolCalls(ctx, ToolCallFilter{SessionID: "s1", Status: ToolCallSuccess, Since: since, Limit: 10})
+	if err != nil {
+		t.Fatalf("RecentToolCalls: %v", err)
+	}
+	if len(got) != 1 || got[0].Tool != "delete_pod" || string(got[0].Input) != `{"name":"proxy-0"}` || got[0].TraceID != "abc" {
+		t.Errorf("RecentToolCalls() = %+v", got)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM " + TableToolCalls + " WHERE time < $1")).
+		WithArgs(since).
+		WillReturnResult(mock.NewResult(0, 3))
+	if n, err := store.Prune(ctx, since); err != nil || n != 3 {
+		t.Errorf("Prune() = %d, %v, want 3", n, err)
+	}
+
+	if err := mock.Mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListRecentToolCalls(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		input      ListRecentToolCallsInput
+		wantFilter ToolCallFilter
+		wantClass  string
+	}{
+		{
+			name:       "defaults",
+			input:      ListRecentToolCallsInput{},
+			wantFilter: ToolCallFilter{Since: now.Add(-24 * time.Hour), Limit: defaultToolCallLimit},
+		},
+		{
+			name:       "filters and caps",
+			input:      ListRecentToolCallsInput{SessionID: "s1", Tool: "delete_pod", Status: ToolCallError, Hours: 100000, Limit: 100000},
+			wantFilter: ToolCallFilter{SessionID: "s1", Tool: "delete_pod", Status: ToolCallError, Since: now.Add(-maxToolCallHours * time.Hour), Limit: maxToolCallLimit},
+		},
+		{
+			name:      "invalid status",
+			input:     ListRecentToolCallsInput{Status: "failed"},
+			wantClass: providers.ErrorClassValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryToolCallStore{records: []ToolCallRecord{{Tool: "delete_pod", Status: ToolCallError}}}
+			h := handleListRecentToolCalls(store, "mcp.audit", func() time.Time { return now })
+			res, _, err := h(context.Background(), nil, tt.input)
+			if err != nil {
+				t.Fatalf("handler error = %v", err)
+			}
+			text := res.Content[0].(*mcp.TextContent).Text
+			if tt.wantClass != "" {
+				var toolErr ToolError
+				if err := json.Unmarshal([]byte(text), &toolErr); err != nil || toolErr.Class != tt.wantClass {
+					t.Errorf("result = %s, want class %s", text, tt.wantClass)
+				}
+				return
+			}
+			if store.filter != tt.wantFilter {
+				t.Errorf("filter = %+v, want %+v", store.filter, tt.wantFilter)
+			}
+			var report ToolCallsReport
+			if err := json.Unmarshal([]byte(text), &report); err != nil || report.Count != 1 {
+				t.Errorf("result = %s, want one call", text)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_UseToolCallStore(t *testing.T) {
+	store := &memoryToolCallStore{}
+	registry := NewToolRegistry(mcp.NewServer(&mcp.Implementation{Name: "test"}, nil), DefaultToolsConfig())
+	registry.UseToolCallStore(store)
+	RegisterToolCallTools(registry, store, "mcp.audit")
+
+	ctx := context.Background()
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil)
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := registry.Server().Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "list_recent_tool_calls", Arguments: map[string]any{"tool": "x"}}); err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(store.records))
+	}
+	if id := store.records[0].SessionID; id == "" || id != store.records[1].SessionID {
+		t.Errorf("session IDs = %q, %q, want the same non-empty ID", id, store.records[1].SessionID)
+	}
+	if got := string(store.records[0].Input); got != `{"tool":"x"}` {
+		t.Errorf("recorded input = %s", got)
+	}
+}
@@ -0,0 +1,310 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"gopkg.in/yaml.v3"
+
+	"observability-hub/internal/mcp/providers"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+// Default per-tool limits, applied unless the tools config overrides them.
+const (
+	DefaultToolTimeout        = 2 * time.Minute
+	DefaultToolMaxOutputBytes = 1 << 20
+)
+
+// ToolLimits bounds a single tool call.
+type ToolLimits struct {
+	// Timeout cancels the call's context; 0 disables the deadline.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxOutputBytes caps the text returned to the agent; 0 disables the cap.
+	MaxOutputBytes int `yaml:"max_output_bytes"`
+}
+
+// ToolConfig overrides the registry defaults for one tool. Zero limits keep the defaults.
+type ToolConfig struct {
+	Enabled        *bool         `yaml:"enabled"`
+	Timeout        time.Duration `yaml:"timeout"`
+	MaxOutputBytes int           `yaml:"max_output_bytes"`
+	// Live makes every call bypass the telemetry response cache, as if it passed live: true.
+	Live bool `yaml:"live"`
+}
+
+// ToolsConfig decides which tools are registered and how each call is bounded.
+//
+// Domains are provider service names without the "mcp." prefix (telemetry, pods, hub, ...).
+// A tool is registered when its domain is not disabled, it is not disabled itself, and it is
+// not mutating while ReadOnly is set.
+type ToolsConfig struct {
+	// ReadOnly skips every mutating tool (delete_pod, rollout_restart, hub_restart_service, ...).
+	ReadOnly bool                  `yaml:"read_only"`
+	Defaults ToolLimits            `yaml:"defaults"`
+	Domains  map[string]bool       `yaml:"domains"`
+	Tools    map[string]ToolConfig `yaml:"tools"`
+}
+
+// DefaultToolsConfig enables every tool with the default limits.
+func DefaultToolsConfig() ToolsConfig {
+	return ToolsConfig{Defaults: ToolLimits{Timeout: DefaultToolTimeout, MaxOutputBytes: DefaultToolMaxOutputBytes}}
+}
+
+// LoadToolsConfig reads a tools config from a YAML file. Unset fields keep their defaults.
+func LoadToolsConfig(path string) (ToolsConfig, error) {
+	config := DefaultToolsConfig()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read tools config: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("failed to parse tools config: %w", err)
+	}
+	for name, tc := range config.Tools {
+		if tc.Timeout < 0 || tc.MaxOutputBytes < 0 {
+			return config, fmt.Errorf("tool %s: timeout and max_output_bytes must not be negative", name)
+		}
+	}
+	if config.Defaults.Timeout < 0 || config.Defaults.MaxOutputBytes < 0 {
+		return config, fmt.Errorf("defaults: timeout and max_output_bytes must not be negative")
+	}
+	return config, nil
+}
+
+// Tool states reported by list_capabilities.
+const (
+	ToolEnabled          = "enabled"
+	ToolDisabled         = "disabled"
+	ToolDisabledDomain   = "domain_disabled"
+	ToolDisabledReadOnly = "read_only"
+)
+
+// Capability describes one tool known to the registry, registered or not.
+type Capability struct {
+	Name           string `json:"name"`
+	Domain         string `json:"domain"`
+	Mutating       bool   `json:"mutating"`
+	State          string `json:"state"`
+	Timeout        string `json:"timeout,omitempty"` // e.g. "2m0s"; only set for enabled tools
+	MaxOutputBytes int    `json:"max_output_bytes,omitempty"`
+}
+
+// ToolDefinition is a tool declared by a Register*Tools function, not yet added to a server.
+type ToolDefinition struct {
+	Tool     *mcp.Tool
+	Mutating bool
+	// Timeout is the tool's own default deadline for tools that wait longer than the registry
+	// default allows (e.g. for a rollout). A per-tool config timeout still takes precedence.
+	Timeout time.Duration
+	add     func(server *mcp.Server, tool *mcp.Tool, prepare func(context.Context) context.Context)
+}
+
+// withTimeout sets the tool's default deadline, see ToolDefinition.Timeout.
+func (d ToolDefinition) withTimeout(timeout time.Duration) ToolDefinition {
+	d.Timeout = timeout
+	return d
+}
+
+// readOnlyTool declares a tool that only reads state.
+func readOnlyTool[I any](tool *mcp.Tool, handler mcp.ToolHandlerFor[I, any]) ToolDefinition {
+	return ToolDefinition{Tool: tool, add: func(server *mcp.Server, tool *mcp.Tool, prepare func(context.Context) context.Context) {
+		mcp.AddTool(server, tool, func(ctx context.Context, req *mcp.CallToolRequest, input I) (*mcp.CallToolResult, any, error) {
+			return handler(prepare(ctx), req, input)
+		})
+	}}
+}
+
+// mutatingTool declares a tool that changes the cluster or host; it is skipped in read-only mode.
+func mutatingTool[I any](tool *mcp.Tool, handler mcp.ToolHandlerFor[I, any]) ToolDefinition {
+	def := readOnlyTool(tool, handler)
+	def.Mutating = true
+	return def
+}
+
+// ToolRegistry adds declared tools to an MCP server according to a ToolsConfig and remembers
+// what it registered, so startup logs and list_capabilities report the real tool set.
+type ToolRegistry struct {
+	server *mcp.Server
+	config ToolsConfig
+	audit  toolCallAudit
+
+	mu    sync.Mutex
+	tools []Capability
+}
+
+// NewToolRegistry returns a registry adding tools to server.
+func NewToolRegistry(server *mcp.Server, config ToolsConfig) *ToolRegistry {
+	return &ToolRegistry{server: server, config: config, audit: toolCallAudit{session: newProcessSessionID()}}
+}
+
+// UseToolCallStore records every call of the tools registered afterwards to store.
+func (r *ToolRegistry) UseToolCallStore(store ToolCallStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.audit.store = store
+}
+
+// Server returns the MCP server tools are added to, for registering resources and prompts.
+func (r *ToolRegistry) Server() *mcp.Server {
+	return r.server
+}
+
+// Register adds the enabled tools among defs under the given provider service name
+// (e.g. "mcp.pods") and returns how many were added.
+func (r *ToolRegistry) Register(serviceName string, defs ...ToolDefinition) int {
+	domain := strings.TrimPrefix(serviceName, "mcp.")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added, skipped := 0, 0
+	for _, def := range defs {
+		tc := r.config.Tools[def.Tool.Name]
+		limits := r.config.Defaults
+		switch {
+		case tc.Timeout > 0:
+			limits.Timeout = tc.Timeout
+		case limits.Timeout > 0 && def.Timeout > limits.Timeout:
+			limits.Timeout = def.Timeout
+		}
+		if tc.MaxOutputBytes > 0 {
+			limits.MaxOutputBytes = tc.MaxOutputBytes
+		}
+
+		domainEnabled, listed := r.config.Domains[domain]
+		capability := Capability{Name: def.Tool.Name, Domain: domain, Mutating: def.Mutating, State: ToolEnabled}
+		switch {
+		case tc.Enabled != nil && !*tc.Enabled:
+			capability.State = ToolDisabled
+		case listed && !domainEnabled:
+			capability.State = ToolDisabledDomain
+		case def.Mutating && r.config.ReadOnly:
+			capability.State = ToolDisabledReadOnly
+		}
+		if capability.State != ToolEnabled {
+			r.tools = append(r.tools, capability)
+			skipped++
+			continue
+		}
+		if limits.Timeout > 0 {
+			capability.Timeout = limits.Timeout.String()
+		}
+		capability.MaxOutputBytes = limits.MaxOutputBytes
+
+		tool := *def.Tool
+		if tool.Annotations == nil {
+			tool.Annotations = &mcp.ToolAnnotations{ReadOnlyHint: !def.Mutating}
+		}
+		audit := r.audit
+		live := tc.Live
+		def.add(r.server, &tool, func(ctx context.Context) context.Context {
+			ctx = withToolCallAudit(withToolLimits(ctx, limits), audit)
+			if live {
+				ctx = providers.WithLiveQuery(ctx)
+			}
+			return ctx
+		})
+		r.tools = append(r.tools, capability)
+		added++
+	}
+
+	libtelemetry.Info("registered "+domain+" tools", "count", added, "skipped", skipped)
+	return added
+}
+
+// Capabilities lists every declared tool sorted by domain and name.
+func (r *ToolRegistry) Capabilities() []Capability {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := append([]Capability(nil), r.tools...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Domain != out[j].Domain {
+			return out[i].Domain < out[j].Domain
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Enabled returns the names of the registered tools.
+func (r *ToolRegistry) Enabled() []string {
+	var names []string
+	for _, c := range r.Capabilities() {
+		if c.State == ToolEnabled {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// UnknownTools returns tools named in the config that no Register call declared, usually typos
+// or tools of a provider that failed to initialize.
+func (r *ToolRegistry) UnknownTools() []string {
+	known := make(map[string]bool)
+	for _, c := range r.Capabilities() {
+		known[c.Name] = true
+	}
+	var unknown []string
+	for name := range r.config.Tools {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// CapabilitiesReport is the output of list_capabilities.
+type CapabilitiesReport struct {
+	ReadOnly bool         `json:"read_only"`
+	Enabled  int          `json:"enabled"`
+	Disabled int          `json:"disabled"`
+	Tools    []Capability `json:"tools"`
+}
+
+// ListCapabilitiesInput is the input for list_capabilities.
+type ListCapabilitiesInput struct {
+	Domain          string `json:"domain,omitempty"`           // only tools of this domain, e.g. pods
+	IncludeDisabled bool   `json:"include_disabled,omitempty"` // also list tools turned off by config or read-only mode
+}
+
+// RegisterCapabilityTools registers list_capabilities, which reports the tool set of registry.
+func RegisterCapabilityTools(registry *ToolRegistry, serviceName string) {
+	registry.Register(serviceName,
+		readOnlyTool(&mcp.Tool{
+			Name:        "list_capabilities",
+			Description: "List the tools this gateway exposes by domain, whether they mutate state, and their timeout and output limits; disabled tools explain why they are off",
+		}, handleListCapabilities(registry, serviceName)),
+	)
+}
+
+func handleListCapabilities(registry *ToolRegistry, serviceName string) mcp.ToolHandlerFor[ListCapabilitiesInput, any] {
+	return InstrumentHandler("list_capabilities", serviceName, func(ctx context.Context, _ *mcp.CallToolRequest, input ListCapabilitiesInput) (*mcp.CallToolResult, any, error) {
+		report := CapabilitiesReport{ReadOnly: registry.config.ReadOnly, Tools: []Capability{}}
+		for _, c := range registry.Capabilities() {
+			if input.Domain != "" && c.Domain != input.Domain {
+				continue
+			}
+			if c.State == ToolEnabled {
+				report.Enabled++
+			} else {
+				report.Disabled++
+				if !input.IncludeDisabled {
+					continue
+				}
+			}
+			report.Tools = append(report.Tools, c)
+		}
+		text, _ := json.Marshal(report)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+const (
+	defaultDigestWindow = time.Hour
+	maxDigestWindow     = 24 * time.Hour
+	defaultDigestGroups = 50
+	maxDigestGroups     = 200
+	maxGroupObjects     = 10
+	// apiServerEventTTL is the API server's default --event-ttl. Events older than this had
+	// already expired when the watcher started, so the buffer cannot cover them.
+	apiServerEventTTL = time.Hour
+)
+
+// DigestInput is the input for cluster_event_digest.
+type DigestInput struct {
+	Namespace string `json:"namespace,omitempty"` // empty covers the whole cluster
+	Window    string `json:"window,omitempty"`    // Go duration, e.g. "30m" (default 1h, max 24h)
+	Kind      string `json:"kind,omitempty"`      // involved object kind, e.g. Pod or Node
+	Reason    string `json:"reason,omitempty"`    // e.g. BackOff, FailedScheduling
+	GroupBy   string `json:"group_by,omitempty"`  // "object" (reason + involved object, default) or "reason"
+	Limit     int    `json:"limit,omitempty"`     // max groups (default 50, max 200)
+}
+
+// EventGroup aggregates the Warning events sharing a reason (and, by default, an involved object).
+type EventGroup struct {
+	Reason      string    `json:"reason"`
+	Kind        string    `json:"kind,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Objects     []string  `json:"objects,omitempty"`      // group_by=reason: up to 10 "Kind namespace/name"
+	ObjectCount int       `json:"object_count,omitempty"` // group_by=reason: distinct involved objects
+	Count       int32     `json:"count"`                  // occurrences, summing each event's repeat count
+	Events      int       `json:"events"`                 // distinct Event objects
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	Message     string    `json:"message"` // most recent message
+	Sources     []string  `json:"sources,omitempty"`
+}
+
+// Digest is the result of cluster_event_digest.
+type Digest struct {
+	Window      string       `json:"window"`
+	Since       time.Time    `json:"since"`
+	Complete    bool         `json:"complete"` // false while the watcher is still syncing, when the window starts before the buffer's coverage or when the buffer evicted events inside it
+	Buffered    int          `json:"buffered"`
+	Evicted     int          `json:"evicted,omitempty"`
+	Matched     int          `json:"matched"` // events seen inside the window after filters
+	TotalGroups int          `json:"total_groups"`
+	Groups      []EventGroup `json:"groups"`
+}
+
+// ClusterEventDigestHandler groups buffered Warning events over a time window.
+type ClusterEventDigestHandler struct {
+	snapshotFn func() providers.EventSnapshot
+	now        func() time.Time
+}
+
+func NewClusterEventDigestHandler(snapshotFn func() providers.EventSnapshot) *ClusterEventDigestHandler {
+	return &ClusterEventDigestHandler{snapshotFn: snapshotFn, now: time.Now}
+}
+
+func (h *ClusterEventDigestHandler) Execute(ctx context.Context, input DigestInput) (interface{}, error) {
+	window := defaultDigestWindow
+	if input.Window != "" {
+		d, err := time.ParseDuration(input.Window)
+		if err != nil || d <= 0 {
+			return nil, providers.InvalidInputf("invalid window %q: use a positive duration such as 30m or 6h", input.Window)
+		}
+		window = d
+	}
+	if window > maxDigestWindow {
+		window = maxDigestWindow
+	}
+
+	byReason := false
+	switch strings.ToLower(input.GroupBy) {
+	case "", "object":
+	case "reason":
+		byReason = true
+	default:
+		return nil, providers.InvalidInputf("invalid group_by %q: use object or reason", input.GroupBy)
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultDigestGroups
+	}
+	if limit > maxDigestGroups {
+		limit = maxDigestGroups
+	}
+
+	snap := h.snapshotFn()
+	since := h.now().Add(-window)
+	digest := Digest{
+		Window:   window.String(),
+		Since:    since,
+		Complete: snap.Synced,
+		Buffered: len(snap.Events),
+		Evicted:  snap.Evicted,
+		Groups:   []EventGroup{},
+	}
+	if !snap.StartedAt.IsZero() && since.Before(snap.StartedAt.Add(-apiServerEventTTL)) {
+		digest.Complete = false
+	}
+	// Events are ordered most recently seen first, so the last one bounds what the buffer still covers.
+	if snap.Evicted > 0 && len(snap.Events) > 0 && snap.Events[len(snap.Events)-1].LastSeen.After(since) {
+		digest.Complete = false
+	}
+
+	groups := make(map[string]*EventGroup)
+	objects := make(map[string]map[string]bool)
+	var order []*EventGroup
+	for _, e := range snap.Events {
+		if e.LastSeen.Before(since) {
+			continue
+		}
+		if input.Namespace != "" && e.Namespace != input.Namespace {
+			continue
+		}
+		if input.Kind != "" && !strings.EqualFold(e.Kind, input.Kind) {
+			continue
+		}
+		if input.Reason != "" && !strings.EqualFold(e.Reason, input.Reason) {
+			continue
+		}
+		digest.Matched++
+
+		object := fmt.Sprintf("%s %s/%s", e.Kind, e.Namespace, e.Name)
+		key := e.Reason
+		if !byReason {
+			key += "|" + object
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &EventGroup{Reason: e.Reason, FirstSeen: e.FirstSeen, LastSeen: e.LastSeen, Message: e.Message}
+			if !byReason {
+				g.Kind, g.Namespace, g.Name = e.Kind, e.Namespace, e.Name
+			}
+			groups[key] = g
+			objects[key] = make(map[string]bool)
+			order = append(order, g)
+		}
+		g.Count += e.Count
+		g.Events++
+		if e.FirstSeen.Before(g.FirstSeen) {
+			g.FirstSeen = e.FirstSeen
+		}
+		if e.LastSeen.After(g.LastSeen) {
+			g.LastSeen = e.LastSeen
+			g.Message = e.Message
+		}
+		if e.Source != "" && !slices.Contains(g.Sources, e.Source) {
+			g.Sources = append(g.Sources, e.Source)
+		}
+		if byReason && !objects[key][object] {
+			objects[key][object] = true
+			g.ObjectCount++
+			if len(g.Objects) < maxGroupObjects {
+				g.Objects = append(g.Objects, object)
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Count != order[j].Count {
+			return order[i].Count > order[j].Count
+		}
+		return order[i].LastSeen.After(order[j].LastSeen)
+	})
+	digest.TotalGroups = len(order)
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	for _, g := range order {
+		sort.Strings(g.Sources)
+		digest.Groups = append(digest.Groups, *g)
+	}
+	return digest, nil
+}
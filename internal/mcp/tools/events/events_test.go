@@ -0,0 +1,165 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func TestClusterEventDigestHandler_Execute(t *testing.T) {
+	now := time.Date(2026, 3, 6, 18, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	ev := func(ns, kind, name, reason, source string, count int32, last time.Duration) providers.WarningEvent {
+		return providers.WarningEvent{Namespace: ns, Kind: kind, Name: name, Reason: reason, Source: source,
+			Message: reason + " on " + name, Count: count, FirstSeen: ago(last + time.Minute), LastSeen: ago(last)}
+	}
+	// Most recently seen first, as the watcher returns them.
+	buffered := []providers.WarningEvent{
+		ev("default", "Pod", "api-0", "BackOff", "kubelet", 12, time.Minute),
+		ev("default", "Pod", "api-1", "BackOff", "kubelet", 3, 2*time.Minute),
+		ev("default", "Pod", "api-0", "BackOff", "kubelet", 2, 5*time.Minute),
+		ev("batch", "Pod", "job-x", "FailedScheduling", "default-scheduler", 4, 10*time.Minute),
+		ev("", "Node", "node-a", "NodeNotReady", "node-controller", 1, 30*time.Minute),
+		ev("default", "Pod", "old", "BackOff", "kubelet", 50, 3*time.Hour),
+	}
+
+	tests := []struct {
+		name         string
+		input        DigestInput
+		snap         providers.EventSnapshot
+		wantGroups   []string // "reason kind/name count"
+		wantTotal    int
+		wantComplete bool
+		wantErr      string
+	}{
+		{
+			name:         "default groups by reason and object",
+			snap:         providers.EventSnapshot{Events: buffered, Synced: true},
+			wantGroups:   []string{"BackOff Pod/api-0 14", "FailedScheduling Pod/job-x 4", "BackOff Pod/api-1 3", "NodeNotReady Node/node-a 1"},
+			wantTotal:    4,
+			wantComplete: true,
+		},
+		{
+			name:         "group by reason",
+			input:        DigestInput{GroupBy: "reason"},
+			snap:         providers.EventSnapshot{Events: buffered, Synced: true},
+			wantGroups:   []string{"BackOff / 17", "FailedScheduling / 4", "NodeNotReady / 1"},
+			wantTotal:    3,
+			wantComplete: true,
+		},
+		{
+			name:         "window includes older events",
+			input:        DigestInput{Window: "4h", Reason: "backoff", GroupBy: "reason"},
+			snap:         providers.EventSnapshot{Events: buffered, Synced: true},
+			wantGroups:   []string{"BackOff / 67"},
+			wantTotal:    1,
+			wantComplete: true,
+		},
+		{
+			name:         "namespace, kind and limit",
+			input:        DigestInput{Window: "15m", Namespace: "default", Kind: "pod", Limit: 1},
+			snap:         providers.EventSnapshot{Events: buffered, Synced: true},
+			wantGroups:   []string{"BackOff Pod/api-0 14"},
+			wantTotal:    2,
+			wantComplete: true,
+		},
+		{
+			name:       "still syncing",
+			snap:       providers.EventSnapshot{Events: buffered[:1]},
+			wantGroups: []string{"BackOff Pod/api-0 12"},
+			wantTotal:  1,
+		},
+		{
+			name:       "evicted inside window",
+			snap:       providers.EventSnapshot{Events: buffered[:2], Synced: true, Evicted: 10},
+			wantGroups: []string{"BackOff Pod/api-0 12", "BackOff Pod/api-1 3"},
+			wantTotal:  2,
+		},
+		{
+			name:         "evicted before window",
+			input:        DigestInput{Window: "1m30s"},
+			snap:         providers.EventSnapshot{Events: buffered, Synced: true, Evicted: 10},
+			wantGroups:   []string{"BackOff Pod/api-0 12"},
+			wantTotal:    1,
+			wantComplete: true,
+		},
+		{
+			name:         "window within the coverage since startup",
+			snap:         providers.EventSnapshot{Events: buffered, Synced: true, StartedAt: ago(30 * time.Minute)},
+			wantGroups:   []string{"BackOff Pod/api-0 14", "FailedScheduling Pod/job-x 4", "BackOff Pod/api-1 3", "NodeNotReady Node/node-a 1"},
+			wantTotal:    4,
+			wantComplete: true,
+		},
+		{
+			name:       "window predates the coverage since startup",
+			input:      DigestInput{Window: "4h", Reason: "backoff", GroupBy: "reason"},
+			snap:       providers.EventSnapshot{Events: buffered, Synced: true, StartedAt: ago(2 * time.Hour)},
+			wantGroups: []string{"BackOff / 67"},
+			wantTotal:  1,
+		},
+		{name: "bad window", input: DigestInput{Window: "an hour"}, wantErr: "invalid window"},
+		{name: "bad group_by", input: DigestInput{GroupBy: "node"}, wantErr: "invalid group_by"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewClusterEventDigestHandler(func() providers.EventSnapshot { return tt.snap })
+			h.now = func() time.Time { return now }
+
+			got, err := h.Execute(context.Background(), tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			digest := got.(Digest)
+			var groups []string
+			for _, g := range digest.Groups {
+				groups = append(groups, g.Reason+" "+g.Kind+"/"+g.Name+" "+fmt.Sprint(g.Count))
+			}
+			if strings.Join(groups, ",") != strings.Join(tt.wantGroups, ",") {
+				t.Errorf("Execute() groups = %v, want %v", groups, tt.wantGroups)
+			}
+			if digest.TotalGroups != tt.wantTotal || digest.Complete != tt.wantComplete {
+				t.Errorf("Execute() total/complete = %d/%v, want %d/%v", digest.TotalGroups, digest.Complete, tt.wantTotal, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestClusterEventDigestHandler_GroupDetails(t *testing.T) {
+	now := time.Date(2026, 3, 6, 18, 0, 0, 0, time.UTC)
+	var buffered []providers.WarningEvent
+	for i := 0; i < 15; i++ {
+		buffered = append(buffered, providers.WarningEvent{
+			Namespace: "default", Kind: "Pod", Name: fmt.Sprintf("pending-%d", i), Reason: "FailedScheduling",
+			Source: "default-scheduler", Message: fmt.Sprintf("0/3 nodes available #%d", i), Count: 1,
+			FirstSeen: now.Add(-time.Duration(i+1) * time.Minute), LastSeen: now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	h := NewClusterEventDigestHandler(func() providers.EventSnapshot { return providers.EventSnapshot{Events: buffered, Synced: true} })
+	h.now = func() time.Time { return now }
+
+	got, err := h.Execute(context.Background(), DigestInput{GroupBy: "reason"})
+	if err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	g := got.(Digest).Groups[0]
+	if g.ObjectCount != 15 || len(g.Objects) != maxGroupObjects || g.Events != 15 || g.Count != 15 {
+		t.Errorf("group objects/events/count = %d (%d listed)/%d/%d, want 15 (%d listed)/15/15", g.ObjectCount, len(g.Objects), g.Events, g.Count, maxGroupObjects)
+	}
+	if g.Message != "0/3 nodes available #0" || !g.LastSeen.Equal(now) || !g.FirstSeen.Equal(now.Add(-15*time.Minute)) {
+		t.Errorf("group message/first/last = %q %v %v", g.Message, g.FirstSeen, g.LastSeen)
+	}
+	if len(g.Sources) != 1 || g.Sources[0] != "default-scheduler" {
+		t.Errorf("group sources = %v", g.Sources)
+	}
+}
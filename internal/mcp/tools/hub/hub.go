@@ -2,14 +2,13 @@ package hub
 
 import (
 	"context"
+	"time"
+
 	"observability-hub/internal/mcp/providers"
 )
 
-// HubInput is the common input for hub-related tools.
-type HubInput struct {
-	Service string `json:"service,omitempty"` // service name for log query
-	Since   string `json:"since,omitempty"`   // relative time for log query (e.g. 5m, 1h)
-}
+// HubInput is the common input for hub tools that take no parameters.
+type HubInput struct{}
 
 // InspectPlatformHandler handles executive summary of platform health.
 type InspectPlatformHandler struct {
@@ -50,15 +49,37 @@ func (h *ListHostServicesHandler) Execute(ctx context.Context, _ HubInput) (inte
 	return h.listFn(ctx)
 }
 
-// QueryServiceLogsHandler handles journal log retrieval.
-type QueryServiceLogsHandler struct {
-	queryFn func(ctx context.Context, service string, since string) (string, error)
+// Restart wait bounds in seconds for hub_restart_service.
+const (
+	defaultRestartWaitSeconds = 30
+	maxRestartWaitSeconds     = 120
+)
+
+// RestartServiceInput is the input for restarting an inventory systemd unit.
+type RestartServiceInput struct {
+	Service      string `json:"service"`                // inventory unit, e.g. proxy or proxy.service
+	WaitSeconds  int    `json:"wait_seconds,omitempty"` // how long to wait for the unit to become healthy (default 30, max 120)
+	Reason       string `json:"reason"`
+	DryRun       bool   `json:"dry_run,omitempty"`
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// RestartServiceHandler handles restarting a systemd unit.
+type RestartServiceHandler struct {
+	restartFn func(ctx context.Context, unit string, wait time.Duration) (*providers.ServiceRestart, error)
 }
 
-func NewQueryServiceLogsHandler(fn func(ctx context.Context, service string, since string) (string, error)) *QueryServiceLogsHandler {
-	return &QueryServiceLogsHandler{queryFn: fn}
+func NewRestartServiceHandler(fn func(ctx context.Context, unit string, wait time.Duration) (*providers.ServiceRestart, error)) *RestartServiceHandler {
+	return &RestartServiceHandler{restartFn: fn}
 }
 
-func (h *QueryServiceLogsHandler) Execute(ctx context.Context, input HubInput) (interface{}, error) {
-	return h.queryFn(ctx, input.Service, input.Since)
+func (h *RestartServiceHandler) Execute(ctx context.Context, input RestartServiceInput) (interface{}, error) {
+	seconds := input.WaitSeconds
+	if seconds <= 0 {
+		seconds = defaultRestartWaitSeconds
+	}
+	if seconds > maxRestartWaitSeconds {
+		seconds = maxRestartWaitSeconds
+	}
+	return h.restartFn(ctx, input.Service, time.Duration(seconds)*time.Second)
 }
@@ -3,8 +3,8 @@ package hub
 import (
 	"context"
 	"errors"
-	"reflect"
 	"testing"
+	"time"
 
 	"observability-hub/internal/mcp/providers"
 )
@@ -51,7 +51,7 @@ func TestInspectHostHandler_Execute(t *testing.T) {
 		{
 			name: "Success",
 			mockFn: func(ctx context.Context) (*providers.HostResource, error) {
-				return &providers.HostResource{CPUUsage: "10%"}, nil
+				return &providers.HostResource{CPUUsagePercent: 10}, nil
 			},
 			wantErr: false,
 		},
@@ -101,44 +101,35 @@ func TestListHostServicesHandler_Execute(t *testing.T) {
 	}
 }
 
-func TestQueryServiceLogsHandler_Execute(t *testing.T) {
+func TestRestartServiceHandler_Execute(t *testing.T) {
 	tests := []struct {
-		name    string
-		input   HubInput
-		mockFn  func(ctx context.Context, service string, since string) (string, error)
-		wantErr bool
+		name     string
+		input    RestartServiceInput
+		wantWait time.Duration
+		err      error
 	}{
-		{
-			name:  "Success",
-			input: HubInput{Service: "test.service", Since: "5m"},
-			mockFn: func(ctx context.Context, service, since string) (string, error) {
-				return "logs", nil
-			},
-			wantErr: false,
-		},
-		{
-			name:  "Service Name Check",
-			input: HubInput{Service: "proxy.service"},
-			mockFn: func(ctx context.Context, service, since string) (string, error) {
-				if service != "proxy.service" {
-					return "", errors.New("wrong service")
-				}
-				return "ok", nil
-			},
-			wantErr: false,
-		},
+		{name: "default wait", input: RestartServiceInput{Service: "proxy"}, wantWait: 30 * time.Second},
+		{name: "custom wait", input: RestartServiceInput{Service: "proxy", WaitSeconds: 5}, wantWait: 5 * time.Second},
+		{name: "wait clamped", input: RestartServiceInput{Service: "proxy", WaitSeconds: 3600}, wantWait: 120 * time.Second},
+		{name: "provider error", input: RestartServiceInput{Service: "proxy"}, wantWait: 30 * time.Second, err: errors.New("not in inventory")},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := NewQueryServiceLogsHandler(tt.mockFn)
-			got, err := h.Execute(context.Background(), tt.input)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			var gotWait time.Duration
+			h := NewRestartServiceHandler(func(ctx context.Context, unit string, wait time.Duration) (*providers.ServiceRestart, error) {
+				gotWait = wait
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &providers.ServiceRestart{Unit: unit, Healthy: true}, nil
+			})
+			_, err := h.Execute(context.Background(), tt.input)
+			if (err != nil) != (tt.err != nil) {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.err != nil)
 			}
-			if !tt.wantErr && !reflect.DeepEqual(got, "logs") && tt.name == "Success" {
-				t.Errorf("got %v, want logs", got)
+			if gotWait != tt.wantWait {
+				t.Errorf("Execute() wait = %v, want %v", gotWait, tt.wantWait)
 			}
 		})
 	}
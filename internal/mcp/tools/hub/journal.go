@@ -0,0 +1,170 @@
+package hub
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+	"observability-hub/internal/mcp/tools/logsummary"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+const (
+	defaultJournalSince   = 5 * time.Minute
+	defaultJournalEntries = 100
+	maxJournalEntries     = 1000
+	maxJournalGrepLength  = 500
+	summarizeJournalLines = 200 // results longer than this are digested by obs-processor
+	summarizedTailEntries = 50  // raw entries kept next to a digest
+)
+
+// QueryServiceLogsInput is the input for querying a unit's journal.
+type QueryServiceLogsInput struct {
+	Service  string `json:"service"`            // inventory unit, e.g. proxy or proxy.service
+	Since    string `json:"since,omitempty"`    // Go duration ago (e.g. 5m, 2h) or RFC3339 time (default 5m before until)
+	Until    string `json:"until,omitempty"`    // Go duration ago or RFC3339 time (default now)
+	Priority string `json:"priority,omitempty"` // most verbose level to include: emerg, alert, crit, err, warning, notice, info, debug or 0-7
+	Grep     string `json:"grep,omitempty"`     // RE2 regular expression on the message; use (?i) for case-insensitive
+	Cursor   string `json:"cursor,omitempty"`   // next_cursor from a previous page
+	Limit    int    `json:"limit,omitempty"`    // entries per page (default 100, max 1000)
+}
+
+// ServiceLogsResult is the output of hub_query_service_logs.
+type ServiceLogsResult struct {
+	*providers.JournalPage
+	Summary interface{} `json:"summary,omitempty"` // obs-processor digest of the page when it is large
+}
+
+// QueryServiceLogsHandler handles journal log retrieval.
+type QueryServiceLogsHandler struct {
+	queryFn       func(ctx context.Context, q providers.JournalQuery) (*providers.JournalPage, error)
+	processorPath string
+	now           func() time.Time
+}
+
+func NewQueryServiceLogsHandler(fn func(ctx context.Context, q providers.JournalQuery) (*providers.JournalPage, error)) *QueryServiceLogsHandler {
+	return &QueryServiceLogsHandler{
+		queryFn:       fn,
+		processorPath: "/usr/local/bin/obs-processor",
+		now:           time.Now,
+	}
+}
+
+func (h *QueryServiceLogsHandler) Execute(ctx context.Context, input QueryServiceLogsInput) (interface{}, error) {
+	if input.Service == "" {
+		return nil, providers.InvalidInputf("service is required")
+	}
+	now := h.now()
+	q := providers.JournalQuery{
+		Unit:        input.Service,
+		Since:       now.Add(-defaultJournalSince),
+		MaxPriority: len(providers.JournalPriorities) - 1,
+		Cursor:      input.Cursor,
+		Limit:       input.Limit,
+	}
+
+	var err error
+	if input.Since != "" {
+		if q.Since, err = parseRelativeTime(input.Since, now); err != nil {
+			return nil, providers.InvalidInputf("invalid since: %w", err)
+		}
+	}
+	if input.Until != "" {
+		if q.Until, err = parseRelativeTime(input.Until, now); err != nil {
+			return nil, providers.InvalidInputf("invalid until: %w", err)
+		}
+		if input.Since == "" {
+			q.Since = q.Until.Add(-defaultJournalSince)
+		}
+		if !q.Until.After(q.Since) {
+			return nil, providers.InvalidInputf("until must be after since")
+		}
+	}
+	if input.Priority != "" {
+		if q.MaxPriority, err = parsePriority(input.Priority); err != nil {
+			return nil, err
+		}
+	}
+	if input.Grep != "" {
+		if len(input.Grep) > maxJournalGrepLength {
+			return nil, providers.InvalidInputf("grep too long (max %d chars)", maxJournalGrepLength)
+		}
+		if q.Pattern, err = regexp.Compile(input.Grep); err != nil {
+			return nil, providers.InvalidInputf("invalid grep pattern: %w", err)
+		}
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultJournalEntries
+	}
+	if q.Limit > maxJournalEntries {
+		q.Limit = maxJournalEntries
+	}
+
+	page, err := h.queryFn(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	result := ServiceLogsResult{JournalPage: page}
+	if len(page.Entries) > summarizeJournalLines {
+		// Fail-open: without the processor the caller still gets the full page.
+		summary, err := h.summarizeJournal(ctx, page)
+		if err != nil {
+			libtelemetry.Warn("journal summarization failed, returning raw entries", "error", err)
+			return result, nil
+		}
+		trimmed := *page
+		trimmed.Entries = page.Entries[:summarizedTailEntries]
+		result = ServiceLogsResult{JournalPage: &trimmed, Summary: summary}
+	}
+	return result, nil
+}
+
+// parsePriority maps a syslog level name or number to its number.
+func parsePriority(value string) (int, error) {
+	value = strings.ToLower(value)
+	switch value {
+	case "error":
+		value = "err"
+	case "warn":
+		value = "warning"
+	}
+	for i, name := range providers.JournalPriorities {
+		if value == name || value == strconv.Itoa(i) {
+			return i, nil
+		}
+	}
+	return 0, providers.InvalidInputf("invalid priority %q: use one of %s or 0-%d", value, strings.Join(providers.JournalPriorities, ", "), len(providers.JournalPriorities)-1)
+}
+
+// summarizeJournal reshapes the page into Loki streams, one per priority level, and
+// pipes them through obs-processor.
+func (h *QueryServiceLogsHandler) summarizeJournal(ctx context.Context, page *providers.JournalPage) (interface{}, error) {
+	var streams []*logsummary.Stream
+	index := make(map[string]*logsummary.Stream)
+	for _, e := range page.Entries {
+		level := journalLevel(e.Priority)
+		s, ok := index[level]
+		if !ok {
+			s = &logsummary.Stream{Stream: map[string]string{"unit": page.Unit, "detected_level": level}}
+			index[level] = s
+			streams = append(streams, s)
+		}
+		s.Values = append(s.Values, []string{strconv.FormatInt(e.Time.UnixNano(), 10), e.Message})
+	}
+	return logsummary.Summarize(ctx, h.processorPath, streams)
+}
+
+// journalLevel maps a syslog priority to the level names obs-processor ranks.
+func journalLevel(priority int) string {
+	switch {
+	case priority <= 3:
+		return "error"
+	case priority == 4:
+		return "warn"
+	default:
+		return "info"
+	}
+}
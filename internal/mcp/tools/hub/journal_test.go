@@ -0,0 +1,182 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func TestQueryServiceLogsHandler_Execute(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		input     QueryServiceLogsInput
+		wantQuery providers.JournalQuery
+		wantErr   string
+	}{
+		{
+			name:      "defaults",
+			input:     QueryServiceLogsInput{Service: "proxy"},
+			wantQuery: providers.JournalQuery{Unit: "proxy", Since: now.Add(-5 * time.Minute), MaxPriority: 7, Limit: 100},
+		},
+		{
+			name:  "absolute range, priority name, cursor and clamped limit",
+			input: QueryServiceLogsInput{Service: "proxy", Since: "2026-03-11T12:00:00Z", Until: "30m", Priority: "error", Cursor: "c1", Limit: 5000},
+			wantQuery: providers.JournalQuery{
+				Unit: "proxy", Since: now.Add(-2 * time.Hour), Until: now.Add(-30 * time.Minute), MaxPriority: 3, Cursor: "c1", Limit: 1000,
+			},
+		},
+		{
+			name:      "until alone ends the default window",
+			input:     QueryServiceLogsInput{Service: "proxy", Until: "2026-03-11T09:00:00Z"},
+			wantQuery: providers.JournalQuery{Unit: "proxy", Since: now.Add(-5*time.Hour - 5*time.Minute), Until: now.Add(-5 * time.Hour), MaxPriority: 7, Limit: 100},
+		},
+		{
+			name:      "numeric priority",
+			input:     QueryServiceLogsInput{Service: "proxy", Since: "1h", Priority: "4"},
+			wantQuery: providers.JournalQuery{Unit: "proxy", Since: now.Add(-time.Hour), MaxPriority: 4, Limit: 100},
+		},
+		{name: "missing service", input: QueryServiceLogsInput{}, wantErr: "service is required"},
+		{name: "bad since", input: QueryServiceLogsInput{Service: "proxy", Since: "yesterday"}, wantErr: "invalid since"},
+		{name: "until before since", input: QueryServiceLogsInput{Service: "proxy", Since: "1h", Until: "2h"}, wantErr: "until must be after since"},
+		{name: "bad priority", input: QueryServiceLogsInput{Service: "proxy", Priority: "loud"}, wantErr: "invalid priority"},
+		{name: "bad grep", input: QueryServiceLogsInput{Service: "proxy", Grep: "("}, wantErr: "invalid grep pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got providers.JournalQuery
+			h := NewQueryServiceLogsHandler(func(ctx context.Context, q providers.JournalQuery) (*providers.JournalPage, error) {
+				got = q
+				return &providers.JournalPage{Unit: "proxy.service"}, nil
+			})
+			h.now = func() time.Time { return now }
+
+			_, err := h.Execute(context.Background(), tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if got.Unit != tt.wantQuery.Unit || !got.Since.Equal(tt.wantQuery.Since) || !got.Until.Equal(tt.wantQuery.Until) ||
+				got.MaxPriority != tt.wantQuery.MaxPriority || got.Cursor != tt.wantQuery.Cursor || got.Limit != tt.wantQuery.Limit {
+				t.Errorf("Execute() query = %+v, want %+v", got, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestQueryServiceLogsHandler_Summarize(t *testing.T) {
+	dir := t.TempDir()
+	processor := filepath.Join(dir, "obs-processor")
+	script := "#!/bin/sh\ngrep -q '\"detected_level\":\"error\"' && echo '{\"summarized_count\":1}'\n"
+	if err := os.WriteFile(processor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []providers.JournalEntry
+	for i := 0; i < 300; i++ {
+		entries = append(entries, providers.JournalEntry{Priority: 3, Level: "err", Message: fmt.Sprintf("failure %d", i)})
+	}
+	h := NewQueryServiceLogsHandler(func(ctx context.Context, q providers.JournalQuery) (*providers.JournalPage, error) {
+		return &providers.JournalPage{Unit: "proxy.service", Entries: entries, Scanned: 300, NextCursor: "c300"}, nil
+	})
+
+	for _, tc := range []struct {
+		name        string
+		path        string
+		wantEntries int
+		wantSummary bool
+	}{
+		{name: "digest keeps the newest entries", path: processor, wantEntries: summarizedTailEntries, wantSummary: true},
+		{name: "missing processor returns the full page", path: filepath.Join(dir, "missing"), wantEntries: 300},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h.processorPath = tc.path
+			got, err := h.Execute(context.Background(), QueryServiceLogsInput{Service: "proxy", Limit: 300})
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			result := got.(ServiceLogsResult)
+			if len(result.Entries) != tc.wantEntries || (result.Summary != nil) != tc.wantSummary {
+				t.Errorf("Execute() entries = %d summary = %v", len(result.Entries), result.Summary)
+			}
+			if result.Entries[0].Message != "failure 0" || result.NextCursor != "c300" || len(entries) != 300 {
+				t.Errorf("Execute() first = %q next = %q", result.Entries[0].Message, result.NextCursor)
+			}
+		})
+	}
+}
+
+// TestQueryServiceLogsHandler_Replay runs a recorded journal flood through the real provider and
+// checks what reaches obs-processor, so changes to the summarizer's input are caught.
+func TestQueryServiceLogsHandler_Replay(t *testing.T) {
+	f, err := providers.LoadFixture(filepath.Join("testdata", "proxy_journal_refused.json"))
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	replayer := providers.NewReplayer(f)
+	hub := providers.NewHubProviderWithRunner(replayer.Runner(), providers.HostInventory{Services: []providers.HostService{{Unit: "proxy.service"}}})
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.json")
+	processor := filepath.Join(dir, "obs-processor")
+	script := "#!/bin/sh\ncat > " + input + " && echo '{\"summarized_count\":2}'\n"
+	if err := os.WriteFile(processor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	h := NewQueryServiceLogsHandler(hub.QueryJournal)
+	h.processorPath = processor
+	h.now = replayer.RecordedAt
+
+	got, err := h.Execute(context.Background(), QueryServiceLogsInput{Service: "proxy", Since: "1h", Limit: 300})
+	if err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	result := got.(ServiceLogsResult)
+	if len(result.Entries) != summarizedTailEntries || result.Summary == nil || result.NextCursor == "" {
+		t.Fatalf("Execute() entries = %d summary = %v next = %q, want a digest, the tail and a cursor", len(result.Entries), result.Summary, result.NextCursor)
+	}
+	if !strings.HasPrefix(result.Entries[0].Message, "GET /api/readings") {
+		t.Errorf("first entry = %q, want the newest line", result.Entries[0].Message)
+	}
+
+	raw, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatalf("processor input: %v", err)
+	}
+	var sent struct {
+		Data struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][]string        `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &sent); err != nil || sent.Data.ResultType != "streams" {
+		t.Fatalf("processor input = %s, want a Loki streams response", raw)
+	}
+	lines := make(map[string]int)
+	for _, s := range sent.Data.Result {
+		if s.Stream["unit"] != "proxy.service" {
+			t.Errorf("stream labels = %v, want unit proxy.service", s.Stream)
+		}
+		lines[s.Stream["detected_level"]] += len(s.Values)
+	}
+	if lines["error"] != 200 || lines["info"] != 100 || len(lines) != 2 {
+		t.Errorf("lines per level = %v, want 200 error and 100 info", lines)
+	}
+}
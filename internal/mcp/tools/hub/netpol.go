@@ -0,0 +1,128 @@
+package hub
+
+import (
+	"context"
+	"strings"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+// PolicyPeerInput names one side of a simulated connection. Set exactly one of pod, labels
+// (with namespace), entity, ip or fqdn.
+type PolicyPeerInput struct {
+	// Pod as namespace/name; its labels are read from the cluster unless labels are given.
+	Pod string `json:"pod,omitempty"`
+	// Namespace of the pod described by labels (default "default").
+	Namespace string `json:"namespace,omitempty"`
+	// Pod labels, e.g. {"app.kubernetes.io/name": "grafana"}; works offline.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Non-pod entity: world, host, remote-node or kube-apiserver.
+	Entity string `json:"entity,omitempty"`
+	// External IP address, matched against CIDR rules.
+	IP string `json:"ip,omitempty"`
+	// External DNS name, matched against toFQDNs rules (destination only).
+	FQDN string `json:"fqdn,omitempty"`
+}
+
+// SimulateNetworkPolicyInput is the input for the simulate_network_policy tool.
+type SimulateNetworkPolicyInput struct {
+	Source      PolicyPeerInput `json:"source"`
+	Destination PolicyPeerInput `json:"destination"`
+	// Destination port (1-65535).
+	Port int `json:"port"`
+	// TCP (default), UDP, SCTP or ANY.
+	Protocol string `json:"protocol,omitempty"`
+	// Where policies come from: "repo" (default, offline) or "cluster".
+	PoliciesFrom string `json:"policies_from,omitempty"`
+}
+
+// SimulateNetworkPolicyHandler evaluates a connection against Cilium and Kubernetes network policies.
+type SimulateNetworkPolicyHandler struct {
+	simulateFn func(ctx context.Context, sim providers.PolicySimulation) (*providers.PolicyVerdict, error)
+}
+
+func NewSimulateNetworkPolicyHandler(fn func(ctx context.Context, sim providers.PolicySimulation) (*providers.PolicyVerdict, error)) *SimulateNetworkPolicyHandler {
+	return &SimulateNetworkPolicyHandler{simulateFn: fn}
+}
+
+func (h *SimulateNetworkPolicyHandler) Execute(ctx context.Context, input SimulateNetworkPolicyInput) (*providers.PolicyVerdict, error) {
+	if input.Port < 1 || input.Port > 65535 {
+		return nil, providers.InvalidInputf("port must be between 1 and 65535")
+	}
+	protocol := strings.ToUpper(input.Protocol)
+	switch protocol {
+	case "":
+		protocol = "TCP"
+	case "TCP", "UDP", "SCTP", "ANY":
+	default:
+		return nil, providers.InvalidInputf("unsupported protocol %q (use TCP, UDP, SCTP or ANY)", input.Protocol)
+	}
+	var fromCluster bool
+	switch input.PoliciesFrom {
+	case "", "repo":
+	case "cluster":
+		fromCluster = true
+	default:
+		return nil, providers.InvalidInputf("policies_from must be repo or cluster")
+	}
+
+	source, err := policyEndpoint("source", input.Source, false)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := policyEndpoint("destination", input.Destination, true)
+	if err != nil {
+		return nil, err
+	}
+	return h.simulateFn(ctx, providers.PolicySimulation{
+		Source:      source,
+		Destination: destination,
+		Port:        input.Port,
+		Protocol:    protocol,
+		FromCluster: fromCluster,
+	})
+}
+
+func policyEndpoint(side string, in PolicyPeerInput, destination bool) (providers.PolicyEndpoint, error) {
+	set := 0
+	for _, given := range []bool{in.Pod != "", len(in.Labels) > 0, in.Entity != "", in.IP != "", in.FQDN != ""} {
+		if given {
+			set++
+		}
+	}
+	// A pod may come with its labels to skip the cluster lookup.
+	if in.Pod != "" && len(in.Labels) > 0 {
+		set--
+	}
+	if set != 1 {
+		return providers.PolicyEndpoint{}, providers.InvalidInputf("%s must set exactly one of pod, labels, entity, ip or fqdn", side)
+	}
+
+	switch {
+	case in.Pod != "":
+		ns, name, ok := strings.Cut(in.Pod, "/")
+		if !ok || ns == "" || name == "" {
+			return providers.PolicyEndpoint{}, providers.InvalidInputf("%s pod must be namespace/name", side)
+		}
+		return providers.PolicyEndpoint{Pod: name, Namespace: ns, Labels: in.Labels}, nil
+	case len(in.Labels) > 0:
+		ns := in.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		return providers.PolicyEndpoint{Namespace: ns, Labels: in.Labels}, nil
+	case in.Entity != "":
+		switch in.Entity {
+		case providers.EntityWorld, providers.EntityHost, providers.EntityRemoteNode, providers.EntityKubeAPIServer:
+		default:
+			return providers.PolicyEndpoint{}, providers.InvalidInputf("%s entity must be world, host, remote-node or kube-apiserver", side)
+		}
+		return providers.PolicyEndpoint{Entity: in.Entity}, nil
+	case in.IP != "":
+		return providers.PolicyEndpoint{Entity: providers.EntityWorld, IP: in.IP}, nil
+	}
+	if !destination {
+		return providers.PolicyEndpoint{}, providers.InvalidInputf("fqdn can only be set on the destination")
+	}
+	return providers.PolicyEndpoint{Entity: providers.EntityWorld, FQDN: in.FQDN}, nil
+}
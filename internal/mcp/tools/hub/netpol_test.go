@@ -0,0 +1,124 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func TestSimulateNetworkPolicyHandler_Execute(t *testing.T) {
+	grafana := map[string]string{"app.kubernetes.io/name": "grafana"}
+	tests := []struct {
+		name    string
+		input   SimulateNetworkPolicyInput
+		wantSim providers.PolicySimulation
+		mockErr error
+		wantErr bool
+	}{
+		{
+			name: "Labels To Pod Defaults",
+			input: SimulateNetworkPolicyInput{
+				Source:      PolicyPeerInput{Namespace: "hub", Labels: grafana},
+				Destination: PolicyPeerInput{Pod: "databases/postgres-1"},
+				Port:        5432,
+			},
+			wantSim: providers.PolicySimulation{
+				Source:      providers.PolicyEndpoint{Namespace: "hub", Labels: grafana},
+				Destination: providers.PolicyEndpoint{Namespace: "databases", Pod: "postgres-1"},
+				Port:        5432,
+				Protocol:    "TCP",
+			},
+		},
+		{
+			name: "Cluster Policies To FQDN",
+			input: SimulateNetworkPolicyInput{
+				Source:       PolicyPeerInput{Labels: grafana},
+				Destination:  PolicyPeerInput{FQDN: "api.github.com"},
+				Port:         443,
+				Protocol:     "tcp",
+				PoliciesFrom: "cluster",
+			},
+			wantSim: providers.PolicySimulation{
+				Source:      providers.PolicyEndpoint{Namespace: "default", Labels: grafana},
+				Destination: providers.PolicyEndpoint{Entity: "world", FQDN: "api.github.com"},
+				Port:        443,
+				Protocol:    "TCP",
+				FromCluster: true,
+			},
+		},
+		{
+			name: "IP And Entity",
+			input: SimulateNetworkPolicyInput{
+				Source:      PolicyPeerInput{IP: "203.0.113.7"},
+				Destination: PolicyPeerInput{Entity: "host"},
+				Port:        10250,
+				Protocol:    "any",
+			},
+			wantSim: providers.PolicySimulation{
+				Source:      providers.PolicyEndpoint{Entity: "world", IP: "203.0.113.7"},
+				Destination: providers.PolicyEndpoint{Entity: "host"},
+				Port:        10250,
+				Protocol:    "ANY",
+			},
+		},
+		{
+			name:    "Invalid Port",
+			input:   SimulateNetworkPolicyInput{Source: PolicyPeerInput{Entity: "world"}, Destination: PolicyPeerInput{Entity: "host"}},
+			wantErr: true,
+		},
+		{
+			name:    "Ambiguous Peer",
+			input:   SimulateNetworkPolicyInput{Source: PolicyPeerInput{Entity: "world", IP: "1.1.1.1"}, Destination: PolicyPeerInput{Entity: "host"}, Port: 80},
+			wantErr: true,
+		},
+		{
+			name:    "FQDN Source",
+			input:   SimulateNetworkPolicyInput{Source: PolicyPeerInput{FQDN: "example.com"}, Destination: PolicyPeerInput{Entity: "host"}, Port: 80},
+			wantErr: true,
+		},
+		{
+			name:    "Pod Without Namespace",
+			input:   SimulateNetworkPolicyInput{Source: PolicyPeerInput{Pod: "grafana-0"}, Destination: PolicyPeerInput{Entity: "host"}, Port: 80},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Policy Source",
+			input:   SimulateNetworkPolicyInput{Source: PolicyPeerInput{Entity: "world"}, Destination: PolicyPeerInput{Entity: "host"}, Port: 80, PoliciesFrom: "git"},
+			wantErr: true,
+		},
+		{
+			name:  "Provider Error",
+			input: SimulateNetworkPolicyInput{Source: PolicyPeerInput{Entity: "world"}, Destination: PolicyPeerInput{Entity: "host"}, Port: 80},
+			wantSim: providers.PolicySimulation{
+				Source:      providers.PolicyEndpoint{Entity: "world"},
+				Destination: providers.PolicyEndpoint{Entity: "host"},
+				Port:        80,
+				Protocol:    "TCP",
+			},
+			mockErr: errors.New("failed to load policies"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewSimulateNetworkPolicyHandler(func(ctx context.Context, sim providers.PolicySimulation) (*providers.PolicyVerdict, error) {
+				if !reflect.DeepEqual(sim, tt.wantSim) {
+					t.Errorf("got simulation %+v, want %+v", sim, tt.wantSim)
+				}
+				return &providers.PolicyVerdict{Verdict: "ALLOWED"}, tt.mockErr
+			})
+
+			got, err := handler.Execute(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Verdict != "ALLOWED" {
+				t.Errorf("got %+v, want the provider's verdict", got)
+			}
+		})
+	}
+}
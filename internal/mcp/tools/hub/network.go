@@ -2,6 +2,19 @@ package hub
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+const (
+	defaultFlows          = 20
+	maxFlows              = 100
+	defaultAggregateFlows = 500
+	topDropReasons        = 5
+	maxFollowSeconds      = 30
 )
 
 // ObserveNetworkFlowsInput is the input for the observe_network_flows tool.
@@ -30,34 +43,189 @@ type ObserveNetworkFlowsInput struct {
 	HTTPPath string `json:"http_path,omitempty"`
 	// Filter by reserved entity (e.g. "host", "world").
 	Reserved string `json:"reserved,omitempty"`
-	// Number of recent flows (default 20, max 100).
+	// Number of recent flows (default 20, max 100; with aggregate default 500, max 2000).
 	Last int `json:"last,omitempty"`
+	// Only flows after this: Go duration ago (e.g. 15m) or RFC3339 time.
+	Since string `json:"since,omitempty"`
+	// Only flows before this: Go duration ago or RFC3339 time.
+	Until string `json:"until,omitempty"`
+	// Stream new flows for up to this many seconds (max 30) instead of reading recent ones; needs Hubble Relay.
+	FollowSeconds int `json:"follow_seconds,omitempty"`
+	// Group flows by (source, destination, port, verdict) instead of listing them.
+	Aggregate bool `json:"aggregate,omitempty"`
+}
+
+// FlowsResult lists parsed flows, newest last as Hubble returns them.
+type FlowsResult struct {
+	Count int              `json:"count"`
+	Flows []providers.Flow `json:"flows"`
+}
+
+// FlowAggregate summarizes flows grouped by (source, destination, port, verdict).
+type FlowAggregate struct {
+	Flows          int            `json:"flows"`
+	Verdicts       map[string]int `json:"verdicts"`
+	TopDropReasons []ReasonCount  `json:"top_drop_reasons,omitempty"`
+	Groups         []FlowGroup    `json:"groups"` // busiest first
+}
+
+// ReasonCount is a drop reason and how many flows it dropped.
+type ReasonCount struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// FlowGroup counts the flows between two endpoints on one destination port with one verdict.
+type FlowGroup struct {
+	Source      string         `json:"source"`
+	Destination string         `json:"destination"`
+	Port        uint32         `json:"port,omitempty"`
+	Protocol    string         `json:"protocol,omitempty"`
+	Verdict     string         `json:"verdict"`
+	Count       int            `json:"count"`
+	DropReasons map[string]int `json:"drop_reasons,omitempty"`
+	HTTPStatus  map[string]int `json:"http_status,omitempty"` // status code → responses
+	FirstSeen   time.Time      `json:"first_seen"`
+	LastSeen    time.Time      `json:"last_seen"`
 }
 
 // ObserveNetworkFlowsHandler handles real-time network flow observation via Hubble.
 type ObserveNetworkFlowsHandler struct {
-	// queryFn is decoupled from the Input struct to prevent import cycles.
-	queryFn func(ctx context.Context, namespace, pod, fromPod, toPod, protocol, verdict, httpStatus, httpMethod, httpPath, reserved string, port, toPort, last int) (string, error)
+	queryFn func(ctx context.Context, f providers.FlowFilter) ([]providers.Flow, error)
+	now     func() time.Time
 }
 
-func NewObserveNetworkFlowsHandler(fn func(ctx context.Context, namespace, pod, fromPod, toPod, protocol, verdict, httpStatus, httpMethod, httpPath, reserved string, port, toPort, last int) (string, error)) *ObserveNetworkFlowsHandler {
-	return &ObserveNetworkFlowsHandler{queryFn: fn}
+func NewObserveNetworkFlowsHandler(fn func(ctx context.Context, f providers.FlowFilter) ([]providers.Flow, error)) *ObserveNetworkFlowsHandler {
+	return &ObserveNetworkFlowsHandler{queryFn: fn, now: time.Now}
 }
 
 func (h *ObserveNetworkFlowsHandler) Execute(ctx context.Context, input ObserveNetworkFlowsInput) (interface{}, error) {
-	return h.queryFn(ctx,
-		input.Namespace,
-		input.Pod,
-		input.FromPod,
-		input.ToPod,
-		input.Protocol,
-		input.Verdict,
-		input.HTTPStatus,
-		input.HTTPMethod,
-		input.HTTPPath,
-		input.Reserved,
-		input.Port,
-		input.ToPort,
-		input.Last,
-	)
+	last := input.Last
+	switch {
+	case input.Aggregate && last <= 0:
+		last = defaultAggregateFlows
+	case input.Aggregate && last > providers.MaxFlowLast:
+		last = providers.MaxFlowLast
+	case !input.Aggregate && last <= 0:
+		last = defaultFlows
+	case !input.Aggregate && last > maxFlows:
+		last = maxFlows
+	}
+
+	if input.FollowSeconds < 0 || input.FollowSeconds > maxFollowSeconds {
+		return nil, providers.InvalidInputf("follow_seconds must be between 0 and %d", maxFollowSeconds)
+	}
+	now := h.now()
+	var since, until time.Time
+	var err error
+	if input.Since != "" {
+		if since, err = parseRelativeTime(input.Since, now); err != nil {
+			return nil, providers.InvalidInputf("invalid since: %w", err)
+		}
+	}
+	if input.Until != "" {
+		if until, err = parseRelativeTime(input.Until, now); err != nil {
+			return nil, providers.InvalidInputf("invalid until: %w", err)
+		}
+	}
+
+	flows, err := h.queryFn(ctx, providers.FlowFilter{
+		Namespace:  input.Namespace,
+		Pod:        input.Pod,
+		FromPod:    input.FromPod,
+		ToPod:      input.ToPod,
+		Protocol:   input.Protocol,
+		Port:       input.Port,
+		ToPort:     input.ToPort,
+		Verdict:    input.Verdict,
+		HTTPStatus: input.HTTPStatus,
+		HTTPMethod: input.HTTPMethod,
+		HTTPPath:   input.HTTPPath,
+		Reserved:   input.Reserved,
+		Last:       last,
+		Since:      since,
+		Until:      until,
+		Follow:     time.Duration(input.FollowSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if input.Aggregate {
+		return aggregateFlows(flows), nil
+	}
+	return &FlowsResult{Count: len(flows), Flows: flows}, nil
+}
+
+// aggregateFlows groups flows by (source, destination, port, verdict). Replies are folded into the
+// request's group so a connection is counted in one direction, keyed on the server port.
+func aggregateFlows(flows []providers.Flow) *FlowAggregate {
+	agg := &FlowAggregate{Flows: len(flows), Verdicts: make(map[string]int), Groups: []FlowGroup{}}
+	type key struct {
+		src, dst string
+		port     uint32
+		verdict  string
+	}
+	groups := make(map[key]*FlowGroup)
+	var order []key
+	drops := make(map[string]int)
+
+	for _, f := range flows {
+		agg.Verdicts[f.Verdict]++
+		if f.DropReason != "" {
+			drops[f.DropReason]++
+		}
+
+		src, dst := f.Source, f.Destination
+		if f.Reply {
+			src, dst = dst, src
+		}
+		k := key{src: src.Name(), dst: dst.Name(), port: dst.Port, verdict: f.Verdict}
+		g, ok := groups[k]
+		if !ok {
+			g = &FlowGroup{Source: k.src, Destination: k.dst, Port: k.port, Protocol: f.Protocol, Verdict: k.verdict, FirstSeen: f.Time, LastSeen: f.Time}
+			groups[k] = g
+			order = append(order, k)
+		}
+		g.Count++
+		if f.Time.Before(g.FirstSeen) {
+			g.FirstSeen = f.Time
+		}
+		if f.Time.After(g.LastSeen) {
+			g.LastSeen = f.Time
+		}
+		if f.DropReason != "" {
+			if g.DropReasons == nil {
+				g.DropReasons = make(map[string]int)
+			}
+			g.DropReasons[f.DropReason]++
+		}
+		if f.L7 != nil && f.L7.Protocol == "http" && f.L7.Status > 0 {
+			if g.HTTPStatus == nil {
+				g.HTTPStatus = make(map[string]int)
+			}
+			g.HTTPStatus[strconv.Itoa(f.L7.Status)]++
+		}
+	}
+
+	for _, k := range order {
+		agg.Groups = append(agg.Groups, *groups[k])
+	}
+	sort.SliceStable(agg.Groups, func(i, j int) bool {
+		return agg.Groups[i].Count > agg.Groups[j].Count
+	})
+
+	for reason, count := range drops {
+		agg.TopDropReasons = append(agg.TopDropReasons, ReasonCount{Reason: reason, Count: count})
+	}
+	sort.Slice(agg.TopDropReasons, func(i, j int) bool {
+		a, b := agg.TopDropReasons[i], agg.TopDropReasons[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Reason < b.Reason
+	})
+	if len(agg.TopDropReasons) > topDropReasons {
+		agg.TopDropReasons = agg.TopDropReasons[:topDropReasons]
+	}
+	return agg
 }
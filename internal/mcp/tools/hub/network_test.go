@@ -3,14 +3,20 @@ package hub
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
 )
 
 func TestObserveNetworkFlowsHandler_Execute(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	flows := []providers.Flow{{Verdict: "FORWARDED"}}
 	tests := []struct {
 		name       string
 		input      ObserveNetworkFlowsInput
-		mockOutput string
+		wantFilter providers.FlowFilter
 		mockErr    error
 		wantErr    bool
 	}{
@@ -31,72 +37,150 @@ func TestObserveNetworkFlowsHandler_Execute(t *testing.T) {
 				Reserved:   "world",
 				Last:       5,
 			},
-			mockOutput: `{"flow":{"verdict":"FORWARDED"}}`,
-			wantErr:    false,
+			wantFilter: providers.FlowFilter{
+				Namespace:  "default",
+				Pod:        "proxy",
+				FromPod:    "frontend",
+				ToPod:      "backend",
+				Protocol:   "tcp",
+				Port:       80,
+				ToPort:     8080,
+				Verdict:    "FORWARDED",
+				HTTPStatus: "200",
+				HTTPMethod: "GET",
+				HTTPPath:   "/api/v1",
+				Reserved:   "world",
+				Last:       5,
+			},
 		},
 		{
-			name: "Provider Error",
-			input: ObserveNetworkFlowsInput{
-				Namespace: "default",
-			},
-			mockErr: errors.New("hubble unreachable"),
+			name:       "Default Last",
+			input:      ObserveNetworkFlowsInput{Namespace: "default"},
+			wantFilter: providers.FlowFilter{Namespace: "default", Last: 20},
+		},
+		{
+			name:       "Last Capped Without Aggregate",
+			input:      ObserveNetworkFlowsInput{Last: 1000},
+			wantFilter: providers.FlowFilter{Last: 100},
+		},
+		{
+			name:       "Aggregate Default Last",
+			input:      ObserveNetworkFlowsInput{Aggregate: true},
+			wantFilter: providers.FlowFilter{Last: 500},
+		},
+		{
+			name:       "Aggregate Last Capped",
+			input:      ObserveNetworkFlowsInput{Aggregate: true, Last: 10000},
+			wantFilter: providers.FlowFilter{Last: 2000},
+		},
+		{
+			name:       "Time Range And Follow",
+			input:      ObserveNetworkFlowsInput{Since: "1h", Until: "2026-03-11T13:30:00Z", FollowSeconds: 10},
+			wantFilter: providers.FlowFilter{Last: 20, Since: now.Add(-time.Hour), Until: time.Date(2026, 3, 11, 13, 30, 0, 0, time.UTC), Follow: 10 * time.Second},
+		},
+		{
+			name:    "Invalid Since",
+			input:   ObserveNetworkFlowsInput{Since: "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "Follow Too Long",
+			input:   ObserveNetworkFlowsInput{FollowSeconds: 600},
 			wantErr: true,
 		},
+		{
+			name:       "Provider Error",
+			input:      ObserveNetworkFlowsInput{Namespace: "default"},
+			wantFilter: providers.FlowFilter{Namespace: "default", Last: 20},
+			mockErr:    errors.New("hubble unreachable"),
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := NewObserveNetworkFlowsHandler(func(ctx context.Context, namespace, pod, fromPod, toPod, protocol, verdict, httpStatus, httpMethod, httpPath, reserved string, port, toPort, last int) (string, error) {
-				if namespace != tt.input.Namespace {
-					t.Errorf("got namespace %q, want %q", namespace, tt.input.Namespace)
-				}
-				if pod != tt.input.Pod {
-					t.Errorf("got pod %q, want %q", pod, tt.input.Pod)
-				}
-				if fromPod != tt.input.FromPod {
-					t.Errorf("got fromPod %q, want %q", fromPod, tt.input.FromPod)
+			handler := NewObserveNetworkFlowsHandler(func(ctx context.Context, f providers.FlowFilter) ([]providers.Flow, error) {
+				if !reflect.DeepEqual(f, tt.wantFilter) {
+					t.Errorf("got filter %+v, want %+v", f, tt.wantFilter)
 				}
-				if toPod != tt.input.ToPod {
-					t.Errorf("got toPod %q, want %q", toPod, tt.input.ToPod)
-				}
-				if protocol != tt.input.Protocol {
-					t.Errorf("got protocol %q, want %q", protocol, tt.input.Protocol)
-				}
-				if verdict != tt.input.Verdict {
-					t.Errorf("got verdict %q, want %q", verdict, tt.input.Verdict)
-				}
-				if httpStatus != tt.input.HTTPStatus {
-					t.Errorf("got httpStatus %q, want %q", httpStatus, tt.input.HTTPStatus)
-				}
-				if httpMethod != tt.input.HTTPMethod {
-					t.Errorf("got httpMethod %q, want %q", httpMethod, tt.input.HTTPMethod)
-				}
-				if httpPath != tt.input.HTTPPath {
-					t.Errorf("got httpPath %q, want %q", httpPath, tt.input.HTTPPath)
-				}
-				if reserved != tt.input.Reserved {
-					t.Errorf("got reserved %q, want %q", reserved, tt.input.Reserved)
-				}
-				if port != tt.input.Port {
-					t.Errorf("got port %d, want %d", port, tt.input.Port)
-				}
-				if toPort != tt.input.ToPort {
-					t.Errorf("got toPort %d, want %d", toPort, tt.input.ToPort)
-				}
-				if last != tt.input.Last {
-					t.Errorf("got last %d, want %d", last, tt.input.Last)
-				}
-				return tt.mockOutput, tt.mockErr
+				return flows, tt.mockErr
 			})
+			handler.now = func() time.Time { return now }
 
 			got, err := handler.Execute(context.Background(), tt.input)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.input.Aggregate {
+				if agg, ok := got.(*FlowAggregate); !ok || agg.Flows != 1 {
+					t.Errorf("got %#v, want an aggregate of 1 flow", got)
+				}
 				return
 			}
-			if !tt.wantErr && got != tt.mockOutput {
-				t.Errorf("got %v, want %v", got, tt.mockOutput)
+			if res, ok := got.(*FlowsResult); !ok || res.Count != 1 {
+				t.Errorf("got %#v, want 1 flow", got)
 			}
 		})
 	}
 }
+
+func TestAggregateFlows(t *testing.T) {
+	t0 := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	grafana := providers.FlowEndpoint{Namespace: "observability", Pod: "grafana-abc", Workload: "grafana"}
+	postgres := providers.FlowEndpoint{Namespace: "observability", Pod: "postgres-0", Port: 5432}
+	api := providers.FlowEndpoint{Namespace: "apps", Pod: "api-0", Port: 8080}
+	world := providers.FlowEndpoint{Labels: []string{"reserved:world"}, IP: "1.1.1.1"}
+	withPort := func(e providers.FlowEndpoint, port uint32) providers.FlowEndpoint {
+		e.Port = port
+		return e
+	}
+	http := func(status int) *providers.FlowL7 {
+		return &providers.FlowL7{Type: "RESPONSE", Protocol: "http", Status: status}
+	}
+
+	flows := []providers.Flow{
+		{Time: t0, Verdict: "DROPPED", DropReason: "POLICY_DENIED", Protocol: "TCP", Source: withPort(grafana, 40000), Destination: postgres},
+		{Time: t0.Add(time.Second), Verdict: "DROPPED", DropReason: "POLICY_DENIED", Protocol: "TCP", Source: withPort(grafana, 40001), Destination: postgres},
+		{Time: t0.Add(2 * time.Second), Verdict: "DROPPED", DropReason: "CT_MAP_INSERTION_FAILED", Protocol: "TCP", Source: withPort(grafana, 40002), Destination: postgres},
+		// A request and its reply belong to the same group.
+		{Time: t0.Add(3 * time.Second), Verdict: "FORWARDED", Protocol: "TCP", Source: withPort(grafana, 41000), Destination: api},
+		{Time: t0.Add(4 * time.Second), Verdict: "FORWARDED", Protocol: "TCP", Source: api, Destination: withPort(grafana, 41000), Reply: true, L7: http(503)},
+		{Time: t0.Add(5 * time.Second), Verdict: "FORWARDED", Protocol: "TCP", Source: api, Destination: withPort(grafana, 41001), Reply: true, L7: http(200)},
+		{Time: t0.Add(6 * time.Second), Verdict: "FORWARDED", Protocol: "UDP", Source: withPort(grafana, 53000), Destination: withPort(world, 53)},
+	}
+
+	agg := aggregateFlows(flows)
+	if agg.Flows != 7 {
+		t.Errorf("flows = %d, want 7", agg.Flows)
+	}
+	if want := map[string]int{"DROPPED": 3, "FORWARDED": 4}; !reflect.DeepEqual(agg.Verdicts, want) {
+		t.Errorf("verdicts = %v, want %v", agg.Verdicts, want)
+	}
+	wantReasons := []ReasonCount{{Reason: "POLICY_DENIED", Count: 2}, {Reason: "CT_MAP_INSERTION_FAILED", Count: 1}}
+	if !reflect.DeepEqual(agg.TopDropReasons, wantReasons) {
+		t.Errorf("top drop reasons = %v, want %v", agg.TopDropReasons, wantReasons)
+	}
+
+	wantGroups := []FlowGroup{
+		{
+			Source: "observability/grafana", Destination: "observability/postgres-0", Port: 5432, Protocol: "TCP", Verdict: "DROPPED", Count: 3,
+			DropReasons: map[string]int{"POLICY_DENIED": 2, "CT_MAP_INSERTION_FAILED": 1},
+			FirstSeen:   t0, LastSeen: t0.Add(2 * time.Second),
+		},
+		{
+			Source: "observability/grafana", Destination: "apps/api-0", Port: 8080, Protocol: "TCP", Verdict: "FORWARDED", Count: 3,
+			HTTPStatus: map[string]int{"503": 1, "200": 1},
+			FirstSeen:  t0.Add(3 * time.Second), LastSeen: t0.Add(5 * time.Second),
+		},
+		{
+			Source: "observability/grafana", Destination: "reserved:world", Port: 53, Protocol: "UDP", Verdict: "FORWARDED", Count: 1,
+			FirstSeen: t0.Add(6 * time.Second), LastSeen: t0.Add(6 * time.Second),
+		},
+	}
+	if !reflect.DeepEqual(agg.Groups, wantGroups) {
+		t.Errorf("groups =\n%+v\nwant\n%+v", agg.Groups, wantGroups)
+	}
+}
@@ -0,0 +1,22 @@
+package hub
+
+import (
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+// parseRelativeTime accepts a Go duration before now or an RFC3339 timestamp.
+func parseRelativeTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, providers.InvalidInputf("%q: durations count back from now and must be positive", value)
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, providers.InvalidInputf("%q: use a duration such as 30m or an RFC3339 time", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,53 @@
+// Package logsummary condenses log lines through the obs-processor binary, which takes a
+// Loki streams response and ranks and deduplicates its lines.
+package logsummary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// processorTimeout keeps a stuck processor from hanging the MCP server.
+const processorTimeout = 5 * time.Second
+
+// Stream is one Loki log stream: its labels and [unix-nanoseconds, line] values.
+// obs-processor ranks streams by their "detected_level" label.
+type Stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][]string        `json:"values"`
+}
+
+// Summarize wraps streams in a Loki streams response and pipes it through the
+// obs-processor at processorPath.
+func Summarize(ctx context.Context, processorPath string, streams []*Stream) (interface{}, error) {
+	rawJSON, err := json.Marshal(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "streams", "result": streams},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal log streams: %w", err)
+	}
+
+	childCtx, cancel := context.WithTimeout(ctx, processorTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(childCtx, processorPath, "--type", "logs")
+	cmd.Stdin = bytes.NewReader(rawJSON)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("rust processor execution failed: %w", err)
+	}
+
+	var summarized interface{}
+	if err := json.Unmarshal(out.Bytes(), &summarized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal summarized logs: %w", err)
+	}
+	return summarized, nil
+}
@@ -0,0 +1,45 @@
+package logsummary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	dir := t.TempDir()
+	// The fake processor only answers when it receives a Loki streams response.
+	processor := filepath.Join(dir, "obs-processor")
+	script := "#!/bin/sh\ngrep -q '\"resultType\":\"streams\"' && echo '{\"summarized_count\":1}'\n"
+	if err := os.WriteFile(processor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	streams := []*Stream{{
+		Stream: map[string]string{"unit": "proxy.service", "detected_level": "error"},
+		Values: [][]string{{"1773237600000000000", "failed to bind port"}},
+	}}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "processor output is decoded", path: processor},
+		{name: "missing processor fails", path: filepath.Join(dir, "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Summarize(context.Background(), tt.path, streams)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Summarize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if m, ok := got.(map[string]interface{}); !ok || m["summarized_count"] != float64(1) {
+				t.Errorf("Summarize() = %v, want the processor's JSON", got)
+			}
+		})
+	}
+}
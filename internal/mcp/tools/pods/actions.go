@@ -0,0 +1,95 @@
+package pods
+
+import (
+	"context"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+// Rollout wait bounds in seconds for rollout_restart and scale_workload.
+const (
+	defaultWaitSeconds = 60
+	maxWaitSeconds     = 300
+)
+
+// RolloutRestartInput is the input for restarting all pods of a workload.
+type RolloutRestartInput struct {
+	Kind         string `json:"kind"` // Deployment, StatefulSet or DaemonSet
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	WaitSeconds  int    `json:"wait_seconds,omitempty"` // how long to wait for the rollout (default 60, max 300)
+	Reason       string `json:"reason"`
+	DryRun       bool   `json:"dry_run,omitempty"`
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// RolloutRestartHandler handles restarting a workload.
+type RolloutRestartHandler struct {
+	restartFn func(ctx context.Context, kind, namespace, name string, wait time.Duration) (*providers.RolloutStatus, error)
+}
+
+func NewRolloutRestartHandler(restartFn func(ctx context.Context, kind, namespace, name string, wait time.Duration) (*providers.RolloutStatus, error)) *RolloutRestartHandler {
+	return &RolloutRestartHandler{restartFn: restartFn}
+}
+
+func (h *RolloutRestartHandler) Execute(ctx context.Context, input RolloutRestartInput) (interface{}, error) {
+	return h.restartFn(ctx, input.Kind, input.Namespace, input.Name, waitDuration(input.WaitSeconds))
+}
+
+// ScaleWorkloadInput is the input for changing the replica count of a workload.
+type ScaleWorkloadInput struct {
+	Kind         string `json:"kind"` // Deployment or StatefulSet
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	Replicas     int32  `json:"replicas"`
+	WaitSeconds  int    `json:"wait_seconds,omitempty"` // how long to wait for the new replica count (default 60, max 300)
+	Reason       string `json:"reason"`
+	DryRun       bool   `json:"dry_run,omitempty"`
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// ScaleWorkloadHandler handles scaling a workload.
+type ScaleWorkloadHandler struct {
+	scaleFn func(ctx context.Context, kind, namespace, name string, replicas int32, wait time.Duration) (*providers.RolloutStatus, error)
+}
+
+func NewScaleWorkloadHandler(scaleFn func(ctx context.Context, kind, namespace, name string, replicas int32, wait time.Duration) (*providers.RolloutStatus, error)) *ScaleWorkloadHandler {
+	return &ScaleWorkloadHandler{scaleFn: scaleFn}
+}
+
+func (h *ScaleWorkloadHandler) Execute(ctx context.Context, input ScaleWorkloadInput) (interface{}, error) {
+	return h.scaleFn(ctx, input.Kind, input.Namespace, input.Name, input.Replicas, waitDuration(input.WaitSeconds))
+}
+
+// CordonNodeInput is the input for cordoning or uncordoning a node.
+type CordonNodeInput struct {
+	Name         string `json:"name"`
+	Uncordon     bool   `json:"uncordon,omitempty"` // make the node schedulable again instead
+	Reason       string `json:"reason"`
+	DryRun       bool   `json:"dry_run,omitempty"`
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// CordonNodeHandler handles changing node schedulability.
+type CordonNodeHandler struct {
+	cordonFn func(ctx context.Context, name string, cordon bool) (*providers.NodeStatus, error)
+}
+
+func NewCordonNodeHandler(cordonFn func(ctx context.Context, name string, cordon bool) (*providers.NodeStatus, error)) *CordonNodeHandler {
+	return &CordonNodeHandler{cordonFn: cordonFn}
+}
+
+func (h *CordonNodeHandler) Execute(ctx context.Context, input CordonNodeInput) (interface{}, error) {
+	return h.cordonFn(ctx, input.Name, !input.Uncordon)
+}
+
+func waitDuration(seconds int) time.Duration {
+	if seconds <= 0 {
+		seconds = defaultWaitSeconds
+	}
+	if seconds > maxWaitSeconds {
+		seconds = maxWaitSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
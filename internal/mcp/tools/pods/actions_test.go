@@ -0,0 +1,86 @@
+package pods
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func TestRolloutRestartHandler_Execute(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    RolloutRestartInput
+		wantWait time.Duration
+		err      error
+	}{
+		{name: "default wait", input: RolloutRestartInput{Kind: "Deployment", Namespace: "default", Name: "api"}, wantWait: 60 * time.Second},
+		{name: "custom wait", input: RolloutRestartInput{Kind: "Deployment", Name: "api", WaitSeconds: 10}, wantWait: 10 * time.Second},
+		{name: "wait clamped", input: RolloutRestartInput{Kind: "Deployment", Name: "api", WaitSeconds: 3600}, wantWait: 300 * time.Second},
+		{name: "provider error", input: RolloutRestartInput{Kind: "Deployment", Name: "api"}, wantWait: 60 * time.Second, err: errors.New("api error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotWait time.Duration
+			h := NewRolloutRestartHandler(func(ctx context.Context, kind, namespace, name string, wait time.Duration) (*providers.RolloutStatus, error) {
+				gotWait = wait
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &providers.RolloutStatus{Kind: kind, Name: name, Complete: true}, nil
+			})
+			_, err := h.Execute(context.Background(), tt.input)
+			if (err != nil) != (tt.err != nil) {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.err != nil)
+			}
+			if gotWait != tt.wantWait {
+				t.Errorf("Execute() wait = %v, want %v", gotWait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestScaleWorkloadHandler_Execute(t *testing.T) {
+	var gotReplicas int32
+	h := NewScaleWorkloadHandler(func(ctx context.Context, kind, namespace, name string, replicas int32, wait time.Duration) (*providers.RolloutStatus, error) {
+		gotReplicas = replicas
+		return &providers.RolloutStatus{Kind: kind, Name: name, Replicas: replicas}, nil
+	})
+	got, err := h.Execute(context.Background(), ScaleWorkloadInput{Kind: "StatefulSet", Name: "db", Replicas: 0})
+	if err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	if gotReplicas != 0 || got.(*providers.RolloutStatus).Replicas != 0 {
+		t.Errorf("Execute() replicas = %d, want 0", gotReplicas)
+	}
+}
+
+func TestCordonNodeHandler_Execute(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      CordonNodeInput
+		wantCordon bool
+	}{
+		{name: "cordon", input: CordonNodeInput{Name: "node-a"}, wantCordon: true},
+		{name: "uncordon", input: CordonNodeInput{Name: "node-a", Uncordon: true}, wantCordon: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCordon bool
+			h := NewCordonNodeHandler(func(ctx context.Context, name string, cordon bool) (*providers.NodeStatus, error) {
+				gotCordon = cordon
+				return &providers.NodeStatus{Name: name, Unschedulable: cordon}, nil
+			})
+			if _, err := h.Execute(context.Background(), tt.input); err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if gotCordon != tt.wantCordon {
+				t.Errorf("Execute() cordon = %v, want %v", gotCordon, tt.wantCordon)
+			}
+		})
+	}
+}
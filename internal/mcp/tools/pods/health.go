@@ -0,0 +1,295 @@
+package pods
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainerHealth is the triage view of one container (or init container).
+type ContainerHealth struct {
+	Name            string            `json:"name"`
+	Init            bool              `json:"init,omitempty"`
+	Image           string            `json:"image,omitempty"`
+	Ready           bool              `json:"ready"`
+	State           string            `json:"state"`            // running, waiting or terminated
+	Reason          string            `json:"reason,omitempty"` // e.g. CrashLoopBackOff, ImagePullBackOff, OOMKilled
+	Message         string            `json:"message,omitempty"`
+	ExitCode        *int32            `json:"exit_code,omitempty"` // set when State is terminated
+	Restarts        int32             `json:"restarts"`
+	StartedAt       *time.Time        `json:"started_at,omitempty"`
+	LastTermination *Termination      `json:"last_termination,omitempty"`
+	Probes          map[string]string `json:"probes,omitempty"`   // describe_pod only
+	Requests        map[string]string `json:"requests,omitempty"` // describe_pod only
+	Limits          map[string]string `json:"limits,omitempty"`   // describe_pod only
+}
+
+// Termination describes how a previous container instance exited.
+type Termination struct {
+	Reason     string     `json:"reason"`
+	ExitCode   int32      `json:"exit_code"`
+	Signal     int32      `json:"signal,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// PodCondition is a pod condition with its last transition time.
+type PodCondition struct {
+	Type    string     `json:"type"`
+	Status  string     `json:"status"`
+	Reason  string     `json:"reason,omitempty"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// ResourceFit compares the pod's total requests and limits with its node's allocatable capacity.
+type ResourceFit struct {
+	Resource        string  `json:"resource"` // cpu or memory
+	Requests        string  `json:"requests,omitempty"`
+	Limits          string  `json:"limits,omitempty"`
+	NodeAllocatable string  `json:"node_allocatable,omitempty"`
+	RequestsPercent float64 `json:"requests_percent,omitempty"` // requests as a share of node allocatable
+}
+
+// describePodHealth adds probes, per-container resources, pod conditions and node fit to a summary.
+func describePodHealth(pod *corev1.Pod, node *corev1.Node) PodSummary {
+	s := summarizePod(pod)
+	specs := containerSpecs(pod)
+	for i := range s.Containers {
+		c := &s.Containers[i]
+		spec, ok := specs[c.Name]
+		if !ok {
+			continue
+		}
+		c.Probes = probeSummaries(spec)
+		c.Requests = resourceStrings(spec.Resources.Requests)
+		c.Limits = resourceStrings(spec.Resources.Limits)
+	}
+	for _, c := range pod.Status.Conditions {
+		s.Conditions = append(s.Conditions, PodCondition{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+			Since:   timePtr(c.LastTransitionTime),
+		})
+	}
+	s.Resources = resourceFit(pod, node)
+	return s
+}
+
+// summarizePod builds the compact health view returned by inspect_pods.
+func summarizePod(pod *corev1.Pod) PodSummary {
+	s := PodSummary{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Status:    string(pod.Status.Phase),
+		Reason:    pod.Status.Reason,
+		IP:        pod.Status.PodIP,
+		Node:      pod.Spec.NodeName,
+		QoS:       string(pod.Status.QOSClass),
+		StartedAt: timePtr(derefTime(pod.Status.StartTime)),
+	}
+	if pod.DeletionTimestamp != nil {
+		s.Reason = "Terminating"
+	}
+
+	images := make(map[string]string)
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		images[c.Name] = c.Image
+	}
+
+	ready := 0
+	for _, cs := range pod.Status.InitContainerStatuses {
+		c := containerHealth(cs, images[cs.Name])
+		c.Init = true
+		s.Restarts += c.Restarts
+		// Completed init containers are noise; only report those still running or failing.
+		if c.State == "terminated" && c.ExitCode != nil && *c.ExitCode == 0 {
+			continue
+		}
+		s.Containers = append(s.Containers, c)
+		if s.Reason == "" && c.Reason != "" {
+			s.Reason = "Init:" + c.Reason
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		c := containerHealth(cs, images[cs.Name])
+		s.Restarts += c.Restarts
+		if c.Ready {
+			ready++
+		}
+		if s.Reason == "" && c.State != "running" && c.Reason != "" && c.Reason != "Completed" {
+			s.Reason = c.Reason
+		}
+		s.Containers = append(s.Containers, c)
+	}
+	s.Ready = fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+	return s
+}
+
+func containerHealth(cs corev1.ContainerStatus, image string) ContainerHealth {
+	c := ContainerHealth{
+		Name:     cs.Name,
+		Image:    image,
+		Ready:    cs.Ready,
+		Restarts: cs.RestartCount,
+	}
+	switch {
+	case cs.State.Running != nil:
+		c.State = "running"
+		c.StartedAt = timePtr(cs.State.Running.StartedAt)
+	case cs.State.Waiting != nil:
+		c.State = "waiting"
+		c.Reason = cs.State.Waiting.Reason
+		c.Message = cs.State.Waiting.Message
+	case cs.State.Terminated != nil:
+		t := cs.State.Terminated
+		c.State = "terminated"
+		c.Reason = t.Reason
+		c.Message = t.Message
+		c.ExitCode = &t.ExitCode
+		c.StartedAt = timePtr(t.StartedAt)
+	default:
+		c.State = "unknown"
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil {
+		c.LastTermination = &Termination{
+			Reason:     t.Reason,
+			ExitCode:   t.ExitCode,
+			Signal:     t.Signal,
+			Message:    t.Message,
+			FinishedAt: timePtr(t.FinishedAt),
+		}
+	}
+	return c
+}
+
+func containerSpecs(pod *corev1.Pod) map[string]corev1.Container {
+	specs := make(map[string]corev1.Container, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for _, c := range pod.Spec.InitContainers {
+		specs[c.Name] = c
+	}
+	for _, c := range pod.Spec.Containers {
+		specs[c.Name] = c
+	}
+	return specs
+}
+
+// probeSummaries renders probes as one line each, e.g.
+// "http-get :8080/healthz delay=10s period=10s timeout=1s failure=3".
+func probeSummaries(c corev1.Container) map[string]string {
+	probes := make(map[string]string)
+	for name, p := range map[string]*corev1.Probe{"liveness": c.LivenessProbe, "readiness": c.ReadinessProbe, "startup": c.StartupProbe} {
+		if p != nil {
+			probes[name] = probeString(p)
+		}
+	}
+	if len(probes) == 0 {
+		return nil
+	}
+	return probes
+}
+
+func probeString(p *corev1.Probe) string {
+	var action string
+	switch {
+	case p.HTTPGet != nil:
+		action = fmt.Sprintf("http-get :%s%s", p.HTTPGet.Port.String(), p.HTTPGet.Path)
+		if p.HTTPGet.Scheme == corev1.URISchemeHTTPS {
+			action = "https-get" + strings.TrimPrefix(action, "http-get")
+		}
+	case p.TCPSocket != nil:
+		action = "tcp-socket :" + p.TCPSocket.Port.String()
+	case p.GRPC != nil:
+		action = fmt.Sprintf("grpc :%d", p.GRPC.Port)
+	case p.Exec != nil:
+		action = "exec " + strings.Join(p.Exec.Command, " ")
+	default:
+		action = "unknown"
+	}
+	// Zero values mean the API server defaults apply.
+	period, timeout, failure := p.PeriodSeconds, p.TimeoutSeconds, p.FailureThreshold
+	if period == 0 {
+		period = 10
+	}
+	if timeout == 0 {
+		timeout = 1
+	}
+	if failure == 0 {
+		failure = 3
+	}
+	return fmt.Sprintf("%s delay=%ds period=%ds timeout=%ds failure=%d", action, p.InitialDelaySeconds, period, timeout, failure)
+}
+
+func resourceStrings(list corev1.ResourceList) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(list))
+	for name, q := range list {
+		out[string(name)] = q.String()
+	}
+	return out
+}
+
+// resourceFit sums container requests and limits for cpu and memory. Init containers are
+// ignored, which slightly understates pods whose init containers request more than the app.
+func resourceFit(pod *corev1.Pod, node *corev1.Node) []ResourceFit {
+	var fits []ResourceFit
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var requests, limits resource.Quantity
+		hasLimit := true
+		for _, c := range pod.Spec.Containers {
+			if q, ok := c.Resources.Requests[name]; ok {
+				requests.Add(q)
+			}
+			if q, ok := c.Resources.Limits[name]; ok {
+				limits.Add(q)
+			} else {
+				hasLimit = false
+			}
+		}
+
+		fit := ResourceFit{Resource: string(name)}
+		if !requests.IsZero() {
+			fit.Requests = requests.String()
+		}
+		if hasLimit && !limits.IsZero() {
+			fit.Limits = limits.String()
+		}
+		if node != nil {
+			if alloc, ok := node.Status.Allocatable[name]; ok && !alloc.IsZero() {
+				fit.NodeAllocatable = alloc.String()
+				if !requests.IsZero() {
+					pct := float64(requests.MilliValue()) / float64(alloc.MilliValue()) * 100
+					fit.RequestsPercent = float64(int(pct*10+0.5)) / 10
+				}
+			}
+		}
+		if fit.Requests == "" && fit.Limits == "" && fit.NodeAllocatable == "" {
+			continue
+		}
+		fits = append(fits, fit)
+	}
+	return fits
+}
+
+func derefTime(t *metav1.Time) metav1.Time {
+	if t == nil {
+		return metav1.Time{}
+	}
+	return *t
+}
+
+func timePtr(t metav1.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
@@ -0,0 +1,195 @@
+package pods
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func crashingPod() *corev1.Pod {
+	finished := metav1.NewTime(time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC))
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: "node-a",
+			Containers: []corev1.Container{
+				{
+					Name:  "app",
+					Image: "api:1.2.3",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+					},
+					LivenessProbe: &corev1.Probe{
+						ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt32(8080)}},
+						InitialDelaySeconds: 5,
+					},
+					ReadinessProbe: &corev1.Probe{
+						ProbeHandler:  corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")}},
+						PeriodSeconds: 5,
+					},
+				},
+				{Name: "sidecar", Image: "proxy:1"},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady", LastTransitionTime: finished},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: 7,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Reason: "OOMKilled", ExitCode: 137, FinishedAt: finished,
+					}},
+				},
+				{
+					Name:  "sidecar",
+					Ready: true,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: finished}},
+				},
+			},
+		},
+	}
+}
+
+func TestSummarizePod(t *testing.T) {
+	pullFailing := crashingPod()
+	pullFailing.Spec.InitContainers = []corev1.Container{{Name: "migrate", Image: "migrate:bad"}}
+	pullFailing.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		{Name: "migrate", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+	}
+	initDone := crashingPod()
+	initDone.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		{Name: "setup", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}},
+	}
+	evicted := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "evicted", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+	}
+
+	tests := []struct {
+		name           string
+		pod            *corev1.Pod
+		wantReason     string
+		wantReady      string
+		wantRestarts   int32
+		wantContainers int
+	}{
+		{name: "crash loop", pod: crashingPod(), wantReason: "CrashLoopBackOff", wantReady: "1/2", wantRestarts: 7, wantContainers: 2},
+		{name: "init image pull", pod: pullFailing, wantReason: "Init:ImagePullBackOff", wantReady: "1/2", wantRestarts: 7, wantContainers: 3},
+		{name: "completed init hidden", pod: initDone, wantReason: "CrashLoopBackOff", wantReady: "1/2", wantRestarts: 7, wantContainers: 2},
+		{name: "evicted", pod: evicted, wantReason: "Evicted", wantReady: "0/0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizePod(tt.pod)
+			if got.Reason != tt.wantReason || got.Ready != tt.wantReady || got.Restarts != tt.wantRestarts || len(got.Containers) != tt.wantContainers {
+				t.Errorf("summarizePod() = reason %q ready %q restarts %d containers %d, want %q %q %d %d",
+					got.Reason, got.Ready, got.Restarts, len(got.Containers), tt.wantReason, tt.wantReady, tt.wantRestarts, tt.wantContainers)
+			}
+			if got.Conditions != nil || got.Resources != nil {
+				t.Error("summarizePod() should leave describe-only fields empty")
+			}
+		})
+	}
+
+	app := summarizePod(crashingPod()).Containers[0]
+	if app.LastTermination == nil || app.LastTermination.Reason != "OOMKilled" || app.LastTermination.ExitCode != 137 {
+		t.Errorf("last termination = %+v, want OOMKilled/137", app.LastTermination)
+	}
+	if app.Image != "api:1.2.3" || app.State != "waiting" {
+		t.Errorf("container = %+v, want waiting api:1.2.3", app)
+	}
+}
+
+func TestDescribePodHandler_Health(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+		}},
+	}
+	getFn := func(ctx context.Context, namespace, name string) (*corev1.Pod, error) { return crashingPod(), nil }
+
+	tests := []struct {
+		name       string
+		nodeFn     func(ctx context.Context, name string) (*corev1.Node, error)
+		wantCPU    ResourceFit
+		wantMemory ResourceFit
+	}{
+		{
+			name:       "with node",
+			nodeFn:     func(ctx context.Context, name string) (*corev1.Node, error) { return node, nil },
+			wantCPU:    ResourceFit{Resource: "cpu", Requests: "500m", NodeAllocatable: "4", RequestsPercent: 12.5},
+			wantMemory: ResourceFit{Resource: "memory", Requests: "256Mi", NodeAllocatable: "8Gi", RequestsPercent: 3.1},
+		},
+		{
+			name:       "node lookup fails",
+			nodeFn:     func(ctx context.Context, name string) (*corev1.Node, error) { return nil, errors.New("forbidden") },
+			wantCPU:    ResourceFit{Resource: "cpu", Requests: "500m"},
+			wantMemory: ResourceFit{Resource: "memory", Requests: "256Mi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewDescribePodHandler(getFn, tt.nodeFn).Execute(context.Background(), PodsInput{Namespace: "default", Name: "api-0"})
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			got := res.(PodSummary)
+			if len(got.Resources) != 2 || got.Resources[0] != tt.wantCPU || got.Resources[1] != tt.wantMemory {
+				t.Errorf("resources = %+v, want [%+v %+v]", got.Resources, tt.wantCPU, tt.wantMemory)
+			}
+			if len(got.Conditions) != 1 || got.Conditions[0].Since == nil {
+				t.Errorf("conditions = %+v, want 1 with timestamp", got.Conditions)
+			}
+			app := got.Containers[0]
+			if app.Probes["liveness"] != "http-get :8080/healthz delay=5s period=10s timeout=1s failure=3" {
+				t.Errorf("liveness probe = %q", app.Probes["liveness"])
+			}
+			if app.Probes["readiness"] != "tcp-socket :http delay=0s period=5s timeout=1s failure=3" {
+				t.Errorf("readiness probe = %q", app.Probes["readiness"])
+			}
+			if app.Limits["memory"] != "512Mi" || app.Requests["cpu"] != "500m" {
+				t.Errorf("container resources = %v / %v", app.Requests, app.Limits)
+			}
+		})
+	}
+}
+
+func TestPodsHandlers_Raw(t *testing.T) {
+	listFn := func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error) {
+		return &corev1.PodList{Items: []corev1.Pod{*crashingPod()}}, nil
+	}
+	got, err := NewInspectPodsHandler(listFn).Execute(context.Background(), InspectPodsInput{Raw: true})
+	if err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	if page := got.(PodPage); len(page.Raw) != 1 || page.Pods != nil {
+		t.Errorf("inspect raw got %+v, want one raw pod and no summaries", page)
+	}
+
+	getFn := func(ctx context.Context, namespace, name string) (*corev1.Pod, error) { return crashingPod(), nil }
+	got, err = NewDescribePodHandler(getFn, nil).Execute(context.Background(), PodsInput{Raw: true})
+	if err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	if _, ok := got.(*corev1.Pod); !ok {
+		t.Errorf("describe raw got %T, want *corev1.Pod", got)
+	}
+}
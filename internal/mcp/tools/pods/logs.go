@@ -0,0 +1,191 @@
+package pods
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+	"observability-hub/internal/mcp/tools/logsummary"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+const (
+	defaultLogSince     = time.Hour
+	maxLogSince         = 24 * time.Hour
+	defaultLogLines     = 500
+	maxLogLines         = 2000
+	maxGrepLength       = 500
+	summarizeLogLines   = 200 // results longer than this are digested by obs-processor
+	summarizedTailLines = 50  // raw lines kept next to a digest
+)
+
+var (
+	errorLevelPattern = regexp.MustCompile(`(?i)\b(error|fatal|panic|exception)\b`)
+	warnLevelPattern  = regexp.MustCompile(`(?i)\bwarn(ing)?\b`)
+)
+
+// SearchPodLogsInput is the input for searching the logs of one pod or a label-selected set of pods.
+type SearchPodLogsInput struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name,omitempty"`           // a single pod; mutually exclusive with label_selector
+	LabelSelector string `json:"label_selector,omitempty"` // e.g. "app=sensor-fleet"
+	Container     string `json:"container,omitempty"`      // empty searches every container, including init containers
+	Since         string `json:"since,omitempty"`          // Go duration, e.g. "15m" (default 1h, max 24h)
+	Grep          string `json:"grep,omitempty"`           // RE2 regular expression; use (?i) for case-insensitive
+	TailLines     int64  `json:"tail_lines,omitempty"`     // per container, before filtering (default 10000)
+	Previous      bool   `json:"previous,omitempty"`       // read the previous (crashed) container instances
+	MaxLines      int    `json:"max_lines,omitempty"`      // merged line cap (default 500, max 2000)
+}
+
+// PodLogsResult is the merged output of search_pod_logs.
+type PodLogsResult struct {
+	Streams   int               `json:"streams"`
+	Scanned   int               `json:"scanned"`
+	Matched   int               `json:"matched"`
+	Returned  int               `json:"returned"`
+	Truncated bool              `json:"truncated"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	Lines     []string          `json:"lines"`             // "<timestamp> <pod>/<container> | <text>", oldest first
+	Summary   interface{}       `json:"summary,omitempty"` // obs-processor digest of every returned line when the result is large
+}
+
+// SearchPodLogsHandler handles streaming, filtering and interleaving pod logs.
+type SearchPodLogsHandler struct {
+	searchFn      func(ctx context.Context, namespace string, opts providers.PodLogSearchOptions) (*providers.PodLogSearch, error)
+	processorPath string
+}
+
+func NewSearchPodLogsHandler(searchFn func(ctx context.Context, namespace string, opts providers.PodLogSearchOptions) (*providers.PodLogSearch, error)) *SearchPodLogsHandler {
+	return &SearchPodLogsHandler{
+		searchFn:      searchFn,
+		processorPath: "/usr/local/bin/obs-processor",
+	}
+}
+
+func (h *SearchPodLogsHandler) Execute(ctx context.Context, input SearchPodLogsInput) (interface{}, error) {
+	if (input.Name == "") == (input.LabelSelector == "") {
+		return nil, providers.InvalidInputf("exactly one of name or label_selector is required")
+	}
+
+	since := defaultLogSince
+	if input.Since != "" {
+		d, err := time.ParseDuration(input.Since)
+		if err != nil || d <= 0 {
+			return nil, providers.InvalidInputf("invalid since %q: use a positive duration such as 15m or 2h", input.Since)
+		}
+		since = d
+	}
+	if since > maxLogSince {
+		since = maxLogSince
+	}
+
+	var pattern *regexp.Regexp
+	if input.Grep != "" {
+		if len(input.Grep) > maxGrepLength {
+			return nil, providers.InvalidInputf("grep too long (max %d chars)", maxGrepLength)
+		}
+		re, err := regexp.Compile(input.Grep)
+		if err != nil {
+			return nil, providers.InvalidInputf("invalid grep pattern: %w", err)
+		}
+		pattern = re
+	}
+
+	maxLines := input.MaxLines
+	if maxLines <= 0 {
+		maxLines = defaultLogLines
+	}
+	if maxLines > maxLogLines {
+		maxLines = maxLogLines
+	}
+
+	search, err := h.searchFn(ctx, input.Namespace, providers.PodLogSearchOptions{
+		Name:          input.Name,
+		LabelSelector: input.LabelSelector,
+		Container:     input.Container,
+		Since:         since,
+		TailLines:     input.TailLines,
+		Previous:      input.Previous,
+		Pattern:       pattern,
+		MaxLines:      maxLines,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := PodLogsResult{
+		Streams:   search.Streams,
+		Scanned:   search.Scanned,
+		Matched:   search.Matched,
+		Returned:  len(search.Lines),
+		Truncated: search.Truncated,
+		Errors:    search.Errors,
+		Lines:     make([]string, 0, len(search.Lines)),
+	}
+	for _, l := range search.Lines {
+		result.Lines = append(result.Lines, formatLogLine(l))
+	}
+
+	if len(search.Lines) > summarizeLogLines {
+		// Fail-open: without the processor the caller still gets the capped raw lines.
+		summary, err := h.summarizeLogs(ctx, input.Namespace, search.Lines)
+		if err != nil {
+			libtelemetry.Warn("pod log summarization failed, returning raw lines", "error", err)
+			return result, nil
+		}
+		result.Summary = summary
+		result.Lines = result.Lines[len(result.Lines)-summarizedTailLines:]
+	}
+	return result, nil
+}
+
+func formatLogLine(l providers.PodLogLine) string {
+	ts := "-"
+	if !l.Time.IsZero() {
+		ts = l.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%s %s/%s | %s", ts, l.Pod, l.Container, l.Text)
+}
+
+// summarizeLogs reshapes the lines into Loki streams, one per pod, container and
+// detected level, and pipes them through obs-processor.
+func (h *SearchPodLogsHandler) summarizeLogs(ctx context.Context, namespace string, lines []providers.PodLogLine) (interface{}, error) {
+	var streams []*logsummary.Stream
+	index := make(map[string]*logsummary.Stream)
+	for _, l := range lines {
+		level := logLevel(l.Text)
+		key := l.Pod + "/" + l.Container + "/" + level
+		s, ok := index[key]
+		if !ok {
+			s = &logsummary.Stream{Stream: map[string]string{
+				"namespace":      namespace,
+				"pod":            l.Pod,
+				"container":      l.Container,
+				"detected_level": level,
+			}}
+			index[key] = s
+			streams = append(streams, s)
+		}
+		var ts int64
+		if !l.Time.IsZero() {
+			ts = l.Time.UnixNano()
+		}
+		s.Values = append(s.Values, []string{strconv.FormatInt(ts, 10), l.Text})
+	}
+	return logsummary.Summarize(ctx, h.processorPath, streams)
+}
+
+// logLevel guesses a line's severity so the processor can rank errors first.
+func logLevel(text string) string {
+	switch {
+	case errorLevelPattern.MatchString(text):
+		return "error"
+	case warnLevelPattern.MatchString(text):
+		return "warn"
+	default:
+		return "info"
+	}
+}
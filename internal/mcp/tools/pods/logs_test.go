@@ -0,0 +1,135 @@
+package pods
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func TestSearchPodLogsHandler_Execute(t *testing.T) {
+	start := time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC)
+	lines := func(n int) []providers.PodLogLine {
+		out := make([]providers.PodLogLine, n)
+		for i := range out {
+			out[i] = providers.PodLogLine{Time: start.Add(time.Duration(i) * time.Second), Pod: "api-0", Container: "app", Text: fmt.Sprintf("line %d", i)}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		input     SearchPodLogsInput
+		lines     []providers.PodLogLine
+		searchErr error
+		wantOpts  func(opts providers.PodLogSearchOptions) bool
+		wantFirst string
+		wantCount int
+		wantErr   string
+	}{
+		{
+			name:  "defaults",
+			input: SearchPodLogsInput{Namespace: "default", Name: "api-0"},
+			lines: lines(2),
+			wantOpts: func(o providers.PodLogSearchOptions) bool {
+				return o.Since == time.Hour && o.MaxLines == 500 && o.Pattern == nil
+			},
+			wantFirst: "2026-03-06T17:00:00Z api-0/app | line 0",
+			wantCount: 2,
+		},
+		{
+			name:  "since, grep and caps",
+			input: SearchPodLogsInput{Namespace: "default", LabelSelector: "app=api", Since: "48h", Grep: "(?i)timeout", MaxLines: 5000},
+			lines: []providers.PodLogLine{{Pod: "api-1", Container: "migrate", Text: "no timestamp"}},
+			wantOpts: func(o providers.PodLogSearchOptions) bool {
+				return o.Since == 24*time.Hour && o.MaxLines == 2000 && o.Pattern.MatchString("Read TIMEOUT") && o.LabelSelector == "app=api"
+			},
+			wantFirst: "- api-1/migrate | no timestamp",
+			wantCount: 1,
+		},
+		{
+			name:      "large result falls back to raw lines without processor",
+			input:     SearchPodLogsInput{Namespace: "default", Name: "api-0"},
+			lines:     lines(300),
+			wantFirst: "2026-03-06T17:00:00Z api-0/app | line 0",
+			wantCount: 300,
+		},
+		{name: "missing selection", input: SearchPodLogsInput{Namespace: "default"}, wantErr: "exactly one of"},
+		{name: "both selections", input: SearchPodLogsInput{Name: "a", LabelSelector: "app=api"}, wantErr: "exactly one of"},
+		{name: "bad since", input: SearchPodLogsInput{Name: "a", Since: "yesterday"}, wantErr: "invalid since"},
+		{name: "negative since", input: SearchPodLogsInput{Name: "a", Since: "-5m"}, wantErr: "invalid since"},
+		{name: "bad grep", input: SearchPodLogsInput{Name: "a", Grep: "(unclosed"}, wantErr: "invalid grep"},
+		{name: "provider error", input: SearchPodLogsInput{Name: "a"}, searchErr: errors.New("pods \"a\" not found"), wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOpts providers.PodLogSearchOptions
+			searchFn := func(ctx context.Context, namespace string, opts providers.PodLogSearchOptions) (*providers.PodLogSearch, error) {
+				gotOpts = opts
+				if tt.searchErr != nil {
+					return nil, tt.searchErr
+				}
+				return &providers.PodLogSearch{Lines: tt.lines, Streams: 1, Scanned: len(tt.lines), Matched: len(tt.lines)}, nil
+			}
+			h := NewSearchPodLogsHandler(searchFn)
+			h.processorPath = "/tmp/non-existent-binary"
+
+			got, err := h.Execute(context.Background(), tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if tt.wantOpts != nil && !tt.wantOpts(gotOpts) {
+				t.Errorf("unexpected search options: %+v", gotOpts)
+			}
+			res := got.(PodLogsResult)
+			if len(res.Lines) != tt.wantCount || res.Lines[0] != tt.wantFirst || res.Summary != nil {
+				t.Errorf("Execute() = %d lines starting %q (summary %v), want %d starting %q", len(res.Lines), res.Lines[0], res.Summary, tt.wantCount, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestSearchPodLogsHandler_Summarize(t *testing.T) {
+	dir := t.TempDir()
+	processor := filepath.Join(dir, "obs-processor")
+	script := "#!/bin/sh\ngrep -q '\"detected_level\":\"error\"' && echo '{\"summarized_count\":2}'\n"
+	if err := os.WriteFile(processor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []providers.PodLogLine
+	for i := 0; i < 250; i++ {
+		text := "GET /healthz 200"
+		if i%10 == 0 {
+			text = "ERROR upstream timeout"
+		}
+		lines = append(lines, providers.PodLogLine{Pod: "api-0", Container: "app", Text: text})
+	}
+	searchFn := func(ctx context.Context, namespace string, opts providers.PodLogSearchOptions) (*providers.PodLogSearch, error) {
+		return &providers.PodLogSearch{Lines: lines, Matched: len(lines)}, nil
+	}
+	h := NewSearchPodLogsHandler(searchFn)
+	h.processorPath = processor
+
+	got, err := h.Execute(context.Background(), SearchPodLogsInput{Namespace: "default", Name: "api-0"})
+	if err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	res := got.(PodLogsResult)
+	if res.Summary == nil || len(res.Lines) != summarizedTailLines || res.Returned != 250 {
+		t.Errorf("Execute() summary %v, %d lines, returned %d; want digest with %d tail lines of 250", res.Summary, len(res.Lines), res.Returned, summarizedTailLines)
+	}
+}
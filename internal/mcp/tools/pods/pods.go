@@ -2,65 +2,270 @@ package pods
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+
+	"observability-hub/internal/mcp/providers"
 )
 
-// PodsInput is the common input for pod-related tools.
+// PodsInput is the common input for tools acting on a single pod.
 type PodsInput struct {
 	Namespace string `json:"namespace"`
 	Name      string `json:"name"`
+	Raw       bool   `json:"raw,omitempty"` // return the full Kubernetes object instead of the health summary
 }
 
-// PodSummary represents a high-level overview of a pod for agentic analysis.
+// PodSummary represents a high-level overview of a pod's health for agentic analysis.
+// inspect_pods fills the compact fields; describe_pod adds probes, resources and conditions.
 type PodSummary struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Status    string `json:"status"`
-	IP        string `json:"ip"`
-	Node      string `json:"node"`
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace"`
+	Status     string            `json:"status"`           // pod phase
+	Reason     string            `json:"reason,omitempty"` // most relevant problem, e.g. CrashLoopBackOff, Evicted, Init:ImagePullBackOff
+	Ready      string            `json:"ready"`            // ready/total app containers
+	Restarts   int32             `json:"restarts"`
+	IP         string            `json:"ip"`
+	Node       string            `json:"node"`
+	QoS        string            `json:"qos,omitempty"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	Containers []ContainerHealth `json:"containers,omitempty"`
+	Conditions []PodCondition    `json:"conditions,omitempty"`
+	Resources  []ResourceFit     `json:"resources,omitempty"`
+}
+
+// Page size bounds for inspect_pods.
+const (
+	defaultPodLimit = 100
+	maxPodLimit     = 500
+)
+
+// InspectPodsInput is the input for listing pods.
+type InspectPodsInput struct {
+	Namespace     string `json:"namespace"`
+	LabelSelector string `json:"label_selector,omitempty"` // e.g. "app=sensor-fleet"
+	FieldSelector string `json:"field_selector,omitempty"` // e.g. "spec.nodeName=node-a"
+	Status        string `json:"status,omitempty"`         // phase, reason or "unhealthy"; prefix "!" to negate
+	SortBy        string `json:"sort_by,omitempty"`        // name (default), restarts, age or status
+	Limit         int    `json:"limit,omitempty"`          // page size (default 100, max 500)
+	Continue      string `json:"continue,omitempty"`       // token from the previous page
+	Raw           bool   `json:"raw,omitempty"`            // return full Kubernetes objects instead of summaries
+}
+
+// PodPage is one page of inspect_pods results.
+type PodPage struct {
+	// Total counts the pods matching all filters across pages. When the API server does the
+	// paging it counts this page plus the pods the server reports as remaining.
+	Total int `json:"total"`
+	// TotalIsLowerBound is set when more pods exist than Total counts: the API server omits
+	// the remaining count for label and field selectors, and Incomplete listings stop early.
+	TotalIsLowerBound bool   `json:"total_is_lower_bound,omitempty"`
+	Returned          int    `json:"returned"`
+	Continue          string `json:"continue,omitempty"` // pass back as continue to fetch the next page
+	// Incomplete is set when the cluster has more pods than one listing scans; narrow the
+	// namespace or selectors to see them all.
+	Incomplete bool         `json:"incomplete,omitempty"`
+	Pods       []PodSummary `json:"pods,omitempty"`
+	Raw        []corev1.Pod `json:"raw,omitempty"`
 }
 
 // InspectPodsHandler handles listing pods and their health status.
 type InspectPodsHandler struct {
-	listFn func(ctx context.Context, namespace string) (*corev1.PodList, error)
+	listFn func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error)
 }
 
-func NewInspectPodsHandler(listFn func(ctx context.Context, namespace string) (*corev1.PodList, error)) *InspectPodsHandler {
+func NewInspectPodsHandler(listFn func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error)) *InspectPodsHandler {
 	return &InspectPodsHandler{listFn: listFn}
 }
 
-func (h *InspectPodsHandler) Execute(ctx context.Context, input PodsInput) (interface{}, error) {
-	pods, err := h.listFn(ctx, input.Namespace)
+// Execute lists one page of pods. Without a status filter or a sort other than by name
+// the API server pages (its order is already by namespace and name) and its continue token
+// is passed through. Otherwise the matching pods are listed, filtered and sorted here, and
+// the continue token is an offset into that list: a best effort without a snapshot, since
+// each page lists again and pods created or deleted in between shift the offsets.
+func (h *InspectPodsHandler) Execute(ctx context.Context, input InspectPodsInput) (interface{}, error) {
+	less, err := podSorter(input.SortBy)
+	if err != nil {
+		return nil, err
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultPodLimit
+	}
+	if limit > maxPodLimit {
+		limit = maxPodLimit
+	}
+	opts := providers.PodListOptions{
+		LabelSelector: input.LabelSelector,
+		FieldSelector: input.FieldSelector,
+	}
+
+	serverPaging := input.Status == "" && (input.SortBy == "" || strings.EqualFold(input.SortBy, "name"))
+	offset := 0
+	if serverPaging {
+		opts.Limit = int64(limit)
+		opts.Continue = input.Continue
+	} else if input.Continue != "" {
+		if offset, err = strconv.Atoi(input.Continue); err != nil || offset < 0 {
+			return nil, providers.InvalidInputf("invalid continue token %q", input.Continue)
+		}
+	}
+
+	pods, err := h.listFn(ctx, input.Namespace, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	summaries := make([]PodSummary, 0, len(pods.Items))
-	for _, p := range pods.Items {
-		summaries = append(summaries, PodSummary{
-			Name:      p.Name,
-			Namespace: p.Namespace,
-			Status:    string(p.Status.Phase),
-			IP:        p.Status.PodIP,
-			Node:      p.Spec.NodeName,
-		})
+	type entry struct {
+		pod     *corev1.Pod
+		summary PodSummary
 	}
+	matched := make([]entry, 0, len(pods.Items))
+	for i := range pods.Items {
+		summary := summarizePod(&pods.Items[i])
+		if matchesStatus(summary, input.Status) {
+			matched = append(matched, entry{pod: &pods.Items[i], summary: summary})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i].summary, matched[j].summary) })
 
-	return summaries, nil
+	page := PodPage{Total: len(matched)}
+	end := len(matched)
+	if serverPaging {
+		page.Continue = pods.Continue
+		if pods.RemainingItemCount != nil {
+			page.Total += int(*pods.RemainingItemCount)
+		} else {
+			page.TotalIsLowerBound = pods.Continue != ""
+		}
+	} else {
+		page.Incomplete = pods.Continue != ""
+		page.TotalIsLowerBound = page.Incomplete
+		offset = min(offset, len(matched))
+		end = min(offset+limit, len(matched))
+		if end < len(matched) {
+			page.Continue = strconv.Itoa(end)
+		}
+	}
+	for _, e := range matched[offset:end] {
+		if input.Raw {
+			page.Raw = append(page.Raw, *e.pod)
+		} else {
+			page.Pods = append(page.Pods, e.summary)
+		}
+	}
+	page.Returned = end - offset
+	return page, nil
+}
+
+// matchesStatus reports whether a pod matches a status filter: a phase ("Pending"), a reason
+// ("CrashLoopBackOff") or "unhealthy". A leading "!" negates the filter; matching ignores case.
+func matchesStatus(s PodSummary, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	negate := strings.HasPrefix(filter, "!")
+	filter = strings.TrimPrefix(filter, "!")
+
+	var match bool
+	if strings.EqualFold(filter, "unhealthy") {
+		match = !s.healthy()
+	} else {
+		match = strings.EqualFold(s.Status, filter) || strings.EqualFold(s.Reason, filter) ||
+			strings.EqualFold(strings.TrimPrefix(s.Reason, "Init:"), filter)
+	}
+	return match != negate
+}
+
+// healthy is true for completed pods and running pods with every container ready and no problem reason.
+func (s PodSummary) healthy() bool {
+	if s.Status == string(corev1.PodSucceeded) {
+		return true
+	}
+	ready, total, _ := strings.Cut(s.Ready, "/")
+	return s.Status == string(corev1.PodRunning) && s.Reason == "" && ready == total
+}
+
+func podSorter(sortBy string) (func(a, b PodSummary) bool, error) {
+	byName := func(a, b PodSummary) bool {
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	}
+	switch strings.ToLower(sortBy) {
+	case "", "name":
+		return byName, nil
+	case "restarts":
+		return func(a, b PodSummary) bool {
+			if a.Restarts != b.Restarts {
+				return a.Restarts > b.Restarts
+			}
+			return byName(a, b)
+		}, nil
+	case "age":
+		// Newest first; pods that have not started yet are the newest of all.
+		return func(a, b PodSummary) bool {
+			switch {
+			case a.StartedAt == nil && b.StartedAt == nil:
+				return byName(a, b)
+			case a.StartedAt == nil:
+				return true
+			case b.StartedAt == nil:
+				return false
+			case !a.StartedAt.Equal(*b.StartedAt):
+				return a.StartedAt.After(*b.StartedAt)
+			}
+			return byName(a, b)
+		}, nil
+	case "status":
+		// Unhealthy pods first, grouped by status and reason.
+		return func(a, b PodSummary) bool {
+			if ah, bh := a.healthy(), b.healthy(); ah != bh {
+				return !ah
+			}
+			if a.Status != b.Status {
+				return a.Status < b.Status
+			}
+			if a.Reason != b.Reason {
+				return a.Reason < b.Reason
+			}
+			return byName(a, b)
+		}, nil
+	}
+	return nil, providers.InvalidInputf("unsupported sort_by %q (want name, restarts, age or status)", sortBy)
 }
 
 // DescribePodHandler handles getting detailed information about a pod.
 type DescribePodHandler struct {
-	getFn func(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+	getFn  func(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+	nodeFn func(ctx context.Context, name string) (*corev1.Node, error)
 }
 
-func NewDescribePodHandler(getFn func(ctx context.Context, namespace, name string) (*corev1.Pod, error)) *DescribePodHandler {
-	return &DescribePodHandler{getFn: getFn}
+// NewDescribePodHandler creates the handler. nodeFn may be nil, in which case the
+// resource summary omits node allocatable capacity.
+func NewDescribePodHandler(getFn func(ctx context.Context, namespace, name string) (*corev1.Pod, error), nodeFn func(ctx context.Context, name string) (*corev1.Node, error)) *DescribePodHandler {
+	return &DescribePodHandler{getFn: getFn, nodeFn: nodeFn}
 }
 
 func (h *DescribePodHandler) Execute(ctx context.Context, input PodsInput) (interface{}, error) {
-	return h.getFn(ctx, input.Namespace, input.Name)
+	pod, err := h.getFn(ctx, input.Namespace, input.Name)
+	if err != nil {
+		return nil, err
+	}
+	if input.Raw {
+		return pod, nil
+	}
+
+	// Node capacity is context, not a requirement; RBAC may not allow reading nodes.
+	var node *corev1.Node
+	if h.nodeFn != nil && pod.Spec.NodeName != "" {
+		node, _ = h.nodeFn(ctx, pod.Spec.NodeName)
+	}
+	return describePodHealth(pod, node), nil
 }
 
 // ListPodEventsHandler handles listing events for a specific pod.
@@ -103,6 +308,9 @@ type DeletePodInput struct {
 	Namespace    string `json:"namespace"`
 	Name         string `json:"name"`
 	GraceSeconds *int64 `json:"grace_seconds,omitempty"`
+	Reason       string `json:"reason"`                  // why the pod should be deleted; recorded in the audit log
+	DryRun       bool   `json:"dry_run,omitempty"`       // preview only; also implied when confirm_token is empty
+	ConfirmToken string `json:"confirm_token,omitempty"` // token from a previous dry-run preview
 }
 
 // DeletePodHandler handles deleting a pod.
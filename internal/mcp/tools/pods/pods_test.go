@@ -3,24 +3,30 @@ package pods
 import (
 	"context"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"observability-hub/internal/mcp/providers"
 )
 
 func TestInspectPodsHandler_Execute(t *testing.T) {
 	tests := []struct {
 		name      string
 		namespace string
-		listFn    func(ctx context.Context, namespace string) (*corev1.PodList, error)
+		listFn    func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error)
 		wantCount int
 		wantErr   bool
 	}{
 		{
 			name:      "successful list",
 			namespace: "default",
-			listFn: func(ctx context.Context, namespace string) (*corev1.PodList, error) {
+			listFn: func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error) {
 				return &corev1.PodList{
 					Items: []corev1.Pod{
 						{
@@ -36,7 +42,7 @@ func TestInspectPodsHandler_Execute(t *testing.T) {
 		{
 			name:      "empty list",
 			namespace: "empty",
-			listFn: func(ctx context.Context, namespace string) (*corev1.PodList, error) {
+			listFn: func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error) {
 				return &corev1.PodList{Items: []corev1.Pod{}}, nil
 			},
 			wantCount: 0,
@@ -45,7 +51,7 @@ func TestInspectPodsHandler_Execute(t *testing.T) {
 		{
 			name:      "provider error",
 			namespace: "error",
-			listFn: func(ctx context.Context, namespace string) (*corev1.PodList, error) {
+			listFn: func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error) {
 				return nil, errors.New("api error")
 			},
 			wantErr: true,
@@ -55,21 +61,135 @@ func TestInspectPodsHandler_Execute(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := NewInspectPodsHandler(tt.listFn)
-			got, err := h.Execute(context.Background(), PodsInput{Namespace: tt.namespace})
+			got, err := h.Execute(context.Background(), InspectPodsInput{Namespace: tt.namespace})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !tt.wantErr {
-				summaries := got.([]PodSummary)
-				if len(summaries) != tt.wantCount {
-					t.Errorf("Execute() got count = %v, want %v", len(summaries), tt.wantCount)
+				page := got.(PodPage)
+				if len(page.Pods) != tt.wantCount || page.Total != tt.wantCount {
+					t.Errorf("Execute() got count = %v (total %v), want %v", len(page.Pods), page.Total, tt.wantCount)
 				}
 			}
 		})
 	}
 }
 
+func TestInspectPodsHandler_FilterSortPage(t *testing.T) {
+	pod := func(name, phase string, restarts int32, ready bool, waiting string, started int) corev1.Pod {
+		cs := corev1.ContainerStatus{Name: "app", Ready: ready, RestartCount: restarts,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+		if waiting != "" {
+			cs.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}}
+		}
+		start := metav1.NewTime(time.Date(2026, 3, 1, started, 0, 0, 0, time.UTC))
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodPhase(phase), StartTime: &start,
+				ContainerStatuses: []corev1.ContainerStatus{cs}},
+		}
+	}
+	var gotOpts providers.PodListOptions
+	listFn := func(ctx context.Context, namespace string, opts providers.PodListOptions) (*corev1.PodList, error) {
+		gotOpts = opts
+		list := &corev1.PodList{Items: []corev1.Pod{
+			pod("c-ok", "Running", 0, true, "", 1),
+			pod("a-crash", "Running", 9, false, "CrashLoopBackOff", 2),
+			pod("b-pending", "Pending", 0, false, "ContainerCreating", 3),
+			pod("d-done", "Succeeded", 1, false, "", 4),
+		}}
+		if opts.Limit == 0 {
+			return list, nil
+		}
+		// Page like the API server: by name, with an index as the continue token.
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+		start := 0
+		if opts.Continue != "" {
+			var err error
+			if start, err = strconv.Atoi(opts.Continue); err != nil {
+				return nil, errors.New("invalid continue token")
+			}
+		}
+		start = min(start, len(list.Items))
+		end := min(start+int(opts.Limit), len(list.Items))
+		if end < len(list.Items) {
+			list.Continue = strconv.Itoa(end)
+			// The API server does not count what remains behind a selector.
+			if opts.LabelSelector == "" && opts.FieldSelector == "" {
+				remaining := int64(len(list.Items) - end)
+				list.RemainingItemCount = &remaining
+			}
+		}
+		list.Items = list.Items[start:end]
+		return list, nil
+	}
+	h := NewInspectPodsHandler(listFn)
+
+	tests := []struct {
+		name         string
+		input        InspectPodsInput
+		wantNames    []string
+		wantTotal    int
+		wantLower    bool
+		wantContinue string
+		wantErr      bool
+	}{
+		{name: "default sort by name", input: InspectPodsInput{}, wantNames: []string{"a-crash", "b-pending", "c-ok", "d-done"}, wantTotal: 4},
+		{name: "phase filter", input: InspectPodsInput{Status: "pending"}, wantNames: []string{"b-pending"}, wantTotal: 1},
+		{name: "negated phase", input: InspectPodsInput{Status: "!Running"}, wantNames: []string{"b-pending", "d-done"}, wantTotal: 2},
+		{name: "reason filter", input: InspectPodsInput{Status: "CrashLoopBackOff"}, wantNames: []string{"a-crash"}, wantTotal: 1},
+		{name: "unhealthy", input: InspectPodsInput{Status: "unhealthy"}, wantNames: []string{"a-crash", "b-pending"}, wantTotal: 2},
+		{name: "sort by restarts", input: InspectPodsInput{SortBy: "restarts"}, wantNames: []string{"a-crash", "d-done", "b-pending", "c-ok"}, wantTotal: 4},
+		{name: "sort by age", input: InspectPodsInput{SortBy: "age"}, wantNames: []string{"d-done", "b-pending", "a-crash", "c-ok"}, wantTotal: 4},
+		{name: "sort by status", input: InspectPodsInput{SortBy: "status"}, wantNames: []string{"b-pending", "a-crash", "c-ok", "d-done"}, wantTotal: 4},
+		{name: "first page", input: InspectPodsInput{Limit: 3}, wantNames: []string{"a-crash", "b-pending", "c-ok"}, wantTotal: 4, wantContinue: "3"},
+		{name: "last page", input: InspectPodsInput{Limit: 3, Continue: "3"}, wantNames: []string{"d-done"}, wantTotal: 1},
+		{name: "selector page", input: InspectPodsInput{LabelSelector: "app", Limit: 3}, wantNames: []string{"a-crash", "b-pending", "c-ok"}, wantTotal: 3, wantLower: true, wantContinue: "3"},
+		{name: "past the end", input: InspectPodsInput{Continue: "10"}, wantTotal: 0},
+		{name: "bad token", input: InspectPodsInput{Continue: "abc"}, wantErr: true},
+		{name: "filtered first page", input: InspectPodsInput{Status: "!Succeeded", Limit: 2}, wantNames: []string{"a-crash", "b-pending"}, wantTotal: 3, wantContinue: "2"},
+		{name: "filtered last page", input: InspectPodsInput{Status: "!Succeeded", Limit: 2, Continue: "2"}, wantNames: []string{"c-ok"}, wantTotal: 3},
+		{name: "sorted page past the end", input: InspectPodsInput{SortBy: "restarts", Continue: "10"}, wantTotal: 4},
+		{name: "bad offset token", input: InspectPodsInput{SortBy: "age", Continue: "abc"}, wantErr: true},
+		{name: "bad sort", input: InspectPodsInput{SortBy: "size"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Execute(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			page := got.(PodPage)
+			var names []string
+			for _, p := range page.Pods {
+				names = append(names, p.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Execute() names = %v, want %v", names, tt.wantNames)
+			}
+			if page.Total != tt.wantTotal || page.TotalIsLowerBound != tt.wantLower || page.Returned != len(tt.wantNames) || page.Continue != tt.wantContinue {
+				t.Errorf("Execute() total/lower bound/returned/continue = %d/%v/%d/%q, want %d/%v/%d/%q",
+					page.Total, page.TotalIsLowerBound, page.Returned, page.Continue, tt.wantTotal, tt.wantLower, len(tt.wantNames), tt.wantContinue)
+			}
+		})
+	}
+
+	_, _ = h.Execute(context.Background(), InspectPodsInput{LabelSelector: "app=sensor-fleet", FieldSelector: "spec.nodeName=node-a"})
+	if gotOpts.LabelSelector != "app=sensor-fleet" || gotOpts.FieldSelector != "spec.nodeName=node-a" || gotOpts.Limit != defaultPodLimit {
+		t.Errorf("selectors and page size not passed to provider: %+v", gotOpts)
+	}
+	_, _ = h.Execute(context.Background(), InspectPodsInput{Status: "unhealthy", Continue: "2"})
+	if gotOpts.Limit != 0 || gotOpts.Continue != "" {
+		t.Errorf("filtered listing must scan all pods, got %+v", gotOpts)
+	}
+}
+
 func TestDescribePodHandler_Execute(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -94,7 +214,7 @@ func TestDescribePodHandler_Execute(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := NewDescribePodHandler(tt.getFn)
+			h := NewDescribePodHandler(tt.getFn, nil)
 			_, err := h.Execute(context.Background(), PodsInput{Namespace: "default", Name: "test-pod"})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
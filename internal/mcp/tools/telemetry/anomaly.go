@@ -0,0 +1,465 @@
+package telemetry
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Anomaly kinds reported by DetectAnomalies.
+const (
+	AnomalySpike             = "spike"
+	AnomalyLevelShift        = "level_shift"
+	AnomalyCounterResetStorm = "counter_reset_storm"
+)
+
+// Anomaly severities, ordered from least to most severe.
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// madScale converts a median absolute deviation into a standard-deviation estimate
+// for normally distributed data.
+const madScale = 1.4826
+
+// minBaselineSamples is the fewest baseline samples a series needs to be evaluated.
+const minBaselineSamples = 3
+
+// maxAnomalyScore caps scores so that a perfectly flat baseline does not produce +Inf
+// (which cannot be encoded as JSON).
+const maxAnomalyScore = 1000
+
+// Sample is a single timestamped value of a range-query series.
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a labelled list of samples parsed from a Prometheus/Loki matrix result.
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// AnomalyOptions tunes DetectAnomalies. Zero values fall back to sensible defaults.
+type AnomalyOptions struct {
+	// BaselineEnd splits each series: samples before it form the baseline, samples at
+	// or after it are evaluated. Defaults to the midpoint of each series.
+	BaselineEnd time.Time
+	// SpikeThreshold is the robust z-score above which a sample is a spike (default 3.5).
+	SpikeThreshold float64
+	// ShiftThreshold is the robust z-score of the evaluation median above which the
+	// series is considered to have shifted level (default 3).
+	ShiftThreshold float64
+	// MinRelativeChange ignores deviations smaller than this fraction of the baseline
+	// median, so tiny absolute wobbles on flat series are not reported (default 0.1).
+	MinRelativeChange float64
+	// MinAbsoluteChange ignores deviations no larger than this absolute amount. It matters
+	// for flat baselines (e.g. a zero error ratio), where any non-zero value would
+	// otherwise score as a maximal anomaly.
+	MinAbsoluteChange float64
+	// MinFlatSpikeSamples is the number of consecutive samples a spike over a flat
+	// (zero-scale) baseline must span to be reported, so a single stray blip is ignored
+	// (default 2).
+	MinFlatSpikeSamples int
+	// Counter treats the series as raw monotonic counters. Only reset storms are
+	// detected, since spikes and shifts are meaningless on cumulative values.
+	Counter bool
+	// ResetStormCount is the number of counter resets in the evaluation window that
+	// makes a storm (default 3).
+	ResetStormCount int
+}
+
+// AnomalyFinding describes a single anomaly detected in a series.
+type AnomalyFinding struct {
+	Kind        string    `json:"kind"`
+	Severity    string    `json:"severity"`
+	Series      string    `json:"series"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Value       float64   `json:"value"`    // peak (spike), evaluation median (shift) or reset count (storm)
+	Baseline    float64   `json:"baseline"` // baseline median
+	Score       float64   `json:"score"`    // robust z-score, or reset count for storms
+	Description string    `json:"description"`
+}
+
+// ParseMatrix converts a raw Prometheus or Loki matrix response into series.
+// Vector and stream results are rejected since they carry no time dimension.
+func ParseMatrix(raw interface{}) ([]Series, error) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type %T", raw)
+	}
+	data, ok := m["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("response has no data field")
+	}
+	if rt := attrStr(data["resultType"]); rt != "matrix" {
+		return nil, fmt.Errorf("expected matrix result, got %q", rt)
+	}
+
+	var series []Series
+	for _, r := range toList(data["result"]) {
+		entry, _ := r.(map[string]interface{})
+		s := Series{Labels: map[string]string{}}
+		if metric, ok := entry["metric"].(map[string]interface{}); ok {
+			for k, v := range metric {
+				s.Labels[k] = attrStr(v)
+			}
+		}
+		for _, v := range toList(entry["values"]) {
+			pair := toList(v)
+			if len(pair) < 2 {
+				continue
+			}
+			ts, ok := pair[0].(float64)
+			if !ok {
+				continue
+			}
+			val, err := strconv.ParseFloat(attrStr(pair[1]), 64)
+			if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+				continue
+			}
+			sec, frac := math.Modf(ts)
+			s.Samples = append(s.Samples, Sample{Time: time.Unix(int64(sec), int64(frac*1e9)).UTC(), Value: val})
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// DetectAnomalies flags spikes, level shifts and counter-reset storms in each series
+// by comparing the evaluation window against the baseline window.
+func DetectAnomalies(series []Series, opts AnomalyOptions) []AnomalyFinding {
+	if opts.SpikeThreshold <= 0 {
+		opts.SpikeThreshold = 3.5
+	}
+	if opts.ShiftThreshold <= 0 {
+		opts.ShiftThreshold = 3
+	}
+	if opts.MinRelativeChange <= 0 {
+		opts.MinRelativeChange = 0.1
+	}
+	if opts.ResetStormCount <= 0 {
+		opts.ResetStormCount = 3
+	}
+	if opts.MinFlatSpikeSamples <= 0 {
+		opts.MinFlatSpikeSamples = 2
+	}
+
+	var findings []AnomalyFinding
+	for _, s := range series {
+		baseline, eval := splitSamples(s.Samples, opts.BaselineEnd)
+		if len(eval) == 0 {
+			continue
+		}
+		name := seriesName(s.Labels)
+
+		if opts.Counter {
+			if f, ok := detectResetStorm(name, baseline, eval, opts); ok {
+				findings = append(findings, f)
+			}
+			continue
+		}
+
+		if len(baseline) < minBaselineSamples {
+			continue
+		}
+		findings = append(findings, detectSpikes(name, baseline, eval, opts)...)
+		if f, ok := detectLevelShift(name, baseline, eval, opts); ok {
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if severityRank(findings[i].Severity) != severityRank(findings[j].Severity) {
+			return severityRank(findings[i].Severity) > severityRank(findings[j].Severity)
+		}
+		return findings[i].Start.Before(findings[j].Start)
+	})
+	return findings
+}
+
+// Evaluable reports whether DetectAnomalies with opts can judge at least one series: one with
+// evaluation samples and, unless opts.Counter, enough baseline samples before them.
+func Evaluable(series []Series, opts AnomalyOptions) bool {
+	for _, s := range series {
+		baseline, eval := splitSamples(s.Samples, opts.BaselineEnd)
+		if len(eval) > 0 && (opts.Counter || len(baseline) >= minBaselineSamples) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluationMedian returns the highest median over the evaluation window of any series,
+// e.g. the current error ratio. It is 0 when no series has evaluation samples.
+func EvaluationMedian(series []Series, baselineEnd time.Time) float64 {
+	highest := 0.0
+	for _, s := range series {
+		if _, eval := splitSamples(s.Samples, baselineEnd); len(eval) > 0 {
+			highest = max(highest, median(values(eval)))
+		}
+	}
+	return highest
+}
+
+// HasSignificantAnomaly reports whether any finding is at least medium severity.
+func HasSignificantAnomaly(findings []AnomalyFinding) bool {
+	for _, f := range findings {
+		if severityRank(f.Severity) >= severityRank(SeverityMedium) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectSpikes groups consecutive evaluation samples whose robust z-score exceeds the threshold.
+func detectSpikes(name string, baseline, eval []Sample, opts AnomalyOptions) []AnomalyFinding {
+	med, scale := robustStats(baseline)
+
+	var findings []AnomalyFinding
+	var current *AnomalyFinding
+	for _, smp := range eval {
+		z := robustZ(smp.Value, med, scale, opts)
+		if math.Abs(z) < opts.SpikeThreshold {
+			if current != nil {
+				findings = append(findings, *current)
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			current = &AnomalyFinding{
+				Kind:     AnomalySpike,
+				Series:   name,
+				Start:    smp.Time,
+				Baseline: med,
+			}
+		}
+		current.End = smp.Time
+		if math.Abs(z) > math.Abs(current.Score) {
+			current.Score = z
+			current.Value = smp.Value
+		}
+	}
+	if current != nil {
+		findings = append(findings, *current)
+	}
+
+	// A run covering most of the evaluation window is a level shift, not a spike, and
+	// over a flat baseline a run shorter than MinFlatSpikeSamples is noise.
+	out := findings[:0]
+	for _, f := range findings {
+		n := countInRange(eval, f.Start, f.End)
+		if n > len(eval)/2 || (scale == 0 && n < opts.MinFlatSpikeSamples) {
+			continue
+		}
+		f.Severity = scoreSeverity(f.Score, opts.SpikeThreshold)
+		f.Description = fmt.Sprintf("%s spiked to %s (baseline median %s, robust z %.1f)",
+			name, formatValue(f.Value), formatValue(f.Baseline), f.Score)
+		out = append(out, f)
+	}
+	return out
+}
+
+// detectLevelShift compares the evaluation median against the baseline distribution.
+func detectLevelShift(name string, baseline, eval []Sample, opts AnomalyOptions) (AnomalyFinding, bool) {
+	med, scale := robustStats(baseline)
+	evalMed := median(values(eval))
+	z := robustZ(evalMed, med, scale, opts)
+	if math.Abs(z) < opts.ShiftThreshold {
+		return AnomalyFinding{}, false
+	}
+
+	// The shift starts at the first evaluation sample past the midpoint of both levels.
+	mid := (med + evalMed) / 2
+	start := eval[0].Time
+	for _, smp := range eval {
+		if (evalMed > med && smp.Value >= mid) || (evalMed < med && smp.Value <= mid) {
+			start = smp.Time
+			break
+		}
+	}
+
+	direction := "up"
+	if evalMed < med {
+		direction = "down"
+	}
+	return AnomalyFinding{
+		Kind:     AnomalyLevelShift,
+		Severity: scoreSeverity(z, opts.ShiftThreshold),
+		Series:   name,
+		Start:    start,
+		End:      eval[len(eval)-1].Time,
+		Value:    evalMed,
+		Baseline: med,
+		Score:    z,
+		Description: fmt.Sprintf("%s shifted %s from %s to %s (robust z %.1f)",
+			name, direction, formatValue(med), formatValue(evalMed), z),
+	}, true
+}
+
+// detectResetStorm counts counter decreases in the evaluation window.
+func detectResetStorm(name string, baseline, eval []Sample, opts AnomalyOptions) (AnomalyFinding, bool) {
+	prev := math.NaN()
+	if len(baseline) > 0 {
+		prev = baseline[len(baseline)-1].Value
+	}
+
+	resets := 0
+	var first, last time.Time
+	for _, smp := range eval {
+		if !math.IsNaN(prev) && smp.Value < prev {
+			resets++
+			if first.IsZero() {
+				first = smp.Time
+			}
+			last = smp.Time
+		}
+		prev = smp.Value
+	}
+	if resets < opts.ResetStormCount {
+		return AnomalyFinding{}, false
+	}
+
+	severity := SeverityLow
+	switch {
+	case resets >= opts.ResetStormCount*3:
+		severity = SeverityHigh
+	case resets >= opts.ResetStormCount*2:
+		severity = SeverityMedium
+	}
+	return AnomalyFinding{
+		Kind:        AnomalyCounterResetStorm,
+		Severity:    severity,
+		Series:      name,
+		Start:       first,
+		End:         last,
+		Value:       float64(resets),
+		Score:       float64(resets),
+		Description: fmt.Sprintf("%s reset %d times (likely restarts or crash loop)", name, resets),
+	}, true
+}
+
+// splitSamples divides samples at cut; a zero cut splits at the midpoint.
+func splitSamples(samples []Sample, cut time.Time) ([]Sample, []Sample) {
+	if cut.IsZero() {
+		mid := len(samples) / 2
+		return samples[:mid], samples[mid:]
+	}
+	idx := sort.Search(len(samples), func(i int) bool { return !samples[i].Time.Before(cut) })
+	return samples[:idx], samples[idx:]
+}
+
+// robustStats returns the median and a MAD-based scale estimate.
+// When the MAD is zero (e.g. mostly-constant data) the mean absolute deviation is used instead.
+func robustStats(samples []Sample) (float64, float64) {
+	vals := values(samples)
+	med := median(vals)
+
+	devs := make([]float64, len(vals))
+	var sumDev float64
+	for i, v := range vals {
+		devs[i] = math.Abs(v - med)
+		sumDev += devs[i]
+	}
+	if mad := median(devs); mad > 0 {
+		return med, mad * madScale
+	}
+	return med, sumDev / float64(len(vals)) * 1.2533
+}
+
+// robustZ returns the robust z-score of v, ignoring changes below the relative and
+// absolute minimums in opts.
+func robustZ(v, med, scale float64, opts AnomalyOptions) float64 {
+	diff := v - med
+	if math.Abs(diff) <= math.Abs(med)*opts.MinRelativeChange || math.Abs(diff) <= opts.MinAbsoluteChange || diff == 0 {
+		return 0
+	}
+	if scale == 0 {
+		return math.Copysign(maxAnomalyScore, diff)
+	}
+	return math.Max(-maxAnomalyScore, math.Min(maxAnomalyScore, diff/scale))
+}
+
+// scoreSeverity maps a z-score to a severity relative to the detection threshold.
+func scoreSeverity(z, threshold float64) string {
+	abs := math.Abs(z)
+	switch {
+	case abs >= threshold*3:
+		return SeverityHigh
+	case abs >= threshold*1.5:
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+func severityRank(s string) int {
+	switch s {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+func values(samples []Sample) []float64 {
+	out := make([]float64, len(samples))
+	for i, s := range samples {
+		out[i] = s.Value
+	}
+	return out
+}
+
+func median(vals []float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func countInRange(samples []Sample, start, end time.Time) int {
+	n := 0
+	for _, s := range samples {
+		if !s.Time.Before(start) && !s.Time.After(end) {
+			n++
+		}
+	}
+	return n
+}
+
+// seriesName renders labels as a compact PromQL-style selector.
+func seriesName(labels map[string]string) string {
+	name := labels["__name__"]
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return name + "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
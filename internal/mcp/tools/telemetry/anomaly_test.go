@@ -0,0 +1,188 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMatrix(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         interface{}
+		wantSeries  int
+		wantSamples int
+		wantErr     bool
+	}{
+		{
+			name: "valid matrix",
+			raw: map[string]interface{}{
+				"data": map[string]interface{}{
+					"resultType": "matrix",
+					"result": []interface{}{
+						map[string]interface{}{
+							"metric": map[string]interface{}{"__name__": "up", "job": "proxy"},
+							"values": []interface{}{
+								[]interface{}{float64(1773237600), "1"},
+								[]interface{}{float64(1773237615), "NaN"},
+								[]interface{}{float64(1773237630), "0"},
+							},
+						},
+					},
+				},
+			},
+			wantSeries:  1,
+			wantSamples: 2,
+		},
+		{
+			name: "vector result is rejected",
+			raw: map[string]interface{}{
+				"data": map[string]interface{}{"resultType": "vector", "result": []interface{}{}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing data is rejected",
+			raw:     map[string]interface{}{"status": "error"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMatrix(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMatrix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != tt.wantSeries {
+				t.Fatalf("got %d series, want %d", len(got), tt.wantSeries)
+			}
+			if len(got[0].Samples) != tt.wantSamples {
+				t.Errorf("got %d samples, want %d (NaN must be skipped)", len(got[0].Samples), tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	base := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	cut := base.Add(60 * time.Minute)
+
+	build := func(fn func(i int) float64) []Series {
+		samples := make([]Sample, 120)
+		for i := range samples {
+			samples[i] = Sample{Time: base.Add(time.Duration(i) * time.Minute), Value: fn(i)}
+		}
+		return []Series{{Labels: map[string]string{"__name__": "errors", "service": "proxy"}, Samples: samples}}
+	}
+	noisy := func(i int) float64 { return 10 + float64(i%5) }
+
+	tests := []struct {
+		name      string
+		series    []Series
+		opts      AnomalyOptions
+		wantKinds []string
+		wantSev   string
+	}{
+		{
+			name:   "steady series has no findings",
+			series: build(noisy),
+			opts:   AnomalyOptions{BaselineEnd: cut},
+		},
+		{
+			name: "short burst is a spike",
+			series: build(func(i int) float64 {
+				if i == 90 || i == 91 {
+					return 200
+				}
+				return noisy(i)
+			}),
+			opts:      AnomalyOptions{BaselineEnd: cut},
+			wantKinds: []string{AnomalySpike},
+			wantSev:   SeverityHigh,
+		},
+		{
+			name: "single blip over a zero baseline is ignored",
+			series: build(func(i int) float64 {
+				if i == 90 {
+					return 0.05
+				}
+				return 0
+			}),
+			opts: AnomalyOptions{BaselineEnd: cut},
+		},
+		{
+			name: "change below the absolute floor over a zero baseline is ignored",
+			series: build(func(i int) float64 {
+				if i >= 90 && i < 95 {
+					return 0.004
+				}
+				return 0
+			}),
+			opts: AnomalyOptions{BaselineEnd: cut, MinAbsoluteChange: 0.01},
+		},
+		{
+			name: "sustained burst over a zero baseline is a spike",
+			series: build(func(i int) float64 {
+				if i >= 90 && i < 95 {
+					return 0.3
+				}
+				return 0
+			}),
+			opts:      AnomalyOptions{BaselineEnd: cut, MinAbsoluteChange: 0.01},
+			wantKinds: []string{AnomalySpike},
+			wantSev:   SeverityHigh,
+		},
+		{
+			name: "sustained change is a level shift, not a spike",
+			series: build(func(i int) float64 {
+				if i >= 70 {
+					return 40 + float64(i%5)
+				}
+				return noisy(i)
+			}),
+			opts:      AnomalyOptions{BaselineEnd: cut},
+			wantKinds: []string{AnomalyLevelShift},
+			wantSev:   SeverityHigh,
+		},
+		{
+			name: "counter resets in evaluation window form a storm",
+			series: build(func(i int) float64 {
+				if i >= 60 {
+					return float64(i % 5)
+				}
+				return float64(i)
+			}),
+			opts:      AnomalyOptions{BaselineEnd: cut, Counter: true},
+			wantKinds: []string{AnomalyCounterResetStorm},
+			wantSev:   SeverityHigh,
+		},
+		{
+			name:   "monotonic counter has no findings",
+			series: build(func(i int) float64 { return float64(i * 3) }),
+			opts:   AnomalyOptions{BaselineEnd: cut, Counter: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectAnomalies(tt.series, tt.opts)
+			if len(got) != len(tt.wantKinds) {
+				t.Fatalf("got %d findings %+v, want kinds %v", len(got), got, tt.wantKinds)
+			}
+			for i, kind := range tt.wantKinds {
+				if got[i].Kind != kind {
+					t.Errorf("finding %d: got kind %q, want %q", i, got[i].Kind, kind)
+				}
+				if got[i].Severity != tt.wantSev {
+					t.Errorf("finding %d: got severity %q, want %q", i, got[i].Severity, tt.wantSev)
+				}
+				if got[i].Start.Before(cut) || got[i].End.Before(got[i].Start) {
+					t.Errorf("finding %d: got window %v-%v, want inside evaluation window", i, got[i].Start, got[i].End)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,570 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+// Dependency graph renderings.
+const (
+	DependencyFormatMermaid = "mermaid"
+	DependencyFormatDOT     = "dot"
+	DependencyFormatNone    = "none"
+)
+
+const (
+	defaultDependencyHours  = 1
+	maxDependencyHours      = 24
+	defaultDependencyTraces = 50
+	maxDependencyTraces     = 200
+	// dependencyTraceFetches bounds concurrent trace fetches from Tempo.
+	dependencyTraceFetches = 8
+)
+
+// dependencyServicePattern keeps service names safe to embed in TraceQL and Hubble label
+// selectors; it is the Kubernetes label value charset.
+var dependencyServicePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.\-]*[a-zA-Z0-9])?$`)
+
+// dependencyServiceLabels are the labels dependencyNode names a flow endpoint by.
+var dependencyServiceLabels = []string{"app.kubernetes.io/name", "app"}
+
+// ServiceDependencyGraphInput represents the input for the service_dependency_graph tool.
+type ServiceDependencyGraphInput struct {
+	Service   string `json:"service,omitempty"`   // focus on one service's dependencies and dependents; empty maps everything
+	Namespace string `json:"namespace,omitempty"` // only Hubble flows from or to this namespace
+	Hours     int    `json:"hours,omitempty"`     // window in hours (default 1, max 24)
+	Traces    int    `json:"traces,omitempty"`    // traces sampled from Tempo (default 50, max 200)
+	Format    string `json:"format,omitempty"`    // diagram rendering: mermaid (default), dot or none
+	Target    string `json:"target,omitempty"`    // named telemetry target (tenant/cluster); empty uses the default
+}
+
+// DependencyEdge is one caller → callee relationship. Calls, errors and latency come from trace
+// spans when traces cover the edge, otherwise from Hubble L7 responses, otherwise from L3/L4 flows
+// (where dropped flows count as errors).
+type DependencyEdge struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	Calls       int      `json:"calls"`
+	Errors      int      `json:"errors"`
+	ErrorRate   float64  `json:"error_rate"`
+	P95MS       float64  `json:"p95_ms,omitempty"`
+	Evidence    []string `json:"evidence"`          // traces, flows
+	Flows       int      `json:"flows,omitempty"`   // Hubble flows from source to destination, replies excluded
+	Dropped     int      `json:"dropped,omitempty"` // of which dropped
+	Ports       []string `json:"ports,omitempty"`   // destination ports seen in flows, e.g. 5432/TCP
+}
+
+// DependencyGraph is the output of service_dependency_graph.
+type DependencyGraph struct {
+	Service       string            `json:"service,omitempty"`
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	Edges         []DependencyEdge  `json:"edges"` // busiest first
+	DependsOn     []string          `json:"depends_on,omitempty"`
+	Dependents    []string          `json:"dependents,omitempty"`
+	TracesSampled int               `json:"traces_sampled"`
+	FlowsSampled  int               `json:"flows_sampled"`
+	Diagram       string            `json:"diagram,omitempty"`
+	SourceErrors  map[string]string `json:"source_errors,omitempty"`
+}
+
+// DependencySources bundles the backends the graph is built from. A nil source is skipped.
+type DependencySources struct {
+	QueryTraces func(ctx context.Context, traceID string, query string, hours int, limit int) (interface{}, error)
+	QueryFlows  func(ctx context.Context, f providers.FlowFilter) ([]providers.Flow, error)
+}
+
+// ServiceDependencyGraphHandler builds a service dependency graph from Tempo traces and Hubble flows.
+type ServiceDependencyGraphHandler struct {
+	sources DependencySources
+	now     func() time.Time
+}
+
+// NewServiceDependencyGraphHandler creates a new service_dependency_graph handler.
+func NewServiceDependencyGraphHandler(sources DependencySources) *ServiceDependencyGraphHandler {
+	return &ServiceDependencyGraphHandler{sources: sources, now: time.Now}
+}
+
+// edgeStats accumulates one edge's evidence before it is summarized.
+type edgeStats struct {
+	spanCalls, spanErrors int
+	spanLatencies         []float64
+	l7Calls, l7Errors     int
+	l7Latencies           []float64
+	flows, dropped        int
+	ports                 map[string]bool
+}
+
+type edgeKey struct{ source, destination string }
+
+// Execute runs the service_dependency_graph tool. Traces and flows are read in parallel;
+// a failing source is reported in SourceErrors and the graph is built from the other.
+func (h *ServiceDependencyGraphHandler) Execute(ctx context.Context, input ServiceDependencyGraphInput) (interface{}, error) {
+	format := input.Format
+	switch format {
+	case "":
+		format = DependencyFormatMermaid
+	case DependencyFormatMermaid, DependencyFormatDOT, DependencyFormatNone:
+	default:
+		return nil, providers.InvalidInputf("format must be mermaid, dot or none")
+	}
+	if input.Service != "" && !dependencyServicePattern.MatchString(input.Service) {
+		return nil, providers.InvalidInputf("invalid service %q: use letters, digits, '-', '_' and '.'", input.Service)
+	}
+	hours := input.Hours
+	if hours <= 0 {
+		hours = defaultDependencyHours
+	}
+	if hours > maxDependencyHours {
+		hours = maxDependencyHours
+	}
+	traces := input.Traces
+	if traces <= 0 {
+		traces = defaultDependencyTraces
+	}
+	if traces > maxDependencyTraces {
+		traces = maxDependencyTraces
+	}
+	end := h.now()
+	start := end.Add(-time.Duration(hours) * time.Hour)
+
+	libtelemetry.Info("building service dependency graph", "service", input.Service, "hours", hours, "traces", traces)
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		edges = make(map[edgeKey]*edgeStats)
+		errs  = make(map[string]string)
+		graph = DependencyGraph{Service: input.Service, Start: start, End: end}
+	)
+	edge := func(source, destination string) *edgeStats {
+		k := edgeKey{source, destination}
+		if edges[k] == nil {
+			edges[k] = &edgeStats{ports: make(map[string]bool)}
+		}
+		return edges[k]
+	}
+
+	if h.sources.QueryTraces != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spans, sampled, err := h.traceSpans(ctx, input.Service, hours, traces)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				libtelemetry.Warn("dependency source failed", "source", "tempo", "error", err)
+				errs["tempo"] = err.Error()
+			}
+			graph.TracesSampled = sampled
+			for _, c := range spanCalls(spans) {
+				s := edge(c.source, c.destination)
+				s.spanCalls++
+				if c.err {
+					s.spanErrors++
+				}
+				s.spanLatencies = append(s.spanLatencies, c.latencyMS)
+			}
+		}()
+	}
+	if h.sources.QueryFlows != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			filter := providers.FlowFilter{Namespace: input.Namespace, Since: start, Last: providers.MaxFlowLast}
+			if input.Service != "" {
+				// Only flows from or to the service's pods, so its edges are not crowded out.
+				for _, key := range dependencyServiceLabels {
+					filter.Labels = append(filter.Labels, "k8s:"+key+"="+input.Service)
+				}
+			}
+			flows, err := h.sources.QueryFlows(ctx, filter)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				libtelemetry.Warn("dependency source failed", "source", "hubble", "error", err)
+				errs["hubble"] = err.Error()
+				return
+			}
+			graph.FlowsSampled = len(flows)
+			for _, f := range flows {
+				src, dst := dependencyNode(f.Source), dependencyNode(f.Destination)
+				if src == dst {
+					continue
+				}
+				if f.Reply {
+					// L7 responses carry the status and latency of the request they answer.
+					if f.L7 != nil && f.L7.Type == "RESPONSE" {
+						s := edge(dst, src)
+						s.l7Calls++
+						if f.L7.Status >= 500 || f.L7.RCode != 0 {
+							s.l7Errors++
+						}
+						s.l7Latencies = append(s.l7Latencies, float64(f.L7.LatencyMS))
+					}
+					continue
+				}
+				s := edge(src, dst)
+				s.flows++
+				if f.Verdict == "DROPPED" {
+					s.dropped++
+				}
+				if f.Destination.Port != 0 {
+					s.ports[fmt.Sprintf("%d/%s", f.Destination.Port, f.Protocol)] = true
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k, s := range edges {
+		if input.Service != "" && k.source != input.Service && k.destination != input.Service {
+			continue
+		}
+		graph.Edges = append(graph.Edges, summarizeEdge(k, s))
+		switch input.Service {
+		case k.source:
+			graph.DependsOn = append(graph.DependsOn, k.destination)
+		case k.destination:
+			graph.Dependents = append(graph.Dependents, k.source)
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Destination < b.Destination
+	})
+	sort.Strings(graph.DependsOn)
+	sort.Strings(graph.Dependents)
+
+	switch format {
+	case DependencyFormatMermaid:
+		graph.Diagram = renderMermaid(graph.Edges)
+	case DependencyFormatDOT:
+		graph.Diagram = renderDOT(graph.Edges)
+	}
+	if len(errs) > 0 {
+		graph.SourceErrors = errs
+	}
+
+	libtelemetry.Info("service dependency graph built", "service", input.Service, "edges", len(graph.Edges))
+	return graph, nil
+}
+
+// traceSpans searches Tempo for traces in the window (involving service, when set) and fetches
+// them in parallel. Traces that fail to load are skipped and reported in the error.
+func (h *ServiceDependencyGraphHandler) traceSpans(ctx context.Context, service string, hours, limit int) ([][]depSpan, int, error) {
+	query := "{}"
+	if service != "" {
+		query = fmt.Sprintf(`{resource.service.name="%s"}`, service)
+	}
+	raw, err := h.sources.QueryTraces(ctx, "", query, hours, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	m, _ := raw.(map[string]interface{})
+	var ids []string
+	for _, t := range toList(m["traces"]) {
+		trace, _ := t.(map[string]interface{})
+		if id := attrStr(trace["traceID"]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		spans  [][]depSpan
+		failed int
+		last   error
+		sem    = make(chan struct{}, dependencyTraceFetches)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+			raw, err := h.sources.QueryTraces(ctx, id, "", 0, 0)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				last = err
+				return
+			}
+			m, _ := raw.(map[string]interface{})
+			spans = append(spans, parseDepSpans(m))
+		}(id)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		return spans, len(spans), fmt.Errorf("%d of %d traces could not be fetched: %w", failed, len(ids), last)
+	}
+	return spans, len(spans), nil
+}
+
+// depSpan is the part of a span the dependency graph needs.
+type depSpan struct {
+	id, parent string
+	service    string
+	client     bool
+	peer       string // remote service named by a client span's attributes
+	durationMS float64
+	err        bool
+}
+
+// peerAttributes name a client span's remote peer, most specific first.
+var peerAttributes = []string{"peer.service", "server.address", "net.peer.name", "db.system", "messaging.system"}
+
+// parseDepSpans flattens a Tempo trace (OTLP JSON, "batches" or "resourceSpans") into spans.
+func parseDepSpans(raw map[string]interface{}) []depSpan {
+	batches := toList(raw["batches"])
+	if batches == nil {
+		batches = toList(raw["resourceSpans"])
+	}
+	var spans []depSpan
+	for _, b := range batches {
+		batch, _ := b.(map[string]interface{})
+		svc := "unknown"
+		if res, ok := batch["resource"].(map[string]interface{}); ok {
+			if name := spanAttr(res, "service.name"); name != "" {
+				svc = name
+			}
+		}
+		for _, ss := range toList(batch["scopeSpans"]) {
+			scope, _ := ss.(map[string]interface{})
+			for _, s := range toList(scope["spans"]) {
+				sp, _ := s.(map[string]interface{})
+				span := depSpan{
+					id:         attrStr(sp["spanId"]),
+					parent:     attrStr(sp["parentSpanId"]),
+					service:    svc,
+					durationMS: float64(parseNano(sp["endTimeUnixNano"])-parseNano(sp["startTimeUnixNano"])) / 1e6,
+				}
+				switch kind := sp["kind"].(type) {
+				case string:
+					span.client = kind == "SPAN_KIND_CLIENT" || kind == "SPAN_KIND_PRODUCER"
+				case float64:
+					span.client = kind == 3 || kind == 4
+				}
+				if status, ok := sp["status"].(map[string]interface{}); ok {
+					span.err = attrStr(status["code"]) == "STATUS_CODE_ERROR"
+				}
+				if span.client {
+					for _, key := range peerAttributes {
+						if v := spanAttr(sp, key); v != "" {
+							span.peer = v
+							break
+						}
+					}
+				}
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans
+}
+
+// spanAttr reads a string attribute from an OTLP object's attribute list.
+func spanAttr(obj map[string]interface{}, key string) string {
+	for _, a := range toList(obj["attributes"]) {
+		attr, _ := a.(map[string]interface{})
+		if attrStr(attr["key"]) != key {
+			continue
+		}
+		if val, ok := attr["value"].(map[string]interface{}); ok {
+			return attrStr(val["stringValue"])
+		}
+	}
+	return ""
+}
+
+type spanCall struct {
+	source, destination string
+	latencyMS           float64
+	err                 bool
+}
+
+// spanCalls derives cross-service calls: a span whose parent belongs to another service is a call
+// from that service, timed by the callee's span. A client span without a child in another service
+// (an uninstrumented database or API) is a call to the peer its attributes name.
+func spanCalls(traces [][]depSpan) []spanCall {
+	var calls []spanCall
+	for _, spans := range traces {
+		byID := make(map[string]depSpan, len(spans))
+		for _, s := range spans {
+			byID[s.id] = s
+		}
+		answered := make(map[string]bool)
+		for _, s := range spans {
+			parent, ok := byID[s.parent]
+			if !ok || parent.service == s.service {
+				continue
+			}
+			answered[parent.id] = true
+			calls = append(calls, spanCall{source: parent.service, destination: s.service, latencyMS: s.durationMS, err: s.err})
+		}
+		for _, s := range spans {
+			if !s.client || s.peer == "" || s.peer == s.service || answered[s.id] {
+				continue
+			}
+			calls = append(calls, spanCall{source: s.service, destination: s.peer, latencyMS: s.durationMS, err: s.err})
+		}
+	}
+	return calls
+}
+
+// dependencyNode names a Hubble endpoint so it lines up with Tempo's service.name: the
+// app.kubernetes.io/name or app label, else the workload, else a DNS name, pod or reserved identity.
+func dependencyNode(e providers.FlowEndpoint) string {
+	for _, key := range dependencyServiceLabels {
+		for _, l := range e.Labels {
+			if name, ok := strings.CutPrefix(l, "k8s:"+key+"="); ok {
+				return name
+			}
+		}
+	}
+	if e.Workload != "" {
+		return e.Workload
+	}
+	if len(e.Names) > 0 {
+		return e.Names[0]
+	}
+	return e.Name()
+}
+
+func summarizeEdge(k edgeKey, s *edgeStats) DependencyEdge {
+	e := DependencyEdge{Source: k.source, Destination: k.destination, Flows: s.flows, Dropped: s.dropped}
+	var latencies []float64
+	switch {
+	case s.spanCalls > 0:
+		e.Calls, e.Errors, latencies = s.spanCalls, s.spanErrors, s.spanLatencies
+	case s.l7Calls > 0:
+		e.Calls, e.Errors, latencies = s.l7Calls, s.l7Errors, s.l7Latencies
+	default:
+		e.Calls, e.Errors = s.flows, s.dropped
+	}
+	if e.Calls > 0 {
+		e.ErrorRate = math.Round(float64(e.Errors)/float64(e.Calls)*1000) / 1000
+	}
+	e.P95MS = percentile(latencies, 0.95)
+	if s.spanCalls > 0 {
+		e.Evidence = append(e.Evidence, "traces")
+	}
+	if s.flows > 0 || s.l7Calls > 0 {
+		e.Evidence = append(e.Evidence, "flows")
+	}
+	for p := range s.ports {
+		e.Ports = append(e.Ports, p)
+	}
+	sort.Strings(e.Ports)
+	return e
+}
+
+// percentile returns the nearest-rank percentile of vals, rounded to 0.1ms; 0 when empty.
+func percentile(vals []float64, p float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return math.Round(sorted[idx]*10) / 10
+}
+
+// blocked reports whether every flow on the edge was dropped and nothing else got through.
+func blocked(e DependencyEdge) bool {
+	return e.Dropped > 0 && e.Dropped == e.Flows && e.Calls == e.Dropped
+}
+
+// edgeLabel summarizes an edge for diagrams, e.g. "120 calls, 2.5% err, p95 45ms".
+func edgeLabel(e DependencyEdge) string {
+	if blocked(e) {
+		return fmt.Sprintf("%d dropped", e.Dropped)
+	}
+	label := fmt.Sprintf("%d calls", e.Calls)
+	if e.Calls == 1 {
+		label = "1 call"
+	}
+	if e.Errors > 0 {
+		label += fmt.Sprintf(", %.1f%% err", e.ErrorRate*100)
+	}
+	if e.P95MS > 0 {
+		label += fmt.Sprintf(", p95 %gms", e.P95MS)
+	}
+	return label
+}
+
+// diagramNodes assigns stable IDs (n0, n1, ...) to the graph's nodes in name order.
+func diagramNodes(edges []DependencyEdge) ([]string, map[string]string) {
+	ids := make(map[string]string)
+	var names []string
+	for _, e := range edges {
+		for _, n := range []string{e.Source, e.Destination} {
+			if _, ok := ids[n]; !ok {
+				ids[n] = ""
+				names = append(names, n)
+			}
+		}
+	}
+	sort.Strings(names)
+	for i, n := range names {
+		ids[n] = fmt.Sprintf("n%d", i)
+	}
+	return names, ids
+}
+
+// renderMermaid draws the graph as a Mermaid flowchart; edges that only saw drops are dotted.
+func renderMermaid(edges []DependencyEdge) string {
+	names, ids := diagramNodes(edges)
+	quote := func(s string) string { return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"` }
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, n := range names {
+		fmt.Fprintf(&b, "  %s[%s]\n", ids[n], quote(n))
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if blocked(e) {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.Source], arrow, quote(edgeLabel(e)), ids[e.Destination])
+	}
+	return b.String()
+}
+
+// renderDOT draws the graph in Graphviz DOT; edges that only saw drops are dashed and red.
+func renderDOT(edges []DependencyEdge) string {
+	quote := func(s string) string { return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"` }
+
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n  rankdir=LR;\n")
+	for _, e := range edges {
+		style := ""
+		if blocked(e) {
+			style = ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", quote(e.Source), quote(e.Destination), quote(edgeLabel(e)), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,225 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+// otlpSpan builds a span in Tempo's OTLP JSON shape.
+func otlpSpan(id, parent, kind string, start time.Time, d time.Duration, isErr bool, attrs ...string) map[string]interface{} {
+	span := map[string]interface{}{
+		"spanId":            id,
+		"parentSpanId":      parent,
+		"kind":              kind,
+		"startTimeUnixNano": strconv.FormatInt(start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(start.Add(d).UnixNano(), 10),
+	}
+	if isErr {
+		span["status"] = map[string]interface{}{"code": "STATUS_CODE_ERROR"}
+	}
+	var list []interface{}
+	for i := 0; i < len(attrs); i += 2 {
+		list = append(list, map[string]interface{}{"key": attrs[i], "value": map[string]interface{}{"stringValue": attrs[i+1]}})
+	}
+	if list != nil {
+		span["attributes"] = list
+	}
+	return span
+}
+
+func otlpBatch(service string, spans ...map[string]interface{}) map[string]interface{} {
+	var list []interface{}
+	for _, s := range spans {
+		list = append(list, s)
+	}
+	return map[string]interface{}{
+		"resource": map[string]interface{}{
+			"attributes": []interface{}{
+				map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": service}},
+			},
+		},
+		"scopeSpans": []interface{}{map[string]interface{}{"spans": list}},
+	}
+}
+
+func TestServiceDependencyGraphHandler_Execute(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	t0 := now.Add(-10 * time.Minute)
+
+	// grafana → api (server span errors on t2) → postgres (client span only, named by db.system).
+	trace := func(id string, apiErr bool, apiLatency time.Duration) map[string]interface{} {
+		return map[string]interface{}{
+			"batches": []interface{}{
+				otlpBatch("grafana",
+					otlpSpan(id+"1", "", "SPAN_KIND_SERVER", t0, time.Second, false),
+					otlpSpan(id+"2", id+"1", "SPAN_KIND_CLIENT", t0, apiLatency, apiErr, "server.address", "api"),
+				),
+				otlpBatch("api",
+					otlpSpan(id+"3", id+"2", "SPAN_KIND_SERVER", t0, apiLatency, apiErr),
+					otlpSpan(id+"4", id+"3", "SPAN_KIND_CLIENT", t0, 5*time.Millisecond, false, "db.system", "postgresql"),
+				),
+			},
+		}
+	}
+	traces := map[string]map[string]interface{}{
+		"t1": trace("a", false, 40*time.Millisecond),
+		"t2": trace("b", true, 200*time.Millisecond),
+	}
+	var searched string
+	tempo := func(ctx context.Context, traceID string, query string, hours int, limit int) (interface{}, error) {
+		if traceID == "" {
+			searched = query
+			return map[string]interface{}{"traces": []interface{}{
+				map[string]interface{}{"traceID": "t1"},
+				map[string]interface{}{"traceID": "t2"},
+			}}, nil
+		}
+		return traces[traceID], nil
+	}
+
+	grafana := providers.FlowEndpoint{Namespace: "hub", Labels: []string{"k8s:app.kubernetes.io/name=grafana"}}
+	api := providers.FlowEndpoint{Namespace: "apps", Workload: "api", Port: 8080}
+	minio := providers.FlowEndpoint{Namespace: "databases", Labels: []string{"k8s:app=minio"}, Port: 9000}
+	var gotFilter providers.FlowFilter
+	hubble := func(ctx context.Context, f providers.FlowFilter) ([]providers.Flow, error) {
+		gotFilter = f
+		return []providers.Flow{
+			{Verdict: "FORWARDED", Protocol: "TCP", Source: grafana, Destination: api},
+			{Verdict: "FORWARDED", Protocol: "TCP", Source: api, Destination: grafana, Reply: true,
+				L7: &providers.FlowL7{Type: "RESPONSE", Protocol: "http", Status: 200, LatencyMS: 30}},
+			{Verdict: "DROPPED", Protocol: "TCP", Source: grafana, Destination: minio},
+			{Verdict: "DROPPED", Protocol: "TCP", Source: grafana, Destination: minio},
+			{Verdict: "FORWARDED", Protocol: "UDP", Source: api, Destination: providers.FlowEndpoint{Names: []string{"hooks.example.com"}, Port: 443}},
+		}, nil
+	}
+
+	tests := []struct {
+		name       string
+		input      ServiceDependencyGraphInput
+		sources    DependencySources
+		wantEdges  []DependencyEdge
+		wantOn     []string
+		wantBy     []string
+		wantQuery  string
+		wantLabels []string // Hubble label selectors for the service
+		wantDiag   []string
+		wantErrSrc string
+		wantErr    bool
+	}{
+		{
+			name:      "traces and flows combined",
+			input:     ServiceDependencyGraphInput{},
+			sources:   DependencySources{QueryTraces: tempo, QueryFlows: hubble},
+			wantQuery: "{}",
+			wantEdges: []DependencyEdge{
+				{Source: "api", Destination: "postgresql", Calls: 2, ErrorRate: 0, P95MS: 5, Evidence: []string{"traces"}},
+				{Source: "grafana", Destination: "api", Calls: 2, Errors: 1, ErrorRate: 0.5, P95MS: 200, Evidence: []string{"traces", "flows"}, Flows: 1, Ports: []string{"8080/TCP"}},
+				{Source: "grafana", Destination: "minio", Calls: 2, Errors: 2, ErrorRate: 1, Evidence: []string{"flows"}, Flows: 2, Dropped: 2, Ports: []string{"9000/TCP"}},
+				{Source: "api", Destination: "hooks.example.com", Calls: 1, Evidence: []string{"flows"}, Flows: 1, Ports: []string{"443/UDP"}},
+			},
+			wantDiag: []string{"graph LR", `n1 -->|"2 calls, 50.0% err, p95 200ms"| n0`, `n1 -.->|"2 dropped"| n3`, `n0 -->|"1 call"| n2`},
+		},
+		{
+			name:       "focus on one service",
+			input:      ServiceDependencyGraphInput{Service: "api", Format: DependencyFormatDOT},
+			sources:    DependencySources{QueryTraces: tempo, QueryFlows: hubble},
+			wantQuery:  `{resource.service.name="api"}`,
+			wantLabels: []string{"k8s:app.kubernetes.io/name=api", "k8s:app=api"},
+			wantEdges: []DependencyEdge{
+				{Source: "api", Destination: "postgresql", Calls: 2, ErrorRate: 0, P95MS: 5, Evidence: []string{"traces"}},
+				{Source: "grafana", Destination: "api", Calls: 2, Errors: 1, ErrorRate: 0.5, P95MS: 200, Evidence: []string{"traces", "flows"}, Flows: 1, Ports: []string{"8080/TCP"}},
+				{Source: "api", Destination: "hooks.example.com", Calls: 1, Evidence: []string{"flows"}, Flows: 1, Ports: []string{"443/UDP"}},
+			},
+			wantOn:   []string{"hooks.example.com", "postgresql"},
+			wantBy:   []string{"grafana"},
+			wantDiag: []string{"digraph dependencies {", `"grafana" -> "api" [label="2 calls, 50.0% err, p95 200ms"];`},
+		},
+		{
+			name:    "L7 responses without traces",
+			input:   ServiceDependencyGraphInput{Format: DependencyFormatNone},
+			sources: DependencySources{QueryFlows: hubble},
+			wantEdges: []DependencyEdge{
+				{Source: "grafana", Destination: "minio", Calls: 2, Errors: 2, ErrorRate: 1, Evidence: []string{"flows"}, Flows: 2, Dropped: 2, Ports: []string{"9000/TCP"}},
+				{Source: "api", Destination: "hooks.example.com", Calls: 1, Evidence: []string{"flows"}, Flows: 1, Ports: []string{"443/UDP"}},
+				{Source: "grafana", Destination: "api", Calls: 1, P95MS: 30, Evidence: []string{"flows"}, Flows: 1, Ports: []string{"8080/TCP"}},
+			},
+		},
+		{
+			name:  "failing source is reported",
+			input: ServiceDependencyGraphInput{Service: "grafana", Format: DependencyFormatNone},
+			sources: DependencySources{
+				QueryTraces: tempo,
+				QueryFlows: func(ctx context.Context, f providers.FlowFilter) ([]providers.Flow, error) {
+					return nil, errors.New("hubble unreachable")
+				},
+			},
+			wantQuery: `{resource.service.name="grafana"}`,
+			wantEdges: []DependencyEdge{
+				{Source: "grafana", Destination: "api", Calls: 2, Errors: 1, ErrorRate: 0.5, P95MS: 200, Evidence: []string{"traces"}},
+			},
+			wantOn:     []string{"api"},
+			wantErrSrc: "hubble",
+		},
+		{
+			name:    "service that would escape the TraceQL string",
+			input:   ServiceDependencyGraphInput{Service: `api"} || {`},
+			sources: DependencySources{QueryTraces: tempo, QueryFlows: hubble},
+			wantErr: true,
+		},
+		{
+			name:    "invalid format",
+			input:   ServiceDependencyGraphInput{Format: "png"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searched, gotFilter = "", providers.FlowFilter{}
+			h := NewServiceDependencyGraphHandler(tt.sources)
+			h.now = func() time.Time { return now }
+
+			out, err := h.Execute(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			graph := out.(DependencyGraph)
+			if !reflect.DeepEqual(graph.Edges, tt.wantEdges) {
+				t.Errorf("edges =\n%+v\nwant\n%+v", graph.Edges, tt.wantEdges)
+			}
+			if !reflect.DeepEqual(graph.DependsOn, tt.wantOn) || !reflect.DeepEqual(graph.Dependents, tt.wantBy) {
+				t.Errorf("depends on %v, dependents %v; want %v, %v", graph.DependsOn, graph.Dependents, tt.wantOn, tt.wantBy)
+			}
+			if searched != tt.wantQuery {
+				t.Errorf("trace search = %q, want %q", searched, tt.wantQuery)
+			}
+			if tt.sources.QueryFlows != nil && gotFilter.Last != 0 {
+				want := providers.FlowFilter{Namespace: tt.input.Namespace, Labels: tt.wantLabels, Since: now.Add(-time.Hour), Last: providers.MaxFlowLast}
+				if !reflect.DeepEqual(gotFilter, want) {
+					t.Errorf("flow filter = %+v, want %+v", gotFilter, want)
+				}
+			}
+			for _, want := range tt.wantDiag {
+				if !strings.Contains(graph.Diagram, want) {
+					t.Errorf("diagram missing %q:\n%s", want, graph.Diagram)
+				}
+			}
+			if len(tt.wantDiag) == 0 && graph.Diagram != "" {
+				t.Errorf("diagram = %q, want none", graph.Diagram)
+			}
+			if _, ok := graph.SourceErrors[tt.wantErrSrc]; tt.wantErrSrc != "" && !ok {
+				t.Errorf("source errors = %v, want %s", graph.SourceErrors, tt.wantErrSrc)
+			}
+		})
+	}
+}
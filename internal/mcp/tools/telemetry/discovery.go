@@ -0,0 +1,329 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"observability-hub/internal/mcp/providers"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+const (
+	defaultDiscoveryLimit = 100
+	maxDiscoveryLimit     = 1000
+	// maxLabelExamples is the number of example values returned per label by list_metric_labels.
+	maxLabelExamples = 5
+)
+
+var (
+	labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// traceTagPattern allows scoped, dotted attribute names such as resource.service.name.
+	traceTagPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.\-]*$`)
+)
+
+// DiscoveryResult is the common output of the discovery tools.
+type DiscoveryResult struct {
+	Total     int      `json:"total"` // matches after prefix filtering, before the cap
+	Returned  int      `json:"returned"`
+	Truncated bool     `json:"truncated"`
+	Values    []string `json:"values"`
+}
+
+// LabelSummary describes one label of a metric with its cardinality and sample values.
+type LabelSummary struct {
+	Name        string   `json:"name"`
+	Cardinality int      `json:"cardinality"` // distinct values, including those not in Examples
+	Examples    []string `json:"examples"`
+	Truncated   bool     `json:"truncated,omitempty"` // Examples holds only some of the values
+}
+
+// MetricLabelsResult is the output of list_metric_labels when listing labels of a metric.
+type MetricLabelsResult struct {
+	Metric      string         `json:"metric"`
+	SeriesCount int            `json:"series_count"`
+	Total       int            `json:"total"` // labels after prefix filtering, before the cap
+	Returned    int            `json:"returned"`
+	Truncated   bool           `json:"truncated"`
+	Labels      []LabelSummary `json:"labels"`
+}
+
+// --- Metrics ---
+
+// ListMetricsInput represents the input for the list_metrics tool.
+type ListMetricsInput struct {
+	Prefix string `json:"prefix,omitempty"` // only return metric names starting with this prefix e.g. "http_"
+	Match  string `json:"match,omitempty"`  // optional series selector e.g. {service="proxy"}
+	Limit  int    `json:"limit,omitempty"`  // max names returned (default 100, max 1000)
+	Target string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
+}
+
+// ListMetricsHandler lists metric names known to Thanos.
+type ListMetricsHandler struct {
+	listFn func(ctx context.Context, match string) ([]string, error)
+}
+
+// NewListMetricsHandler creates a new list_metrics handler.
+func NewListMetricsHandler(listFn func(ctx context.Context, match string) ([]string, error)) *ListMetricsHandler {
+	return &ListMetricsHandler{listFn: listFn}
+}
+
+// Execute runs the list_metrics tool.
+func (h *ListMetricsHandler) Execute(ctx context.Context, input ListMetricsInput) (interface{}, error) {
+	if err := validateSelector(input.Match); err != nil {
+		return nil, err
+	}
+	names, err := h.listFn(ctx, input.Match)
+	if err != nil {
+		libtelemetry.Error("list metrics failed", "error", err)
+		return nil, fmt.Errorf("list metrics failed: %w", err)
+	}
+	return filterValues(names, input.Prefix, input.Limit), nil
+}
+
+// ListMetricLabelsInput represents the input for the list_metric_labels tool.
+// With only metric set, labels of that metric are summarized from its series.
+// With label set, the values of that label are listed (optionally scoped to metric).
+type ListMetricLabelsInput struct {
+	Metric string `json:"metric,omitempty"` // metric name or series selector e.g. http_requests_total
+	Label  string `json:"label,omitempty"`  // label whose values to list e.g. "service"
+	Prefix string `json:"prefix,omitempty"` // only return label names/values starting with this prefix
+	Limit  int    `json:"limit,omitempty"`  // max entries returned (default 100, max 1000)
+	Target string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
+}
+
+// ListMetricLabelsHandler lists label names and values for Thanos metrics.
+type ListMetricLabelsHandler struct {
+	labelNamesFn  func(ctx context.Context, match string) ([]string, error)
+	labelValuesFn func(ctx context.Context, label string, match string) ([]string, error)
+	seriesFn      func(ctx context.Context, match string) ([]map[string]string, error)
+}
+
+// NewListMetricLabelsHandler creates a new list_metric_labels handler.
+func NewListMetricLabelsHandler(
+	labelNamesFn func(ctx context.Context, match string) ([]string, error),
+	labelValuesFn func(ctx context.Context, label string, match string) ([]string, error),
+	seriesFn func(ctx context.Context, match string) ([]map[string]string, error),
+) *ListMetricLabelsHandler {
+	return &ListMetricLabelsHandler{labelNamesFn: labelNamesFn, labelValuesFn: labelValuesFn, seriesFn: seriesFn}
+}
+
+// Execute runs the list_metric_labels tool.
+func (h *ListMetricLabelsHandler) Execute(ctx context.Context, input ListMetricLabelsInput) (interface{}, error) {
+	if err := validateSelector(input.Metric); err != nil {
+		return nil, err
+	}
+
+	if input.Label != "" {
+		if !labelNamePattern.MatchString(input.Label) {
+			return nil, providers.InvalidInputf("invalid label name: %q", input.Label)
+		}
+		values, err := h.labelValuesFn(ctx, input.Label, input.Metric)
+		if err != nil {
+			libtelemetry.Error("list metric label values failed", "label", input.Label, "error", err)
+			return nil, fmt.Errorf("list label values failed: %w", err)
+		}
+		return filterValues(values, input.Prefix, input.Limit), nil
+	}
+
+	if input.Metric == "" {
+		names, err := h.labelNamesFn(ctx, "")
+		if err != nil {
+			libtelemetry.Error("list metric label names failed", "error", err)
+			return nil, fmt.Errorf("list label names failed: %w", err)
+		}
+		return filterValues(names, input.Prefix, input.Limit), nil
+	}
+
+	series, err := h.seriesFn(ctx, input.Metric)
+	if err != nil {
+		libtelemetry.Error("list metric series failed", "metric", input.Metric, "error", err)
+		return nil, fmt.Errorf("list series failed: %w", err)
+	}
+	return summarizeSeriesLabels(input.Metric, series, input.Prefix, input.Limit), nil
+}
+
+// summarizeSeriesLabels aggregates series label sets into per-label cardinality and examples.
+func summarizeSeriesLabels(metric string, series []map[string]string, prefix string, limit int) MetricLabelsResult {
+	distinct := make(map[string]map[string]struct{})
+	for _, s := range series {
+		for k, v := range s {
+			if k == "__name__" || !strings.HasPrefix(k, prefix) {
+				continue
+			}
+			if distinct[k] == nil {
+				distinct[k] = make(map[string]struct{})
+			}
+			distinct[k][v] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(distinct))
+	for k := range distinct {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	total := len(names)
+	names = names[:min(len(names), clampLimit(limit))]
+
+	labels := make([]LabelSummary, 0, len(names))
+	for _, name := range names {
+		values := make([]string, 0, len(distinct[name]))
+		for v := range distinct[name] {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		labels = append(labels, LabelSummary{
+			Name:        name,
+			Cardinality: len(values),
+			Examples:    values[:min(len(values), maxLabelExamples)],
+			Truncated:   len(values) > maxLabelExamples,
+		})
+	}
+	return MetricLabelsResult{
+		Metric:      metric,
+		SeriesCount: len(series),
+		Total:       total,
+		Returned:    len(labels),
+		Truncated:   len(labels) < total,
+		Labels:      labels,
+	}
+}
+
+// --- Logs ---
+
+// ListLogLabelsInput represents the input for the list_log_labels tool.
+type ListLogLabelsInput struct {
+	Label  string `json:"label,omitempty"`  // label whose values to list e.g. "service"; empty lists label names
+	Prefix string `json:"prefix,omitempty"` // only return names/values starting with this prefix
+	Hours  int    `json:"hours,omitempty"`  // lookback window (default 6, max 168)
+	Limit  int    `json:"limit,omitempty"`  // max entries returned (default 100, max 1000)
+	Target string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
+}
+
+// ListLogLabelsHandler lists Loki label names and values.
+type ListLogLabelsHandler struct {
+	labelsFn func(ctx context.Context, hours int) ([]string, error)
+	valuesFn func(ctx context.Context, label string, hours int) ([]string, error)
+}
+
+// NewListLogLabelsHandler creates a new list_log_labels handler.
+func NewListLogLabelsHandler(
+	labelsFn func(ctx context.Context, hours int) ([]string, error),
+	valuesFn func(ctx context.Context, label string, hours int) ([]string, error),
+) *ListLogLabelsHandler {
+	return &ListLogLabelsHandler{labelsFn: labelsFn, valuesFn: valuesFn}
+}
+
+// Execute runs the list_log_labels tool.
+func (h *ListLogLabelsHandler) Execute(ctx context.Context, input ListLogLabelsInput) (interface{}, error) {
+	var (
+		values []string
+		err    error
+	)
+	if input.Label != "" {
+		if !labelNamePattern.MatchString(input.Label) {
+			return nil, providers.InvalidInputf("invalid label name: %q", input.Label)
+		}
+		values, err = h.valuesFn(ctx, input.Label, input.Hours)
+	} else {
+		values, err = h.labelsFn(ctx, input.Hours)
+	}
+	if err != nil {
+		libtelemetry.Error("list log labels failed", "label", input.Label, "error", err)
+		return nil, fmt.Errorf("list log labels failed: %w", err)
+	}
+	return filterValues(values, input.Prefix, input.Limit), nil
+}
+
+// --- Traces ---
+
+// ListTraceTagsInput represents the input for the list_trace_tags tool.
+type ListTraceTagsInput struct {
+	Tag    string `json:"tag,omitempty"`    // scoped tag whose values to list e.g. "resource.service.name"; empty lists tags
+	Prefix string `json:"prefix,omitempty"` // only return tags/values starting with this prefix e.g. "resource."
+	Limit  int    `json:"limit,omitempty"`  // max entries returned (default 100, max 1000)
+	Target string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
+}
+
+// ListTraceTagsHandler lists Tempo tag names and values.
+type ListTraceTagsHandler struct {
+	tagsFn   func(ctx context.Context) ([]string, error)
+	valuesFn func(ctx context.Context, tag string) ([]string, error)
+}
+
+// NewListTraceTagsHandler creates a new list_trace_tags handler.
+func NewListTraceTagsHandler(
+	tagsFn func(ctx context.Context) ([]string, error),
+	valuesFn func(ctx context.Context, tag string) ([]string, error),
+) *ListTraceTagsHandler {
+	return &ListTraceTagsHandler{tagsFn: tagsFn, valuesFn: valuesFn}
+}
+
+// Execute runs the list_trace_tags tool.
+func (h *ListTraceTagsHandler) Execute(ctx context.Context, input ListTraceTagsInput) (interface{}, error) {
+	var (
+		values []string
+		err    error
+	)
+	if input.Tag != "" {
+		if !traceTagPattern.MatchString(input.Tag) {
+			return nil, providers.InvalidInputf("invalid tag name: %q", input.Tag)
+		}
+		values, err = h.valuesFn(ctx, input.Tag)
+	} else {
+		values, err = h.tagsFn(ctx)
+	}
+	if err != nil {
+		libtelemetry.Error("list trace tags failed", "tag", input.Tag, "error", err)
+		return nil, fmt.Errorf("list trace tags failed: %w", err)
+	}
+	return filterValues(values, input.Prefix, input.Limit), nil
+}
+
+// --- Helpers ---
+
+// filterValues applies prefix filtering and the result cap.
+func filterValues(values []string, prefix string, limit int) DiscoveryResult {
+	matched := make([]string, 0, len(values))
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			matched = append(matched, v)
+		}
+	}
+	limit = clampLimit(limit)
+	result := DiscoveryResult{Total: len(matched), Values: matched}
+	if len(matched) > limit {
+		result.Values = matched[:limit]
+		result.Truncated = true
+	}
+	result.Returned = len(result.Values)
+	return result
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultDiscoveryLimit
+	}
+	if limit > maxDiscoveryLimit {
+		return maxDiscoveryLimit
+	}
+	return limit
+}
+
+// validateSelector applies the same safety checks as query_metrics to series selectors.
+func validateSelector(selector string) error {
+	if len(selector) > 1000 {
+		return providers.InvalidInputf("selector too long (max 1000 chars)")
+	}
+	lower := strings.ToLower(selector)
+	for _, pattern := range dangerousPatterns {
+		if strings.Contains(lower, pattern) {
+			libtelemetry.Warn("dangerous keyword detected in selector", "keyword", pattern)
+			return providers.InvalidInputf("selector contains potentially dangerous keyword: %s", pattern)
+		}
+	}
+	return nil
+}
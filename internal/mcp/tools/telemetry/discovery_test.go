@@ -0,0 +1,218 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterValues(t *testing.T) {
+	values := []string{"http_requests_total", "http_request_duration_seconds", "up"}
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   DiscoveryResult
+	}{
+		{
+			name: "no filter",
+			want: DiscoveryResult{Total: 3, Returned: 3, Values: values},
+		},
+		{
+			name:   "prefix filter",
+			prefix: "http_",
+			want:   DiscoveryResult{Total: 2, Returned: 2, Values: values[:2]},
+		},
+		{
+			name:  "capped",
+			limit: 1,
+			want:  DiscoveryResult{Total: 3, Returned: 1, Truncated: true, Values: values[:1]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterValues(values, tt.prefix, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListMetricsHandler_Execute(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   ListMetricsInput
+		listFn  func(ctx context.Context, match string) ([]string, error)
+		wantN   int
+		wantErr string
+	}{
+		{
+			name:  "prefix filtered",
+			input: ListMetricsInput{Prefix: "http_"},
+			listFn: func(ctx context.Context, match string) ([]string, error) {
+				return []string{"http_requests_total", "up"}, nil
+			},
+			wantN: 1,
+		},
+		{
+			name:    "dangerous selector rejected",
+			input:   ListMetricsInput{Match: `{job="x"} drop`},
+			wantErr: "dangerous keyword",
+		},
+		{
+			name:  "provider error",
+			input: ListMetricsInput{},
+			listFn: func(ctx context.Context, match string) ([]string, error) {
+				return nil, errors.New("unavailable")
+			},
+			wantErr: "list metrics failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewListMetricsHandler(tt.listFn).Execute(context.Background(), tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res := got.(DiscoveryResult); res.Returned != tt.wantN {
+				t.Errorf("got %d values, want %d", res.Returned, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestListMetricLabelsHandler_Execute(t *testing.T) {
+	names := func(ctx context.Context, match string) ([]string, error) { return []string{"job", "service"}, nil }
+	values := func(ctx context.Context, label, match string) ([]string, error) {
+		return []string{"analytics", "proxy"}, nil
+	}
+	series := func(ctx context.Context, match string) ([]map[string]string, error) {
+		return []map[string]string{
+			{"__name__": "http_requests_total", "service": "proxy", "status": "200"},
+			{"__name__": "http_requests_total", "service": "proxy", "status": "500"},
+			{"__name__": "http_requests_total", "service": "analytics", "status": "200"},
+		}, nil
+	}
+	h := NewListMetricLabelsHandler(names, values, series)
+
+	t.Run("label values", func(t *testing.T) {
+		got, err := h.Execute(context.Background(), ListMetricLabelsInput{Label: "service", Prefix: "p"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res := got.(DiscoveryResult); !reflect.DeepEqual(res.Values, []string{"proxy"}) {
+			t.Errorf("got %v, want [proxy]", res.Values)
+		}
+	})
+
+	t.Run("label names without metric", func(t *testing.T) {
+		got, err := h.Execute(context.Background(), ListMetricLabelsInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res := got.(DiscoveryResult); res.Total != 2 {
+			t.Errorf("got %d label names, want 2", res.Total)
+		}
+	})
+
+	t.Run("labels of a metric summarized from series", func(t *testing.T) {
+		got, err := h.Execute(context.Background(), ListMetricLabelsInput{Metric: "http_requests_total"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := MetricLabelsResult{
+			Metric:      "http_requests_total",
+			SeriesCount: 3,
+			Total:       2,
+			Returned:    2,
+			Labels: []LabelSummary{
+				{Name: "service", Cardinality: 2, Examples: []string{"analytics", "proxy"}},
+				{Name: "status", Cardinality: 2, Examples: []string{"200", "500"}},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("label summary truncation is reported", func(t *testing.T) {
+		var series []map[string]string
+		for i := 0; i < 8; i++ {
+			series = append(series, map[string]string{"__name__": "up", "instance": fmt.Sprintf("node-%d", i), "job": "node", "zone": "a"})
+		}
+		got := summarizeSeriesLabels("up", series, "", 2)
+		if got.Total != 3 || got.Returned != 2 || !got.Truncated {
+			t.Errorf("got total/returned/truncated %d/%d/%v, want 3/2/true", got.Total, got.Returned, got.Truncated)
+		}
+		if l := got.Labels[0]; l.Name != "instance" || l.Cardinality != 8 || len(l.Examples) != maxLabelExamples || !l.Truncated {
+			t.Errorf("got %+v, want 8 instances with %d examples, truncated", l, maxLabelExamples)
+		}
+		if l := got.Labels[1]; l.Truncated {
+			t.Errorf("got %+v, want job untruncated", l)
+		}
+	})
+
+	t.Run("invalid label name", func(t *testing.T) {
+		if _, err := h.Execute(context.Background(), ListMetricLabelsInput{Label: "bad-label"}); err == nil {
+			t.Error("expected error for invalid label name")
+		}
+	})
+}
+
+func TestListLogLabelsHandler_Execute(t *testing.T) {
+	h := NewListLogLabelsHandler(
+		func(ctx context.Context, hours int) ([]string, error) { return []string{"level", "service"}, nil },
+		func(ctx context.Context, label string, hours int) ([]string, error) {
+			if label != "service" {
+				return nil, errors.New("unexpected label")
+			}
+			return []string{"proxy", "worker"}, nil
+		},
+	)
+
+	got, err := h.Execute(context.Background(), ListLogLabelsInput{})
+	if err != nil || got.(DiscoveryResult).Total != 2 {
+		t.Errorf("got %v, %v, want 2 label names", got, err)
+	}
+	got, err = h.Execute(context.Background(), ListLogLabelsInput{Label: "service", Prefix: "w"})
+	if err != nil || !reflect.DeepEqual(got.(DiscoveryResult).Values, []string{"worker"}) {
+		t.Errorf("got %v, %v, want [worker]", got, err)
+	}
+	if _, err := h.Execute(context.Background(), ListLogLabelsInput{Label: "1bad"}); err == nil {
+		t.Error("expected error for invalid label name")
+	}
+}
+
+func TestListTraceTagsHandler_Execute(t *testing.T) {
+	h := NewListTraceTagsHandler(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"resource.service.name", "span.http.method"}, nil
+		},
+		func(ctx context.Context, tag string) ([]string, error) { return []string{"proxy"}, nil },
+	)
+
+	got, err := h.Execute(context.Background(), ListTraceTagsInput{Prefix: "resource."})
+	if err != nil || !reflect.DeepEqual(got.(DiscoveryResult).Values, []string{"resource.service.name"}) {
+		t.Errorf("got %v, %v, want [resource.service.name]", got, err)
+	}
+	got, err = h.Execute(context.Background(), ListTraceTagsInput{Tag: "resource.service.name"})
+	if err != nil || got.(DiscoveryResult).Total != 1 {
+		t.Errorf("got %v, %v, want 1 value", got, err)
+	}
+	if _, err := h.Execute(context.Background(), ListTraceTagsInput{Tag: "bad tag"}); err == nil {
+		t.Error("expected error for invalid tag")
+	}
+}
@@ -7,66 +7,92 @@ import (
 	"sync"
 	"time"
 
+	"observability-hub/internal/mcp/providers"
 	libtelemetry "observability-hub/internal/telemetry"
 )
 
 // InvestigateIncidentInput represents the input for the investigate_incident tool.
 type InvestigateIncidentInput struct {
-	Service string `json:"service"`         // service name to investigate e.g. "proxy", "analytics"
-	Hours   int    `json:"hours,omitempty"` // lookback window in hours (default 1, max 168)
-	Since   string `json:"since,omitempty"` // ISO 8601 start time e.g. "2026-03-06T17:00:00Z" — overrides hours
+	Service string `json:"service"`          // service name to investigate e.g. "proxy", "analytics"
+	Hours   int    `json:"hours,omitempty"`  // lookback window in hours (default 1, max 168)
+	Since   string `json:"since,omitempty"`  // ISO 8601 start time e.g. "2026-03-06T17:00:00Z" — overrides hours
+	Target  string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
 }
 
+// Verdict bases recorded in IncidentReport.VerdictBasis.
+const (
+	VerdictAnomalies     = "metric_anomalies"
+	VerdictErrorPresence = "error_presence"
+	VerdictErrorRate     = "elevated_error_rate"
+)
+
+// minErrorRatioChange is the smallest change in the 5xx ratio (one percentage point)
+// treated as an anomaly, so a stray error on an otherwise clean service is not one.
+const minErrorRatioChange = 0.01
+
+// elevatedErrorRatio is the 5xx ratio over the window at which found errors make the service
+// unhealthy even without an anomaly, e.g. an outage that predates the baseline.
+const elevatedErrorRatio = 0.05
+
 // IncidentReport is the structured output of an investigation.
 type IncidentReport struct {
 	Service  string `json:"service"`
 	WindowHr int    `json:"window_hours"`
 	Since    string `json:"since,omitempty"`
 	Healthy  bool   `json:"healthy"`
+	// VerdictBasis explains how Healthy was decided: from metric anomalies when a
+	// baseline was available, from errors found while the error ratio is elevated, otherwise
+	// from the presence of error logs/traces.
+	VerdictBasis string `json:"verdict_basis"`
 
-	ErrorLogs    interface{} `json:"error_logs,omitempty"`
-	ErrorTraces  interface{} `json:"error_traces,omitempty"`
-	Metrics      interface{} `json:"metrics,omitempty"`
-	ErrorSummary string      `json:"error_summary,omitempty"`
+	Anomalies    []AnomalyFinding `json:"anomalies,omitempty"`
+	ErrorLogs    interface{}      `json:"error_logs,omitempty"`
+	ErrorTraces  interface{}      `json:"error_traces,omitempty"`
+	Metrics      interface{}      `json:"metrics,omitempty"`
+	ErrorSummary string           `json:"error_summary,omitempty"`
 }
 
 // InvestigateIncidentHandler orchestrates metrics, logs, and traces to produce an incident report.
 type InvestigateIncidentHandler struct {
-	queryMetrics func(ctx context.Context, query string) (interface{}, error)
-	queryLogs    func(ctx context.Context, query string, limit int, hours int) (interface{}, error)
-	queryTraces  func(ctx context.Context, traceID string, query string, hours int, limit int) (interface{}, error)
+	queryMetricsRange func(ctx context.Context, query string, start, end time.Time, step time.Duration) (interface{}, error)
+	queryLogs         func(ctx context.Context, query string, limit int, hours int) (interface{}, error)
+	queryTraces       func(ctx context.Context, traceID string, query string, hours int, limit int) (interface{}, error)
+	now               func() time.Time
 }
 
 // NewInvestigateIncidentHandler creates a new investigate_incident handler.
 func NewInvestigateIncidentHandler(
-	queryMetrics func(ctx context.Context, query string) (interface{}, error),
+	queryMetricsRange func(ctx context.Context, query string, start, end time.Time, step time.Duration) (interface{}, error),
 	queryLogs func(ctx context.Context, query string, limit int, hours int) (interface{}, error),
 	queryTraces func(ctx context.Context, traceID string, query string, hours int, limit int) (interface{}, error),
 ) *InvestigateIncidentHandler {
 	return &InvestigateIncidentHandler{
-		queryMetrics: queryMetrics,
-		queryLogs:    queryLogs,
-		queryTraces:  queryTraces,
+		queryMetricsRange: queryMetricsRange,
+		queryLogs:         queryLogs,
+		queryTraces:       queryTraces,
+		now:               time.Now,
 	}
 }
 
 // Execute runs the investigate_incident tool.
-// It checks for errors in logs and traces in parallel, then fetches supporting
-// metrics if issues are found, and returns a structured incident report.
+// It fetches error logs, error traces and metric range data (covering a baseline window
+// before the investigation window) in parallel. The health verdict is based on metric
+// anomalies when a baseline is available, so a single stray error line does not flip it;
+// otherwise it falls back to the presence of errors.
 func (h *InvestigateIncidentHandler) Execute(ctx context.Context, input InvestigateIncidentInput) (interface{}, error) {
 	if input.Service == "" {
-		return nil, fmt.Errorf("service is required")
+		return nil, providers.InvalidInputf("service is required")
 	}
 
 	// Resolve hours: since overrides hours when set
 	if input.Since != "" {
 		t, err := time.Parse(time.RFC3339, input.Since)
 		if err != nil {
-			return nil, fmt.Errorf("invalid since format, expected RFC3339 e.g. 2026-03-06T17:00:00Z: %w", err)
+			return nil, providers.InvalidInputf("invalid since format, expected RFC3339 e.g. 2026-03-06T17:00:00Z: %w", err)
 		}
-		computed := int(math.Ceil(time.Since(t).Hours()))
+		computed := int(math.Ceil(h.now().Sub(t).Hours()))
 		if computed <= 0 {
-			return nil, fmt.Errorf("since must be in the past")
+			return nil, providers.InvalidInputf("since must be in the past")
 		}
 		input.Hours = computed
 	}
@@ -79,36 +105,55 @@ func (h *InvestigateIncidentHandler) Execute(ctx context.Context, input Investig
 
 	libtelemetry.Info("investigating incident", "service", input.Service, "hours", input.Hours, "since", input.Since)
 
-	// Step 1: Check for errors in logs and traces in parallel
+	// The baseline covers the same length as the investigation window, capped at 24h.
+	end := h.now()
+	evalStart := end.Add(-time.Duration(input.Hours) * time.Hour)
+	baselineStart := evalStart.Add(-time.Duration(min(input.Hours, 24)) * time.Hour)
+	step := end.Sub(baselineStart) / 240
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+
+	// Step 1: Fetch logs, traces and metric ranges in parallel
 	type result struct {
 		data interface{}
 		err  error
 	}
 
-	logsCh := make(chan result, 1)
-	tracesCh := make(chan result, 1)
-
 	logQuery := fmt.Sprintf(`{service="%s"} |~ "(?i)error"`, input.Service)
 	traceQuery := fmt.Sprintf(`{resource.service.name="%s"} && status=error`, input.Service)
+	errorRateQuery := fmt.Sprintf(`(sum(rate(http_requests_total{service="%s",status=~"5.."}[5m])) or vector(0)) / sum(rate(http_requests_total{service="%s"}[5m]))`, input.Service, input.Service)
+	counterQuery := fmt.Sprintf(`sum by (instance) (http_requests_total{service="%s"})`, input.Service)
 
+	var logsResult, tracesResult, rateResult, counterResult result
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(4)
 
 	go func() {
 		defer wg.Done()
 		data, err := h.queryLogs(ctx, logQuery, 20, input.Hours)
-		logsCh <- result{data, err}
+		logsResult = result{data, err}
 	}()
 
 	go func() {
 		defer wg.Done()
 		data, err := h.queryTraces(ctx, "", traceQuery, input.Hours, 10)
-		tracesCh <- result{data, err}
+		tracesResult = result{data, err}
+	}()
+
+	go func() {
+		defer wg.Done()
+		data, err := h.queryMetricsRange(ctx, errorRateQuery, baselineStart, end, step)
+		rateResult = result{data, err}
+	}()
+
+	go func() {
+		defer wg.Done()
+		data, err := h.queryMetricsRange(ctx, counterQuery, baselineStart, end, step)
+		counterResult = result{data, err}
 	}()
 
 	wg.Wait()
-	logsResult := <-logsCh
-	tracesResult := <-tracesCh
 
 	report := IncidentReport{
 		Service:  input.Service,
@@ -118,7 +163,6 @@ func (h *InvestigateIncidentHandler) Execute(ctx context.Context, input Investig
 	}
 
 	hasErrors := false
-
 	if logsResult.err == nil && hasLogEntries(logsResult.data) {
 		report.ErrorLogs = logsResult.data
 		hasErrors = true
@@ -128,21 +172,48 @@ func (h *InvestigateIncidentHandler) Execute(ctx context.Context, input Investig
 		hasErrors = true
 	}
 
-	if !hasErrors {
-		libtelemetry.Info("incident investigation complete: no errors found", "service", input.Service)
-		return report, nil
+	// Step 2: Detect anomalies against the baseline window
+	haveBaseline, errorRatio := false, 0.0
+	if rateResult.err == nil {
+		if series, err := ParseMatrix(rateResult.data); err == nil && len(series) > 0 {
+			opts := AnomalyOptions{BaselineEnd: evalStart, MinAbsoluteChange: minErrorRatioChange}
+			haveBaseline = Evaluable(series, opts)
+			errorRatio = EvaluationMedian(series, evalStart)
+			report.Anomalies = append(report.Anomalies, DetectAnomalies(series, opts)...)
+		}
+	}
+	if counterResult.err == nil {
+		if series, err := ParseMatrix(counterResult.data); err == nil && len(series) > 0 {
+			report.Anomalies = append(report.Anomalies, DetectAnomalies(series, AnomalyOptions{BaselineEnd: evalStart, Counter: true})...)
+		}
 	}
 
-	// Step 2: Errors found — fetch supporting metrics
-	report.Healthy = false
-	errorRateQuery := fmt.Sprintf(`sum(rate(http_requests_total{service="%s",status=~"5.."}[5m])) / sum(rate(http_requests_total{service="%s"}[5m]))`, input.Service, input.Service)
-	metricsData, err := h.queryMetrics(ctx, errorRateQuery)
-	if err == nil {
-		report.Metrics = metricsData
+	// Step 3: Decide the verdict
+	switch {
+	case haveBaseline && HasSignificantAnomaly(report.Anomalies):
+		report.VerdictBasis = VerdictAnomalies
+		report.Healthy = false
+	case hasErrors && errorRatio >= elevatedErrorRatio:
+		// A flat but high error ratio has no anomaly, yet the errors are not isolated.
+		report.VerdictBasis = VerdictErrorRate
+		report.Healthy = false
+	case haveBaseline:
+		report.VerdictBasis = VerdictAnomalies
+		report.Healthy = true
+	default:
+		report.VerdictBasis = VerdictErrorPresence
+		report.Healthy = !hasErrors
+	}
+	if !report.Healthy && rateResult.err == nil {
+		report.Metrics = rateResult.data
 	}
 
 	report.ErrorSummary = buildSummary(report)
-	libtelemetry.Info("incident investigation complete: errors detected", "service", input.Service)
+	if report.Healthy {
+		libtelemetry.Info("incident investigation complete: healthy", "service", input.Service, "basis", report.VerdictBasis)
+	} else {
+		libtelemetry.Info("incident investigation complete: issues detected", "service", input.Service, "basis", report.VerdictBasis, "anomalies", len(report.Anomalies))
+	}
 	return report, nil
 }
 
@@ -171,14 +242,30 @@ func hasTraceEntries(data interface{}) bool {
 }
 
 // buildSummary produces a plain-text summary for the AI to reason over.
+// It is empty for a healthy service with no error signals at all.
 func buildSummary(r IncidentReport) string {
-	summary := fmt.Sprintf("Incident detected for service %q over the last %d hour(s).", r.Service, r.WindowHr)
+	if r.Healthy && len(r.Anomalies) == 0 && r.ErrorLogs == nil && r.ErrorTraces == nil {
+		return ""
+	}
+
+	var summary string
+	if r.Healthy {
+		summary = fmt.Sprintf("No significant anomaly for service %q over the last %d hour(s).", r.Service, r.WindowHr)
+	} else {
+		summary = fmt.Sprintf("Incident detected for service %q over the last %d hour(s).", r.Service, r.WindowHr)
+	}
+	for _, a := range r.Anomalies {
+		summary += fmt.Sprintf(" [%s] %s.", a.Severity, a.Description)
+	}
 	if r.ErrorLogs != nil {
 		summary += " Error log entries found."
 	}
 	if r.ErrorTraces != nil {
 		summary += " Error spans found in distributed traces."
 	}
+	if r.Healthy && (r.ErrorLogs != nil || r.ErrorTraces != nil) {
+		summary += " Errors look isolated: the error rate stayed within its baseline."
+	}
 	if r.Metrics != nil {
 		summary += " Error rate metrics retrieved for correlation."
 	}
@@ -2,13 +2,52 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
+// matrixResponse builds a Prometheus matrix response with one series sampled every
+// step from start, taking values from fn.
+func matrixResponse(labels map[string]interface{}, start time.Time, step time.Duration, n int, fn func(i int) float64) map[string]interface{} {
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		ts := float64(start.Add(time.Duration(i) * step).Unix())
+		values = append(values, []interface{}{ts, fmt.Sprintf("%g", fn(i))})
+	}
+	return map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"resultType": "matrix",
+			"result":     []interface{}{map[string]interface{}{"metric": labels, "values": values}},
+		},
+	}
+}
+
 func TestInvestigateIncidentHandler_Execute(t *testing.T) {
-	noopMetrics := func(ctx context.Context, query string) (interface{}, error) {
-		return map[string]interface{}{"data": map[string]interface{}{"result": []interface{}{}}}, nil
+	noopMetrics := func(ctx context.Context, query string, start, end time.Time, step time.Duration) (interface{}, error) {
+		return map[string]interface{}{"data": map[string]interface{}{"resultType": "matrix", "result": []interface{}{}}}, nil
+	}
+
+	// rangeMetrics serves a flat error ratio (or a ratio built by ratioFn) and a steadily
+	// increasing request counter (or one built by counterFn) over [start, end].
+	rangeMetrics := func(ratioFn, counterFn func(i, n int) float64) func(context.Context, string, time.Time, time.Time, time.Duration) (interface{}, error) {
+		return func(ctx context.Context, query string, start, end time.Time, step time.Duration) (interface{}, error) {
+			n := int(end.Sub(start)/step) + 1
+			if strings.HasPrefix(query, "sum by (instance)") {
+				fn := func(i int) float64 { return float64(i * 10) }
+				if counterFn != nil {
+					fn = func(i int) float64 { return counterFn(i, n) }
+				}
+				return matrixResponse(map[string]interface{}{"instance": "proxy:8080"}, start, step, n, fn), nil
+			}
+			fn := func(i int) float64 { return 0.01 + float64(i%3)*0.001 }
+			if ratioFn != nil {
+				fn = func(i int) float64 { return ratioFn(i, n) }
+			}
+			return matrixResponse(map[string]interface{}{}, start, step, n, fn), nil
+		}
 	}
 	noopLogs := func(ctx context.Context, query string, limit int, hours int) (interface{}, error) {
 		return map[string]interface{}{"data": map[string]interface{}{"result": []interface{}{}}}, nil
@@ -35,13 +74,15 @@ func TestInvestigateIncidentHandler_Execute(t *testing.T) {
 	tests := []struct {
 		name        string
 		input       InvestigateIncidentInput
-		mockMetrics func(context.Context, string) (interface{}, error)
+		mockMetrics func(context.Context, string, time.Time, time.Time, time.Duration) (interface{}, error)
 		mockLogs    func(context.Context, string, int, int) (interface{}, error)
 		mockTraces  func(context.Context, string, string, int, int) (interface{}, error)
 		wantErr     bool
 		errMsg      string
 		wantHealthy bool
 		wantSummary string
+		wantBasis   string
+		wantKinds   []string
 	}{
 		{
 			name:        "healthy service returns no errors",
@@ -78,6 +119,69 @@ func TestInvestigateIncidentHandler_Execute(t *testing.T) {
 			wantHealthy: false,
 			wantSummary: "Error log entries found",
 		},
+		{
+			name:        "stray error with flat baseline stays healthy",
+			input:       InvestigateIncidentInput{Service: "proxy", Hours: 1},
+			mockMetrics: rangeMetrics(nil, nil),
+			mockLogs:    logsWithErrors,
+			mockTraces:  noopTraces,
+			wantHealthy: true,
+			wantSummary: "Errors look isolated",
+			wantBasis:   VerdictAnomalies,
+		},
+		{
+			name:  "error rate level shift marks unhealthy",
+			input: InvestigateIncidentInput{Service: "proxy", Hours: 1},
+			mockMetrics: rangeMetrics(func(i, n int) float64 {
+				if i > n/2 {
+					return 0.4
+				}
+				return 0.01 + float64(i%3)*0.001
+			}, nil),
+			mockLogs:    logsWithErrors,
+			mockTraces:  noopTraces,
+			wantHealthy: false,
+			wantSummary: "shifted up",
+			wantBasis:   VerdictAnomalies,
+			wantKinds:   []string{AnomalyLevelShift},
+		},
+		{
+			name:        "sustained outage predating the baseline marks unhealthy",
+			input:       InvestigateIncidentInput{Service: "proxy", Hours: 1},
+			mockMetrics: rangeMetrics(func(i, n int) float64 { return 0.3 }, nil),
+			mockLogs:    logsWithErrors,
+			mockTraces:  noopTraces,
+			wantHealthy: false,
+			wantBasis:   VerdictErrorRate,
+		},
+		{
+			name:  "series too short for a baseline falls back to error presence",
+			input: InvestigateIncidentInput{Service: "proxy", Hours: 1},
+			mockMetrics: func(ctx context.Context, query string, start, end time.Time, step time.Duration) (interface{}, error) {
+				return matrixResponse(map[string]interface{}{}, end.Add(-2*step), step, 3, func(i int) float64 { return 0.01 }), nil
+			},
+			mockLogs:    logsWithErrors,
+			mockTraces:  noopTraces,
+			wantHealthy: false,
+			wantSummary: "Error log entries found",
+			wantBasis:   VerdictErrorPresence,
+		},
+		{
+			name:  "counter reset storm is reported",
+			input: InvestigateIncidentInput{Service: "proxy", Hours: 1},
+			mockMetrics: rangeMetrics(nil, func(i, n int) float64 {
+				if i > n/2 {
+					return float64(i % 4)
+				}
+				return float64(i * 10)
+			}),
+			mockLogs:    noopLogs,
+			mockTraces:  noopTraces,
+			wantHealthy: false,
+			wantSummary: "reset",
+			wantBasis:   VerdictAnomalies,
+			wantKinds:   []string{AnomalyCounterResetStorm},
+		},
 		{
 			name:    "missing service returns error",
 			input:   InvestigateIncidentInput{},
@@ -147,6 +251,20 @@ func TestInvestigateIncidentHandler_Execute(t *testing.T) {
 			if tt.wantSummary != "" && !strings.Contains(report.ErrorSummary, tt.wantSummary) {
 				t.Errorf("got summary %q, want it to contain %q", report.ErrorSummary, tt.wantSummary)
 			}
+			if tt.wantBasis != "" && report.VerdictBasis != tt.wantBasis {
+				t.Errorf("got verdict basis %q, want %q", report.VerdictBasis, tt.wantBasis)
+			}
+			for _, kind := range tt.wantKinds {
+				found := false
+				for _, a := range report.Anomalies {
+					if a.Kind == kind {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected anomaly of kind %q, got %+v", kind, report.Anomalies)
+				}
+			}
 		})
 	}
 }
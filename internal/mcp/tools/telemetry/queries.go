@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"observability-hub/internal/mcp/providers"
 	libtelemetry "observability-hub/internal/telemetry"
 )
 
@@ -28,7 +29,9 @@ var dangerousPatterns = []string{
 
 // QueryMetricsInput represents the input for query_metrics tool.
 type QueryMetricsInput struct {
-	Query string `json:"query"`
+	Query  string `json:"query"`
+	Live   bool   `json:"live,omitempty"`   // bypass the short-lived response cache
+	Target string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
 }
 
 // QueryMetricsHandler executes a PromQL query and validates input safety.
@@ -106,19 +109,19 @@ func (h *QueryMetricsHandler) validateInput(input QueryMetricsInput) error {
 	query := input.Query
 
 	if query == "" {
-		return fmt.Errorf("query cannot be empty")
+		return providers.InvalidInputf("query cannot be empty")
 	}
 
 	if len(query) > 5000 {
 		libtelemetry.Warn("query exceeds max length", "query_len", len(query))
-		return fmt.Errorf("query too long (max 5000 chars)")
+		return providers.InvalidInputf("query too long (max 5000 chars)")
 	}
 
 	lower := strings.ToLower(query)
 	for _, pattern := range dangerousPatterns {
 		if strings.Contains(lower, pattern) {
 			libtelemetry.Warn("dangerous keyword detected in query", "keyword", pattern)
-			return fmt.Errorf("query contains potentially dangerous keyword: %s", pattern)
+			return providers.InvalidInputf("query contains potentially dangerous keyword: %s", pattern)
 		}
 	}
 
@@ -129,9 +132,11 @@ func (h *QueryMetricsHandler) validateInput(input QueryMetricsInput) error {
 
 // QueryLogsInput represents the input for query_logs tool.
 type QueryLogsInput struct {
-	Query string `json:"query"`
-	Limit int    `json:"limit,omitempty"` // max log lines to return, default 100
-	Hours int    `json:"hours,omitempty"` // how many hours to look back, default 1, max 168 (7 days)
+	Query  string `json:"query"`
+	Limit  int    `json:"limit,omitempty"`  // max log lines to return, default 100
+	Hours  int    `json:"hours,omitempty"`  // how many hours to look back, default 1, max 168 (7 days)
+	Live   bool   `json:"live,omitempty"`   // bypass the short-lived response cache
+	Target string `json:"target,omitempty"` // named telemetry target (tenant/cluster); empty uses the default
 }
 
 // QueryLogsHandler executes a LogQL query and validates input safety.
@@ -207,19 +212,19 @@ func (h *QueryLogsHandler) validateInput(input QueryLogsInput) error {
 	query := input.Query
 
 	if query == "" {
-		return fmt.Errorf("query cannot be empty")
+		return providers.InvalidInputf("query cannot be empty")
 	}
 
 	if len(query) > 5000 {
 		libtelemetry.Warn("logs query exceeds max length", "query_len", len(query))
-		return fmt.Errorf("query too long (max 5000 chars)")
+		return providers.InvalidInputf("query too long (max 5000 chars)")
 	}
 
 	lower := strings.ToLower(query)
 	for _, pattern := range dangerousPatterns {
 		if strings.Contains(lower, pattern) {
 			libtelemetry.Warn("dangerous keyword detected in logs query", "keyword", pattern)
-			return fmt.Errorf("query contains potentially dangerous keyword: %s", pattern)
+			return providers.InvalidInputf("query contains potentially dangerous keyword: %s", pattern)
 		}
 	}
 
@@ -235,6 +240,8 @@ type QueryTracesInput struct {
 	Query   string `json:"query,omitempty"`    // TraceQL query e.g. {resource.service.name="analytics"}
 	Hours   int    `json:"hours,omitempty"`    // lookback window in hours for search mode (default 1, max 168)
 	Limit   int    `json:"limit,omitempty"`    // max results in search mode (default 20)
+	Live    bool   `json:"live,omitempty"`     // bypass the short-lived response cache
+	Target  string `json:"target,omitempty"`   // named telemetry target (tenant/cluster); empty uses the default
 }
 
 // QueryTracesHandler retrieves distributed traces and validates input safety.
@@ -279,12 +286,12 @@ func (h *QueryTracesHandler) Execute(ctx context.Context, input QueryTracesInput
 func validateTraceID(traceID string) error {
 	if len(traceID) > 128 {
 		libtelemetry.Warn("trace_id exceeds max length", "trace_id_len", len(traceID))
-		return fmt.Errorf("trace_id too long (max 128 chars)")
+		return providers.InvalidInputf("trace_id too long (max 128 chars)")
 	}
 	for _, ch := range traceID {
 		if !isHexChar(ch) {
 			libtelemetry.Warn("invalid trace_id format", "char", string(ch))
-			return fmt.Errorf("trace_id must be hexadecimal")
+			return providers.InvalidInputf("trace_id must be hexadecimal")
 		}
 	}
 	return nil
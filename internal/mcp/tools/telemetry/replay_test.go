@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+// replayProvider serves the named testdata fixture (recorded with MCP_RECORD_FIXTURE) through a
+// telemetry provider, and returns the replayer so tests can pin their clock to the recording.
+func replayProvider(t *testing.T, fixture string) (*providers.TelemetryProvider, *providers.Replayer) {
+	t.Helper()
+	f, err := providers.LoadFixture(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	replayer := providers.NewReplayer(f)
+	client := &http.Client{Transport: replayer.Transport()}
+	return providers.NewTelemetryProviderWithClient("http://thanos", "http://loki", "http://tempo", client), replayer
+}
+
+func TestInvestigateIncident_Replay(t *testing.T) {
+	tests := []struct {
+		name          string
+		fixture       string
+		input         InvestigateIncidentInput
+		wantHealthy   bool
+		wantBasis     string
+		wantAnomalies []string // anomaly kinds, in order
+		wantSummary   []string
+	}{
+		{
+			name:          "proxy upstream refused",
+			fixture:       "proxy_upstream_refused.json",
+			input:         InvestigateIncidentInput{Service: "proxy", Hours: 1},
+			wantHealthy:   false,
+			wantBasis:     VerdictAnomalies,
+			wantAnomalies: []string{"spike", "level_shift"},
+			wantSummary:   []string{`Incident detected for service "proxy"`, "Error log entries found.", "Error spans found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, replayer := replayProvider(t, tt.fixture)
+			h := NewInvestigateIncidentHandler(tp.QueryMetricsRange, tp.QueryLogs, tp.QueryTraces)
+			h.now = replayer.RecordedAt
+
+			res, err := h.Execute(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			report := res.(IncidentReport)
+			if report.Healthy != tt.wantHealthy || report.VerdictBasis != tt.wantBasis {
+				t.Errorf("verdict = (healthy %v, %s), want (healthy %v, %s)", report.Healthy, report.VerdictBasis, tt.wantHealthy, tt.wantBasis)
+			}
+			var anomalies []string
+			for _, a := range report.Anomalies {
+				anomalies = append(anomalies, a.Kind)
+			}
+			if strings.Join(anomalies, ",") != strings.Join(tt.wantAnomalies, ",") {
+				t.Errorf("anomalies = %v, want %v (%+v)", anomalies, tt.wantAnomalies, report.Anomalies)
+			}
+			for _, want := range tt.wantSummary {
+				if !strings.Contains(report.ErrorSummary, want) {
+					t.Errorf("summary %q does not contain %q", report.ErrorSummary, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryTraces_Replay(t *testing.T) {
+	tp, _ := replayProvider(t, "proxy_upstream_refused.json")
+	res, err := NewQueryTracesHandler(tp.QueryTraces).Execute(context.Background(), QueryTracesInput{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	got, _ := json.Marshal(res)
+	for _, want := range []string{`"span_count":2`, `"service":"analytics"`, `"error":true`, `"total_duration_ms":3004`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("summary %s does not contain %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"observability-hub/internal/mcp/providers"
+	libtelemetry "observability-hub/internal/telemetry"
+)
+
+// Timeline event types.
+const (
+	TimelineEventLog     = "log"
+	TimelineEventSpan    = "error_span"
+	TimelineEventK8s     = "k8s_event"
+	TimelineEventJournal = "journal"
+	TimelineEventDeploy  = "deploy"
+)
+
+const (
+	defaultTimelineTokens = 2000
+	maxTimelineTokens     = 8000
+	maxTimelineMessageLen = 300
+	// timelineJournalEntries caps the journal entries read, keeping the newest in the window.
+	timelineJournalEntries = 500
+	// timelineCharsPerToken is a rough heuristic used to turn the token budget into bytes.
+	timelineCharsPerToken = 4
+)
+
+// BuildIncidentTimelineInput represents the input for the build_incident_timeline tool.
+type BuildIncidentTimelineInput struct {
+	Service   string `json:"service"`              // service name to build the timeline for e.g. "proxy"
+	Namespace string `json:"namespace,omitempty"`  // Kubernetes namespace for events (default: all namespaces)
+	Unit      string `json:"unit,omitempty"`       // systemd unit for journal entries (default: <service>.service)
+	Hours     int    `json:"hours,omitempty"`      // lookback window in hours (default 1, max 168)
+	Since     string `json:"since,omitempty"`      // RFC3339 window start e.g. "2026-03-06T17:00:00Z" — overrides hours
+	Until     string `json:"until,omitempty"`      // RFC3339 window end (default: now)
+	MaxTokens int    `json:"max_tokens,omitempty"` // approximate output budget (default 2000, max 8000)
+	Target    string `json:"target,omitempty"`     // named telemetry target (tenant/cluster); empty uses the default
+}
+
+// TimelineEvent is a single normalized entry in an incident timeline.
+type TimelineEvent struct {
+	Time     time.Time  `json:"time"`
+	Type     string     `json:"type"`     // log, error_span, k8s_event, journal, deploy
+	Severity string     `json:"severity"` // info, warning, error
+	Source   string     `json:"source"`   // originating stream, object or unit
+	Message  string     `json:"message"`
+	Ref      string     `json:"ref,omitempty"`       // trace ID for spans
+	Count    int        `json:"count,omitempty"`     // number of collapsed adjacent duplicates when > 1
+	LastSeen *time.Time `json:"last_seen,omitempty"` // time of the last collapsed duplicate
+}
+
+// IncidentTimeline is the chronological output of build_incident_timeline.
+type IncidentTimeline struct {
+	Service      string            `json:"service"`
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Events       []TimelineEvent   `json:"events"`
+	Deduplicated int               `json:"deduplicated,omitempty"` // events folded into an adjacent duplicate
+	Omitted      int               `json:"omitted,omitempty"`      // events dropped to respect the token budget
+	Truncated    bool              `json:"truncated"`
+	SourceErrors map[string]string `json:"source_errors,omitempty"`
+}
+
+// TimelineSources bundles the backends the timeline is built from.
+// Any nil source is skipped, so the tool still works when a provider failed to initialize.
+type TimelineSources struct {
+	// QueryLogs and SearchTraces take the absolute window, so historical windows get the newest
+	// results before their end rather than the newest overall.
+	QueryLogs    func(ctx context.Context, query string, limit int, start, end time.Time) (interface{}, error)
+	SearchTraces func(ctx context.Context, query string, start, end time.Time, limit int) (interface{}, error)
+	ListEvents   func(ctx context.Context, namespace string) (*corev1.EventList, error)
+	QueryJournal func(ctx context.Context, q providers.JournalQuery) (*providers.JournalPage, error)
+	// DeployLogQuery returns the LogQL selecting deploy events; nil uses providers.DefaultDeployLogQuery.
+	DeployLogQuery func(ctx context.Context) string
+}
+
+// BuildIncidentTimelineHandler merges logs, traces, events and deploys into one ordered timeline.
+type BuildIncidentTimelineHandler struct {
+	sources TimelineSources
+	now     func() time.Time
+}
+
+// NewBuildIncidentTimelineHandler creates a new build_incident_timeline handler.
+func NewBuildIncidentTimelineHandler(sources TimelineSources) *BuildIncidentTimelineHandler {
+	return &BuildIncidentTimelineHandler{sources: sources, now: time.Now}
+}
+
+// Execute runs the build_incident_timeline tool.
+// All sources are queried in parallel; a failing source is reported in SourceErrors
+// instead of failing the whole timeline.
+func (h *BuildIncidentTimelineHandler) Execute(ctx context.Context, input BuildIncidentTimelineInput) (interface{}, error) {
+	if input.Service == "" {
+		return nil, providers.InvalidInputf("service is required")
+	}
+
+	start, end, err := h.resolveWindow(input)
+	if err != nil {
+		return nil, err
+	}
+	budget := input.MaxTokens
+	if budget <= 0 {
+		budget = defaultTimelineTokens
+	}
+	if budget > maxTimelineTokens {
+		budget = maxTimelineTokens
+	}
+
+	unit := input.Unit
+	if unit == "" {
+		unit = input.Service + ".service"
+	}
+
+	libtelemetry.Info("building incident timeline", "service", input.Service, "start", start, "end", end)
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		events []TimelineEvent
+		errs   = make(map[string]string)
+	)
+	collect := func(source string, fetch func() ([]TimelineEvent, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := fetch()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				libtelemetry.Warn("timeline source failed", "source", source, "error", err)
+				errs[source] = err.Error()
+				return
+			}
+			events = append(events, got...)
+		}()
+	}
+
+	if h.sources.QueryLogs != nil {
+		collect("loki", func() ([]TimelineEvent, error) {
+			query := fmt.Sprintf(`{service="%s"} |~ "(?i)(error|warn|fail|panic)"`, input.Service)
+			raw, err := h.sources.QueryLogs(ctx, query, 200, start, end)
+			if err != nil {
+				return nil, err
+			}
+			return parseLokiTimeline(raw, TimelineEventLog), nil
+		})
+		collect("gitops", func() ([]TimelineEvent, error) {
+			query := providers.DefaultDeployLogQuery
+			if h.sources.DeployLogQuery != nil {
+				query = h.sources.DeployLogQuery(ctx)
+			}
+			raw, err := h.sources.QueryLogs(ctx, query, 50, start, end)
+			if err != nil {
+				return nil, err
+			}
+			return parseLokiTimeline(raw, TimelineEventDeploy), nil
+		})
+	}
+	if h.sources.SearchTraces != nil {
+		collect("tempo", func() ([]TimelineEvent, error) {
+			query := fmt.Sprintf(`{resource.service.name="%s"} && status=error`, input.Service)
+			raw, err := h.sources.SearchTraces(ctx, query, start, end, 50)
+			if err != nil {
+				return nil, err
+			}
+			return parseTempoTimeline(raw), nil
+		})
+	}
+	if h.sources.ListEvents != nil {
+		collect("kubernetes", func() ([]TimelineEvent, error) {
+			list, err := h.sources.ListEvents(ctx, input.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			return parseK8sTimeline(list, input.Service), nil
+		})
+	}
+	if h.sources.QueryJournal != nil {
+		collect("journal", func() ([]TimelineEvent, error) {
+			page, err := h.sources.QueryJournal(ctx, providers.JournalQuery{
+				Unit: unit, Since: start, Until: end, MaxPriority: len(providers.JournalPriorities) - 1, Limit: timelineJournalEntries,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return journalTimeline(page), nil
+		})
+	}
+	wg.Wait()
+
+	inWindow := events[:0]
+	for _, e := range events {
+		if e.Time.Before(start) || e.Time.After(end) {
+			continue
+		}
+		inWindow = append(inWindow, e)
+	}
+	sort.SliceStable(inWindow, func(i, j int) bool { return inWindow[i].Time.Before(inWindow[j].Time) })
+
+	deduped, folded := dedupeTimeline(inWindow)
+	kept, omitted := capTimeline(deduped, budget*timelineCharsPerToken)
+
+	timeline := IncidentTimeline{
+		Service:      input.Service,
+		Start:        start,
+		End:          end,
+		Events:       kept,
+		Deduplicated: folded,
+		Omitted:      omitted,
+		Truncated:    omitted > 0,
+	}
+	if len(errs) > 0 {
+		timeline.SourceErrors = errs
+	}
+
+	libtelemetry.Info("incident timeline built", "service", input.Service, "events", len(kept), "omitted", omitted)
+	return timeline, nil
+}
+
+// resolveWindow turns since/until/hours into an absolute [start, end] window.
+func (h *BuildIncidentTimelineHandler) resolveWindow(input BuildIncidentTimelineInput) (time.Time, time.Time, error) {
+	now := h.now()
+	end := now
+	if input.Until != "" {
+		t, err := time.Parse(time.RFC3339, input.Until)
+		if err != nil {
+			return time.Time{}, time.Time{}, providers.InvalidInputf("invalid until format, expected RFC3339 e.g. 2026-03-06T18:00:00Z: %w", err)
+		}
+		if t.Before(end) {
+			end = t
+		}
+	}
+
+	if input.Since != "" {
+		t, err := time.Parse(time.RFC3339, input.Since)
+		if err != nil {
+			return time.Time{}, time.Time{}, providers.InvalidInputf("invalid since format, expected RFC3339 e.g. 2026-03-06T17:00:00Z: %w", err)
+		}
+		if !t.Before(end) {
+			return time.Time{}, time.Time{}, providers.InvalidInputf("since must be before until and in the past")
+		}
+		if now.Sub(t) > 168*time.Hour {
+			t = now.Add(-168 * time.Hour)
+		}
+		return t, end, nil
+	}
+
+	hours := input.Hours
+	if hours <= 0 {
+		hours = 1
+	}
+	if hours > 168 {
+		hours = 168
+	}
+	return end.Add(-time.Duration(hours) * time.Hour), end, nil
+}
+
+// dedupeTimeline collapses adjacent events with the same type, source and message.
+func dedupeTimeline(events []TimelineEvent) ([]TimelineEvent, int) {
+	out := make([]TimelineEvent, 0, len(events))
+	folded := 0
+	for _, e := range events {
+		if n := len(out); n > 0 {
+			prev := &out[n-1]
+			if prev.Type == e.Type && prev.Source == e.Source && prev.Message == e.Message {
+				if prev.Count == 0 {
+					prev.Count = 1
+				}
+				prev.Count++
+				last := e.Time
+				prev.LastSeen = &last
+				folded++
+				continue
+			}
+		}
+		out = append(out, e)
+	}
+	return out, folded
+}
+
+// capTimeline keeps the most recent events that fit the byte budget, in chronological order:
+// the events closest to the end of the window matter most during an incident.
+func capTimeline(events []TimelineEvent, budget int) ([]TimelineEvent, int) {
+	used := 0
+	for i := len(events) - 1; i >= 0; i-- {
+		b, _ := json.Marshal(events[i])
+		used += len(b)
+		if used > budget {
+			return events[i+1:], i + 1
+		}
+	}
+	return events, 0
+}
+
+// parseLokiTimeline normalizes a Loki query_range response into timeline events.
+func parseLokiTimeline(raw interface{}, eventType string) []TimelineEvent {
+	m, _ := raw.(map[string]interface{})
+	data, _ := m["data"].(map[string]interface{})
+
+	var events []TimelineEvent
+	for _, r := range toList(data["result"]) {
+		stream, _ := r.(map[string]interface{})
+		labels, _ := stream["stream"].(map[string]interface{})
+		source := attrStr(labels["service"])
+		if source == "" {
+			source = attrStr(labels["job"])
+		}
+		for _, v := range toList(stream["values"]) {
+			pair := toList(v)
+			if len(pair) < 2 {
+				continue
+			}
+			ts := parseNano(pair[0])
+			msg, level := extractLogMessage(attrStr(pair[1]))
+			events = append(events, TimelineEvent{
+				Time:     time.Unix(0, ts).UTC(),
+				Type:     eventType,
+				Severity: classifySeverity(level, msg),
+				Source:   source,
+				Message:  truncateMessage(msg),
+			})
+		}
+	}
+	return events
+}
+
+// extractLogMessage pulls the message and level out of structured (JSON) log lines.
+// Plain-text lines are returned unchanged with an empty level.
+func extractLogMessage(line string) (string, string) {
+	var structured map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &structured); err != nil {
+		return line, ""
+	}
+	msg := attrStr(structured["msg"])
+	if msg == "" {
+		msg = attrStr(structured["body"])
+	}
+	if msg == "" {
+		return line, attrStr(structured["level"])
+	}
+	if errMsg := attrStr(structured["error"]); errMsg != "" {
+		msg = fmt.Sprintf("%s: %s", msg, errMsg)
+	}
+	return msg, attrStr(structured["level"])
+}
+
+// parseTempoTimeline normalizes a Tempo search response into error span events.
+func parseTempoTimeline(raw interface{}) []TimelineEvent {
+	m, _ := raw.(map[string]interface{})
+
+	var events []TimelineEvent
+	for _, t := range toList(m["traces"]) {
+		trace, _ := t.(map[string]interface{})
+		msg := attrStr(trace["rootTraceName"])
+		if msg == "" {
+			msg = "error trace"
+		}
+		if d, ok := trace["durationMs"].(float64); ok {
+			msg = fmt.Sprintf("%s (%.0fms)", msg, d)
+		}
+		events = append(events, TimelineEvent{
+			Time:     time.Unix(0, parseNano(trace["startTimeUnixNano"])).UTC(),
+			Type:     TimelineEventSpan,
+			Severity: "error",
+			Source:   attrStr(trace["rootServiceName"]),
+			Message:  truncateMessage(msg),
+			Ref:      attrStr(trace["traceID"]),
+		})
+	}
+	return events
+}
+
+// generatedNameChars is the alphabet Kubernetes uses for generated name suffixes
+// (pod-template-hash and generateName), which has no vowels.
+const generatedNameChars = "bcdfghjklmnpqrstvwxz2456789"
+
+// belongsToService reports whether a Kubernetes object name is the service's own workload or an
+// object generated from it: <service>-<hash> (ReplicaSet, DaemonSet pod), <service>-<hash>-<hash>
+// (Deployment pod) or <service>-<ordinal> (StatefulSet pod). "api-gateway" is not part of "api".
+func belongsToService(name, service string) bool {
+	if name == service {
+		return true
+	}
+	rest, ok := strings.CutPrefix(name, service+"-")
+	if !ok {
+		return false
+	}
+	parts := strings.Split(rest, "-")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !isGeneratedSuffix(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func isGeneratedSuffix(part string) bool {
+	if part == "" {
+		return false
+	}
+	if strings.Trim(part, "0123456789") == "" {
+		return true // StatefulSet ordinal
+	}
+	return len(part) >= 5 && len(part) <= 10 && strings.Trim(part, generatedNameChars) == ""
+}
+
+// parseK8sTimeline normalizes Kubernetes events whose involved object belongs to the service.
+func parseK8sTimeline(list *corev1.EventList, service string) []TimelineEvent {
+	if list == nil {
+		return nil
+	}
+
+	var events []TimelineEvent
+	for _, e := range list.Items {
+		if !belongsToService(e.InvolvedObject.Name, service) {
+			continue
+		}
+		ts := e.LastTimestamp.Time
+		if ts.IsZero() {
+			ts = e.EventTime.Time
+		}
+		if ts.IsZero() {
+			ts = e.FirstTimestamp.Time
+		}
+		severity := "info"
+		if e.Type == corev1.EventTypeWarning {
+			severity = "warning"
+		}
+		events = append(events, TimelineEvent{
+			Time:     ts.UTC(),
+			Type:     TimelineEventK8s,
+			Severity: severity,
+			Source:   fmt.Sprintf("%s/%s", e.InvolvedObject.Kind, e.InvolvedObject.Name),
+			Message:  truncateMessage(fmt.Sprintf("%s: %s", e.Reason, e.Message)),
+		})
+	}
+	return events
+}
+
+// journalTimeline converts journal entries, using their syslog priority as severity.
+func journalTimeline(page *providers.JournalPage) []TimelineEvent {
+	events := make([]TimelineEvent, 0, len(page.Entries))
+	for _, e := range page.Entries {
+		severity := "info"
+		switch {
+		case e.Priority <= 3:
+			severity = "error"
+		case e.Priority == 4:
+			severity = "warning"
+		}
+		events = append(events, TimelineEvent{
+			Time:     e.Time.UTC(),
+			Type:     TimelineEventJournal,
+			Severity: severity,
+			Source:   page.Unit,
+			Message:  truncateMessage(e.Message),
+		})
+	}
+	return events
+}
+
+// classifySeverity maps a log level (or, failing that, message keywords) to a timeline severity.
+func classifySeverity(level, msg string) string {
+	switch strings.ToLower(level) {
+	case "error", "fatal", "panic", "critical":
+		return "error"
+	case "warn", "warning":
+		return "warning"
+	case "info", "debug":
+		return "info"
+	}
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "error"), strings.Contains(lower, "fail"), strings.Contains(lower, "panic"):
+		return "error"
+	case strings.Contains(lower, "warn"):
+		return "warning"
+	}
+	return "info"
+}
+
+// truncateMessage caps a message to keep individual events compact.
+func truncateMessage(msg string) string {
+	msg = strings.TrimSpace(msg)
+	runes := []rune(msg)
+	if len(runes) <= maxTimelineMessageLen {
+		return msg
+	}
+	return string(runes[:maxTimelineMessageLen]) + "…"
+}
@@ -0,0 +1,301 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func TestBuildIncidentTimelineHandler_Execute(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	ns := func(t time.Time) string { return strconv.FormatInt(t.UnixNano(), 10) }
+
+	lokiLogs := func(ctx context.Context, query string, limit int, start, end time.Time) (interface{}, error) {
+		if strings.Contains(query, "webhook_sync_") {
+			return map[string]interface{}{
+				"data": map[string]interface{}{
+					"result": []interface{}{
+						map[string]interface{}{
+							"stream": map[string]interface{}{"service": "proxy"},
+							"values": []interface{}{
+								[]interface{}{ns(now.Add(-50 * time.Minute)), `{"level":"INFO","msg":"webhook_sync_success"}`},
+							},
+						},
+					},
+				},
+			}, nil
+		}
+		return map[string]interface{}{
+			"data": map[string]interface{}{
+				"result": []interface{}{
+					map[string]interface{}{
+						"stream": map[string]interface{}{"service": "proxy"},
+						"values": []interface{}{
+							[]interface{}{ns(now.Add(-30 * time.Minute)), `{"level":"ERROR","msg":"db timeout"}`},
+							[]interface{}{ns(now.Add(-29 * time.Minute)), `{"level":"ERROR","msg":"db timeout"}`},
+							[]interface{}{ns(now.Add(-3 * time.Hour)), "error outside window"},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	tempoTraces := func(ctx context.Context, query string, start, end time.Time, limit int) (interface{}, error) {
+		return map[string]interface{}{
+			"traces": []interface{}{
+				map[string]interface{}{
+					"traceID":           "abc123",
+					"rootServiceName":   "proxy",
+					"rootTraceName":     "GET /api",
+					"startTimeUnixNano": ns(now.Add(-20 * time.Minute)),
+					"durationMs":        float64(1200),
+				},
+			},
+		}, nil
+	}
+	k8sEvents := func(ctx context.Context, namespace string) (*corev1.EventList, error) {
+		return &corev1.EventList{Items: []corev1.Event{
+			{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "proxy-6c9f8b7d4-x2k9p"},
+				Type:           corev1.EventTypeWarning,
+				Reason:         "BackOff",
+				Message:        "Back-off restarting failed container",
+				LastTimestamp:  metav1.NewTime(now.Add(-10 * time.Minute)),
+			},
+			{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "analytics-1"},
+				Type:           corev1.EventTypeWarning,
+				Reason:         "BackOff",
+				LastTimestamp:  metav1.NewTime(now.Add(-10 * time.Minute)),
+			},
+		}}, nil
+	}
+	journal := func(ctx context.Context, q providers.JournalQuery) (*providers.JournalPage, error) {
+		if q.Unit != "proxy.service" || !q.Until.Equal(now) {
+			return nil, fmt.Errorf("unexpected journal query %+v", q)
+		}
+		return &providers.JournalPage{Unit: q.Unit, Entries: []providers.JournalEntry{
+			{Time: now.Add(-5 * time.Minute), Priority: 3, Level: "err", PID: 42, Message: "failed to bind port"},
+		}}, nil
+	}
+
+	tests := []struct {
+		name         string
+		input        BuildIncidentTimelineInput
+		sources      TimelineSources
+		wantErr      string
+		wantTypes    []string
+		wantDedup    int
+		wantTrunc    bool
+		wantSrcError string
+	}{
+		{
+			name:  "merges all sources in chronological order",
+			input: BuildIncidentTimelineInput{Service: "proxy"},
+			sources: TimelineSources{
+				QueryLogs:    lokiLogs,
+				SearchTraces: tempoTraces,
+				ListEvents:   k8sEvents,
+				QueryJournal: journal,
+			},
+			wantTypes: []string{TimelineEventDeploy, TimelineEventLog, TimelineEventSpan, TimelineEventK8s, TimelineEventJournal},
+			wantDedup: 1,
+		},
+		{
+			name:  "deploy events use the target's deploy query",
+			input: BuildIncidentTimelineInput{Service: "proxy"},
+			sources: TimelineSources{
+				QueryLogs: func(ctx context.Context, query string, limit int, start, end time.Time) (interface{}, error) {
+					switch {
+					case strings.Contains(query, "webhook_sync_"):
+						return nil, errors.New("default deploy query used")
+					case strings.Contains(query, "argocd"):
+						return lokiLogs(ctx, "webhook_sync_", limit, start, end)
+					}
+					return lokiLogs(ctx, query, limit, start, end)
+				},
+				DeployLogQuery: func(ctx context.Context) string { return `{app="argocd"} |= "sync"` },
+			},
+			wantTypes: []string{TimelineEventDeploy, TimelineEventLog},
+			wantDedup: 1,
+		},
+		{
+			name:      "nil sources are skipped",
+			input:     BuildIncidentTimelineInput{Service: "proxy"},
+			sources:   TimelineSources{SearchTraces: tempoTraces},
+			wantTypes: []string{TimelineEventSpan},
+		},
+		{
+			name:  "failing source is reported without failing the timeline",
+			input: BuildIncidentTimelineInput{Service: "proxy"},
+			sources: TimelineSources{
+				SearchTraces: tempoTraces,
+				ListEvents: func(ctx context.Context, namespace string) (*corev1.EventList, error) {
+					return nil, errors.New("forbidden")
+				},
+			},
+			wantTypes:    []string{TimelineEventSpan},
+			wantSrcError: "kubernetes",
+		},
+		{
+			name:  "token budget keeps the most recent events",
+			input: BuildIncidentTimelineInput{Service: "proxy", MaxTokens: 60},
+			sources: TimelineSources{
+				QueryLogs:    lokiLogs,
+				SearchTraces: tempoTraces,
+			},
+			wantTypes: []string{TimelineEventSpan},
+			wantDedup: 1,
+			wantTrunc: true,
+		},
+		{
+			name:    "missing service returns error",
+			input:   BuildIncidentTimelineInput{},
+			wantErr: "service is required",
+		},
+		{
+			name:    "invalid until returns error",
+			input:   BuildIncidentTimelineInput{Service: "proxy", Until: "yesterday"},
+			wantErr: "invalid until format",
+		},
+		{
+			name:    "since after until returns error",
+			input:   BuildIncidentTimelineInput{Service: "proxy", Since: "2026-03-11T13:00:00Z", Until: "2026-03-11T12:00:00Z"},
+			wantErr: "since must be before until",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewBuildIncidentTimelineHandler(tt.sources)
+			h.now = func() time.Time { return now }
+
+			result, err := h.Execute(context.Background(), tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			timeline, ok := result.(IncidentTimeline)
+			if !ok {
+				t.Fatalf("expected IncidentTimeline, got %T", result)
+			}
+
+			var gotTypes []string
+			for _, e := range timeline.Events {
+				gotTypes = append(gotTypes, e.Type)
+			}
+			if strings.Join(gotTypes, ",") != strings.Join(tt.wantTypes, ",") {
+				t.Errorf("got event types %v, want %v", gotTypes, tt.wantTypes)
+			}
+			if timeline.Deduplicated != tt.wantDedup {
+				t.Errorf("got deduplicated=%d, want %d", timeline.Deduplicated, tt.wantDedup)
+			}
+			if timeline.Truncated != tt.wantTrunc {
+				t.Errorf("got truncated=%v, want %v", timeline.Truncated, tt.wantTrunc)
+			}
+			if tt.wantSrcError != "" {
+				if _, ok := timeline.SourceErrors[tt.wantSrcError]; !ok {
+					t.Errorf("expected source error for %q, got %v", tt.wantSrcError, timeline.SourceErrors)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildIncidentTimelineHandler_HistoricalWindow(t *testing.T) {
+	now := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	since, until := now.Add(-3*time.Hour), now.Add(-2*time.Hour)
+	var (
+		mu      sync.Mutex
+		windows []string
+	)
+	window := func(start, end time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		windows = append(windows, start.Format(time.RFC3339)+"/"+end.Format(time.RFC3339))
+	}
+	h := NewBuildIncidentTimelineHandler(TimelineSources{
+		QueryLogs: func(ctx context.Context, query string, limit int, start, end time.Time) (interface{}, error) {
+			window(start, end)
+			return map[string]interface{}{"data": map[string]interface{}{"result": []interface{}{}}}, nil
+		},
+		SearchTraces: func(ctx context.Context, query string, start, end time.Time, limit int) (interface{}, error) {
+			window(start, end)
+			return map[string]interface{}{"traces": []interface{}{}}, nil
+		},
+	})
+	h.now = func() time.Time { return now }
+
+	if _, err := h.Execute(context.Background(), BuildIncidentTimelineInput{
+		Service: "proxy", Since: since.Format(time.RFC3339), Until: until.Format(time.RFC3339),
+	}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := "2026-03-11T11:00:00Z/2026-03-11T12:00:00Z"
+	if len(windows) != 3 {
+		t.Fatalf("queried %d sources, want loki, gitops and tempo", len(windows))
+	}
+	for _, got := range windows {
+		if got != want {
+			t.Errorf("queried window %s, want %s", got, want)
+		}
+	}
+}
+
+func TestBelongsToService(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"api", true},
+		{"api-7d9f8b6c4", true},       // ReplicaSet
+		{"api-7d9f8b6c4-x2k9p", true}, // Deployment pod
+		{"api-0", true},               // StatefulSet pod
+		{"api-gateway", false},        // another service
+		{"api-gateway-7d9f8b6c4-x2k9p", false},
+		{"apiserver", false},
+		{"api-7d9f8b6c4-x2k9p-extra", false},
+	}
+	for _, tt := range tests {
+		if got := belongsToService(tt.name, "api"); got != tt.want {
+			t.Errorf("belongsToService(%q, api) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeTimeline(t *testing.T) {
+	base := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	events := []TimelineEvent{
+		{Time: base, Type: TimelineEventLog, Source: "proxy", Message: "timeout"},
+		{Time: base.Add(time.Second), Type: TimelineEventLog, Source: "proxy", Message: "timeout"},
+		{Time: base.Add(2 * time.Second), Type: TimelineEventSpan, Source: "proxy", Message: "GET /"},
+		{Time: base.Add(3 * time.Second), Type: TimelineEventLog, Source: "proxy", Message: "timeout"},
+	}
+
+	got, folded := dedupeTimeline(events)
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3 (non-adjacent duplicates must be kept)", len(got))
+	}
+	if folded != 1 {
+		t.Errorf("got folded=%d, want 1", folded)
+	}
+	if got[0].Count != 2 || got[0].LastSeen == nil || !got[0].LastSeen.Equal(base.Add(time.Second)) {
+		t.Errorf("got first event %+v, want count 2 with last_seen set", got[0])
+	}
+}
@@ -0,0 +1,620 @@
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+// maxCronJobRuns caps how many recent runs are reported per CronJob.
+const maxCronJobRuns = 5
+
+// WorkloadsInput is the input for inspect_workloads.
+type WorkloadsInput struct {
+	Namespace     string `json:"namespace"`
+	Kind          string `json:"kind,omitempty"` // Deployment, StatefulSet, DaemonSet, Job or CronJob
+	Name          string `json:"name,omitempty"`
+	UnhealthyOnly bool   `json:"unhealthy_only,omitempty"`
+}
+
+// ResourceInput is the input for inspect_services and inspect_volumes.
+type ResourceInput struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name,omitempty"`
+	UnhealthyOnly bool   `json:"unhealthy_only,omitempty"`
+}
+
+// PodRef links an object to one of the pods backing it.
+type PodRef struct {
+	Name     string `json:"name"`
+	Phase    string `json:"phase"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	Node     string `json:"node,omitempty"`
+}
+
+// Condition is a status condition of a workload or claim.
+type Condition struct {
+	Type    string     `json:"type"`
+	Status  string     `json:"status"`
+	Reason  string     `json:"reason,omitempty"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// JobRun summarizes one execution of a Job.
+type JobRun struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"` // Running, Complete, Failed or Suspended
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+	Succeeded int32      `json:"succeeded"`
+	Failed    int32      `json:"failed"`
+	Reason    string     `json:"reason,omitempty"` // e.g. BackoffLimitExceeded, DeadlineExceeded
+}
+
+// WorkloadSummary is a compact health view of a controller and its pods.
+type WorkloadSummary struct {
+	Kind         string      `json:"kind"`
+	Namespace    string      `json:"namespace"`
+	Name         string      `json:"name"`
+	Healthy      bool        `json:"healthy"`
+	Status       string      `json:"status"`
+	Desired      int32       `json:"desired"`
+	Ready        int32       `json:"ready"`
+	Updated      int32       `json:"updated"`
+	Available    int32       `json:"available"`
+	Schedule     string      `json:"schedule,omitempty"`
+	Suspended    bool        `json:"suspended,omitempty"`
+	LastSchedule *time.Time  `json:"last_schedule,omitempty"`
+	LastSuccess  *time.Time  `json:"last_success,omitempty"`
+	Runs         []JobRun    `json:"runs,omitempty"` // a Job's own run, or a CronJob's recent runs (newest first)
+	Conditions   []Condition `json:"conditions,omitempty"`
+	Pods         []PodRef    `json:"pods,omitempty"`
+}
+
+// ServiceSummary reports whether a Service has ready endpoints and which pods back it.
+type ServiceSummary struct {
+	Namespace         string            `json:"namespace"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	ClusterIP         string            `json:"cluster_ip,omitempty"`
+	Ports             []string          `json:"ports,omitempty"` // "80/TCP->8080"
+	Selector          map[string]string `json:"selector,omitempty"`
+	ReadyEndpoints    int               `json:"ready_endpoints"`
+	NotReadyEndpoints int               `json:"not_ready_endpoints"`
+	Healthy           bool              `json:"healthy"`
+	Status            string            `json:"status"`
+	Pods              []PodRef          `json:"pods,omitempty"`
+}
+
+// VolumeSummary reports the binding and capacity of a PersistentVolumeClaim.
+type VolumeSummary struct {
+	Namespace    string      `json:"namespace"`
+	Name         string      `json:"name"`
+	Phase        string      `json:"phase"`
+	StorageClass string      `json:"storage_class,omitempty"`
+	Volume       string      `json:"volume,omitempty"`
+	AccessModes  []string    `json:"access_modes,omitempty"`
+	Requested    string      `json:"requested,omitempty"`
+	Capacity     string      `json:"capacity,omitempty"`
+	Healthy      bool        `json:"healthy"`
+	Status       string      `json:"status"`
+	Conditions   []Condition `json:"conditions,omitempty"`
+	Pods         []PodRef    `json:"pods,omitempty"` // pods mounting the claim
+}
+
+// InspectWorkloadsHandler handles summarizing controllers and linking them to their pods.
+type InspectWorkloadsHandler struct {
+	listFn func(ctx context.Context, namespace string) (*providers.WorkloadSet, error)
+}
+
+func NewInspectWorkloadsHandler(listFn func(ctx context.Context, namespace string) (*providers.WorkloadSet, error)) *InspectWorkloadsHandler {
+	return &InspectWorkloadsHandler{listFn: listFn}
+}
+
+func (h *InspectWorkloadsHandler) Execute(ctx context.Context, input WorkloadsInput) (interface{}, error) {
+	kind := ""
+	if input.Kind != "" {
+		var err error
+		if kind, err = normalizeKind(input.Kind); err != nil {
+			return nil, err
+		}
+	}
+	set, err := h.listFn(ctx, input.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []WorkloadSummary
+	want := func(k string) bool { return kind == "" || kind == k }
+	if want("Deployment") {
+		for i := range set.Deployments {
+			all = append(all, summarizeDeployment(&set.Deployments[i], set.Pods))
+		}
+	}
+	if want("StatefulSet") {
+		for i := range set.StatefulSets {
+			all = append(all, summarizeStatefulSet(&set.StatefulSets[i], set.Pods))
+		}
+	}
+	if want("DaemonSet") {
+		for i := range set.DaemonSets {
+			all = append(all, summarizeDaemonSet(&set.DaemonSets[i], set.Pods))
+		}
+	}
+	if want("Job") {
+		for i := range set.Jobs {
+			// CronJob runs are reported under their CronJob unless Jobs were asked for explicitly.
+			if kind == "" && ownerKind(set.Jobs[i].OwnerReferences) == "CronJob" {
+				continue
+			}
+			all = append(all, summarizeJob(&set.Jobs[i], set.Pods))
+		}
+	}
+	if want("CronJob") {
+		for i := range set.CronJobs {
+			all = append(all, summarizeCronJob(&set.CronJobs[i], set.Jobs))
+		}
+	}
+
+	summaries := make([]WorkloadSummary, 0, len(all))
+	for _, s := range all {
+		if (input.Name == "" || s.Name == input.Name) && (!input.UnhealthyOnly || !s.Healthy) {
+			summaries = append(summaries, s)
+		}
+	}
+	return summaries, nil
+}
+
+// InspectServicesHandler handles summarizing Services and their endpoint readiness.
+type InspectServicesHandler struct {
+	listFn func(ctx context.Context, namespace string) (*providers.WorkloadSet, error)
+}
+
+func NewInspectServicesHandler(listFn func(ctx context.Context, namespace string) (*providers.WorkloadSet, error)) *InspectServicesHandler {
+	return &InspectServicesHandler{listFn: listFn}
+}
+
+func (h *InspectServicesHandler) Execute(ctx context.Context, input ResourceInput) (interface{}, error) {
+	set, err := h.listFn(ctx, input.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ServiceSummary, 0, len(set.Services))
+	for i := range set.Services {
+		svc := &set.Services[i]
+		if input.Name != "" && svc.Name != input.Name {
+			continue
+		}
+		s := summarizeService(svc, set)
+		if input.UnhealthyOnly && s.Healthy {
+			continue
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+// InspectVolumesHandler handles summarizing PersistentVolumeClaims.
+type InspectVolumesHandler struct {
+	listFn func(ctx context.Context, namespace string) (*providers.WorkloadSet, error)
+}
+
+func NewInspectVolumesHandler(listFn func(ctx context.Context, namespace string) (*providers.WorkloadSet, error)) *InspectVolumesHandler {
+	return &InspectVolumesHandler{listFn: listFn}
+}
+
+func (h *InspectVolumesHandler) Execute(ctx context.Context, input ResourceInput) (interface{}, error) {
+	set, err := h.listFn(ctx, input.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]VolumeSummary, 0, len(set.PVCs))
+	for i := range set.PVCs {
+		pvc := &set.PVCs[i]
+		if input.Name != "" && pvc.Name != input.Name {
+			continue
+		}
+		s := summarizeVolume(pvc, set.Pods)
+		if input.UnhealthyOnly && s.Healthy {
+			continue
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+func summarizeDeployment(d *appsv1.Deployment, pods []corev1.Pod) WorkloadSummary {
+	rollout := providers.DeploymentRolloutStatus(d)
+	s := fromRollout(rollout)
+	s.Healthy = rollout.Complete && rollout.Available >= rollout.Replicas
+	if rollout.Complete && !s.Healthy {
+		s.Status = fmt.Sprintf("%d of %d replicas available", rollout.Available, rollout.Replicas)
+	}
+	for _, c := range d.Status.Conditions {
+		s.Conditions = append(s.Conditions, condition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime))
+	}
+	s.Pods = selectPods(d.Namespace, d.Spec.Selector, pods)
+	return s
+}
+
+func summarizeStatefulSet(sts *appsv1.StatefulSet, pods []corev1.Pod) WorkloadSummary {
+	rollout := providers.StatefulSetRolloutStatus(sts)
+	s := fromRollout(rollout)
+	s.Healthy = rollout.Complete
+	for _, c := range sts.Status.Conditions {
+		s.Conditions = append(s.Conditions, condition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime))
+	}
+	s.Pods = selectPods(sts.Namespace, sts.Spec.Selector, pods)
+	return s
+}
+
+func summarizeDaemonSet(ds *appsv1.DaemonSet, pods []corev1.Pod) WorkloadSummary {
+	rollout := providers.DaemonSetRolloutStatus(ds)
+	s := fromRollout(rollout)
+	s.Healthy = rollout.Complete && rollout.Ready >= rollout.Replicas
+	if rollout.Complete && !s.Healthy {
+		s.Status = fmt.Sprintf("%d of %d pods ready", rollout.Ready, rollout.Replicas)
+	}
+	for _, c := range ds.Status.Conditions {
+		s.Conditions = append(s.Conditions, condition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime))
+	}
+	s.Pods = selectPods(ds.Namespace, ds.Spec.Selector, pods)
+	return s
+}
+
+func fromRollout(r *providers.RolloutStatus) WorkloadSummary {
+	return WorkloadSummary{
+		Kind:      r.Kind,
+		Namespace: r.Namespace,
+		Name:      r.Name,
+		Status:    r.Message,
+		Desired:   r.Replicas,
+		Ready:     r.Ready,
+		Updated:   r.Updated,
+		Available: r.Available,
+	}
+}
+
+func summarizeJob(job *batchv1.Job, pods []corev1.Pod) WorkloadSummary {
+	run := jobRun(job)
+	s := WorkloadSummary{
+		Kind:      "Job",
+		Namespace: job.Namespace,
+		Name:      job.Name,
+		Healthy:   run.Status != "Failed",
+		Status:    run.Status,
+		Desired:   1,
+		Ready:     valueOr(job.Status.Ready, 0),
+		Available: job.Status.Active,
+		Suspended: job.Spec.Suspend != nil && *job.Spec.Suspend,
+		Runs:      []JobRun{run},
+	}
+	if job.Spec.Completions != nil {
+		s.Desired = *job.Spec.Completions
+	}
+	if run.Reason != "" {
+		s.Status += ": " + run.Reason
+	}
+	for _, c := range job.Status.Conditions {
+		s.Conditions = append(s.Conditions, condition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime))
+	}
+	for _, pod := range pods {
+		if pod.Namespace == job.Namespace && jobNameOf(&pod) == job.Name {
+			s.Pods = append(s.Pods, podRef(&pod))
+		}
+	}
+	return s
+}
+
+func summarizeCronJob(cj *batchv1.CronJob, jobs []batchv1.Job) WorkloadSummary {
+	s := WorkloadSummary{
+		Kind:         "CronJob",
+		Namespace:    cj.Namespace,
+		Name:         cj.Name,
+		Healthy:      true,
+		Schedule:     cj.Spec.Schedule,
+		Suspended:    cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+		LastSchedule: timePtr(cj.Status.LastScheduleTime),
+		LastSuccess:  timePtr(cj.Status.LastSuccessfulTime),
+		Available:    int32(len(cj.Status.Active)),
+	}
+
+	var owned []*batchv1.Job
+	for i := range jobs {
+		j := &jobs[i]
+		if j.Namespace == cj.Namespace && ownerKind(j.OwnerReferences) == "CronJob" && ownerName(j.OwnerReferences) == cj.Name {
+			owned = append(owned, j)
+		}
+	}
+	sort.Slice(owned, func(i, k int) bool {
+		return owned[k].CreationTimestamp.Before(&owned[i].CreationTimestamp)
+	})
+	failures := 0
+	for i, j := range owned {
+		run := jobRun(j)
+		if run.Status == "Failed" {
+			failures++
+		}
+		if i < maxCronJobRuns {
+			s.Runs = append(s.Runs, run)
+		}
+	}
+
+	// The most recent finished run decides health; a running job is not yet a verdict.
+	s.Status = "no runs retained"
+	for _, run := range s.Runs {
+		if run.Status == "Running" || run.Status == "Suspended" {
+			continue
+		}
+		s.Healthy = run.Status == "Complete"
+		s.Status = fmt.Sprintf("last run %s %s", run.Name, strings.ToLower(run.Status))
+		break
+	}
+	if failures > 0 {
+		s.Status += fmt.Sprintf(" (%d of %d retained runs failed)", failures, len(owned))
+	}
+	if s.Suspended {
+		s.Status = "suspended; " + s.Status
+	}
+	return s
+}
+
+func jobRun(job *batchv1.Job) JobRun {
+	run := JobRun{
+		Name:      job.Name,
+		Status:    "Running",
+		Started:   timePtr(job.Status.StartTime),
+		Finished:  timePtr(job.Status.CompletionTime),
+		Succeeded: job.Status.Succeeded,
+		Failed:    job.Status.Failed,
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			run.Status = "Complete"
+		case batchv1.JobFailed:
+			run.Status = "Failed"
+			run.Reason = c.Reason
+			if run.Finished == nil {
+				run.Finished = timePtr(&c.LastTransitionTime)
+			}
+		case batchv1.JobSuspended:
+			run.Status = "Suspended"
+		}
+	}
+	if run.Started != nil && run.Finished != nil {
+		run.Duration = run.Finished.Sub(*run.Started).Round(time.Second).String()
+	}
+	return run
+}
+
+func summarizeService(svc *corev1.Service, set *providers.WorkloadSet) ServiceSummary {
+	s := ServiceSummary{
+		Namespace: svc.Namespace,
+		Name:      svc.Name,
+		Type:      string(svc.Spec.Type),
+		ClusterIP: svc.Spec.ClusterIP,
+		Selector:  svc.Spec.Selector,
+	}
+	for _, port := range svc.Spec.Ports {
+		s.Ports = append(s.Ports, fmt.Sprintf("%d/%s->%s", port.Port, port.Protocol, port.TargetPort.String()))
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		s.Healthy = true
+		s.Status = "external name " + svc.Spec.ExternalName
+		return s
+	}
+
+	// Endpoints are deduplicated across slices (dual-stack services have one slice per family).
+	ready := make(map[string]bool)
+	for _, slice := range set.EndpointSlices {
+		if slice.Namespace != svc.Namespace || slice.Labels[discoveryv1.LabelServiceName] != svc.Name {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			key := strings.Join(ep.Addresses, ",")
+			if ep.TargetRef != nil {
+				key = ep.TargetRef.Name
+			}
+			ready[key] = ready[key] || ep.Conditions.Ready == nil || *ep.Conditions.Ready
+		}
+	}
+	for _, ok := range ready {
+		if ok {
+			s.ReadyEndpoints++
+		} else {
+			s.NotReadyEndpoints++
+		}
+	}
+
+	if len(svc.Spec.Selector) > 0 {
+		selector := labels.SelectorFromSet(svc.Spec.Selector)
+		for _, pod := range set.Pods {
+			if pod.Namespace == svc.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				s.Pods = append(s.Pods, podRef(&pod))
+			}
+		}
+	}
+
+	switch {
+	case s.ReadyEndpoints > 0 && s.NotReadyEndpoints > 0:
+		s.Healthy = true
+		s.Status = fmt.Sprintf("%d ready, %d not ready endpoints", s.ReadyEndpoints, s.NotReadyEndpoints)
+	case s.ReadyEndpoints > 0:
+		s.Healthy = true
+		s.Status = fmt.Sprintf("%d ready endpoints", s.ReadyEndpoints)
+	case len(svc.Spec.Selector) == 0:
+		s.Status = "no selector and no ready endpoints; endpoints are managed manually"
+	case len(s.Pods) == 0:
+		s.Status = "selector matches no pods"
+	default:
+		s.Status = fmt.Sprintf("no ready endpoints; %d matching pods are not ready", len(s.Pods))
+	}
+	return s
+}
+
+func summarizeVolume(pvc *corev1.PersistentVolumeClaim, pods []corev1.Pod) VolumeSummary {
+	s := VolumeSummary{
+		Namespace: pvc.Namespace,
+		Name:      pvc.Name,
+		Phase:     string(pvc.Status.Phase),
+		Volume:    pvc.Spec.VolumeName,
+	}
+	if pvc.Spec.StorageClassName != nil {
+		s.StorageClass = *pvc.Spec.StorageClassName
+	}
+	for _, m := range pvc.Spec.AccessModes {
+		s.AccessModes = append(s.AccessModes, string(m))
+	}
+	requested, hasRequest := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if hasRequest {
+		s.Requested = requested.String()
+	}
+	capacity, hasCapacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	if hasCapacity {
+		s.Capacity = capacity.String()
+	}
+	for _, c := range pvc.Status.Conditions {
+		s.Conditions = append(s.Conditions, condition(string(c.Type), string(c.Status), c.Reason, c.Message, c.LastTransitionTime))
+	}
+	for _, pod := range pods {
+		if pod.Namespace != pvc.Namespace {
+			continue
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == pvc.Name {
+				s.Pods = append(s.Pods, podRef(&pod))
+				break
+			}
+		}
+	}
+
+	switch pvc.Status.Phase {
+	case corev1.ClaimBound:
+		s.Healthy = true
+		s.Status = "bound to " + pvc.Spec.VolumeName
+		if hasRequest && hasCapacity && capacity.Cmp(requested) < 0 {
+			s.Status += fmt.Sprintf("; capacity %s is below the requested %s (resize pending?)", s.Capacity, s.Requested)
+		}
+	case corev1.ClaimPending:
+		s.Status = "pending: no volume bound yet; check the storage class provisioner and claim events"
+	case corev1.ClaimLost:
+		s.Status = "lost: the bound volume no longer exists"
+	default:
+		s.Status = strings.ToLower(s.Phase)
+	}
+	return s
+}
+
+// selectPods returns the pods in namespace matched by a controller's label selector.
+func selectPods(namespace string, selector *metav1.LabelSelector, pods []corev1.Pod) []PodRef {
+	if selector == nil {
+		return nil
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || sel.Empty() {
+		return nil
+	}
+	var refs []PodRef
+	for _, pod := range pods {
+		if pod.Namespace == namespace && sel.Matches(labels.Set(pod.Labels)) {
+			refs = append(refs, podRef(&pod))
+		}
+	}
+	return refs
+}
+
+func podRef(pod *corev1.Pod) PodRef {
+	ref := PodRef{Name: pod.Name, Phase: string(pod.Status.Phase), Node: pod.Spec.NodeName}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			ref.Ready = c.Status == corev1.ConditionTrue
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		ref.Restarts += cs.RestartCount
+	}
+	return ref
+}
+
+// jobNameOf returns the Job that created pod, from the labels the Job controller sets.
+func jobNameOf(pod *corev1.Pod) string {
+	if name := pod.Labels["batch.kubernetes.io/job-name"]; name != "" {
+		return name
+	}
+	return pod.Labels["job-name"]
+}
+
+func ownerKind(refs []metav1.OwnerReference) string {
+	for _, r := range refs {
+		if r.Controller != nil && *r.Controller {
+			return r.Kind
+		}
+	}
+	return ""
+}
+
+func ownerName(refs []metav1.OwnerReference) string {
+	for _, r := range refs {
+		if r.Controller != nil && *r.Controller {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+func condition(typ, status, reason, message string, since metav1.Time) Condition {
+	return Condition{Type: typ, Status: status, Reason: reason, Message: message, Since: timePtr(&since)}
+}
+
+func timePtr(t *metav1.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+func valueOr(v *int32, def int32) int32 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+// normalizeKind accepts kubectl-style kind names ("deploy", "sts", "cronjob", ...).
+func normalizeKind(kind string) (string, error) {
+	switch strings.ToLower(kind) {
+	case "deployment", "deployments", "deploy":
+		return "Deployment", nil
+	case "statefulset", "statefulsets", "sts":
+		return "StatefulSet", nil
+	case "daemonset", "daemonsets", "ds":
+		return "DaemonSet", nil
+	case "job", "jobs":
+		return "Job", nil
+	case "cronjob", "cronjobs", "cj":
+		return "CronJob", nil
+	}
+	return "", providers.InvalidInputf("unsupported workload kind %q (want Deployment, StatefulSet, DaemonSet, Job or CronJob)", kind)
+}
@@ -0,0 +1,307 @@
+package workloads
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func boolPtr(b bool) *bool { return &b }
+
+func readyPod(name string, labels map[string]string, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 2}},
+		},
+	}
+}
+
+func TestInspectWorkloadsHandler_Execute(t *testing.T) {
+	start := metav1.NewTime(time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(start.Add(90 * time.Second))
+	cronOwner := []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", Controller: boolPtr(true)}}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}
+
+	set := &providers.WorkloadSet{
+		Deployments: []appsv1.Deployment{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2), Selector: selector},
+				Status: appsv1.DeploymentStatus{
+					Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 1, AvailableReplicas: 1,
+					Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "MinimumReplicasUnavailable"}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1},
+			},
+		},
+		StatefulSets: []appsv1.StatefulSet{{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(1)},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}},
+		Jobs: []batchv1.Job{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+				Status: batchv1.JobStatus{
+					Failed: 6, StartTime: &start,
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", LastTransitionTime: end}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup-1", Namespace: "default", OwnerReferences: cronOwner, CreationTimestamp: start},
+				Status: batchv1.JobStatus{
+					Succeeded: 1, StartTime: &start, CompletionTime: &end,
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup-2", Namespace: "default", OwnerReferences: cronOwner, CreationTimestamp: end},
+				Status: batchv1.JobStatus{
+					Failed: 1, StartTime: &end,
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded"}},
+				},
+			},
+		},
+		CronJobs: []batchv1.CronJob{{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 2 * * *"},
+		}},
+		Pods: []corev1.Pod{
+			readyPod("api-a", map[string]string{"app": "api"}, true),
+			readyPod("api-b", map[string]string{"app": "api"}, false),
+			readyPod("migrate-x", map[string]string{"batch.kubernetes.io/job-name": "migrate"}, false),
+		},
+	}
+	listFn := func(ctx context.Context, namespace string) (*providers.WorkloadSet, error) { return set, nil }
+
+	byName := func(t *testing.T, got []WorkloadSummary, name string) WorkloadSummary {
+		t.Helper()
+		for _, s := range got {
+			if s.Name == name {
+				return s
+			}
+		}
+		t.Fatalf("workload %s not found in %+v", name, got)
+		return WorkloadSummary{}
+	}
+
+	t.Run("summaries", func(t *testing.T) {
+		res, err := NewInspectWorkloadsHandler(listFn).Execute(context.Background(), WorkloadsInput{Namespace: "default"})
+		if err != nil {
+			t.Fatalf("Execute() unexpected error: %v", err)
+		}
+		got := res.([]WorkloadSummary)
+		if len(got) != 5 {
+			t.Fatalf("Execute() got %d workloads, want 5 (cron-owned jobs folded into their CronJob)", len(got))
+		}
+
+		api := byName(t, got, "api")
+		if api.Healthy || api.Ready != 1 || len(api.Pods) != 2 || len(api.Conditions) != 1 {
+			t.Errorf("api = %+v, want unhealthy with 1 ready, 2 pods and 1 condition", api)
+		}
+		if !byName(t, got, "web").Healthy || !byName(t, got, "db").Healthy {
+			t.Error("web and db should be healthy")
+		}
+
+		migrate := byName(t, got, "migrate")
+		if migrate.Healthy || !strings.Contains(migrate.Status, "BackoffLimitExceeded") || len(migrate.Pods) != 1 {
+			t.Errorf("migrate = %+v, want failed with BackoffLimitExceeded and 1 pod", migrate)
+		}
+		if migrate.Runs[0].Duration != "1m30s" {
+			t.Errorf("migrate duration = %q, want 1m30s", migrate.Runs[0].Duration)
+		}
+
+		backup := byName(t, got, "backup")
+		if backup.Healthy || len(backup.Runs) != 2 || backup.Runs[0].Name != "backup-2" {
+			t.Errorf("backup = %+v, want unhealthy with newest run backup-2 first", backup)
+		}
+		if !strings.Contains(backup.Status, "1 of 2 retained runs failed") {
+			t.Errorf("backup status = %q", backup.Status)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		input     WorkloadsInput
+		wantNames []string
+		wantErr   bool
+	}{
+		{name: "kind filter", input: WorkloadsInput{Kind: "deploy"}, wantNames: []string{"api", "web"}},
+		{name: "jobs include cron runs", input: WorkloadsInput{Kind: "Job"}, wantNames: []string{"migrate", "backup-1", "backup-2"}},
+		{name: "name filter", input: WorkloadsInput{Name: "db"}, wantNames: []string{"db"}},
+		{name: "unhealthy only", input: WorkloadsInput{UnhealthyOnly: true}, wantNames: []string{"api", "migrate", "backup"}},
+		{name: "bad kind", input: WorkloadsInput{Kind: "Pod"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewInspectWorkloadsHandler(listFn).Execute(context.Background(), tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var names []string
+			for _, s := range res.([]WorkloadSummary) {
+				names = append(names, s.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Execute() names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+
+	t.Run("provider error", func(t *testing.T) {
+		h := NewInspectWorkloadsHandler(func(ctx context.Context, namespace string) (*providers.WorkloadSet, error) {
+			return nil, errors.New("api error")
+		})
+		if _, err := h.Execute(context.Background(), WorkloadsInput{}); err == nil {
+			t.Error("Execute() expected error, got nil")
+		}
+	})
+}
+
+func TestInspectServicesHandler_Execute(t *testing.T) {
+	slice := func(service string, ready ...bool) discoveryv1.EndpointSlice {
+		s := discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+			Name: service + "-slice", Namespace: "default", Labels: map[string]string{discoveryv1.LabelServiceName: service},
+		}}
+		for i, r := range ready {
+			s.Endpoints = append(s.Endpoints, discoveryv1.Endpoint{
+				Addresses:  []string{"10.0.0." + string(rune('1'+i))},
+				Conditions: discoveryv1.EndpointConditions{Ready: boolPtr(r)},
+			})
+		}
+		return s
+	}
+	service := func(name string, selector map[string]string) corev1.Service {
+		return corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: selector},
+		}
+	}
+	set := &providers.WorkloadSet{
+		Services: []corev1.Service{
+			service("api", map[string]string{"app": "api"}),
+			service("broken", map[string]string{"app": "broken"}),
+			service("orphan", map[string]string{"app": "nothing"}),
+			{ObjectMeta: metav1.ObjectMeta{Name: "ext", Namespace: "default"}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"}},
+		},
+		EndpointSlices: []discoveryv1.EndpointSlice{slice("api", true, false), slice("broken", false)},
+		Pods: []corev1.Pod{
+			readyPod("api-a", map[string]string{"app": "api"}, true),
+			readyPod("broken-a", map[string]string{"app": "broken"}, false),
+		},
+	}
+	h := NewInspectServicesHandler(func(ctx context.Context, namespace string) (*providers.WorkloadSet, error) { return set, nil })
+
+	tests := []struct {
+		name        string
+		service     string
+		wantHealthy bool
+		wantStatus  string
+	}{
+		{name: "partially ready", service: "api", wantHealthy: true, wantStatus: "1 ready, 1 not ready endpoints"},
+		{name: "no ready endpoints", service: "broken", wantHealthy: false, wantStatus: "1 matching pods are not ready"},
+		{name: "selector matches nothing", service: "orphan", wantHealthy: false, wantStatus: "selector matches no pods"},
+		{name: "external name", service: "ext", wantHealthy: true, wantStatus: "example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := h.Execute(context.Background(), ResourceInput{Name: tt.service})
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			got := res.([]ServiceSummary)
+			if len(got) != 1 {
+				t.Fatalf("Execute() got %d services, want 1", len(got))
+			}
+			if got[0].Healthy != tt.wantHealthy || !strings.Contains(got[0].Status, tt.wantStatus) {
+				t.Errorf("Execute() = %+v, want healthy=%v status containing %q", got[0], tt.wantHealthy, tt.wantStatus)
+			}
+		})
+	}
+
+	res, _ := h.Execute(context.Background(), ResourceInput{UnhealthyOnly: true})
+	if n := len(res.([]ServiceSummary)); n != 2 {
+		t.Errorf("Execute(unhealthy_only) got %d services, want 2", n)
+	}
+}
+
+func TestInspectVolumesHandler_Execute(t *testing.T) {
+	pvc := func(name string, phase corev1.PersistentVolumeClaimPhase, requested, capacity string) corev1.PersistentVolumeClaim {
+		c := corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				VolumeName: "pv-" + name,
+				Resources:  corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)}},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+		if capacity != "" {
+			c.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)}
+		}
+		return c
+	}
+	mounting := readyPod("db-0", nil, true)
+	mounting.Spec.Volumes = []corev1.Volume{{Name: "data", VolumeSource: corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+	}}}
+	set := &providers.WorkloadSet{
+		PVCs: []corev1.PersistentVolumeClaim{
+			pvc("data", corev1.ClaimBound, "10Gi", "10Gi"),
+			pvc("growing", corev1.ClaimBound, "20Gi", "10Gi"),
+			pvc("waiting", corev1.ClaimPending, "1Gi", ""),
+		},
+		Pods: []corev1.Pod{mounting},
+	}
+	h := NewInspectVolumesHandler(func(ctx context.Context, namespace string) (*providers.WorkloadSet, error) { return set, nil })
+
+	tests := []struct {
+		name        string
+		claim       string
+		wantHealthy bool
+		wantStatus  string
+		wantPods    int
+	}{
+		{name: "bound and mounted", claim: "data", wantHealthy: true, wantStatus: "bound to pv-data", wantPods: 1},
+		{name: "resize pending", claim: "growing", wantHealthy: true, wantStatus: "below the requested 20Gi"},
+		{name: "pending", claim: "waiting", wantHealthy: false, wantStatus: "pending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := h.Execute(context.Background(), ResourceInput{Name: tt.claim})
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			got := res.([]VolumeSummary)[0]
+			if got.Healthy != tt.wantHealthy || !strings.Contains(got.Status, tt.wantStatus) || len(got.Pods) != tt.wantPods {
+				t.Errorf("Execute() = %+v, want healthy=%v status %q and %d pods", got, tt.wantHealthy, tt.wantStatus, tt.wantPods)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"observability-hub/internal/mcp/providers"
+)
+
+func testTools(echo string) []ToolDefinition {
+	handler := InstrumentHandler("test_tool", "mcp.test", func(ctx context.Context, _ *sdkmcp.CallToolRequest, _ struct{}) (*sdkmcp.CallToolResult, any, error) {
+		switch echo {
+		case "wait":
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		case "live":
+			echo := fmt.Sprint(providers.IsLiveQuery(ctx))
+			return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: echo}}}, nil, nil
+		}
+		return &sdkmcp.CallToolResult{Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: echo}}}, nil, nil
+	})
+	return []ToolDefinition{
+		readOnlyTool(&sdkmcp.Tool{Name: "inspect_pods"}, handler),
+		mutatingTool(&sdkmcp.Tool{Name: "delete_pod"}, handler),
+		readOnlyTool(&sdkmcp.Tool{Name: "get_pod_logs"}, handler),
+	}
+}
+
+func TestToolRegistry_Register(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name        string
+		config      ToolsConfig
+		wantStates  map[string]string
+		wantUnknown []string
+	}{
+		{
+			name:       "defaults enable everything",
+			config:     DefaultToolsConfig(),
+			wantStates: map[string]string{"inspect_pods": ToolEnabled, "delete_pod": ToolEnabled, "get_pod_logs": ToolEnabled},
+		},
+		{
+			name:       "read-only skips mutating tools",
+			config:     ToolsConfig{ReadOnly: true},
+			wantStates: map[string]string{"inspect_pods": ToolEnabled, "delete_pod": ToolDisabledReadOnly, "get_pod_logs": ToolEnabled},
+		},
+		{
+			name: "tool disabled by name",
+			config: ToolsConfig{Tools: map[string]ToolConfig{
+				"get_pod_logs": {Enabled: &disabled},
+				"delete_pods":  {Enabled: &disabled},
+			}},
+			wantStates:  map[string]string{"inspect_pods": ToolEnabled, "delete_pod": ToolEnabled, "get_pod_logs": ToolDisabled},
+			wantUnknown: []string{"delete_pods"},
+		},
+		{
+			name:       "domain disabled",
+			config:     ToolsConfig{Domains: map[string]bool{"pods": false, "hub": true}},
+			wantStates: map[string]string{"inspect_pods": ToolDisabledDomain, "delete_pod": ToolDisabledDomain, "get_pod_logs": ToolDisabledDomain},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewToolRegistry(sdkmcp.NewServer(&sdkmcp.Implementation{Name: "test", Version: "v0"}, nil), tt.config)
+			added := registry.Register("mcp.pods", testTools("ok")...)
+
+			states := make(map[string]string)
+			wantAdded := 0
+			for _, c := range registry.Capabilities() {
+				if c.Domain != "pods" {
+					t.Errorf("%s domain = %q, want pods", c.Name, c.Domain)
+				}
+				states[c.Name] = c.State
+			}
+			for _, state := range tt.wantStates {
+				if state == ToolEnabled {
+					wantAdded++
+				}
+			}
+			if !reflect.DeepEqual(states, tt.wantStates) {
+				t.Errorf("states = %v, want %v", states, tt.wantStates)
+			}
+			if added != wantAdded || len(registry.Enabled()) != wantAdded {
+				t.Errorf("added %d, enabled %v, want %d", added, registry.Enabled(), wantAdded)
+			}
+			if got := registry.UnknownTools(); !reflect.DeepEqual(got, tt.wantUnknown) {
+				t.Errorf("UnknownTools() = %v, want %v", got, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_Limits(t *testing.T) {
+	ctx := context.Background()
+	config := ToolsConfig{
+		Defaults: ToolLimits{Timeout: time.Minute, MaxOutputBytes: 8},
+		Tools: map[string]ToolConfig{
+			"get_pod_logs":      {Timeout: 20 * time.Millisecond},
+			"list_capabilities": {MaxOutputBytes: 4096},
+		},
+	}
+	server := sdkmcp.NewServer(&sdkmcp.Implementation{Name: "test", Version: "v0"}, nil)
+	registry := NewToolRegistry(server, config)
+	registry.Register("mcp.pods", testTools("0123456789")[:2]...)
+	registry.Register("mcp.logs", testTools("wait")[2])
+	RegisterCapabilityTools(registry, "mcp.registry")
+
+	serverTransport, clientTransport := sdkmcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	session, err := sdkmcp.NewClient(&sdkmcp.Implementation{Name: "client", Version: "v0"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "inspect_pods"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Content) != 2 || res.Content[0].(*sdkmcp.TextContent).Text != "01234567" {
+		t.Errorf("inspect_pods content = %+v, want 8 bytes and a truncation marker", res.Content)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "get_pod_logs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*sdkmcp.TextContent).Text, `20ms timeout: context deadline exceeded","class":"timeout","retryable":true`) {
+		t.Errorf("get_pod_logs = %+v, want a timeout error", res.Content)
+	}
+
+	tools, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range tools.Tools {
+		if tool.Name == "delete_pod" && tool.Annotations.ReadOnlyHint {
+			t.Error("delete_pod annotated read-only")
+		}
+		if tool.Name == "inspect_pods" && !tool.Annotations.ReadOnlyHint {
+			t.Error("inspect_pods not annotated read-only")
+		}
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "list_capabilities", Arguments: map[string]any{"domain": "logs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report CapabilitiesReport
+	if err := json.Unmarshal([]byte(res.Content[0].(*sdkmcp.TextContent).Text), &report); err != nil {
+		t.Fatalf("list_capabilities output: %v", err)
+	}
+	want := []Capability{{Name: "get_pod_logs", Domain: "logs", State: ToolEnabled, Timeout: "20ms", MaxOutputBytes: 8}}
+	if report.Enabled != 1 || !reflect.DeepEqual(report.Tools, want) {
+		t.Errorf("list_capabilities = %+v, want %+v", report, want)
+	}
+}
+
+func TestToolRegistry_ToolTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ToolsConfig
+		want   string
+	}{
+		{name: "tool default beats a shorter registry default", config: DefaultToolsConfig(), want: "6m0s"},
+		{
+			name:   "config timeout wins",
+			config: ToolsConfig{Defaults: ToolLimits{Timeout: time.Minute}, Tools: map[string]ToolConfig{"delete_pod": {Timeout: 30 * time.Second}}},
+			want:   "30s",
+		},
+		{name: "longer registry default is kept", config: ToolsConfig{Defaults: ToolLimits{Timeout: 10 * time.Minute}}, want: "10m0s"},
+		{name: "disabled deadline stays disabled", config: ToolsConfig{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewToolRegistry(sdkmcp.NewServer(&sdkmcp.Implementation{Name: "test", Version: "v0"}, nil), tt.config)
+			registry.Register("mcp.pods", testTools("")[1].withTimeout(6*time.Minute))
+			if got := registry.Capabilities()[0].Timeout; got != tt.want {
+				t.Errorf("timeout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_LiveTool(t *testing.T) {
+	ctx := context.Background()
+	config := ToolsConfig{Tools: map[string]ToolConfig{"inspect_pods": {Live: true}}}
+	server := sdkmcp.NewServer(&sdkmcp.Implementation{Name: "test", Version: "v0"}, nil)
+	registry := NewToolRegistry(server, config)
+	tools := testTools("live")
+	registry.Register("mcp.pods", tools[0], tools[2])
+
+	serverTransport, clientTransport := sdkmcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	session, err := sdkmcp.NewClient(&sdkmcp.Implementation{Name: "client", Version: "v0"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for tool, want := range map[string]string{"inspect_pods": "true", "get_pod_logs": "false"} {
+		res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: tool})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Content[0].(*sdkmcp.TextContent).Text; got != want {
+			t.Errorf("%s live = %s, want %s", tool, got, want)
+		}
+	}
+}
+
+func TestLoadToolsConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	config, err := LoadToolsConfig(write("tools.yaml", `
+read_only: true
+domains:
+  network: false
+tools:
+  delete_pod:
+    enabled: false
+  query_logs:
+    timeout: 45s
+    max_output_bytes: 65536
+`))
+	if err != nil {
+		t.Fatalf("LoadToolsConfig() error = %v", err)
+	}
+	if !config.ReadOnly || config.Domains["network"] || *config.Tools["delete_pod"].Enabled {
+		t.Errorf("config = %+v", config)
+	}
+	if config.Defaults.Timeout != DefaultToolTimeout || config.Tools["query_logs"].Timeout != 45*time.Second {
+		t.Errorf("timeouts = %v, %v", config.Defaults.Timeout, config.Tools["query_logs"].Timeout)
+	}
+
+	if _, err := LoadToolsConfig(write("negative.yaml", "tools:\n  query_logs:\n    max_output_bytes: -1\n")); err == nil {
+		t.Error("LoadToolsConfig(negative limit) error = nil, want error")
+	}
+	if _, err := LoadToolsConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("LoadToolsConfig(missing) error = nil, want error")
+	}
+}